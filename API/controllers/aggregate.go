@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"p3/models"
+	u "p3/utils"
+)
+
+// swagger:operation POST /api/objects/aggregate Objects AggregateObjects
+// Group the objects matching a complex filter and compute metrics on each group.
+// Only the objects the user can read are taken into account.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: namespace
+//     in: query
+//     description: 'One of the values: physical, physical.stray, physical.hierarchy,
+//     logical, logical.objtemplate, logical.bldgtemplate, logical.roomtemplate, logical.tag,
+//     organisational.
+//     If none provided, all namespaces are used by default.'
+//   - name: body
+//     in: body
+//     description: 'A JSON with an optional filter (same syntax as POST /api/objects/search),
+//     the fields to group by and the metrics to compute.
+//     groupBy accepts object properties (category, domain), attributes (model or attributes.model),
+//     parent for the direct parent and parent.site, parent.building, parent.room or parent.rack.
+//     Metrics op can be count, sum, avg, min or max, all but count require a numeric field.
+//     Each metric is returned as its as value if given, op_field otherwise (e.g. sum_height).'
+//     required: true
+//     format: object
+//     example: '{"filter": "category=rack", "groupBy": ["parent.room", "model"],
+//     "metrics": [{"op": "count"}, {"op": "sum", "field": "height", "as": "totalU"}]}'
+// responses:
+//		'200':
+//			description: 'Aggregated. A response body will be returned with
+//			the list of groups, each with its group keys and metrics.'
+//		'400':
+//			description: 'Bad request. Invalid filter, groupBy or metrics.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func AggregateObjects(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 AggregateObjects ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	var request models.AggregateRequest
+	if err := decodeRequestBody(w, r, &request); err != nil {
+		return
+	}
+
+	filters := getFiltersFromQueryParams(r)
+	results, err := models.AggregateObjects(request, filters.Namespace, user.Roles)
+	if err != nil {
+		u.ErrLog("Error while aggregating objects", "AggregateObjects", err.Message, r)
+		u.RespondWithError(w, err)
+	} else {
+		u.Respond(w, u.RespDataWrapper("successfully aggregated objects", results))
+	}
+}
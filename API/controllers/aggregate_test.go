@@ -0,0 +1,67 @@
+package controllers_test
+
+import (
+	"net/http"
+	"p3/test/e2e"
+	"p3/test/integration"
+	test_utils "p3/test/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	integration.RequireCreateSite("site-aggregate")
+	integration.RequireCreateBuilding("site-aggregate", "building-1")
+	integration.RequireCreateRoom("site-aggregate.building-1", "room-1")
+	integration.RequireCreateRack("site-aggregate.building-1.room-1", "rack-1")
+	integration.RequireCreateRack("site-aggregate.building-1.room-1", "rack-2")
+}
+
+func TestAggregateWithoutMetricsRespondsWithError(t *testing.T) {
+	requestBody := []byte(`{"groupBy": ["category"]}`)
+	e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("aggregate"), requestBody, http.StatusBadRequest, "At least one metric must be given")
+}
+
+func TestAggregateWithInvalidMetricRespondsWithError(t *testing.T) {
+	requestBody := []byte(`{"metrics": [{"op": "sum"}]}`)
+	e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("aggregate"), requestBody, http.StatusBadRequest, "Metric sum requires a valid field")
+}
+
+func TestAggregateGroupsByParentLevel(t *testing.T) {
+	requestBody := []byte(`{
+		"filter": "category=rack & id~='^site-aggregate\\.'",
+		"groupBy": ["parent.room", "category"],
+		"metrics": [{"op": "count"}, {"op": "sum", "field": "height"}, {"op": "max", "field": "height", "as": "maxU"}]
+	}`)
+
+	response := e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("aggregate")+"?namespace=physical.hierarchy", requestBody, http.StatusOK, "successfully aggregated objects")
+
+	data, ok := response["data"].([]any)
+	assert.True(t, ok)
+	assert.Len(t, data, 1)
+
+	group := data[0].(map[string]any)
+	assert.Equal(t, map[string]any{
+		"parent.room": "site-aggregate.building-1.room-1",
+		"category":    "rack",
+	}, group["group"])
+	assert.Equal(t, 2.0, group["count"])
+	assert.Equal(t, 94.0, group["sum_height"])
+	assert.Equal(t, 47.0, group["maxU"])
+}
+
+func TestAggregateWithoutGroupByComputesGlobalMetrics(t *testing.T) {
+	requestBody := []byte(`{
+		"filter": "id~='^site-aggregate\\.'",
+		"metrics": [{"op": "count"}]
+	}`)
+
+	response := e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("aggregate")+"?namespace=physical.hierarchy", requestBody, http.StatusOK, "successfully aggregated objects")
+
+	data, ok := response["data"].([]any)
+	assert.True(t, ok)
+	assert.Len(t, data, 1)
+	// building, room and 2 racks
+	assert.Equal(t, 4.0, data[0].(map[string]any)["count"])
+}
@@ -0,0 +1,391 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"p3/models"
+	u "p3/utils"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Changes usually come in bursts, the rules are evaluated once the burst is over
+const alertRulesDebounce = 2 * time.Second
+
+// Buffered so that triggering an evaluation never blocks
+var alertRulesTrigger = make(chan struct{}, 1)
+
+// triggerAlertRulesEvaluation: asks the alert rules engine to evaluate the rules,
+// does nothing if an evaluation is already pending
+func triggerAlertRulesEvaluation() {
+	select {
+	case alertRulesTrigger <- struct{}{}:
+	default:
+	}
+}
+
+// StartAlertRulesEngine: evaluates the alert rules every interval
+// (never if interval is 0) and after each change event
+func StartAlertRulesEngine(interval time.Duration) {
+	listener := broadcaster.Subscribe()
+	go func() {
+		for event := range listener {
+			// the alerts raised by the engine must not trigger it again
+			if !isAlertEvent(event) {
+				triggerAlertRulesEvaluation()
+			}
+		}
+	}()
+
+	go func() {
+		var tick <-chan time.Time
+		if interval > 0 {
+			tick = time.NewTicker(interval).C
+		}
+
+		for {
+			select {
+			case <-tick:
+			case <-alertRulesTrigger:
+				time.Sleep(alertRulesDebounce)
+				select {
+				case <-alertRulesTrigger:
+				default:
+				}
+			}
+
+			evaluation, err := models.EvaluateAlertRules()
+			if err != nil {
+				log.Println("Error while evaluating alert rules:", err.Message)
+			}
+			notifyAlertRuleEvaluation(evaluation)
+		}
+	}()
+}
+
+func isAlertEvent(event string) bool {
+	var data map[string]any
+	if err := json.Unmarshal([]byte(event), &data); err != nil {
+		return false
+	}
+	msgType, _ := data["type"].(string)
+	return strings.HasSuffix(msgType, "-alert")
+}
+
+func notifyAlertRuleEvaluation(evaluation models.AlertRuleEvaluation) {
+	for _, alert := range evaluation.Raised {
+		eventNotifier <- u.FormatNotifyData("raise-alert", "alert", alert)
+	}
+	for _, alert := range evaluation.Resolved {
+		eventNotifier <- u.FormatNotifyData("resolve-alert", "alert", alert)
+	}
+}
+
+// swagger:operation POST /api/alert_rules Alerts CreateAlertRule
+// Create an alert rule.
+// An alert is raised on each physical object of the domain of the rule
+// (and its subdomains) matching the filter and the telemetry condition.
+// It is resolved once the object no longer matches. Rules are evaluated
+// periodically, after each change and when they are created or updated.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: body
+//     in: body
+//     description: 'Mandatory: name, severity (info, minor, major or critical),
+//     domain and at least one of filter (complex filter) and telemetry
+//     (latest value of a sensor compared to a threshold with <, <=, >, >=, = or !=).
+//     Optional: description (subtitle of the alerts), disabled.
+//     Only managers of the domain can manage its rules.'
+//     required: true
+//     format: object
+//     example: '{"name": "Hot rack", "severity": "major", "domain": "mydomain",
+//     "filter": "category=rack", "telemetry": {"sensor": "temperature",
+//     "operator": ">", "threshold": 30}}'
+// responses:
+//		'201':
+//			description: 'Created. The rule is returned.'
+//		'400':
+//			description: 'Bad request. Invalid rule.'
+//		'401':
+//			description: 'Unauthorized. The user is not a manager of the domain.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func CreateAlertRule(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 CreateAlertRule ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	var rule models.AlertRule
+	if err := decodeRequestBody(w, r, &rule); err != nil {
+		return
+	}
+
+	createdRule, err := models.CreateAlertRule(rule, user.Roles)
+	if err != nil {
+		u.ErrLog("Error while creating alert rule", "CreateAlertRule", err.Message, r)
+		u.RespondWithError(w, err)
+		return
+	}
+
+	evaluateAlertRule(*createdRule)
+	w.WriteHeader(http.StatusCreated)
+	u.Respond(w, u.RespDataWrapper("successfully created alert rule", createdRule))
+}
+
+// swagger:operation GET /api/alert_rules Alerts GetAlertRules
+// Get the alert rules of the domains the user can read.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// responses:
+//		'200':
+//			description: 'Return the alert rules.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func GetAlertRules(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetAlertRules ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	rules, err := models.GetAlertRules(user.Roles)
+	if err != nil {
+		u.ErrLog("Error while getting alert rules", "GetAlertRules", err.Message, r)
+		u.RespondWithError(w, err)
+	} else {
+		u.Respond(w, u.RespDataWrapper("successfully got alert rules", map[string]any{"alert_rules": rules}))
+	}
+}
+
+// swagger:operation GET /api/alert_rules/{id} Alerts GetAlertRule
+// Get an alert rule.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: id
+//     in: path
+//     description: 'ID of the alert rule.'
+//     required: true
+//     type: string
+// responses:
+//		'200':
+//			description: 'Return the alert rule.'
+//		'404':
+//			description: 'Not Found. The alert rule does not exist.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func GetAlertRule(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetAlertRule ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	rule, err := models.GetAlertRule(mux.Vars(r)["id"], user.Roles)
+	if err != nil {
+		u.ErrLog("Error while getting alert rule", "GetAlertRule", err.Message, r)
+		u.RespondWithError(w, err)
+	} else {
+		u.Respond(w, u.RespDataWrapper("successfully got alert rule", rule))
+	}
+}
+
+// swagger:operation PUT /api/alert_rules/{id} Alerts UpdateAlertRule
+// Replace an alert rule. The rule is evaluated again.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: id
+//     in: path
+//     description: 'ID of the alert rule.'
+//     required: true
+//     type: string
+//   - name: body
+//     in: body
+//     description: 'The new rule, same format as for the creation.'
+//     required: true
+//     format: object
+// responses:
+//		'200':
+//			description: 'Updated. The rule is returned.'
+//		'400':
+//			description: 'Bad request. Invalid rule.'
+//		'401':
+//			description: 'Unauthorized. The user is not a manager of the domain.'
+//		'404':
+//			description: 'Not Found. The alert rule does not exist.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func UpdateAlertRule(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 UpdateAlertRule ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	var rule models.AlertRule
+	if err := decodeRequestBody(w, r, &rule); err != nil {
+		return
+	}
+
+	updatedRule, err := models.UpdateAlertRule(mux.Vars(r)["id"], rule, user.Roles)
+	if err != nil {
+		u.ErrLog("Error while updating alert rule", "UpdateAlertRule", err.Message, r)
+		u.RespondWithError(w, err)
+		return
+	}
+
+	evaluateAlertRule(*updatedRule)
+	u.Respond(w, u.RespDataWrapper("successfully updated alert rule", updatedRule))
+}
+
+// swagger:operation DELETE /api/alert_rules/{id} Alerts DeleteAlertRule
+// Delete an alert rule. Its open alerts are resolved.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: id
+//     in: path
+//     description: 'ID of the alert rule.'
+//     required: true
+//     type: string
+// responses:
+//		'200':
+//			description: 'Deleted.'
+//		'401':
+//			description: 'Unauthorized. The user is not a manager of the domain.'
+//		'404':
+//			description: 'Not Found. The alert rule does not exist.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func DeleteAlertRule(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 DeleteAlertRule ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	resolved, err := models.DeleteAlertRule(mux.Vars(r)["id"], user.Roles)
+	if err != nil {
+		u.ErrLog("Error while deleting alert rule", "DeleteAlertRule", err.Message, r)
+		u.RespondWithError(w, err)
+		return
+	}
+
+	notifyAlertRuleEvaluation(models.AlertRuleEvaluation{Resolved: resolved})
+	u.Respond(w, u.Message("successfully deleted alert rule"))
+}
+
+// swagger:operation POST /api/alert_rules/{id}/evaluate Alerts EvaluateAlertRule
+// Evaluate an alert rule now.
+// Returns the alerts raised and resolved by the evaluation.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: id
+//     in: path
+//     description: 'ID of the alert rule.'
+//     required: true
+//     type: string
+// responses:
+//		'200':
+//			description: 'Evaluated. The raised and resolved alerts are returned.'
+//		'404':
+//			description: 'Not Found. The alert rule does not exist.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func EvaluateAlertRule(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 EvaluateAlertRule ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	rule, err := models.GetAlertRule(mux.Vars(r)["id"], user.Roles)
+	if err != nil {
+		u.ErrLog("Error while getting alert rule", "EvaluateAlertRule", err.Message, r)
+		u.RespondWithError(w, err)
+		return
+	}
+
+	evaluation, err := models.EvaluateAlertRule(*rule)
+	if err != nil {
+		u.ErrLog("Error while evaluating alert rule", "EvaluateAlertRule", err.Message, r)
+		u.RespondWithError(w, err)
+		return
+	}
+
+	notifyAlertRuleEvaluation(evaluation)
+	u.Respond(w, u.RespDataWrapper("successfully evaluated alert rule", evaluation))
+}
+
+// evaluateAlertRule: evaluates a rule that has just been created or updated,
+// errors are only logged as the rule itself was saved
+func evaluateAlertRule(rule models.AlertRule) {
+	evaluation, err := models.EvaluateAlertRule(rule)
+	if err != nil {
+		log.Println("Error while evaluating alert rule", rule.Id+":", err.Message)
+	}
+	notifyAlertRuleEvaluation(evaluation)
+}
@@ -0,0 +1,112 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"p3/test/e2e"
+	"p3/test/integration"
+	test_utils "p3/test/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const alertRulesRackId = "site-alert-rules.building-1.room-1.rack-1"
+
+func init() {
+	integration.RequireCreateSite("site-alert-rules")
+	integration.RequireCreateBuilding("site-alert-rules", "building-1")
+	integration.RequireCreateRoom("site-alert-rules.building-1", "room-1")
+	integration.RequireCreateRack("site-alert-rules.building-1.room-1", "rack-1")
+}
+
+func hotRackRuleBody(domain string) []byte {
+	requestBody, _ := json.Marshal(map[string]any{
+		"name":     "Hot rack",
+		"severity": "major",
+		"domain":   domain,
+		"filter":   "id=site-alert-rules.*.*.* & category=rack",
+		"telemetry": map[string]any{
+			"sensor":    "temperature",
+			"operator":  ">",
+			"threshold": 30,
+		},
+	})
+	return requestBody
+}
+
+func TestCreateAlertRuleWithInvalidSeverityRespondsWithError(t *testing.T) {
+	requestBody := []byte(`{"name": "rule", "severity": "urgent", "domain": "*", "filter": "category=rack"}`)
+	e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("alertRules"), requestBody, http.StatusBadRequest, "severity must be one of: info, minor, major, critical")
+}
+
+func TestCreateAlertRuleWithInvalidFilterRespondsWithError(t *testing.T) {
+	requestBody := []byte(`{"name": "rule", "severity": "minor", "domain": "*", "filter": "category="}`)
+	e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("alertRules"), requestBody, http.StatusBadRequest, "invalid filter expression at position 10: expected value but found end of filter")
+}
+
+func TestCreateAlertRuleWithoutConditionRespondsWithError(t *testing.T) {
+	requestBody := []byte(`{"name": "rule", "severity": "minor", "domain": "*"}`)
+	e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("alertRules"), requestBody, http.StatusBadRequest, "A filter or a telemetry condition is mandatory")
+}
+
+func TestViewerCannotCreateAlertRule(t *testing.T) {
+	e2e.ValidateRequestWithUser(t, "POST", test_utils.GetEndpoint("alertRules"), hotRackRuleBody("*"), "viewer", http.StatusUnauthorized, "User does not have permission to manage alert rules of domain *")
+}
+
+func TestAlertRuleRaisesAndResolvesAlerts(t *testing.T) {
+	response := e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("alertRules"), hotRackRuleBody(integration.TestDBName), http.StatusCreated, "successfully created alert rule")
+	ruleId := response["data"].(map[string]any)["id"].(string)
+	defer e2e.ValidateManagedRequest(t, "DELETE", test_utils.GetEndpoint("alertRule", ruleId), nil, http.StatusOK, "successfully deleted alert rule")
+
+	e2e.ValidateManagedRequest(t, "GET", test_utils.GetEndpoint("alertRule", ruleId), nil, http.StatusOK, "successfully got alert rule")
+
+	// temperature above the threshold: an alert is raised on the rack
+	requestBody := []byte(`[{"objectId": "` + alertRulesRackId + `", "sensor": "temperature", "value": 35, "timestamp": "2024-01-31T10:00:00Z"}]`)
+	e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("telemetry"), requestBody, http.StatusCreated, "successfully created telemetry")
+
+	response = e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("evaluateAlertRule", ruleId), nil, http.StatusOK, "successfully evaluated alert rule")
+	evaluation := response["data"].(map[string]any)
+	raised := evaluation["raised"].([]any)
+	assert.Len(t, raised, 1)
+	alert := raised[0].(map[string]any)
+	alertId := alert["id"].(string)
+	assert.Equal(t, []any{alertRulesRackId}, alert["objects"])
+	assert.Equal(t, "major", alert["type"])
+	assert.Equal(t, "Hot rack", alert["title"])
+	assert.Equal(t, "temperature is 35 (threshold > 30)", alert["subtitle"])
+	assert.Equal(t, "open", alert["status"])
+
+	e2e.ValidateManagedRequest(t, "GET", test_utils.GetEndpoint("alert", alertId), nil, http.StatusOK, "successfully got alert")
+
+	// evaluating again does not raise the alert twice
+	response = e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("evaluateAlertRule", ruleId), nil, http.StatusOK, "successfully evaluated alert rule")
+	evaluation = response["data"].(map[string]any)
+	assert.Len(t, evaluation["raised"], 0)
+	assert.Len(t, evaluation["resolved"], 0)
+
+	// temperature back to normal: the alert is resolved
+	requestBody = []byte(`[{"objectId": "` + alertRulesRackId + `", "sensor": "temperature", "value": 25, "timestamp": "2024-01-31T10:05:00Z"}]`)
+	e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("telemetry"), requestBody, http.StatusCreated, "successfully created telemetry")
+
+	response = e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("evaluateAlertRule", ruleId), nil, http.StatusOK, "successfully evaluated alert rule")
+	evaluation = response["data"].(map[string]any)
+	assert.Len(t, evaluation["raised"], 0)
+	resolved := evaluation["resolved"].([]any)
+	assert.Len(t, resolved, 1)
+	assert.Equal(t, alertId, resolved[0].(map[string]any)["id"])
+
+	response = e2e.ValidateManagedRequest(t, "GET", test_utils.GetEndpoint("alert", alertId), nil, http.StatusOK, "successfully got alert")
+	assert.Equal(t, "resolved", response["data"].(map[string]any)["status"])
+}
+
+func TestGetAlertRules(t *testing.T) {
+	response := e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("alertRules"), hotRackRuleBody("*"), http.StatusCreated, "successfully created alert rule")
+	ruleId := response["data"].(map[string]any)["id"].(string)
+	defer e2e.ValidateManagedRequest(t, "DELETE", test_utils.GetEndpoint("alertRule", ruleId), nil, http.StatusOK, "successfully deleted alert rule")
+
+	response = e2e.ValidateRequestWithUser(t, "GET", test_utils.GetEndpoint("alertRules"), nil, "viewer", http.StatusOK, "successfully got alert rules")
+	rules := response["data"].(map[string]any)["alert_rules"].([]any)
+	assert.Len(t, rules, 1)
+	assert.Equal(t, ruleId, rules[0].(map[string]any)["id"])
+}
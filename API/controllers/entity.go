@@ -107,6 +107,13 @@ func getUserFromToken(w http.ResponseWriter, r *http.Request) *models.Account {
 //     in: body
 //     required: true
 //     default: {}
+//   - name: Idempotency-Key
+//     in: header
+//     description: 'Optional unique key to safely retry the request.
+//     The first response is stored and replayed for repeated requests
+//     with the same key and body. Reusing a key with a different body is rejected.'
+//     required: false
+//     type: string
 // responses:
 //     '201':
 //         description: 'Created. A response body will be returned with
@@ -195,6 +202,13 @@ func CreateEntity(w http.ResponseWriter, r *http.Request) {
 //     in: body
 //     required: true
 //     default: [{}]
+//   - name: Idempotency-Key
+//     in: header
+//     description: 'Optional unique key to safely retry the request.
+//     The first response is stored and replayed for repeated requests
+//     with the same key and body. Reusing a key with a different body is rejected.'
+//     required: false
+//     type: string
 // responses:
 //     '200':
 //         description: 'Request processed. Check the response body
@@ -504,13 +518,14 @@ func HandleGenericObjects(w http.ResponseWriter, r *http.Request) {
 				objStr = obj["id"].(string)
 			}
 
-			modelErr := models.DeleteObject(entStr, objStr, user.Roles)
+			changes, modelErr := models.DeleteObject(entStr, objStr, user.Roles)
 			if modelErr != nil {
 				u.ErrLog("Error while deleting object: "+objStr, "DELETE GetGenericObjectById", modelErr.Message, r)
 				u.RespondWithError(w, modelErr)
 				return
 			}
 			eventNotifier <- u.FormatNotifyData("delete", entStr, objStr)
+			notifyReferenceChanges(changes)
 		}
 		u.Respond(w, u.RespDataWrapper("successfully deleted objects", matchingObjects))
 	} else if r.Method == "OPTIONS" {
@@ -566,21 +581,31 @@ func HandleGenericObjects(w http.ResponseWriter, r *http.Request) {
 //     type: string
 //     default: domain=DemoDomain
 //     example: vendor=ibm ; name=siteA ; orientation=front
+//   - name: explain
+//     in: query
+//     description: 'If true, nothing is searched nor deleted, the mongo query
+//     generated from the filter is returned instead.'
+//     type: boolean
 //   - name: body
 //     in: body
-//     description: 'A JSON containing a mongoDB query to select and filter the desired objects.
-//     Operators can be `$not`, `$lt`, `$lte`, `$gt`, `$gte`, `$and` and `$or`.
-//     For equality, the syntax is: `[field]: value`.
-//     Objects can be filtered by any of their properties and attributes.'
+//     description: 'A JSON with a filter key containing the filter expression.
+//     Conditions are `field op value` with op one of `=`, `!=`, `<`, `>`, `<=`, `>=`,
+//     `~=` (regex), `in [v1, v2]`, `contains value` and `exists`.
+//     `in`, `contains` and `exists` can be negated with a leading `!`.
+//     Conditions are combined with `&` (and), `|` (or), `!` (not) and parentheses,
+//     & having precedence over |. Values with spaces or special characters must be quoted.
+//     Objects can be filtered by any of their properties and attributes,
+//     nested attributes are accessed with a dot (e.g. `virtual_config.type`).'
 //     required: true
 //     default: {}
-//     example: '{"$and": [{"domain": "DemoDomain"}, {"attributes.height": {"$lt": "3"}}]}'
+//     example: '{"filter": "category=rack & (vendor in [ibm, dell] | !(name contains \"old\"))"}'
 // responses:
 //      '200':
 //          description: 'Found. A response body will be returned with
 //          a meaningful message.'
 //      '400':
-//         description: 'Bad request. Request has wrong format.'
+//         description: 'Bad request. Request has wrong format or the filter is invalid,
+//         the message gives the position of the error.'
 //      '500':
 //          description: Internal Error. A system error stopped the request.
 
@@ -671,6 +696,21 @@ func HandleComplexFilters(w http.ResponseWriter, r *http.Request) {
 	req := u.FilteredReqFromQueryParams(r.URL)
 	entities := u.GetEntitiesById(filters.Namespace, filters.Id)
 
+	if filters.Explain {
+		// only show the query, nothing is searched nor deleted
+		if err := models.ApplyRequestFilters(req, filters, complexFilterExp); err != nil {
+			u.ErrLog("Error while explaining filter", "HandleComplexFilters", err.Message, r)
+			u.RespondWithError(w, err)
+			return
+		}
+		u.Respond(w, u.RespDataWrapper("successfully explained filter", map[string]any{
+			"filter":   complexFilterExp,
+			"query":    req,
+			"entities": entities,
+		}))
+		return
+	}
+
 	for _, entStr := range entities {
 		// Get objects
 		entData, err := models.GetManyObjects(entStr, req, filters, complexFilterExp, user.Roles)
@@ -704,12 +744,13 @@ func HandleComplexFilters(w http.ResponseWriter, r *http.Request) {
 				objStr = obj["id"].(string)
 			}
 
-			modelErr := models.DeleteObject(entStr, objStr, user.Roles)
+			changes, modelErr := models.DeleteObject(entStr, objStr, user.Roles)
 			if modelErr != nil {
 				u.ErrLog("Error while deleting object: "+objStr, "DELETE GetGenericObjectById", modelErr.Message, r)
 				u.RespondWithError(w, modelErr)
 				return
 			}
+			notifyReferenceChanges(changes)
 		}
 		u.Respond(w, u.RespDataWrapper("successfully deleted objects", matchingObjects))
 	} else if r.Method == "OPTIONS" {
@@ -757,6 +798,12 @@ func HandleComplexFilters(w http.ResponseWriter, r *http.Request) {
 //     in: query
 //     description: 'filter objects by lastUpdated <= endDate.
 //     Format: yyyy-mm-dd'
+//   - name: asOf
+//     in: query
+//     description: 'get the object as it was in a snapshot: name of the
+//     snapshot or date (yyyy-mm-dd or RFC3339), the latest snapshot
+//     containing the object taken at or before the date is used.
+//     Only physical and virtual objects are kept in snapshots.'
 // responses:
 // 	'200':
 // 	  description: 'Found. A response body will be returned with
@@ -787,7 +834,14 @@ func GetEntity(w http.ResponseWriter, r *http.Request) {
 	filters := getFiltersFromQueryParams(r)
 
 	// Get entity
-	if id, canParse = mux.Vars(r)["id"]; canParse {
+	asOf := r.URL.Query().Get("asOf")
+	if id, canParse = mux.Vars(r)["id"]; canParse && asOf != "" {
+		// As it was in a snapshot
+		var snapshot *models.Snapshot
+		if snapshot, modelErr = models.ResolveSnapshot(asOf, id); modelErr == nil {
+			data, modelErr = models.GetSnapshotObjectById(snapshot.Name, id, entityStr, user.Roles)
+		}
+	} else if canParse {
 		data, modelErr = models.GetObjectById(id, entityStr, filters, user.Roles)
 	} else {
 		w.WriteHeader(http.StatusBadRequest)
@@ -1032,6 +1086,8 @@ func getVirtualRootObjects(data []map[string]any) []map[string]any {
 
 // swagger:operation DELETE /api/{entity}/{id} Objects DeleteObject
 // Deletes an Object in the system.
+// Its name is removed from the content of groups (groups left empty are deleted),
+// vlinks pointing to it or its children are removed and so are clusterIds linked to them.
 // ---
 // security:
 // - bearer: []
@@ -1105,7 +1161,7 @@ func DeleteEntity(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		modelErr := models.DeleteObject(entityStr, id, user.Roles)
+		changes, modelErr := models.DeleteObject(entityStr, id, user.Roles)
 		if modelErr != nil {
 			u.ErrLog("Error while deleting entity", "DELETE ENTITY", modelErr.Message, r)
 			u.RespondWithError(w, modelErr)
@@ -1113,6 +1169,7 @@ func DeleteEntity(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusNoContent)
 			u.Respond(w, u.Message("successfully deleted"))
 			eventNotifier <- u.FormatNotifyData("delete", entityStr, id)
+			notifyReferenceChanges(changes)
 		}
 	}
 }
@@ -1122,6 +1179,7 @@ func DeleteEntity(w http.ResponseWriter, r *http.Request) {
 // This is the preferred method for modifying data in the system.
 // If you want to do a full data replace, please use PUT instead.
 // If no data is effectively changed, an OK will still be returned.
+// If the name changes, group contents, vlinks, clusterIds and breakers referencing the object are updated.
 // ---
 // security:
 // - bearer: []
@@ -1166,6 +1224,7 @@ func DeleteEntity(w http.ResponseWriter, r *http.Request) {
 // received, thus fully replacing the data. If you do not
 // want to do this, please use PATCH.
 // If no data is effectively changed, an OK will still be returned.
+// If the name changes, group contents, vlinks, clusterIds and breakers referencing the object are updated.
 // ---
 // security:
 // - bearer: []
@@ -1248,7 +1307,8 @@ func UpdateEntity(w http.ResponseWriter, r *http.Request) {
 		u.Respond(w, u.Message("Error while extracting from path parameters"))
 		u.ErrLog("Error while extracting from path parameters", "UPDATE ENTITY", "", r)
 	} else {
-		data, modelErr = models.UpdateObject(entity, id, updateData, isPatch, user.Roles, isRecursiveUpdate)
+		var changes []models.ReferenceChange
+		data, changes, modelErr = models.UpdateObject(entity, id, updateData, isPatch, user.Roles, isRecursiveUpdate)
 		if modelErr != nil {
 			u.RespondWithError(w, modelErr)
 		} else {
@@ -1260,6 +1320,7 @@ func UpdateEntity(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 			eventNotifier <- u.FormatNotifyData("modify", entity, data)
+			notifyReferenceChanges(changes)
 		}
 	}
 }
@@ -1580,6 +1641,11 @@ func GetEntitiesOfAncestor(w http.ResponseWriter, r *http.Request) {
 //   in: query
 //   description: 'filter objects by lastUpdated <= endDate.
 //   Format: yyyy-mm-dd'
+// - name: asOf
+//   in: query
+//   description: 'get the object and its children as they were in a snapshot:
+//   name of the snapshot or date (yyyy-mm-dd or RFC3339), the latest snapshot
+//   containing the object taken at or before the date is used.'
 // responses:
 //     '200':
 //         description: 'Found. A response body will be returned with
@@ -1624,7 +1690,17 @@ func GetHierarchyByName(w http.ResponseWriter, r *http.Request) {
 	// Get object and its family
 	var modelErr *u.Error
 	var data map[string]interface{}
-	if entity == u.HIERARCHYOBJS_ENT {
+	asOf := r.URL.Query().Get("asOf")
+	if asOf != "" {
+		// As it was in a snapshot
+		var snapshot *models.Snapshot
+		if snapshot, modelErr = models.ResolveSnapshot(asOf, id); modelErr == nil {
+			data, modelErr = models.GetSnapshotObjectById(snapshot.Name, id, entity, user.Roles)
+		}
+		if limit >= 1 && modelErr == nil {
+			data["children"], modelErr = models.GetSnapshotHierarchy(snapshot.Name, id, limit, user.Roles)
+		}
+	} else if entity == u.HIERARCHYOBJS_ENT {
 		// Generic endpoint only for physical objs
 		data, modelErr = models.GetHierarchicalObjectById(id, filters, user.Roles)
 		if modelErr == nil {
@@ -1634,7 +1710,7 @@ func GetHierarchyByName(w http.ResponseWriter, r *http.Request) {
 		// Entity already known
 		data, modelErr = models.GetObject(bson.M{"id": id}, entity, filters, user.Roles)
 	}
-	if limit >= 1 && modelErr == nil {
+	if limit >= 1 && modelErr == nil && asOf == "" {
 		if entity == u.EntityToString(u.STRAYOBJ) {
 			// use stray's category as entity
 			entity = data["category"].(string)
@@ -1761,6 +1837,7 @@ func GetCompleteHierarchyAttributes(w http.ResponseWriter, r *http.Request) {
 // Removes the object from its original entity and hierarchy tree to make it stray.
 // The object will no longer have a parent, its id will change as well as the id of all its children.
 // The object will then belong to the stray_objects entity.
+// Group contents, vlinks, clusterIds and breakers referencing the object are updated.
 // ---
 // security:
 // - bearer: []
@@ -1798,6 +1875,7 @@ func GetCompleteHierarchyAttributes(w http.ResponseWriter, r *http.Request) {
 // Removes the object from stray and add it to the entity of its category attribute.
 // The object will again have a parent, its id will change as well as the id of all its children.
 // The object will then belong to the given entity.
+// Vlinks, clusterIds and breakers referencing the object are updated.
 // ---
 // security:
 // - bearer: []
@@ -1921,7 +1999,7 @@ func LinkEntity(w http.ResponseWriter, r *http.Request) {
 		deleteEnt = "stray_object"
 	}
 
-	if modelErr := models.SwapEntity(createEnt, deleteEnt, id, data, user.Roles); modelErr != nil {
+	if changes, modelErr := models.SwapEntity(createEnt, deleteEnt, id, data, user.Roles); modelErr != nil {
 		u.RespondWithError(w, modelErr)
 		return
 	} else {
@@ -1930,6 +2008,161 @@ func LinkEntity(w http.ResponseWriter, r *http.Request) {
 		} else {
 			u.Respond(w, u.Message("successfully linked"))
 		}
+		notifyReferenceChanges(changes)
+	}
+}
+
+// swagger:operation POST /api/{entity}/{id}/move Objects MoveObject
+// Moves the object under a new parent, optionally changing its position.
+// The id of the object will change as well as the id of all its children.
+// Group contents, vlinks, clusterIds and breakers referencing the moved objects are updated.
+// All is done in a single transaction.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: id
+//     in: path
+//     description: 'ID of desired object.'
+//     required: true
+//     type: string
+//     default: "Site.Building.Room.RackB"
+//   - name: entity
+//     in: path
+//     description: 'Entity (same as category) of the object. Accepted values:
+//     buildings, rooms, racks, devices, acs, panels,
+//     cabinets, groups, corridors, generics, virtual_objs, hierarchy_objects.'
+//     required: true
+//     type: string
+//     default: "racks"
+//   - name: body
+//     in: body
+//     required: true
+//     description: 'ParentId is mandatory. Attributes is optional and can only contain
+//     position attributes: posXY, posXYUnit, posXYZ, posZUnit, posU, slot and rotation.'
+//     default: {"parentId": "Site.Building.RoomB", "attributes": {"posXY": [1, 2]}}
+// responses:
+//     '200':
+//         description: 'Moved. The moved object is returned.'
+//     '400':
+//         description: 'Bad request. Request has wrong format or the new position is invalid.'
+//     '401':
+//         description: 'Unauthorized. User does not have permission to move the object.'
+//     '404':
+//         description: 'Not Found. Object not found.'
+//     '409':
+//         description: 'Conflict. An object with the new id already exists.'
+
+func MoveEntity(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 MoveEntity ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	move := models.ObjectMove{}
+	if err := decodeRequestBody(w, r, &move); err != nil {
+		return
+	}
+
+	entity := mux.Vars(r)["entity"]
+	id := mux.Vars(r)["id"]
+	data, changes, modelErr := models.MoveObject(entity, id, move, user.Roles)
+	if modelErr != nil {
+		u.RespondWithError(w, modelErr)
+		return
+	}
+
+	u.Respond(w, u.RespDataWrapper("successfully moved "+entity, data))
+	entity = data["category"].(string)
+	eventNotifier <- u.FormatNotifyData("modify", entity, map[string]any{
+		"old-id": id,
+		entity:   data,
+	})
+	notifyReferenceChanges(changes)
+}
+
+// swagger:operation POST /api/{entity}/{id}/copy Objects CopyObject
+// Copies the object and its whole subtree under a parent with a new name.
+// The name can be a pattern such as R{01..10} to make several copies.
+// The nth copy is moved by n times the offset. Each copy is validated
+// against its destination and all are created in a single transaction.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: id
+//     in: path
+//     description: 'ID of desired object.'
+//     required: true
+//     type: string
+//     default: "Site.Building.Room.RackB"
+//   - name: entity
+//     in: path
+//     description: 'Entity (same as category) of the object. Accepted values:
+//     buildings, rooms, racks, devices, acs, panels,
+//     cabinets, groups, corridors, generics, virtual_objs, hierarchy_objects.'
+//     required: true
+//     type: string
+//     default: "racks"
+//   - name: body
+//     in: body
+//     required: true
+//     description: 'ParentId is optional, the parent of the object by default.
+//     Name is the name or name pattern of the copies, the name of the object by default.
+//     Offset is optional, it is added to posXYZ, posXY or posU.
+//     Attributes is optional and can only contain position attributes:
+//     posXY, posXYUnit, posXYZ, posZUnit, posU, slot and rotation.'
+//     default: {"parentId": "Site.Building.RoomB", "name": "R{01..10}", "offset": [0, 1, 0]}
+// responses:
+//     '201':
+//         description: 'Copied. The copies of the object are returned.'
+//     '400':
+//         description: 'Bad request. Request has wrong format or a copy is invalid.'
+//     '401':
+//         description: 'Unauthorized. User does not have permission to copy the object.'
+//     '404':
+//         description: 'Not Found. Object not found.'
+//     '409':
+//         description: 'Conflict. An object with the id of a copy already exists.'
+
+func CopyEntity(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 CopyEntity ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	copyReq := models.ObjectCopy{}
+	if err := decodeRequestBody(w, r, &copyReq); err != nil {
+		return
+	}
+
+	entity := mux.Vars(r)["entity"]
+	data, modelErr := models.CopyObject(entity, mux.Vars(r)["id"], copyReq, user.Roles)
+	if modelErr != nil {
+		u.RespondWithError(w, modelErr)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	u.Respond(w, u.RespDataWrapper("successfully copied "+entity, data))
+	for _, objCopy := range data {
+		eventNotifier <- u.FormatNotifyData("create", objCopy["category"].(string), objCopy)
 	}
 }
 
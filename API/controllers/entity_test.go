@@ -301,6 +301,33 @@ func TestComplexFilterSearchWithDateFilter(t *testing.T) {
 	}
 }
 
+func TestComplexFilterWithInvalidFilterReturnsPosition(t *testing.T) {
+	requestBody := []byte(`{
+		"filter": "category=rack & (name=rack-1"
+	}`)
+
+	message := "invalid filter expression at position 29: expected ')' but found end of filter"
+	e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("complexFilterSearch"), requestBody, http.StatusBadRequest, message)
+}
+
+func TestComplexFilterExplainReturnsQuery(t *testing.T) {
+	requestBody := []byte(`{
+		"filter": "category=rack & vendor in [ibm, dell]"
+	}`)
+
+	endpoint := test_utils.GetEndpoint("complexFilterSearch") + "?explain=true"
+	response := e2e.ValidateManagedRequest(t, "POST", endpoint, requestBody, http.StatusOK, "successfully explained filter")
+
+	data, exists := response["data"].(map[string]interface{})
+	assert.True(t, exists)
+	assert.Equal(t, map[string]interface{}{
+		"$and": []interface{}{
+			map[string]interface{}{"category": "rack"},
+			map[string]interface{}{"attributes.vendor": map[string]interface{}{"$in": []interface{}{"ibm", "dell"}}},
+		},
+	}, data["query"])
+}
+
 // Tests get different entities
 func TestGetDomainEntity(t *testing.T) {
 	integration.CreateTestDomain(t, "temporaryDomain", "", "")
@@ -488,6 +515,74 @@ func TestLinkUnlinkRoomss(t *testing.T) {
 	}
 }
 
+func TestMoveRack(t *testing.T) {
+	integration.RequireCreateRoom("site-no-temperature.building-3", "move-room-1")
+	integration.RequireCreateRoom("site-no-temperature.building-3", "move-room-2")
+	integration.RequireCreateRack("site-no-temperature.building-3.move-room-1", "move-rack")
+	integration.RequireCreateDevice("site-no-temperature.building-3.move-room-1.move-rack", "move-device")
+
+	rackId := "site-no-temperature.building-3.move-room-1.move-rack"
+	moveEndpoint := test_utils.GetEndpoint("entityMove", "racks", rackId)
+	tests := []struct {
+		name        string
+		requestBody []byte
+		statusCode  int
+		message     string
+	}{
+		{"MoveWithoutParentId", []byte(`{}`), http.StatusBadRequest, "Error while decoding request body: must contain parentId"},
+		{"MoveUnderItself", []byte(`{"parentId": "` + rackId + `.move-device"}`), http.StatusBadRequest, "An object cannot be moved under itself"},
+		{"MoveWithNonPositionAttribute", []byte(`{"parentId": "site-no-temperature.building-3.move-room-2", "attributes": {"color": "aaaaaa"}}`), http.StatusBadRequest, "Only position attributes can be given when moving an object"},
+		{"MoveSuccess", []byte(`{"parentId": "site-no-temperature.building-3.move-room-2", "attributes": {"rotation": [0, 0, 90]}}`), http.StatusOK, "successfully moved rack"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e2e.ValidateManagedRequest(t, "POST", moveEndpoint, tt.requestBody, tt.statusCode, tt.message)
+		})
+	}
+
+	// We verify the rack and its device were moved
+	oldDeviceEndpoint := test_utils.GetEndpoint("entityInstance", "devices", rackId+".move-device")
+	e2e.ValidateManagedRequest(t, "GET", oldDeviceEndpoint, nil, http.StatusNotFound, "Nothing matches this request")
+	newDeviceEndpoint := test_utils.GetEndpoint("entityInstance", "devices", "site-no-temperature.building-3.move-room-2.move-rack.move-device")
+	e2e.ValidateManagedRequest(t, "GET", newDeviceEndpoint, nil, http.StatusOK, "successfully got device")
+}
+
+func TestCopyRack(t *testing.T) {
+	integration.RequireCreateRoom("site-no-temperature.building-3", "copy-room-1")
+	integration.RequireCreateRoom("site-no-temperature.building-3", "copy-room-2")
+	integration.RequireCreateRack("site-no-temperature.building-3.copy-room-1", "copy-rack")
+	integration.RequireCreateDevice("site-no-temperature.building-3.copy-room-1.copy-rack", "copy-device")
+
+	rackId := "site-no-temperature.building-3.copy-room-1.copy-rack"
+	copyEndpoint := test_utils.GetEndpoint("entityCopy", "racks", rackId)
+	tests := []struct {
+		name        string
+		requestBody []byte
+		statusCode  int
+		message     string
+	}{
+		{"CopyUnderItself", []byte(`{"parentId": "` + rackId + `.copy-device"}`), http.StatusBadRequest, "An object cannot be copied under itself"},
+		{"CopyWithInvalidPattern", []byte(`{"name": "R{10..01}"}`), http.StatusBadRequest, "Invalid name pattern: the end of the range must be greater than its start"},
+		{"CopyToExistingId", []byte(`{}`), http.StatusConflict, "Object " + rackId + " already exists"},
+		{"CopySuccess", []byte(`{"parentId": "site-no-temperature.building-3.copy-room-2", "name": "R{1..2}", "offset": [1, 0, 0]}`), http.StatusCreated, "successfully copied rack"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e2e.ValidateManagedRequest(t, "POST", copyEndpoint, tt.requestBody, tt.statusCode, tt.message)
+		})
+	}
+
+	// We verify the rack and its device were copied, and the source is still there
+	for _, deviceId := range []string{
+		rackId + ".copy-device",
+		"site-no-temperature.building-3.copy-room-2.R1.copy-device",
+		"site-no-temperature.building-3.copy-room-2.R2.copy-device",
+	} {
+		deviceEndpoint := test_utils.GetEndpoint("entityInstance", "devices", deviceId)
+		e2e.ValidateManagedRequest(t, "GET", deviceEndpoint, nil, http.StatusOK, "successfully got device")
+	}
+}
+
 // Tests entity validation
 func TestValidateNonExistentEntity(t *testing.T) {
 	requestBody := []byte(`{}`)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"p3/models"
 	u "p3/utils"
 )
 
@@ -21,6 +22,8 @@ func init() {
 // Get real-time notifications (SSE stream)
 // Opens a SSE stream with the caller where the API will send a new event (message in JSON format)
 // every time a modify or delete of any object succeeds. Also applies to create layer.
+// Objects whose references (group content, vlinks, clusterId) are updated or pruned
+// by a modify or delete get their own modify or delete event.
 // ---
 // security:
 // - bearer: []
@@ -53,3 +56,15 @@ func CreateEventStream(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "event: close")
 	w.(http.Flusher).Flush()
 }
+
+// notifyReferenceChanges: sends an event for each object updated as a side effect
+// of the request (e.g. its references to a moved object, its latest telemetry)
+func notifyReferenceChanges(changes []models.ReferenceChange) {
+	for _, change := range changes {
+		if change.Object == nil {
+			eventNotifier <- u.FormatNotifyData("delete", change.Category, change.Id)
+		} else {
+			eventNotifier <- u.FormatNotifyData("modify", change.Category, change.Object)
+		}
+	}
+}
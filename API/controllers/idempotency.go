@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"p3/models"
+	u "p3/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const IdempotencyKeyHeader = "Idempotency-Key"
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+const idempotencyKeyMaxLength = 255
+
+// responseRecorder writes the response to the client while keeping
+// a copy of its status and body
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(data []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(data)
+	return rec.ResponseWriter.Write(data)
+}
+
+// WithIdempotency: if the request has an Idempotency-Key header, the first
+// response is stored and replayed for repeated requests with the same key and body.
+// The key is released if the first request ends with a server error.
+func WithIdempotency(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" || r.Method != http.MethodPost {
+			next(w, r)
+			return
+		}
+
+		if len(key) > idempotencyKeyMaxLength {
+			w.WriteHeader(http.StatusBadRequest)
+			u.Respond(w, u.Message(fmt.Sprintf("%s must not exceed %d characters",
+				IdempotencyKeyHeader, idempotencyKeyMaxLength)))
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			u.Respond(w, u.Message(ErrDecodingBodyMsg))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		hash := sha256.Sum256(body)
+
+		userId := getUserIdFromContext(r)
+		record, modelErr := models.StartIdempotentRequest(userId, key, r.Method, r.URL.Path,
+			hex.EncodeToString(hash[:]))
+		if modelErr != nil {
+			u.ErrLog("Error with idempotency key", "IDEMPOTENCY", modelErr.Message, r)
+			u.RespondWithError(w, modelErr)
+			return
+		}
+
+		if record != nil {
+			// Same request already processed, replay its response
+			w.Header().Set("Content-Type", u.HttpResponseContentType)
+			w.Header().Set(IdempotentReplayedHeader, "true")
+			w.WriteHeader(record.Status)
+			w.Write(record.Response)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w}
+		next(recorder, r)
+
+		if recorder.status == 0 || recorder.status >= http.StatusInternalServerError {
+			modelErr = models.ReleaseIdempotencyKey(userId, key)
+		} else {
+			modelErr = models.CompleteIdempotentRequest(userId, key, recorder.status, recorder.body.Bytes())
+		}
+		if modelErr != nil {
+			u.ErrLog("Error while saving idempotency key", "IDEMPOTENCY", modelErr.Message, r)
+		}
+	}
+}
+
+func getUserIdFromContext(r *http.Request) string {
+	userData, ok := r.Context().Value("user").(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	if userId, ok := userData["userID"].(primitive.ObjectID); ok {
+		return userId.Hex()
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"p3/controllers"
+	"p3/test/e2e"
+	test_utils "p3/test/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var sitesEndpoint = test_utils.GetEndpoint("entity", "sites")
+
+func TestCreateWithIdempotencyKeyReplaysFirstResponse(t *testing.T) {
+	requestBody, _ := json.Marshal(test_utils.GetEntityMap("site", "site-idempotent", "", ""))
+
+	first := e2e.MakeRequestWithIdempotencyKey("POST", sitesEndpoint, requestBody, "create-site-idempotent")
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(controllers.IdempotentReplayedHeader))
+
+	// without the key the object would be duplicated
+	second := e2e.MakeRequestWithIdempotencyKey("POST", sitesEndpoint, requestBody, "create-site-idempotent")
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, "true", second.Header().Get(controllers.IdempotentReplayedHeader))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+}
+
+func TestCreateWithIdempotencyKeyAndDifferentBodyIsRejected(t *testing.T) {
+	requestBody, _ := json.Marshal(test_utils.GetEntityMap("site", "site-idempotent-2", "", ""))
+	otherBody, _ := json.Marshal(test_utils.GetEntityMap("site", "site-idempotent-3", "", ""))
+
+	recorder := e2e.MakeRequestWithIdempotencyKey("POST", sitesEndpoint, requestBody, "create-site-idempotent-2")
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	recorder = e2e.MakeRequestWithIdempotencyKey("POST", sitesEndpoint, otherBody, "create-site-idempotent-2")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	var response map[string]any
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	assert.Equal(t, "Idempotency-Key has already been used with a different request", response["message"])
+
+	// the other site was not created
+	e2e.ValidateManagedRequest(t, "GET", test_utils.GetEndpoint("entityInstance", "sites", "site-idempotent-3"), nil, http.StatusNotFound, "Nothing matches this request")
+}
+
+func TestBulkDomainWithIdempotencyKeyReplaysFirstResponse(t *testing.T) {
+	requestBody := []byte(`[{"name": "domain-idempotent"}]`)
+	endpoint := test_utils.GetEndpoint("domainsBulk")
+
+	first := e2e.MakeRequestWithIdempotencyKey("POST", endpoint, requestBody, "bulk-domain-idempotent")
+	assert.Equal(t, http.StatusOK, first.Code)
+
+	second := e2e.MakeRequestWithIdempotencyKey("POST", endpoint, requestBody, "bulk-domain-idempotent")
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, "true", second.Header().Get(controllers.IdempotentReplayedHeader))
+
+	var response map[string]any
+	json.Unmarshal(second.Body.Bytes(), &response)
+	assert.Equal(t, "successfully created domain", response["domain-idempotent"])
+}
+
+func TestCreateRelationWithIdempotencyKeyReplaysFirstResponse(t *testing.T) {
+	requestBody := []byte(`{"type": "backs-up", "from": "site-relations.building-1.room-1.rack-2", "to": "site-relations.building-1.room-1.rack-1"}`)
+	endpoint := test_utils.GetEndpoint("relations")
+
+	first := e2e.MakeRequestWithIdempotencyKey("POST", endpoint, requestBody, "create-relation-idempotent")
+	assert.Equal(t, http.StatusCreated, first.Code)
+
+	// without the key the relation would be a duplicate
+	second := e2e.MakeRequestWithIdempotencyKey("POST", endpoint, requestBody, "create-relation-idempotent")
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, "true", second.Header().Get(controllers.IdempotentReplayedHeader))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+}
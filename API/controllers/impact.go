@@ -5,7 +5,9 @@ import (
 	"net/http"
 	"p3/models"
 	u "p3/utils"
+	"strings"
 
+	"github.com/elliotchance/pie/v2"
 	"github.com/gorilla/mux"
 )
 
@@ -44,9 +46,23 @@ func getImpactFiltersFromQueryParams(r *http.Request) models.ImpactFilters {
 //     in: query
 //     description: 'Virtual types to include on indirect impact search.
 //     Can be repeated to create a list.'
+//   - name: relations
+//     in: query
+//     description: 'Types of relations to follow (depends-on, backs-up, connected-to, managed-by).
+//     Objects with such a relation to an impacted object are indirectly impacted, and so on.
+//     The relations followed are returned in typedRelations. Can be repeated to create a list.'
+//   - name: format
+//     in: query
+//     description: 'Return the impact as a graph of nodes and edges in the given format:
+//     dot (graphviz), graphml, mermaid or json-graph. The graph has the target, its direct
+//     and indirect children, their clusters and the typed relations followed,
+//     with a color and a shape per category. dot, graphml and mermaid are returned as a file.'
+//     type: string
 // responses:
 //		'200':
 //			description: 'Request is valid.'
+//		'400':
+//			description: 'Bad request. Invalid format.'
 //		'500':
 //			description: Server error.
 
@@ -62,6 +78,13 @@ func GetImpact(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	format := r.URL.Query().Get("format")
+	if format != "" && !pie.Contains(models.ImpactGraphFormats, format) {
+		u.RespondWithError(w, &u.Error{Type: u.ErrBadFormat,
+			Message: "Invalid format, it must be one of: " + strings.Join(models.ImpactGraphFormats, ", ")})
+		return
+	}
+
 	filters := getImpactFiltersFromQueryParams(r)
 
 	// Get id of impact target
@@ -73,6 +96,11 @@ func GetImpact(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if format != "" {
+		getImpactGraph(w, r, id, format, user.Roles, filters)
+		return
+	}
+
 	data, err := models.GetImpact(id, user.Roles, filters)
 	if err != nil {
 		u.RespondWithError(w, err)
@@ -84,3 +112,32 @@ func GetImpact(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 }
+
+// getImpactGraph: writes the impact graph in the format, as a file except for json-graph
+func getImpactGraph(w http.ResponseWriter, r *http.Request, id, format string,
+	userRoles map[string]models.Role, filters models.ImpactFilters) {
+	graph, err := models.GetImpactGraph(id, userRoles, filters)
+	if err != nil {
+		u.ErrLog("Error while getting impact graph", "GetImpact", err.Message, r)
+		u.RespondWithError(w, err)
+		return
+	}
+
+	var contentType, extension, content string
+	switch format {
+	case "dot":
+		contentType, extension, content = "text/vnd.graphviz", "dot", graph.Dot()
+	case "graphml":
+		contentType, extension, content = "application/graphml+xml", "graphml", graph.GraphML()
+	case "mermaid":
+		contentType, extension, content = "text/plain", "mmd", graph.Mermaid()
+	default:
+		u.Respond(w, u.RespDataWrapper("successfully got impact graph", graph))
+		return
+	}
+
+	fileName := strings.ReplaceAll(id, `"`, "") + "-impact." + extension
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+fileName+`"`)
+	w.Write([]byte(content))
+}
@@ -0,0 +1,48 @@
+package controllers_test
+
+import (
+	"net/http"
+	"p3/test/e2e"
+	"p3/test/integration"
+	test_utils "p3/test/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	integration.RequireCreateSite("site-impact-graph")
+	integration.RequireCreateBuilding("site-impact-graph", "building-1")
+	integration.RequireCreateRoom("site-impact-graph.building-1", "room-1")
+	integration.RequireCreateRack("site-impact-graph.building-1.room-1", "rack-1")
+}
+
+func TestGetImpactWithInvalidFormatRespondsWithError(t *testing.T) {
+	endpoint := test_utils.GetEndpoint("impact", "site-impact-graph.building-1.room-1") + "?format=png"
+	e2e.ValidateManagedRequest(t, "GET", endpoint, nil, http.StatusBadRequest, "Invalid format, it must be one of: dot, graphml, mermaid, json-graph")
+}
+
+func TestGetImpactAsJsonGraph(t *testing.T) {
+	endpoint := test_utils.GetEndpoint("impact", "site-impact-graph.building-1.room-1") + "?format=json-graph"
+	response := e2e.ValidateManagedRequest(t, "GET", endpoint, nil, http.StatusOK, "successfully got impact graph")
+	graph := response["data"].(map[string]any)
+	nodes := graph["nodes"].([]any)
+	require.Len(t, nodes, 2)
+	assert.Equal(t, "target", nodes[0].(map[string]any)["impact"])
+	assert.Equal(t, "rack", nodes[1].(map[string]any)["category"])
+	assert.Equal(t, []any{map[string]any{
+		"from": "site-impact-graph.building-1.room-1",
+		"to":   "site-impact-graph.building-1.room-1.rack-1",
+		"type": "contains",
+	}}, graph["edges"])
+}
+
+func TestGetImpactAsDot(t *testing.T) {
+	endpoint := test_utils.GetEndpoint("impact", "site-impact-graph.building-1.room-1") + "?format=dot"
+	recorder := e2e.MakeRequest("GET", endpoint, nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/vnd.graphviz", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="site-impact-graph.building-1.room-1-impact.dot"`, recorder.Header().Get("Content-Disposition"))
+	assert.Contains(t, recorder.Body.String(), `"site-impact-graph.building-1.room-1" -> "site-impact-graph.building-1.room-1.rack-1"`)
+}
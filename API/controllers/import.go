@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"p3/models"
+	u "p3/utils"
+)
+
+// swagger:operation POST /api/import/csv Objects ImportCSV
+// Create objects from the rows of a CSV file (e.g. an inventory spreadsheet).
+// Each row is converted to an object with the mapping of the columns, its
+// template is applied and it is validated as any created object. A row can be
+// the parent of the rows below it. If all the rows are valid, all the objects
+// are created in a single transaction, otherwise none is created.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: body
+//     in: body
+//     description: 'Mandatory: file (content of the CSV file, with a header row),
+//     mapping (column: name, category, description, domain or attribute)
+//     and parentColumn (column with the id or the /P/ path of the parent).
+//     Optional: category (of all the objects, if no column is mapped to category),
+//     templateColumn (column with the slug of the template of the object),
+//     separator (default ",") and defaults (values of the fields and
+//     attributes not given by the file). The values of the attributes that
+//     are numbers or arrays are given as JSON, e.g. 42 or [600,1200].'
+//     required: true
+//     format: object
+//     example: '{"file": "Name,Rack,Model,U\nsrv01,site.B1.R1.A01,dell-r640,10",
+//     "category": "device", "mapping": {"Name": "name", "U": "posU"},
+//     "parentColumn": "Rack", "templateColumn": "Model"}'
+// responses:
+//		'201':
+//			description: 'Created. A report with the id of the object
+//			created for each row is returned.'
+//		'400':
+//			description: 'Bad request. Invalid file or mapping, or invalid rows:
+//			a report with the error of each invalid row is returned.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func ImportCSV(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 ImportCSV ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	var csvImport models.CSVImport
+	if err := decodeRequestBody(w, r, &csvImport); err != nil {
+		return
+	}
+
+	report, created, err := models.ImportCSV(csvImport, user.Roles)
+	if err != nil {
+		u.ErrLog("Error while importing CSV", "ImportCSV", err.Message, r)
+		u.RespondWithError(w, err)
+	} else if report.Errors > 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		u.Respond(w, u.RespDataWrapper(fmt.Sprintf("%d invalid rows, no object was created", report.Errors), report))
+	} else {
+		w.WriteHeader(http.StatusCreated)
+		u.Respond(w, u.RespDataWrapper("successfully imported objects", report))
+		for _, object := range created {
+			eventNotifier <- u.FormatNotifyData("create", object["category"].(string), object)
+		}
+	}
+}
+
+// swagger:operation POST /api/obj_templates/import Objects ImportDeviceTypes
+// Create object templates from device types of the community device-type
+// library (YAML). The size of the device is given by its height in U, its
+// ports (interfaces, console, power, front and rear ports) are components on
+// its rear face and its device and module bays are slots. The templates are
+// validated as any created template and all of them are created, or none.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: body
+//     in: body
+//     description: 'Mandatory: file (content of the YAML file, that may have
+//     several documents). Optional: widthMm (default 482.6, 19"), depthMm
+//     (of the full depth devices, default 800, the others are half of it) and
+//     imagesUrl (base URL of the elevation images of the library).'
+//     required: true
+//     format: object
+//     example: '{"file": "manufacturer: Dell\nmodel: PowerEdge R640\nslug: dell-poweredge-r640\nu_height: 1\n"}'
+// responses:
+//		'201':
+//			description: 'Created. The created templates are returned.'
+//		'400':
+//			description: 'Bad request. Invalid YAML file or invalid template.'
+//		'409':
+//			description: 'Conflict. A template with the same slug already exists.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func ImportDeviceTypes(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 ImportDeviceTypes ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	var deviceTypeImport models.DeviceTypeImport
+	if err := decodeRequestBody(w, r, &deviceTypeImport); err != nil {
+		return
+	}
+
+	templates, err := models.ImportDeviceTypes(deviceTypeImport, user.Roles)
+	if err != nil {
+		u.ErrLog("Error while importing device types", "ImportDeviceTypes", err.Message, r)
+		u.RespondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	u.Respond(w, u.RespDataWrapper("successfully imported templates", templates))
+}
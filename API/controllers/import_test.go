@@ -0,0 +1,171 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"p3/models"
+	"p3/test/e2e"
+	"p3/test/integration"
+	test_utils "p3/test/utils"
+	u "p3/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var importCSVEndpoint = test_utils.GetEndpoint("importCSV")
+
+func init() {
+	integration.RequireCreateSite("site-import")
+	integration.RequireCreateBuilding("site-import", "building-1")
+	integration.RequireCreateRoom("site-import.building-1", "room-1")
+
+	_, err := models.CreateEntity(u.OBJTMPL, map[string]any{
+		"slug":        "import-server",
+		"description": "1U server",
+		"category":    "device",
+		"sizeWDHmm":   []any{450, 700, 44},
+		"fbxModel":    "",
+		"attributes":  map[string]any{"type": "server", "vendor": "vendor"},
+	}, integration.ManagerUserRoles)
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+}
+
+// racks and their servers, the arrays are separated by commas so the columns by semicolons
+func importCSVBody(file string) []byte {
+	requestBody, _ := json.Marshal(map[string]any{
+		"file":      file,
+		"separator": ";",
+		"mapping": map[string]string{
+			"Type":     "category",
+			"Name":     "name",
+			"Height":   "height",
+			"Size":     "size",
+			"Position": "attributes.posXYZ",
+			"U":        "posU",
+		},
+		"parentColumn":   "Parent",
+		"templateColumn": "Model",
+		"defaults": map[string]any{
+			"heightUnit":  "U",
+			"sizeUnit":    "cm",
+			"posXYUnit":   "m",
+			"rotation":    []any{0, 0, 0},
+			"orientation": "front",
+		},
+	})
+	return requestBody
+}
+
+func TestImportCSVWithUnknownColumnRespondsWithError(t *testing.T) {
+	requestBody, _ := json.Marshal(map[string]any{
+		"file":         "Name,Parent\nrack-1,site-import.building-1.room-1",
+		"category":     "rack",
+		"mapping":      map[string]string{"Name": "name", "Vendor": "vendor"},
+		"parentColumn": "Parent",
+	})
+	e2e.ValidateManagedRequest(t, "POST", importCSVEndpoint, requestBody, http.StatusBadRequest, "Column Vendor not found in the CSV file")
+}
+
+func TestImportCSVWithoutCategoryRespondsWithError(t *testing.T) {
+	requestBody, _ := json.Marshal(map[string]any{
+		"file":         "Name,Parent\nrack-1,site-import.building-1.room-1",
+		"mapping":      map[string]string{"Name": "name"},
+		"parentColumn": "Parent",
+	})
+	e2e.ValidateManagedRequest(t, "POST", importCSVEndpoint, requestBody, http.StatusBadRequest, "A category or a column mapped to category is mandatory")
+}
+
+func TestImportCSVWithInvalidRowsCreatesNothing(t *testing.T) {
+	requestBody := importCSVBody("Type;Name;Parent;Model;Height;Size;Position;U\n" +
+		"rack;rack-invalid-1;/P/site-import/building-1/room-1;;42;[60,120];[1,1,0];\n" +
+		"rack;rack-invalid-2;site-import.building-1.room-1;;42;[60,120];;\n" +
+		"device;server-1;site-import.building-1.room-1.rack-invalid-1;unknown-template;;;;1\n")
+
+	response := e2e.ValidateManagedRequest(t, "POST", importCSVEndpoint, requestBody, http.StatusBadRequest, "2 invalid rows, no object was created")
+	report := response["data"].(map[string]any)
+	assert.Equal(t, 0.0, report["created"])
+	rows := report["rows"].([]any)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, "valid", rows[0].(map[string]any)["status"])
+	assert.Equal(t, "site-import.building-1.room-1.rack-invalid-1", rows[0].(map[string]any)["id"])
+	assert.Equal(t, "error", rows[1].(map[string]any)["status"])
+	assert.Equal(t, "JSON body doesn't validate with the expected JSON schema", rows[1].(map[string]any)["error"])
+	assert.Equal(t, 4.0, rows[2].(map[string]any)["row"])
+	assert.Equal(t, "Template unknown-template not found", rows[2].(map[string]any)["error"])
+
+	recorder := e2e.MakeRequest("GET", test_utils.GetEndpoint("entityInstance", "racks", "site-import.building-1.room-1.rack-invalid-1"), nil)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestImportCSV(t *testing.T) {
+	requestBody := importCSVBody("Type;Name;Parent;Model;Height;Size;Position;U\n" +
+		"rack;rack-1;/P/site-import/building-1/room-1;;42;[60,120];[1,1,0];\n" +
+		"device;server-1;site-import.building-1.room-1.rack-1;import-server;;;;10\n")
+
+	response := e2e.ValidateManagedRequest(t, "POST", importCSVEndpoint, requestBody, http.StatusCreated, "successfully imported objects")
+	report := response["data"].(map[string]any)
+	assert.Equal(t, 2.0, report["created"])
+	assert.Equal(t, 0.0, report["errors"])
+	rows := report["rows"].([]any)
+	assert.Equal(t, "created", rows[0].(map[string]any)["status"])
+	assert.Equal(t, "created", rows[1].(map[string]any)["status"])
+
+	response = e2e.ValidateManagedRequest(t, "GET", test_utils.GetEndpoint("entityInstance", "racks", "site-import.building-1.room-1.rack-1"), nil, http.StatusOK, "successfully got rack")
+	rack := response["data"].(map[string]any)
+	assert.Equal(t, integration.TestDBName, rack["domain"])
+	assert.Equal(t, 42.0, rack["attributes"].(map[string]any)["height"])
+
+	// the template of the device is applied
+	response = e2e.ValidateManagedRequest(t, "GET", test_utils.GetEndpoint("entityInstance", "devices", "site-import.building-1.room-1.rack-1.server-1"), nil, http.StatusOK, "successfully got device")
+	attributes := response["data"].(map[string]any)["attributes"].(map[string]any)
+	assert.Equal(t, "import-server", attributes["template"])
+	assert.Equal(t, 44.0, attributes["height"])
+	assert.Equal(t, 1.0, attributes["sizeU"])
+	assert.Equal(t, 10.0, attributes["posU"])
+	assert.Equal(t, "vendor", attributes["vendor"])
+
+	// importing again fails as the objects exist
+	response = e2e.ValidateManagedRequest(t, "POST", importCSVEndpoint, requestBody, http.StatusBadRequest, "2 invalid rows, no object was created")
+	rows = response["data"].(map[string]any)["rows"].([]any)
+	assert.Equal(t, "Object site-import.building-1.room-1.rack-1 already exists", rows[0].(map[string]any)["error"])
+}
+
+func TestImportCSVWithoutPermissionRespondsWithError(t *testing.T) {
+	requestBody := importCSVBody("Type;Name;Parent;Model;Height;Size;Position;U\n" +
+		"rack;rack-viewer;site-import.building-1.room-1;;42;[60,120];[1,1,0];\n")
+
+	response := e2e.ValidateRequestWithUser(t, "POST", importCSVEndpoint, requestBody, "viewer", http.StatusBadRequest, "1 invalid rows, no object was created")
+	rows := response["data"].(map[string]any)["rows"].([]any)
+	assert.Equal(t, "User does not have permission to create this object", rows[0].(map[string]any)["error"])
+}
+
+func TestImportDeviceTypes(t *testing.T) {
+	requestBody, _ := json.Marshal(map[string]any{
+		"file": "manufacturer: Dell\nmodel: PowerEdge R650\nslug: import-dell-r650\nu_height: 1\n" +
+			"interfaces:\n  - name: NIC1\n    type: 10gbase-t\nmodule-bays:\n  - name: PSU1\n" +
+			"---\nmanufacturer: Dell\nmodel: PowerEdge R750\nslug: import-dell-r750\nu_height: 2\nis_full_depth: false\n",
+		"depthMm": 700,
+	})
+	endpoint := test_utils.GetEndpoint("importDeviceTypes")
+
+	response := e2e.ValidateManagedRequest(t, "POST", endpoint, requestBody, http.StatusCreated, "successfully imported templates")
+	templates := response["data"].([]any)
+	assert.Len(t, templates, 2)
+
+	response = e2e.ValidateManagedRequest(t, "GET", test_utils.GetEndpoint("entityInstance", "obj_templates", "import-dell-r750"), nil, http.StatusOK, "successfully got obj_template")
+	template := response["data"].(map[string]any)
+	assert.Equal(t, []any{482.6, 350.0, 88.9}, template["sizeWDHmm"])
+	assert.Equal(t, "Dell", template["attributes"].(map[string]any)["vendor"])
+
+	// importing again fails as the templates exist
+	e2e.ValidateManagedRequest(t, "POST", endpoint, requestBody, http.StatusConflict, "Error while creating obj_template: Duplicates not allowed")
+}
+
+func TestImportInvalidDeviceTypesRespondsWithError(t *testing.T) {
+	requestBody, _ := json.Marshal(map[string]any{"file": "manufacturer: Dell\nu_height: 1\n"})
+	e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("importDeviceTypes"), requestBody, http.StatusBadRequest, "manufacturer and model are mandatory")
+}
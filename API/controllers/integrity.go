@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"p3/models"
+	u "p3/utils"
+)
+
+// swagger:operation GET /api/integrity Integrity CheckIntegrity
+// Scan the database for dangling references.
+// Each issue gives the object (id and category), the field holding the
+// reference and the reference that does not exist. Types of issues:
+// group-content (name in the content of a group), vlink, tag (slug in the
+// tags of an object), breaker-powerpanel and orphan (object whose parent
+// does not exist). Only the issues of the objects the user can read are returned.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// responses:
+//		'200':
+//			description: 'Return the issues.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func CheckIntegrity(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 CheckIntegrity ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	issues, err := models.CheckIntegrity(user.Roles)
+	if err != nil {
+		u.ErrLog("Error while checking integrity", "CheckIntegrity", err.Message, r)
+		u.RespondWithError(w, err)
+	} else {
+		u.Respond(w, u.RespDataWrapper("successfully checked integrity", map[string]any{"issues": issues}))
+	}
+}
+
+// swagger:operation POST /api/integrity/fix Integrity FixIntegrity
+// Repair the dangling references that can be safely removed.
+// Names of group contents, vlinks and tags that do not exist are removed
+// from the objects the user can modify, groups left empty are deleted.
+// Breakers and orphans need a manual repair and are returned as remaining.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// responses:
+//		'200':
+//			description: 'Return the fixed and remaining issues.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func FixIntegrity(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 FixIntegrity ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	fix, err := models.FixIntegrity(user.Roles)
+	if err != nil {
+		u.ErrLog("Error while fixing integrity", "FixIntegrity", err.Message, r)
+		u.RespondWithError(w, err)
+	} else {
+		u.Respond(w, u.RespDataWrapper("successfully fixed integrity", fix))
+	}
+}
@@ -0,0 +1,22 @@
+package controllers_test
+
+import (
+	"net/http"
+	"p3/test/e2e"
+	test_utils "p3/test/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckIntegrity(t *testing.T) {
+	response := e2e.ValidateManagedRequest(t, "GET", test_utils.GetEndpoint("integrity"), nil, http.StatusOK, "successfully checked integrity")
+	assert.Contains(t, response["data"].(map[string]any), "issues")
+}
+
+func TestFixIntegrity(t *testing.T) {
+	response := e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("integrityFix"), nil, http.StatusOK, "successfully fixed integrity")
+	data := response["data"].(map[string]any)
+	assert.Contains(t, data, "fixed")
+	assert.Contains(t, data, "remaining")
+}
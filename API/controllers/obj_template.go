@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"p3/models"
+	u "p3/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:operation GET /api/obj_templates/{slug}/instances Objects GetObjTemplateInstances
+// Get the objects built from an object template.
+// For each object, the version of the template it was built from is given,
+// 0 if it was created before templates had versions.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: slug
+//     in: path
+//     description: 'Slug of the object template.'
+//     required: true
+//     type: string
+//     default: "ibm-ns1200"
+// responses:
+//		'200':
+//			description: 'Found. The current version of the template
+//			and its instances are returned.'
+//		'404':
+//			description: Not Found. Template not found.
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func GetObjTemplateInstances(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetObjTemplateInstances ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	instances, err := models.GetObjTemplateInstances(mux.Vars(r)["slug"], user.Roles)
+	if err != nil {
+		u.ErrLog("Error while getting template instances", "GetObjTemplateInstances", err.Message, r)
+		u.RespondWithError(w, err)
+		return
+	}
+
+	u.Respond(w, u.RespDataWrapper("successfully got template instances", instances))
+}
+
+// swagger:operation POST /api/obj_templates/{slug}/instances/upgrade Objects UpgradeObjTemplateInstances
+// Apply the current version of an object template to the objects built from it.
+// The size, height, model and attributes of the template replace the values of
+// the objects. A value modified on an object since it was built is a conflict:
+// the object is not upgraded unless force is given. A device placed in a slot
+// that the template no longer has is always a conflict.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: slug
+//     in: path
+//     description: 'Slug of the object template.'
+//     required: true
+//     type: string
+//     default: "ibm-ns1200"
+//   - name: body
+//     in: body
+//     description: 'Optional: ids (of the instances to upgrade, all by default)
+//     and force (replace the values modified on the instances).'
+//     required: false
+//     format: object
+//     example: '{"ids": ["site.B1.R1.A01.srv01"], "force": false}'
+// responses:
+//		'200':
+//			description: 'Upgraded. A report with the status of each
+//			instance (upgraded, up-to-date, conflict or error) and its
+//			conflicts is returned.'
+//		'400':
+//			description: Bad request. Invalid body.
+//		'404':
+//			description: Not Found. Template not found.
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func UpgradeObjTemplateInstances(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 UpgradeObjTemplateInstances ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	var upgrade models.ObjTemplateUpgrade
+	if r.ContentLength != 0 {
+		if err := decodeRequestBody(w, r, &upgrade); err != nil {
+			return
+		}
+	}
+
+	report, err := models.UpgradeObjTemplateInstances(mux.Vars(r)["slug"], upgrade, user.Roles)
+	if err != nil {
+		u.ErrLog("Error while upgrading template instances", "UpgradeObjTemplateInstances", err.Message, r)
+		u.RespondWithError(w, err)
+		return
+	}
+
+	u.Respond(w, u.RespDataWrapper("successfully upgraded template instances", report))
+}
@@ -0,0 +1,150 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"p3/models"
+	"p3/test/e2e"
+	"p3/test/integration"
+	test_utils "p3/test/utils"
+	u "p3/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const upgradeRackId = "site-upgrade.building-1.room-1.rack-1"
+
+func init() {
+	integration.RequireCreateSite("site-upgrade")
+	integration.RequireCreateBuilding("site-upgrade", "building-1")
+	integration.RequireCreateRoom("site-upgrade.building-1", "room-1")
+	integration.RequireCreateRack("site-upgrade.building-1.room-1", "rack-1")
+
+	_, err := models.CreateEntity(u.OBJTMPL, map[string]any{
+		"slug":        "upgrade-server",
+		"description": "1U server",
+		"category":    "device",
+		"sizeWDHmm":   []any{450, 700, 44},
+		"fbxModel":    "",
+		"attributes":  map[string]any{"type": "server", "vendor": "vendor-1"},
+		"components":  []any{},
+		"slots":       []any{},
+	}, integration.ManagerUserRoles)
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+
+	for _, name := range []string{"server-1", "server-2"} {
+		device := test_utils.GetEntityMap("device", name, upgradeRackId, integration.TestDBName)
+		device["description"] = "1U server"
+		device["attributes"] = map[string]any{
+			"height":     44,
+			"heightUnit": "mm",
+			"size":       []any{450, 700},
+			"sizeUnit":   "mm",
+			"sizeU":      1,
+			"fbxModel":   "",
+			"template":   "upgrade-server",
+			"type":       "server",
+			"vendor":     "vendor-1",
+		}
+		if _, err := models.CreateEntity(u.DEVICE, device, integration.ManagerUserRoles); err != nil {
+			log.Fatalln(err.Error())
+		}
+	}
+}
+
+func getUpgradeInstance(t *testing.T, instances []any, id string) map[string]any {
+	for _, instance := range instances {
+		if instance.(map[string]any)["id"] == id {
+			return instance.(map[string]any)
+		}
+	}
+	t.Fatalf("Instance %s not found", id)
+	return nil
+}
+
+func TestGetInstancesOfUnknownTemplateRespondsWithError(t *testing.T) {
+	e2e.ValidateManagedRequest(t, "GET", test_utils.GetEndpoint("objTemplateInstances", "unknown-template"), nil, http.StatusNotFound, "Template unknown-template not found")
+}
+
+func TestUpgradeObjTemplateInstances(t *testing.T) {
+	endpoint := test_utils.GetEndpoint("objTemplateInstances", "upgrade-server")
+	response := e2e.ValidateManagedRequest(t, "GET", endpoint, nil, http.StatusOK, "successfully got template instances")
+	data := response["data"].(map[string]any)
+	assert.Equal(t, 1.0, data["version"])
+	instances := data["instances"].([]any)
+	assert.Len(t, instances, 2)
+	assert.Equal(t, 1.0, getUpgradeInstance(t, instances, upgradeRackId+".server-1")["templateVersion"])
+	assert.Equal(t, true, getUpgradeInstance(t, instances, upgradeRackId+".server-1")["upToDate"])
+
+	// server-2 is modified locally, then the template is corrected
+	requestBody, _ := json.Marshal(map[string]any{"attributes": map[string]any{"vendor": "local-vendor"}})
+	e2e.ValidateManagedRequest(t, "PATCH", test_utils.GetEndpoint("entityInstance", "devices", upgradeRackId+".server-2"), requestBody, http.StatusOK, "successfully updated device")
+	requestBody, _ = json.Marshal(map[string]any{"attributes": map[string]any{"type": "server", "vendor": "vendor-2"}})
+	response = e2e.ValidateManagedRequest(t, "PATCH", test_utils.GetEndpoint("entityInstance", "obj_templates", "upgrade-server"), requestBody, http.StatusOK, "successfully updated obj_template")
+	assert.Equal(t, 2.0, response["data"].(map[string]any)["version"])
+
+	response = e2e.ValidateManagedRequest(t, "GET", endpoint, nil, http.StatusOK, "successfully got template instances")
+	data = response["data"].(map[string]any)
+	assert.Equal(t, 2.0, data["version"])
+	assert.Equal(t, false, getUpgradeInstance(t, data["instances"].([]any), upgradeRackId+".server-1")["upToDate"])
+
+	// the modified value is a conflict
+	upgradeEndpoint := test_utils.GetEndpoint("upgradeObjTemplateInstances", "upgrade-server")
+	response = e2e.ValidateManagedRequest(t, "POST", upgradeEndpoint, nil, http.StatusOK, "successfully upgraded template instances")
+	instances = response["data"].(map[string]any)["instances"].([]any)
+	server1 := getUpgradeInstance(t, instances, upgradeRackId+".server-1")
+	assert.Equal(t, "upgraded", server1["status"])
+	assert.Equal(t, 1.0, server1["fromVersion"])
+	server2 := getUpgradeInstance(t, instances, upgradeRackId+".server-2")
+	assert.Equal(t, "conflict", server2["status"])
+	conflicts := server2["conflicts"].([]any)
+	assert.Len(t, conflicts, 1)
+	assert.Equal(t, "attributes.vendor", conflicts[0].(map[string]any)["field"])
+	assert.Equal(t, "local-vendor", conflicts[0].(map[string]any)["value"])
+	assert.Equal(t, "vendor-2", conflicts[0].(map[string]any)["templateValue"])
+
+	response = e2e.ValidateManagedRequest(t, "GET", test_utils.GetEndpoint("entityInstance", "devices", upgradeRackId+".server-1"), nil, http.StatusOK, "successfully got device")
+	attributes := response["data"].(map[string]any)["attributes"].(map[string]any)
+	assert.Equal(t, "vendor-2", attributes["vendor"])
+	assert.Equal(t, 2.0, attributes["templateVersion"])
+
+	// force replaces the modified value
+	requestBody, _ = json.Marshal(map[string]any{"ids": []string{upgradeRackId + ".server-2"}, "force": true})
+	response = e2e.ValidateManagedRequest(t, "POST", upgradeEndpoint, requestBody, http.StatusOK, "successfully upgraded template instances")
+	instances = response["data"].(map[string]any)["instances"].([]any)
+	assert.Len(t, instances, 1)
+	assert.Equal(t, "upgraded", instances[0].(map[string]any)["status"])
+
+	response = e2e.ValidateManagedRequest(t, "GET", test_utils.GetEndpoint("entityInstance", "devices", upgradeRackId+".server-2"), nil, http.StatusOK, "successfully got device")
+	attributes = response["data"].(map[string]any)["attributes"].(map[string]any)
+	assert.Equal(t, "vendor-2", attributes["vendor"])
+	assert.Equal(t, 2.0, attributes["templateVersion"])
+}
+
+func TestUpdateObjTemplateWithoutChangesKeepsItsVersion(t *testing.T) {
+	_, err := models.CreateEntity(u.OBJTMPL, map[string]any{
+		"slug":        "unchanged-server",
+		"description": "1U server",
+		"category":    "device",
+		"sizeWDHmm":   []any{450, 700, 44},
+		"fbxModel":    "",
+		"attributes":  map[string]any{"type": "server"},
+		"components":  []any{},
+		"slots":       []any{},
+	}, integration.ManagerUserRoles)
+	require.Nil(t, err)
+	endpoint := test_utils.GetEndpoint("entityInstance", "obj_templates", "unchanged-server")
+
+	requestBody, _ := json.Marshal(map[string]any{"description": "1U server"})
+	response := e2e.ValidateManagedRequest(t, "PATCH", endpoint, requestBody, http.StatusOK, "successfully updated obj_template")
+	assert.Equal(t, 1.0, response["data"].(map[string]any)["version"])
+
+	requestBody, _ = json.Marshal(map[string]any{"description": "1U rack server"})
+	response = e2e.ValidateManagedRequest(t, "PATCH", endpoint, requestBody, http.StatusOK, "successfully updated obj_template")
+	assert.Equal(t, 2.0, response["data"].(map[string]any)["version"])
+}
@@ -0,0 +1,264 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"p3/models"
+	u "p3/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:operation POST /api/relations Relations CreateRelation
+// Create a typed and directional relation between two objects.
+// Relations can be created between any hierarchical, stray or virtual objects.
+// They follow the objects when they are renamed or moved and are deleted with them.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: body
+//     in: body
+//     description: 'Mandatory: type (depends-on, backs-up, connected-to or managed-by),
+//     from and to (ids of the objects). Optional: attributes.
+//     The user must be able to modify the from object and to read the to object.'
+//     required: true
+//     format: object
+//     example: '{"type": "depends-on", "from": "app1", "to": "site.bldg.room.rack.srv.vm1",
+//     "attributes": {"criticality": "high"}}'
+// responses:
+//		'201':
+//			description: 'Created. The relation is returned.'
+//		'400':
+//			description: 'Bad request. Invalid relation, an object does not exist
+//			or the relation already exists.'
+//		'401':
+//			description: 'Unauthorized. The user cannot relate the objects.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func CreateRelation(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 CreateRelation ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	var relation models.Relation
+	if err := decodeRequestBody(w, r, &relation); err != nil {
+		return
+	}
+
+	createdRelation, err := models.CreateRelation(relation, user.Roles)
+	if err != nil {
+		u.ErrLog("Error while creating relation", "CreateRelation", err.Message, r)
+		u.RespondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	u.Respond(w, u.RespDataWrapper("successfully created relation", createdRelation))
+}
+
+// swagger:operation GET /api/relations Relations GetRelations
+// Get the relations between objects the user can read.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: from
+//     in: query
+//     description: 'Only return the relations from the object of this id.'
+//     type: string
+//   - name: to
+//     in: query
+//     description: 'Only return the relations to the object of this id.'
+//     type: string
+//   - name: type
+//     in: query
+//     description: 'Only return the relations of this type.'
+//     type: string
+// responses:
+//		'200':
+//			description: 'Return the relations.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func GetRelations(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetRelations ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	var query models.RelationQuery
+	decoder.Decode(&query, r.URL.Query())
+
+	relations, err := models.GetRelations(query, user.Roles)
+	if err != nil {
+		u.ErrLog("Error while getting relations", "GetRelations", err.Message, r)
+		u.RespondWithError(w, err)
+	} else {
+		u.Respond(w, u.RespDataWrapper("successfully got relations", map[string]any{"relations": relations}))
+	}
+}
+
+// swagger:operation GET /api/relations/{id} Relations GetRelation
+// Get a relation.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: id
+//     in: path
+//     description: 'ID of the relation.'
+//     required: true
+//     type: string
+// responses:
+//		'200':
+//			description: 'Return the relation.'
+//		'401':
+//			description: 'Unauthorized. The user cannot read the related objects.'
+//		'404':
+//			description: 'Not Found. The relation does not exist.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func GetRelation(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetRelation ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	relation, err := models.GetRelation(mux.Vars(r)["id"], user.Roles)
+	if err != nil {
+		u.ErrLog("Error while getting relation", "GetRelation", err.Message, r)
+		u.RespondWithError(w, err)
+	} else {
+		u.Respond(w, u.RespDataWrapper("successfully got relation", relation))
+	}
+}
+
+// swagger:operation PUT /api/relations/{id} Relations UpdateRelation
+// Replace a relation.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: id
+//     in: path
+//     description: 'ID of the relation.'
+//     required: true
+//     type: string
+//   - name: body
+//     in: body
+//     description: 'The new relation, same format as for the creation.'
+//     required: true
+//     format: object
+// responses:
+//		'200':
+//			description: 'Updated. The relation is returned.'
+//		'400':
+//			description: 'Bad request. Invalid relation, an object does not exist
+//			or the relation already exists.'
+//		'401':
+//			description: 'Unauthorized. The user cannot relate the objects.'
+//		'404':
+//			description: 'Not Found. The relation does not exist.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func UpdateRelation(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 UpdateRelation ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	var relation models.Relation
+	if err := decodeRequestBody(w, r, &relation); err != nil {
+		return
+	}
+
+	updatedRelation, err := models.UpdateRelation(mux.Vars(r)["id"], relation, user.Roles)
+	if err != nil {
+		u.ErrLog("Error while updating relation", "UpdateRelation", err.Message, r)
+		u.RespondWithError(w, err)
+		return
+	}
+
+	u.Respond(w, u.RespDataWrapper("successfully updated relation", updatedRelation))
+}
+
+// swagger:operation DELETE /api/relations/{id} Relations DeleteRelation
+// Delete a relation.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: id
+//     in: path
+//     description: 'ID of the relation.'
+//     required: true
+//     type: string
+// responses:
+//		'200':
+//			description: 'Deleted.'
+//		'401':
+//			description: 'Unauthorized. The user cannot relate the objects.'
+//		'404':
+//			description: 'Not Found. The relation does not exist.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func DeleteRelation(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 DeleteRelation ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	if err := models.DeleteRelation(mux.Vars(r)["id"], user.Roles); err != nil {
+		u.ErrLog("Error while deleting relation", "DeleteRelation", err.Message, r)
+		u.RespondWithError(w, err)
+		return
+	}
+
+	u.Respond(w, u.Message("successfully deleted relation"))
+}
@@ -0,0 +1,53 @@
+package controllers_test
+
+import (
+	"net/http"
+	"p3/test/e2e"
+	"p3/test/integration"
+	test_utils "p3/test/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	integration.RequireCreateSite("site-relations")
+	integration.RequireCreateBuilding("site-relations", "building-1")
+	integration.RequireCreateRoom("site-relations.building-1", "room-1")
+	integration.RequireCreateRack("site-relations.building-1.room-1", "rack-1")
+	integration.RequireCreateRack("site-relations.building-1.room-1", "rack-2")
+}
+
+func TestCreateRelationWithInvalidTypeRespondsWithError(t *testing.T) {
+	requestBody := []byte(`{"type": "likes", "from": "site-relations.building-1.room-1.rack-1", "to": "site-relations.building-1.room-1.rack-2"}`)
+	e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("relations"), requestBody, http.StatusBadRequest, "type must be one of: depends-on, backs-up, connected-to, managed-by")
+}
+
+func TestCreateRelationToUnknownObjectRespondsWithError(t *testing.T) {
+	requestBody := []byte(`{"type": "depends-on", "from": "site-relations.building-1.room-1.rack-1", "to": "site-relations.building-1.room-1.unknown"}`)
+	e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("relations"), requestBody, http.StatusBadRequest, "Object not found: site-relations.building-1.room-1.unknown")
+}
+
+func TestViewerCannotCreateRelation(t *testing.T) {
+	requestBody := []byte(`{"type": "depends-on", "from": "site-relations.building-1.room-1.rack-1", "to": "site-relations.building-1.room-1.rack-2"}`)
+	e2e.ValidateRequestWithUser(t, "POST", test_utils.GetEndpoint("relations"), requestBody, "viewer", http.StatusUnauthorized, "User does not have permission to relate object site-relations.building-1.room-1.rack-1")
+}
+
+func TestCreateGetAndDeleteRelation(t *testing.T) {
+	requestBody := []byte(`{"type": "connected-to", "from": "site-relations.building-1.room-1.rack-1", "to": "site-relations.building-1.room-1.rack-2", "attributes": {"cable": "fiber"}}`)
+	response := e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("relations"), requestBody, http.StatusCreated, "successfully created relation")
+	id := response["data"].(map[string]any)["id"].(string)
+
+	e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("relations"), requestBody, http.StatusBadRequest, "A relation connected-to from site-relations.building-1.room-1.rack-1 to site-relations.building-1.room-1.rack-2 already exists")
+
+	endpoint := test_utils.GetEndpoint("relations") + "?from=site-relations.building-1.room-1.rack-1&type=connected-to"
+	response = e2e.ValidateManagedRequest(t, "GET", endpoint, nil, http.StatusOK, "successfully got relations")
+	relations := response["data"].(map[string]any)["relations"].([]any)
+	require.Len(t, relations, 1)
+	assert.Equal(t, id, relations[0].(map[string]any)["id"])
+	assert.Equal(t, map[string]any{"cable": "fiber"}, relations[0].(map[string]any)["attributes"])
+
+	e2e.ValidateManagedRequest(t, "DELETE", test_utils.GetEndpoint("relation", id), nil, http.StatusOK, "successfully deleted relation")
+	e2e.ValidateManagedRequest(t, "GET", test_utils.GetEndpoint("relation", id), nil, http.StatusNotFound, "Relation not found")
+}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"p3/models"
+	u "p3/utils"
+)
+
+// swagger:operation GET /api/objects/search Objects SearchObjects
+// Full text search on objects names, descriptions and attributes.
+// Returns the matching objects of all entities ranked by relevance,
+// with the fields that matched the search terms.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: q
+//     in: query
+//     description: 'Words to search for. Objects matching any of the words
+//     are returned, the more matches the higher the score.
+//     Use quotes to search for a phrase and a leading - to exclude a word.'
+//     required: true
+//     type: string
+//     example: dell r740
+//   - name: namespace
+//     in: query
+//     description: 'One of the values: physical, physical.stray, physical.hierarchy,
+//     logical, logical.objtemplate, logical.bldgtemplate, logical.roomtemplate, logical.tag,
+//     organisational.
+//     If none provided, all namespaces are used by default.'
+//   - name: limit
+//     in: query
+//     description: 'Maximum number of results. Default is 50.'
+//     type: integer
+// responses:
+//		'200':
+//			description: 'Found. A response body will be returned with
+//			the list of results.'
+//		'400':
+//			description: 'Bad request. Query param q is missing.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func SearchObjects(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 SearchObjects ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	var filters u.SearchFilters
+	decoder.Decode(&filters, r.URL.Query())
+
+	results, err := models.SearchObjects(filters, user.Roles)
+	if err != nil {
+		u.ErrLog("Error while searching objects", "SearchObjects", err.Message, r)
+		u.RespondWithError(w, err)
+	} else {
+		u.Respond(w, u.RespDataWrapper("successfully searched objects", results))
+	}
+}
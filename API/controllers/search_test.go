@@ -0,0 +1,70 @@
+package controllers_test
+
+import (
+	"net/http"
+	"p3/models"
+	"p3/test/e2e"
+	"p3/test/integration"
+	test_utils "p3/test/utils"
+	u "p3/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	integration.RequireCreateSite("site-search")
+	integration.RequireCreateBuilding("site-search", "building-1")
+	integration.RequireCreateRoom("site-search.building-1", "room-1")
+	integration.RequireCreateRack("site-search.building-1.room-1", "rack-1")
+	integration.RequireCreateDevice("site-search.building-1.room-1.rack-1", "device-1")
+	models.UpdateObject("device", "site-search.building-1.room-1.rack-1.device-1", map[string]any{
+		"attributes": map[string]any{
+			"vendor": "Dell",
+			"model":  "PowerEdge R740",
+			"serial": "SN-SEARCH-4242",
+		},
+	}, true, integration.ManagerUserRoles, false)
+}
+
+func TestSearchWithoutQueryRespondsWithError(t *testing.T) {
+	e2e.ValidateManagedRequest(t, "GET", test_utils.GetEndpoint("textSearch", ""), nil, http.StatusBadRequest, "Query param q is mandatory")
+}
+
+func TestSearchFindsAttributeValues(t *testing.T) {
+	response := e2e.ValidateManagedRequest(t, "GET", test_utils.GetEndpoint("textSearch", "dell%20r740"), nil, http.StatusOK, "successfully searched objects")
+
+	results, ok := response["data"].([]any)
+	assert.True(t, ok)
+	assert.NotEmpty(t, results)
+
+	first := results[0].(map[string]any)
+	assert.Equal(t, "device", first["entity"])
+	assert.Equal(t, "site-search.building-1.room-1.rack-1.device-1", first["object"].(map[string]any)["id"])
+
+	fields := []string{}
+	for _, highlight := range first["highlights"].([]any) {
+		fields = append(fields, highlight.(map[string]any)["field"].(string))
+	}
+	assert.Contains(t, fields, "attributes.vendor")
+	assert.Contains(t, fields, "attributes.model")
+}
+
+func TestSearchWithNamespaceIgnoresOtherEntities(t *testing.T) {
+	response := e2e.ValidateManagedRequest(t, "GET", test_utils.GetEndpoint("textSearch", "SN-SEARCH-4242")+"&namespace=logical", nil, http.StatusOK, "successfully searched objects")
+	assert.Empty(t, response["data"])
+}
+
+func TestSearchOfObjectsOfParentDomainOnlyReturnsTheirName(t *testing.T) {
+	userRoles := map[string]models.Role{integration.TestDBName + ".child": models.Viewer}
+	results, err := models.SearchObjects(u.SearchFilters{Query: "SN-SEARCH-4242"}, userRoles)
+	assert.Nil(t, err)
+	assert.Len(t, results, 1)
+
+	assert.Equal(t, map[string]any{
+		"id":       "site-search.building-1.room-1.rack-1.device-1",
+		"category": "device",
+		"name":     "device-1",
+	}, results[0].Object)
+	assert.Empty(t, results[0].Highlights)
+}
@@ -0,0 +1,401 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"p3/models"
+	u "p3/utils"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// StartSnapshotsEngine: runs the snapshot schedules that are due every interval
+// (never if interval is 0)
+func StartSnapshotsEngine(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		for now := range time.NewTicker(interval).C {
+			taken, err := models.RunSnapshotSchedules(now)
+			if err != nil {
+				log.Println("Error while running snapshot schedules:", err.Message)
+			}
+			for _, snapshot := range taken {
+				log.Println("Scheduled snapshot taken:", snapshot.Name)
+			}
+		}
+	}()
+}
+
+// swagger:operation POST /api/snapshots Snapshots TakeSnapshot
+// Take a snapshot of the physical and virtual objects of the tenant or of a subtree.
+// The objects can then be read as they were with the asOf query parameter
+// of the object and hierarchy endpoints, and compared with /api/diff.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: body
+//     in: body
+//     description: 'Mandatory: name (letters, digits, - and _, not a date).
+//     Optional: root, id of the object whose subtree is copied,
+//     the whole tenant if not given. Only managers of the root domain
+//     can take snapshots of the whole tenant.'
+//     required: true
+//     format: object
+//     example: '{"name": "before-migration", "root": "siteA.B1.R1"}'
+// responses:
+//		'201':
+//			description: 'Created. The snapshot is returned.'
+//		'400':
+//			description: 'Bad request. Invalid name or root, or the snapshot already exists.'
+//		'401':
+//			description: 'Unauthorized. The user cannot read the root.'
+//		'404':
+//			description: 'Not Found. The root does not exist.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func TakeSnapshot(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 TakeSnapshot ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	var snapshot models.Snapshot
+	if err := decodeRequestBody(w, r, &snapshot); err != nil {
+		return
+	}
+
+	taken, err := models.TakeSnapshot(snapshot.Name, snapshot.Root, user.Roles)
+	if err != nil {
+		u.ErrLog("Error while taking snapshot", "TakeSnapshot", err.Message, r)
+		u.RespondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	u.Respond(w, u.RespDataWrapper("successfully took snapshot", taken))
+}
+
+// swagger:operation GET /api/snapshots Snapshots GetSnapshots
+// Get the snapshots of the tenant and of the objects the user can see, latest first.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// responses:
+//		'200':
+//			description: 'Return the snapshots.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func GetSnapshots(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetSnapshots ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	snapshots, err := models.GetSnapshots(user.Roles)
+	if err != nil {
+		u.ErrLog("Error while getting snapshots", "GetSnapshots", err.Message, r)
+		u.RespondWithError(w, err)
+	} else {
+		u.Respond(w, u.RespDataWrapper("successfully got snapshots", map[string]any{"snapshots": snapshots}))
+	}
+}
+
+// swagger:operation GET /api/snapshots/{name} Snapshots GetSnapshot
+// Get a snapshot.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: name
+//     in: path
+//     description: 'Name of the snapshot.'
+//     required: true
+//     type: string
+// responses:
+//		'200':
+//			description: 'Return the snapshot.'
+//		'404':
+//			description: 'Not Found. The snapshot does not exist.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func GetSnapshot(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetSnapshot ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	snapshot, err := models.GetSnapshot(mux.Vars(r)["name"], user.Roles)
+	if err != nil {
+		u.ErrLog("Error while getting snapshot", "GetSnapshot", err.Message, r)
+		u.RespondWithError(w, err)
+	} else {
+		u.Respond(w, u.RespDataWrapper("successfully got snapshot", snapshot))
+	}
+}
+
+// swagger:operation DELETE /api/snapshots/{name} Snapshots DeleteSnapshot
+// Delete a snapshot and its objects.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: name
+//     in: path
+//     description: 'Name of the snapshot.'
+//     required: true
+//     type: string
+// responses:
+//		'200':
+//			description: 'Deleted.'
+//		'401':
+//			description: 'Unauthorized. The user cannot modify the root of the snapshot.'
+//		'404':
+//			description: 'Not Found. The snapshot does not exist.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func DeleteSnapshot(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 DeleteSnapshot ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	if err := models.DeleteSnapshot(mux.Vars(r)["name"], user.Roles); err != nil {
+		u.ErrLog("Error while deleting snapshot", "DeleteSnapshot", err.Message, r)
+		u.RespondWithError(w, err)
+	} else {
+		u.Respond(w, u.Message("successfully deleted snapshot"))
+	}
+}
+
+// swagger:operation POST /api/snapshot_schedules Snapshots CreateSnapshotSchedule
+// Schedule snapshots of the tenant or of a subtree.
+// A snapshot named {name}-{yyyymmddThhmmss} is taken every interval.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: body
+//     in: body
+//     description: 'Mandatory: name and interval (duration of at least 1m, e.g. 24h).
+//     Optional: root (whole tenant if not given) and keep, number of snapshots
+//     kept, the oldest ones are deleted (all are kept if 0).
+//     The user must be able to modify the root, or manage the root domain for the tenant.'
+//     required: true
+//     format: object
+//     example: '{"name": "daily-R1", "root": "siteA.B1.R1", "interval": "24h", "keep": 30}'
+// responses:
+//		'201':
+//			description: 'Created. The schedule is returned.'
+//		'400':
+//			description: 'Bad request. Invalid schedule.'
+//		'401':
+//			description: 'Unauthorized. The user cannot modify the root.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func CreateSnapshotSchedule(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 CreateSnapshotSchedule ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	var schedule models.SnapshotSchedule
+	if err := decodeRequestBody(w, r, &schedule); err != nil {
+		return
+	}
+
+	createdSchedule, err := models.CreateSnapshotSchedule(schedule, user.Roles)
+	if err != nil {
+		u.ErrLog("Error while creating snapshot schedule", "CreateSnapshotSchedule", err.Message, r)
+		u.RespondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	u.Respond(w, u.RespDataWrapper("successfully created snapshot schedule", createdSchedule))
+}
+
+// swagger:operation GET /api/snapshot_schedules Snapshots GetSnapshotSchedules
+// Get the snapshot schedules of the tenant and of the objects the user can see.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// responses:
+//		'200':
+//			description: 'Return the schedules.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func GetSnapshotSchedules(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetSnapshotSchedules ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	schedules, err := models.GetSnapshotSchedules(user.Roles)
+	if err != nil {
+		u.ErrLog("Error while getting snapshot schedules", "GetSnapshotSchedules", err.Message, r)
+		u.RespondWithError(w, err)
+	} else {
+		u.Respond(w, u.RespDataWrapper("successfully got snapshot schedules",
+			map[string]any{"snapshot_schedules": schedules}))
+	}
+}
+
+// swagger:operation DELETE /api/snapshot_schedules/{id} Snapshots DeleteSnapshotSchedule
+// Delete a snapshot schedule. The snapshots already taken are kept.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: id
+//     in: path
+//     description: 'ID of the schedule.'
+//     required: true
+//     type: string
+// responses:
+//		'200':
+//			description: 'Deleted.'
+//		'401':
+//			description: 'Unauthorized. The user cannot modify the root of the schedule.'
+//		'404':
+//			description: 'Not Found. The schedule does not exist.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func DeleteSnapshotSchedule(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 DeleteSnapshotSchedule ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	if err := models.DeleteSnapshotSchedule(mux.Vars(r)["id"], user.Roles); err != nil {
+		u.ErrLog("Error while deleting snapshot schedule", "DeleteSnapshotSchedule", err.Message, r)
+		u.RespondWithError(w, err)
+	} else {
+		u.Respond(w, u.Message("successfully deleted snapshot schedule"))
+	}
+}
+
+// swagger:operation GET /api/diff Snapshots GetObjectsDiff
+// List the objects added, removed or changed between two points in time.
+// Changed objects come with the list of their changed attributes, nested
+// fields are given with a dotted path (e.g. attributes.height).
+// Only the objects the user can read are compared.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: from
+//     in: query
+//     description: 'Mandatory. Name of a snapshot or date (yyyy-mm-dd or RFC3339),
+//     the latest snapshot of the root taken at or before the date is used.'
+//     required: true
+//     type: string
+//   - name: to
+//     in: query
+//     description: 'Same as from. The current state is used if not given.'
+//     type: string
+//   - name: root
+//     in: query
+//     description: 'ID of the object whose subtree is compared, the whole tenant if not given.'
+//     type: string
+// responses:
+//		'200':
+//			description: 'Return the added, removed and changed objects.'
+//		'400':
+//			description: 'Bad request. from is missing.'
+//		'404':
+//			description: 'Not Found. No snapshot of the root matches from or to.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func GetObjectsDiff(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetObjectsDiff ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	query := r.URL.Query()
+	diff, err := models.DiffObjects(query.Get("from"), query.Get("to"), query.Get("root"), user.Roles)
+	if err != nil {
+		u.ErrLog("Error while comparing objects", "GetObjectsDiff", err.Message, r)
+		u.RespondWithError(w, err)
+	} else {
+		u.Respond(w, u.RespDataWrapper("successfully compared objects", diff))
+	}
+}
@@ -0,0 +1,87 @@
+package controllers_test
+
+import (
+	"net/http"
+	"p3/models"
+	"p3/test/e2e"
+	"p3/test/integration"
+	test_utils "p3/test/utils"
+	u "p3/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	integration.RequireCreateSite("site-snapshots")
+	integration.RequireCreateBuilding("site-snapshots", "building-1")
+	integration.RequireCreateRoom("site-snapshots.building-1", "room-1")
+	integration.RequireCreateRack("site-snapshots.building-1.room-1", "rack-1")
+}
+
+func TestTakeSnapshotWithoutNameRespondsWithError(t *testing.T) {
+	requestBody := []byte(`{"root": "site-snapshots"}`)
+	e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("snapshots"), requestBody, http.StatusBadRequest, "name is mandatory and can only contain letters, digits, - and _")
+}
+
+func TestViewerCannotTakeSnapshotOfTenant(t *testing.T) {
+	requestBody := []byte(`{"name": "tenant-snapshot"}`)
+	e2e.ValidateRequestWithUser(t, "POST", test_utils.GetEndpoint("snapshots"), requestBody, "viewer", http.StatusUnauthorized, "Only managers of the root domain can take snapshots of the whole tenant")
+}
+
+func TestGetObjectAsOfSnapshot(t *testing.T) {
+	requestBody := []byte(`{"name": "snapshot-rooms", "root": "site-snapshots.building-1.room-1"}`)
+	response := e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("snapshots"), requestBody, http.StatusCreated, "successfully took snapshot")
+	assert.Equal(t, 2.0, response["data"].(map[string]any)["objectCount"])
+	defer e2e.ValidateManagedRequest(t, "DELETE", test_utils.GetEndpoint("snapshot", "snapshot-rooms"), nil, http.StatusOK, "successfully deleted snapshot")
+
+	_, _, err := models.UpdateObject(u.EntityToString(u.RACK), "site-snapshots.building-1.room-1.rack-1",
+		map[string]any{"description": "after snapshot"}, true, integration.ManagerUserRoles, false)
+	require.Nil(t, err)
+
+	endpoint := test_utils.GetEndpoint("entityInstance", "racks", "site-snapshots.building-1.room-1.rack-1") + "?asOf=snapshot-rooms"
+	response = e2e.ValidateManagedRequest(t, "GET", endpoint, nil, http.StatusOK, "successfully got rack")
+	assert.NotEqual(t, "after snapshot", response["data"].(map[string]any)["description"])
+
+	endpoint = test_utils.GetEndpoint("entityInstance", "rooms", "site-snapshots.building-1.room-1") + "/all?asOf=snapshot-rooms"
+	response = e2e.ValidateManagedRequest(t, "GET", endpoint, nil, http.StatusOK, "successfully got object's hierarchy")
+	children := response["data"].(map[string]any)["children"].([]any)
+	require.Len(t, children, 1)
+	assert.Equal(t, "site-snapshots.building-1.room-1.rack-1", children[0].(map[string]any)["id"])
+
+	endpoint = test_utils.GetEndpoint("diff") + "?from=snapshot-rooms&root=site-snapshots.building-1.room-1"
+	response = e2e.ValidateManagedRequest(t, "GET", endpoint, nil, http.StatusOK, "successfully compared objects")
+	changed := response["data"].(map[string]any)["changed"].([]any)
+	require.Len(t, changed, 1)
+	assert.Equal(t, []any{map[string]any{
+		"attribute": "description",
+		"from":      "rack-1",
+		"to":        "after snapshot",
+	}}, changed[0].(map[string]any)["changes"])
+}
+
+func TestGetObjectAsOfDateWithoutSnapshotRespondsWithError(t *testing.T) {
+	endpoint := test_utils.GetEndpoint("entityInstance", "sites", "site-snapshots") + "?asOf=2000-01-01"
+	e2e.ValidateManagedRequest(t, "GET", endpoint, nil, http.StatusNotFound, "No snapshot of site-snapshots was taken at or before 2000-01-01")
+}
+
+func TestDiffWithoutFromRespondsWithError(t *testing.T) {
+	e2e.ValidateManagedRequest(t, "GET", test_utils.GetEndpoint("diff"), nil, http.StatusBadRequest, "from is mandatory")
+}
+
+func TestCreateSnapshotScheduleWithInvalidIntervalRespondsWithError(t *testing.T) {
+	requestBody := []byte(`{"name": "schedule", "root": "site-snapshots", "interval": "10s"}`)
+	e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("snapshotSchedules"), requestBody, http.StatusBadRequest, "interval must be a duration of at least 1m, e.g. 24h")
+}
+
+func TestCreateAndDeleteSnapshotSchedule(t *testing.T) {
+	requestBody := []byte(`{"name": "daily-site-snapshots", "root": "site-snapshots", "interval": "24h", "keep": 7}`)
+	response := e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("snapshotSchedules"), requestBody, http.StatusCreated, "successfully created snapshot schedule")
+	scheduleId := response["data"].(map[string]any)["id"].(string)
+
+	response = e2e.ValidateManagedRequest(t, "GET", test_utils.GetEndpoint("snapshotSchedules"), nil, http.StatusOK, "successfully got snapshot schedules")
+	assert.NotEmpty(t, response["data"].(map[string]any)["snapshot_schedules"])
+
+	e2e.ValidateManagedRequest(t, "DELETE", test_utils.GetEndpoint("snapshotSchedule", scheduleId), nil, http.StatusOK, "successfully deleted snapshot schedule")
+}
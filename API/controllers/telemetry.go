@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"p3/models"
+	u "p3/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:operation POST /api/telemetry Telemetry CreateTelemetry
+// Store sensor readings of physical objects.
+// The latest value of each sensor is also saved in the object, in
+// attributes.telemetry.[sensor].value, so that it can be used in filters and layers
+// (e.g. telemetry.temperature.value>30).
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: body
+//     in: body
+//     description: 'A JSON array of readings. Each reading has the objectId,
+//     the sensor name, a numeric value and an optional RFC3339 timestamp
+//     (the time of the request if not given).
+//     The user must have write permission on the objects.'
+//     required: true
+//     format: object
+//     example: '[{"objectId": "siteA.B1.R1.A01", "sensor": "temperature",
+//     "value": 24.5, "timestamp": "2024-01-31T10:00:00Z"}]'
+// responses:
+//		'201':
+//			description: 'Created. The number of stored readings is returned.'
+//		'400':
+//			description: 'Bad request. Invalid reading.'
+//		'401':
+//			description: 'Unauthorized. No write permission on an object.'
+//		'404':
+//			description: 'Not Found. An object does not exist.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func CreateTelemetry(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 CreateTelemetry ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	var readings []models.TelemetryReading
+	if err := decodeRequestBody(w, r, &readings); err != nil {
+		return
+	}
+
+	count, changes, err := models.CreateTelemetryReadings(readings, user.Roles)
+	if err != nil {
+		u.ErrLog("Error while creating telemetry", "CreateTelemetry", err.Message, r)
+		u.RespondWithError(w, err)
+	} else {
+		// the latest values can raise or resolve alerts
+		triggerAlertRulesEvaluation()
+		w.WriteHeader(http.StatusCreated)
+		u.Respond(w, u.RespDataWrapper("successfully created telemetry", map[string]any{"count": count}))
+		notifyReferenceChanges(changes)
+	}
+}
+
+// swagger:operation GET /api/objects/{id}/telemetry Telemetry GetObjectTelemetry
+// Get the sensor readings of a physical object, downsampled by step.
+// Each point of a series gives the average, minimum and maximum
+// of the readings of its step, and the number of readings.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: id
+//     in: path
+//     description: 'ID of the object.'
+//     required: true
+//     type: string
+//     example: siteA.B1.R1.A01
+//   - name: from
+//     in: query
+//     description: 'RFC3339 start of the series. Default is 24h before to.'
+//     type: string
+//     example: 2024-01-31T00:00:00Z
+//   - name: to
+//     in: query
+//     description: 'RFC3339 end of the series, excluded. Default is now.'
+//     type: string
+//   - name: step
+//     in: query
+//     description: 'Duration of each point (e.g. 30s, 5m, 1h). By default,
+//     the step is chosen to return at most 1000 points.'
+//     type: string
+//   - name: sensor
+//     in: query
+//     description: 'Only return the series of this sensor.'
+//     type: string
+// responses:
+//		'200':
+//			description: 'Found. A response body will be returned with
+//			the series of each sensor.'
+//		'400':
+//			description: 'Bad request. Invalid from, to or step.'
+//		'404':
+//			description: 'Not Found. The object does not exist.'
+//		'500':
+//			description: Internal Error. A system error stopped the request.
+
+func GetObjectTelemetry(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetObjectTelemetry ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	var query models.TelemetryQuery
+	decoder.Decode(&query, r.URL.Query())
+
+	series, err := models.GetTelemetrySeries(mux.Vars(r)["id"], query, user.Roles)
+	if err != nil {
+		u.ErrLog("Error while getting telemetry", "GetObjectTelemetry", err.Message, r)
+		u.RespondWithError(w, err)
+	} else {
+		u.Respond(w, u.RespDataWrapper("successfully got telemetry", series))
+	}
+}
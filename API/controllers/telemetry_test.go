@@ -0,0 +1,95 @@
+package controllers_test
+
+import (
+	"net/http"
+	"net/url"
+	"p3/test/e2e"
+	"p3/test/integration"
+	test_utils "p3/test/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const telemetryRackId = "site-telemetry.building-1.room-1.rack-1"
+
+func init() {
+	integration.RequireCreateSite("site-telemetry")
+	integration.RequireCreateBuilding("site-telemetry", "building-1")
+	integration.RequireCreateRoom("site-telemetry.building-1", "room-1")
+	integration.RequireCreateRack("site-telemetry.building-1.room-1", "rack-1")
+}
+
+func TestCreateTelemetryWithoutValueRespondsWithError(t *testing.T) {
+	requestBody := []byte(`[{"objectId": "` + telemetryRackId + `", "sensor": "temperature"}]`)
+	e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("telemetry"), requestBody, http.StatusBadRequest, "Reading 0: value is mandatory")
+}
+
+func TestCreateTelemetryOfUnknownObjectRespondsWithError(t *testing.T) {
+	requestBody := []byte(`[{"objectId": "site-telemetry.unknown", "sensor": "temperature", "value": 20}]`)
+	e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("telemetry"), requestBody, http.StatusNotFound, "Unable to find object site-telemetry.unknown")
+}
+
+func TestGetTelemetryWithInvalidStepRespondsWithError(t *testing.T) {
+	endpoint := test_utils.GetEndpoint("objectTelemetry", telemetryRackId) + "?step=1ms"
+	e2e.ValidateManagedRequest(t, "GET", endpoint, nil, http.StatusBadRequest, "Invalid step, it must be a duration of at least 1s (e.g. 30s, 5m, 1h)")
+}
+
+func TestCreateTelemetryAndGetDownsampledSeries(t *testing.T) {
+	requestBody := []byte(`[
+		{"objectId": "` + telemetryRackId + `", "sensor": "temperature", "value": 20, "timestamp": "2024-01-31T10:00:00Z"},
+		{"objectId": "` + telemetryRackId + `", "sensor": "temperature", "value": 22, "timestamp": "2024-01-31T10:00:30Z"},
+		{"objectId": "` + telemetryRackId + `", "sensor": "temperature", "value": 30, "timestamp": "2024-01-31T10:02:10Z"},
+		{"objectId": "` + telemetryRackId + `", "sensor": "power", "value": 1200, "timestamp": "2024-01-31T10:01:00Z"}
+	]`)
+	response := e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("telemetry"), requestBody, http.StatusCreated, "successfully created telemetry")
+	assert.Equal(t, 4.0, response["data"].(map[string]any)["count"])
+
+	query := url.Values{}
+	query.Set("from", "2024-01-31T10:00:00Z")
+	query.Set("to", "2024-01-31T10:05:00Z")
+	query.Set("step", "1m")
+	endpoint := test_utils.GetEndpoint("objectTelemetry", telemetryRackId) + "?" + query.Encode()
+	response = e2e.ValidateManagedRequest(t, "GET", endpoint, nil, http.StatusOK, "successfully got telemetry")
+
+	data := response["data"].(map[string]any)
+	assert.Equal(t, "1m0s", data["step"])
+	series := data["series"].(map[string]any)
+	assert.Len(t, series["power"], 1)
+
+	temperature := series["temperature"].([]any)
+	assert.Len(t, temperature, 2)
+	first := temperature[0].(map[string]any)
+	assert.Equal(t, "2024-01-31T10:00:00Z", first["timestamp"])
+	assert.Equal(t, 21.0, first["avg"])
+	assert.Equal(t, 20.0, first["min"])
+	assert.Equal(t, 22.0, first["max"])
+	assert.Equal(t, 2.0, first["count"])
+	second := temperature[1].(map[string]any)
+	assert.Equal(t, "2024-01-31T10:02:00Z", second["timestamp"])
+	assert.Equal(t, 30.0, second["avg"])
+
+	// the latest value is on the object and can be filtered
+	filterBody := []byte(`{"filter": "telemetry.temperature.value>25"}`)
+	response = e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("complexFilterSearch")+"?namespace=physical.hierarchy", filterBody, http.StatusOK, "successfully processed request")
+	ids := []string{}
+	for _, object := range response["data"].([]any) {
+		ids = append(ids, object.(map[string]any)["id"].(string))
+	}
+	assert.Contains(t, ids, telemetryRackId)
+}
+
+func TestCreateTelemetryUpdatesTheObject(t *testing.T) {
+	endpoint := test_utils.GetEndpoint("entityInstance", "racks", telemetryRackId)
+	response := e2e.ValidateManagedRequest(t, "GET", endpoint, nil, http.StatusOK, "successfully got rack")
+	lastUpdated := response["data"].(map[string]any)["lastUpdated"]
+
+	requestBody := []byte(`[{"objectId": "` + telemetryRackId + `", "sensor": "humidity", "value": 40}]`)
+	e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("telemetry"), requestBody, http.StatusCreated, "successfully created telemetry")
+
+	response = e2e.ValidateManagedRequest(t, "GET", endpoint, nil, http.StatusOK, "successfully got rack")
+	rack := response["data"].(map[string]any)
+	assert.NotEqual(t, lastUpdated, rack["lastUpdated"])
+	humidity := rack["attributes"].(map[string]any)["telemetry"].(map[string]any)["humidity"]
+	assert.Equal(t, 40.0, humidity.(map[string]any)["value"])
+}
@@ -1,18 +1,23 @@
 package controllers
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
 	"p3/models"
 	u "p3/utils"
+	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
 
 // swagger:operation GET /api/projects FlutterApp GetProjects
-// Get a list of projects for the specified user.
+// Get a list of projects for the specified user: the projects it owns,
+// the public projects and the projects shared with it or with one of its domains.
+// Each project has the role of the user on it (owner, editor or viewer).
 // ---
 // security:
 // - bearer: []
@@ -21,16 +26,16 @@ import (
 // parameters:
 // - name: user
 //   in: query
-//   description: 'Email of the user whose projects are being requested.
-//   Example: /api/projects?user=user@test.com'
-//   required: true
+//   description: 'Email of the user whose projects are being requested,
+//   it must be the user of the token. Example: /api/projects?user=user@test.com'
+//   required: false
 //   type: string
 //   default: user@test.com
 // responses:
 //		'200':
 //			description: 'Return all possible projects.'
-//		'400':
-//			description: 'Bad Request. Invalid user query param.'
+//		'401':
+//			description: 'Unauthorized. The user query param is not the user of the token.'
 //		'500':
 //			description: 'Internal server error.'
 
@@ -39,16 +44,20 @@ func GetProjects(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("FUNCTION CALL: 	 GetProjects ")
 	fmt.Println("******************************************************")
 
-	query, _ := url.ParseQuery(r.URL.RawQuery)
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
 
-	if len(query["user"]) <= 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		resp := u.Message("Error: user should be sent as query param")
-		u.Respond(w, resp)
+	query, _ := url.ParseQuery(r.URL.RawQuery)
+	if len(query["user"]) > 0 && query["user"][0] != user.Email {
+		u.RespondWithError(w, &u.Error{Type: u.ErrUnauthorized,
+			Message: "Users can only get their own projects"})
 		return
 	}
 
-	projects, err := models.GetProjectsByUserEmail(query["user"][0])
+	projects, err := models.GetProjectsByUserEmail(user.Email, user.Roles)
 	if err != nil {
 		u.RespondWithError(w, err)
 	} else {
@@ -63,7 +72,7 @@ func GetProjects(w http.ResponseWriter, r *http.Request) {
 }
 
 // swagger:operation POST /api/projects FlutterApp CreateProjects
-// Create a new project
+// Create a new project, owned by the user that creates it.
 // ---
 // security:
 // - bearer: []
@@ -74,7 +83,9 @@ func GetProjects(w http.ResponseWriter, r *http.Request) {
 //     in: body
 //     description: 'Mandatory: name, dateRange, namespace, attributes,
 //     objects, permissions, authorLastUpdate, lastUpdate.
-//     Optional: showAvg, showSum, isPublic.'
+//     Optional: showAvg, showSum, isPublic, owner, sharing.
+//     sharing is a list of {"user": email, "role": role} or
+//     {"domain": domain, "role": role}, role being owner, editor or viewer.'
 //     required: true
 //     format: object
 //     example: '{"attributes":["domain"],"authorLastUpdate":"helder","dateRange":"01/01/2023-02/02/2023",
@@ -89,7 +100,9 @@ func GetProjects(w http.ResponseWriter, r *http.Request) {
 //			description: 'Internal server error.'
 
 // swagger:operation PUT /api/projects/{ProjectID} FlutterApp UpdateProjects
-// Replace the data of an existing project.
+// Replace the data of an existing project, the previous data is saved as a revision.
+// Editors and owners of the project can update it, only owners can change
+// its owner, permissions, isPublic and sharing. If sharing is not given, it is kept.
 // ---
 // security:
 // - bearer: []
@@ -106,7 +119,9 @@ func GetProjects(w http.ResponseWriter, r *http.Request) {
 //     in: body
 //     description: 'Mandatory: name, dateRange, namespace, attributes,
 //     objects, permissions, authorLastUpdate, lastUpdate.
-//     Optional: showAvg, showSum, isPublic.'
+//     Optional: showAvg, showSum, isPublic, owner, sharing.
+//     sharing is a list of {"user": email, "role": role} or
+//     {"domain": domain, "role": role}, role being owner, editor or viewer.'
 //     required: true
 //     format: object
 //     example: '{"attributes":["domain"],"authorLastUpdate":"helder","dateRange":"01/01/2023-02/02/2023",
@@ -117,6 +132,12 @@ func GetProjects(w http.ResponseWriter, r *http.Request) {
 //			description: Project successfully updated.
 //		'400':
 //			description: Bad Request. Invalid project format.
+//		'401':
+//			description: Unauthorized. The user is not an editor of the project.
+//		'404':
+//			description: Not Found. Invalid project ID.
+//		'409':
+//			description: Conflict. The project was modified by another user.
 //		'500':
 //			description: Internal server error
 
@@ -125,6 +146,12 @@ func CreateOrUpdateProject(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("FUNCTION CALL: 	 CreateOrUpdateProject ")
 	fmt.Println("******************************************************")
 
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
 	project := &models.Project{}
 	err := json.NewDecoder(r.Body).Decode(project)
 	if err != nil {
@@ -136,10 +163,10 @@ func CreateOrUpdateProject(w http.ResponseWriter, r *http.Request) {
 	var modelErr *u.Error
 	if r.Method == "POST" {
 		// Create project
-		modelErr = models.AddProject(*project)
+		project, modelErr = models.CreateProject(*project, user.Email)
 	} else {
 		// Update project
-		modelErr = models.UpdateProject(*project, mux.Vars(r)["id"])
+		project, modelErr = models.UpdateProject(*project, mux.Vars(r)["id"], user.Email, user.Roles)
 	}
 
 	if modelErr != nil {
@@ -156,7 +183,7 @@ func CreateOrUpdateProject(w http.ResponseWriter, r *http.Request) {
 }
 
 // swagger:operation DELETE /api/projects/{ProjectID} FlutterApp DeleteProjects
-// Delete an existing project.
+// Delete an existing project and its revisions. Only owners of the project can delete it.
 // ---
 // security:
 // - bearer: []
@@ -172,6 +199,8 @@ func CreateOrUpdateProject(w http.ResponseWriter, r *http.Request) {
 // responses:
 //  '200':
 //      description: Project successfully removed.
+//  '401':
+//      description: Unauthorized. The user is not an owner of the project.
 //  '404':
 //      description: Not Found. Invalid project ID.
 //  '500':
@@ -182,7 +211,13 @@ func DeleteProject(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("FUNCTION CALL: 	 DeleteProject ")
 	fmt.Println("******************************************************")
 
-	err := models.DeleteProject(mux.Vars(r)["id"])
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	err := models.DeleteProject(mux.Vars(r)["id"], user.Email, user.Roles)
 
 	if err != nil {
 		u.RespondWithError(w, err)
@@ -195,6 +230,191 @@ func DeleteProject(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// swagger:operation GET /api/projects/{ProjectID}/revisions FlutterApp GetProjectRevisions
+// Get the previous versions of a project, latest first.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+// - name: ProjectID
+//   in: path
+//   description: 'ID of the project.'
+//   required: true
+//   type: string
+// responses:
+//  '200':
+//      description: The revisions of the project.
+//  '401':
+//      description: Unauthorized. The project is not shared with the user.
+//  '404':
+//      description: Not Found. Invalid project ID.
+//  '500':
+//      description: Internal server error
+
+func GetProjectRevisions(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetProjectRevisions ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	revisions, err := models.GetProjectRevisions(mux.Vars(r)["id"], user.Email, user.Roles)
+	if err != nil {
+		u.ErrLog("Error while getting project revisions", "GetProjectRevisions", err.Message, r)
+		u.RespondWithError(w, err)
+		return
+	}
+
+	u.Respond(w, u.RespDataWrapper("successfully got project revisions",
+		map[string]any{"revisions": revisions}))
+}
+
+// swagger:operation POST /api/projects/{ProjectID}/revisions/{revision}/restore FlutterApp RestoreProjectRevision
+// Replace the data of a project by the data of one of its revisions.
+// The current data is saved as a new revision, the owner and the sharing
+// of the project are not restored. Editors and owners of the project can restore it.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+// - name: ProjectID
+//   in: path
+//   description: 'ID of the project.'
+//   required: true
+//   type: string
+// - name: revision
+//   in: path
+//   description: 'Number of the revision to restore.'
+//   required: true
+//   type: integer
+// responses:
+//  '200':
+//      description: Project successfully restored.
+//  '401':
+//      description: Unauthorized. The user is not an editor of the project.
+//  '404':
+//      description: Not Found. Invalid project ID or revision.
+//  '409':
+//      description: Conflict. The project was modified by another user.
+//  '500':
+//      description: Internal server error
+
+func RestoreProjectRevision(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 RestoreProjectRevision ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	// the route only accepts digits
+	revision, _ := strconv.Atoi(mux.Vars(r)["revision"])
+
+	project, err := models.RestoreProjectRevision(mux.Vars(r)["id"], revision, user.Email, user.Roles)
+	if err != nil {
+		u.ErrLog("Error while restoring project revision", "RestoreProjectRevision", err.Message, r)
+		u.RespondWithError(w, err)
+		return
+	}
+
+	u.Respond(w, u.RespDataWrapper("successfully restored project", project))
+}
+
+// swagger:operation GET /api/projects/{ProjectID}/result FlutterApp GetProjectResult
+// Get the table of a project, computed as in the APP: the value of each
+// attribute of the project for each of its objects, and the sum and average
+// of each attribute if the project shows them. The objects that are not in
+// the namespace of the project or that were not updated in its date range
+// have no values.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// - text/csv
+// - application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// parameters:
+//   - name: ProjectID
+//     in: path
+//     description: 'ID of the project.'
+//     required: true
+//     type: string
+//   - name: format
+//     in: query
+//     description: 'Format of the result: json (default), csv or xlsx.
+//     The csv and xlsx files have a header row, one row per object
+//     and the Sum and Average rows at the end.'
+//     type: string
+//     example: csv
+// responses:
+//  '200':
+//      description: The result of the project.
+//  '400':
+//      description: Bad request. Invalid format or invalid date range of the project.
+//  '401':
+//      description: Unauthorized. The project is not shared with the user.
+//  '404':
+//      description: Not Found. Invalid project ID.
+//  '500':
+//      description: Internal server error
+
+func GetProjectResult(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 GetProjectResult ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" && format != "xlsx" {
+		u.RespondWithError(w, &u.Error{Type: u.ErrBadFormat,
+			Message: "Invalid format, it must be one of: json, csv, xlsx"})
+		return
+	}
+
+	result, err := models.GetProjectResult(mux.Vars(r)["id"], user.Email, user.Roles)
+	if err != nil {
+		u.ErrLog("Error while getting project result", "GetProjectResult", err.Message, r)
+		u.RespondWithError(w, err)
+		return
+	}
+
+	fileName := strings.ReplaceAll(result.Name, `"`, "") + "." + format
+	switch format {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+fileName+`"`)
+		writer := csv.NewWriter(w)
+		writer.WriteAll(result.Table())
+	case "xlsx":
+		w.Header().Set("Content-Type", u.XlsxContentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="`+fileName+`"`)
+		if err := u.WriteXLSX(w, result.Name, result.Table()); err != nil {
+			u.ErrLog("Error while writing project result", "GetProjectResult", err.Error(), r)
+		}
+	default:
+		u.Respond(w, u.RespDataWrapper("successfully got project result", result))
+	}
+}
+
 // swagger:operation POST /api/alerts FlutterApp CreateAlert
 // Create a new alert
 // ---
@@ -205,8 +425,12 @@ func DeleteProject(w http.ResponseWriter, r *http.Request) {
 // parameters:
 //   - name: body
 //     in: body
-//     description: 'Mandatory: id, type.
-//     Optional: title, subtitle.'
+//     description: 'Optional: id (generated if not given), severity (info, minor,
+//     major or critical, type is accepted as an alias), title, subtitle,
+//     objects (ids of the affected objects), status (open, acknowledged or resolved), assignee.
+//     An alert created with the id of an object and without objects is linked to it.
+//     The user must be able to modify the objects of the alert. Only administrators
+//     can create alerts that are not linked to objects.'
 //     required: true
 //     format: object
 //     example: '{"id":"OBJID.WITH.ALERT","type":"minor",
@@ -216,6 +440,12 @@ func DeleteProject(w http.ResponseWriter, r *http.Request) {
 //			description: 'Alert successfully created.'
 //		'400':
 //			description: 'Bad Request. Invalid alert format.'
+//		'401':
+//			description: 'Unauthorized. The user cannot modify the objects of the alert.'
+//		'404':
+//			description: 'Not Found. An object of the alert does not exist.'
+//		'409':
+//			description: 'Conflict. An alert with this id already exists.'
 //		'500':
 //			description: 'Internal server error.'
 
@@ -224,6 +454,12 @@ func CreateAlert(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("FUNCTION CALL: 	 CreateAlert ")
 	fmt.Println("******************************************************")
 
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
 	alert := &models.Alert{}
 	err := json.NewDecoder(r.Body).Decode(alert)
 	if err != nil {
@@ -232,7 +468,7 @@ func CreateAlert(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	mErr := models.AddAlert(*alert)
+	createdAlert, mErr := models.CreateAlert(*alert, user.Roles)
 
 	if mErr != nil {
 		u.RespondWithError(w, mErr)
@@ -240,18 +476,33 @@ func CreateAlert(w http.ResponseWriter, r *http.Request) {
 		if r.Method == "OPTIONS" {
 			u.WriteOptionsHeader(w, "GET, HEAD")
 		} else {
-			u.Respond(w, u.Message("successfully created alert"))
+			u.Respond(w, u.RespDataWrapper("successfully created alert", createdAlert))
 		}
 	}
 }
 
 // swagger:operation GET /api/alerts FlutterApp GetAlerts
-// Get a list of all alerts
+// Get a list of the alerts on objects of the domains of the user.
+// Alerts not linked to objects are returned to all users.
 // ---
 // security:
 // - bearer: []
 // produces:
 // - application/json
+// parameters:
+//   - name: status
+//     in: query
+//     description: 'Only return the alerts with this status (open, acknowledged
+//     or resolved). By default, all the alerts that are not resolved are returned.'
+//     type: string
+//   - name: severity
+//     in: query
+//     description: 'Only return the alerts with this severity.'
+//     type: string
+//   - name: object
+//     in: query
+//     description: 'Only return the alerts affecting the object with this id.'
+//     type: string
 // responses:
 //		'200':
 //			description: 'Return all possible alerts.'
@@ -263,7 +514,16 @@ func GetAlerts(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("FUNCTION CALL: 	 GetAlerts ")
 	fmt.Println("******************************************************")
 
-	projects, err := models.GetAlerts()
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	var filters models.AlertFilters
+	decoder.Decode(&filters, r.URL.Query())
+
+	alerts, err := models.GetAlerts(filters, user.Roles)
 	if err != nil {
 		u.RespondWithError(w, err)
 	} else {
@@ -271,14 +531,14 @@ func GetAlerts(w http.ResponseWriter, r *http.Request) {
 			u.WriteOptionsHeader(w, "GET, HEAD")
 		} else {
 			resp := map[string]interface{}{}
-			resp["alerts"] = projects
+			resp["alerts"] = alerts
 			u.Respond(w, u.RespDataWrapper("successfully got alerts", resp))
 		}
 	}
 }
 
 // swagger:operation GET /api/alerts/{AlertID} FlutterApp GetAlert
-// Get a list of all alerts
+// Get an alert, with its comments and history
 // ---
 // security:
 // - bearer: []
@@ -303,7 +563,13 @@ func GetAlert(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("FUNCTION CALL: 	 GetAlert ")
 	fmt.Println("******************************************************")
 
-	alert, err := models.GetAlert(mux.Vars(r)["id"])
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	alert, err := models.GetAlert(mux.Vars(r)["id"], user.Roles)
 	if err != nil {
 		u.RespondWithError(w, err)
 	} else {
@@ -315,6 +581,68 @@ func GetAlert(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// swagger:operation PATCH /api/alerts/{AlertID} FlutterApp UpdateAlert
+// Acknowledge, resolve, assign or comment an alert.
+// Each change of status, severity or assignee is kept in the history of the alert.
+// ---
+// security:
+// - bearer: []
+// produces:
+// - application/json
+// parameters:
+//   - name: AlertID
+//     in: path
+//     description: 'ID of the alert to update.'
+//     required: true
+//     type: string
+//   - name: body
+//     in: body
+//     description: 'Optional: status (open, acknowledged or resolved),
+//     severity (info, minor, major or critical), assignee (email of a user,
+//     empty to unassign), comment. At least one of them must be given.'
+//     required: true
+//     format: object
+//     example: '{"status":"acknowledged","assignee":"user@test.com",
+//     "comment":"Technician on the way"}'
+// responses:
+//		'200':
+//			description: 'Alert successfully updated, it is returned.'
+//		'400':
+//			description: 'Bad Request. Invalid status, severity or assignee.'
+//		'401':
+//			description: 'Unauthorized. The user cannot modify the objects of the alert.'
+//		'404':
+//			description: 'Alert not found'
+//		'500':
+//			description: 'Internal server error'
+
+func UpdateAlert(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("******************************************************")
+	fmt.Println("FUNCTION CALL: 	 UpdateAlert ")
+	fmt.Println("******************************************************")
+	DispRequestMetaData(r)
+
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	var patch models.AlertPatch
+	if err := decodeRequestBody(w, r, &patch); err != nil {
+		return
+	}
+
+	alert, err := models.UpdateAlert(mux.Vars(r)["id"], patch, user.Email, user.Roles)
+	if err != nil {
+		u.ErrLog("Error while updating alert", "UpdateAlert", err.Message, r)
+		u.RespondWithError(w, err)
+	} else {
+		eventNotifier <- u.FormatNotifyData("modify-alert", "alert", alert)
+		u.Respond(w, u.RespDataWrapper("successfully updated alert", alert))
+	}
+}
+
 // swagger:operation DELETE /api/alerts/{AlertID} FlutterApp DeleteAlert
 // Delete an existing alert.
 // ---
@@ -331,6 +659,8 @@ func GetAlert(w http.ResponseWriter, r *http.Request) {
 // responses:
 //  '200':
 //      description: Alert successfully removed.
+//  '401':
+//      description: Unauthorized. The user cannot modify the objects of the alert.
 //  '404':
 //      description: Not Found. Invalid alert ID.
 //  '500':
@@ -341,7 +671,13 @@ func DeleteAlert(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("FUNCTION CALL: 	 DeleteAlert ")
 	fmt.Println("******************************************************")
 
-	err := models.DeleteAlert(mux.Vars(r)["id"])
+	// Get user roles for permissions
+	user := getUserFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	err := models.DeleteAlert(mux.Vars(r)["id"], user.Roles)
 
 	if err != nil {
 		u.RespondWithError(w, err)
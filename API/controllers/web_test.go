@@ -8,12 +8,15 @@ import (
 	test_utils "p3/test/utils"
 	"slices"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func init() {
 	integration.RequireCreateSite("site-project")
+	integration.RequireCreateBuilding("site-project", "building-result-1")
+	integration.RequireCreateBuilding("site-project", "building-result-2")
 }
 
 var projectsEndpoint = test_utils.GetEndpoint("projects")
@@ -40,13 +43,13 @@ func TestCreateProject(t *testing.T) {
 	e2e.ValidateManagedRequest(t, "POST", projectsEndpoint, requestBody, http.StatusOK, "successfully handled project request")
 }
 
-func TestGetProjectsWithNoUserRespondsWithError(t *testing.T) {
-	e2e.ValidateManagedRequest(t, "GET", projectsEndpoint, nil, http.StatusBadRequest, "Error: user should be sent as query param")
+func TestGetProjectsOfAnotherUserRespondsWithError(t *testing.T) {
+	e2e.ValidateRequestWithUser(t, "GET", projectsEndpoint+"?user=admin@admin.com", nil, "viewer", http.StatusUnauthorized, "Users can only get their own projects")
 }
 
 func TestGetProjectsFromUserWithNoProjects(t *testing.T) {
 	integration.CreateTestProject(t, "temporaryProject")
-	response := e2e.ValidateManagedRequest(t, "GET", projectsEndpoint+"?user=someUser", nil, http.StatusOK, "successfully got projects")
+	response := e2e.ValidateRequestWithUser(t, "GET", projectsEndpoint, nil, "viewer", http.StatusOK, "successfully got projects")
 
 	data, exists := response["data"].(map[string]interface{})
 	assert.True(t, exists)
@@ -93,6 +96,210 @@ func TestDeleteProject(t *testing.T) {
 	e2e.ValidateManagedRequest(t, "DELETE", projectsEndpoint+"/"+id, nil, http.StatusNotFound, "Project not found")
 }
 
+func createSharedProject(t *testing.T, sharing []map[string]any) string {
+	requestBody, _ := json.Marshal(map[string]any{
+		"attributes":       []string{"domain"},
+		"authorLastUpdate": "admin@admin.com",
+		"dateRange":        "01/01/2023-02/02/2023",
+		"lastUpdate":       "02/02/2023",
+		"name":             "sharedProject",
+		"namespace":        "physical",
+		"objects":          []string{"site-project"},
+		"permissions":      []string{},
+		"sharing":          sharing,
+	})
+
+	response := e2e.ValidateManagedRequest(t, "POST", projectsEndpoint, requestBody, http.StatusOK, "successfully handled project request")
+	project := response["data"].(map[string]any)
+	assert.Equal(t, "admin@admin.com", project["owner"])
+	assert.Equal(t, "owner", project["userRole"])
+
+	id := project["Id"].(string)
+	t.Cleanup(func() {
+		e2e.MakeRequest("DELETE", projectsEndpoint+"/"+id, nil)
+	})
+	return id
+}
+
+func TestCreateProjectSharedWithUnknownDomainRespondsWithError(t *testing.T) {
+	requestBody, _ := json.Marshal(map[string]any{
+		"name":    "sharedProject",
+		"sharing": []map[string]any{{"domain": "unknown-domain", "role": "viewer"}},
+	})
+	e2e.ValidateManagedRequest(t, "POST", projectsEndpoint, requestBody, http.StatusBadRequest, "Domain not found: unknown-domain")
+}
+
+func TestCreateProjectWithInvalidRoleRespondsWithError(t *testing.T) {
+	requestBody, _ := json.Marshal(map[string]any{
+		"name":    "sharedProject",
+		"sharing": []map[string]any{{"user": "user@user.com", "role": "admin"}},
+	})
+	e2e.ValidateManagedRequest(t, "POST", projectsEndpoint, requestBody, http.StatusBadRequest, "Invalid project role admin, must be one of: owner, editor, viewer")
+}
+
+func TestProjectRoles(t *testing.T) {
+	id := createSharedProject(t, []map[string]any{
+		{"user": "user@user.com", "role": "editor"},
+		{"user": "viewer@viewer.com", "role": "viewer"},
+	})
+
+	response := e2e.ValidateRequestWithUser(t, "GET", projectsEndpoint+"?user=viewer@viewer.com", nil, "viewer", http.StatusOK, "successfully got projects")
+	projects := response["data"].(map[string]any)["projects"].([]any)
+	assert.Len(t, projects, 1)
+	assert.Equal(t, "viewer", projects[0].(map[string]any)["userRole"])
+
+	// viewers can see the project but not modify it
+	e2e.ValidateRequestWithUser(t, "GET", projectsEndpoint+"/"+id+"/result", nil, "viewer", http.StatusOK, "successfully got project result")
+	requestBody, _ := json.Marshal(map[string]any{"name": "sharedProject", "showAvg": true})
+	e2e.ValidateRequestWithUser(t, "PUT", projectsEndpoint+"/"+id, requestBody, "viewer", http.StatusUnauthorized, "User does not have permission to modify this project")
+
+	// editors can modify the project but not its sharing
+	requestBody, _ = json.Marshal(map[string]any{
+		"name":    "sharedProject",
+		"showAvg": true,
+		"sharing": []map[string]any{{"user": "user@user.com", "role": "owner"}},
+	})
+	response = e2e.ValidateRequestWithUser(t, "PUT", projectsEndpoint+"/"+id, requestBody, "user", http.StatusOK, "successfully handled project request")
+	project := response["data"].(map[string]any)
+	assert.Equal(t, true, project["showAvg"])
+	assert.Equal(t, "editor", project["userRole"])
+	assert.Len(t, project["sharing"], 2)
+
+	// only owners can delete the project
+	e2e.ValidateRequestWithUser(t, "DELETE", projectsEndpoint+"/"+id, nil, "user", http.StatusUnauthorized, "User does not have permission to manage this project")
+	e2e.ValidateManagedRequest(t, "DELETE", projectsEndpoint+"/"+id, nil, http.StatusOK, "successfully removed project")
+}
+
+func TestProjectSharedWithDomain(t *testing.T) {
+	createSharedProject(t, []map[string]any{{"domain": integration.TestDBName, "role": "viewer"}})
+
+	response := e2e.ValidateRequestWithUser(t, "GET", projectsEndpoint+"?user=viewer@viewer.com", nil, "viewer", http.StatusOK, "successfully got projects")
+	projects := response["data"].(map[string]any)["projects"].([]any)
+	assert.Len(t, projects, 1)
+	assert.Equal(t, "viewer", projects[0].(map[string]any)["userRole"])
+}
+
+func TestRestoreProjectRevision(t *testing.T) {
+	temporaryProject, id := integration.CreateTestProject(t, "temporaryProject")
+	temporaryProject.ShowAvg = true
+	requestBody, _ := json.Marshal(temporaryProject)
+	response := e2e.ValidateManagedRequest(t, "PUT", projectsEndpoint+"/"+id, requestBody, http.StatusOK, "successfully handled project request")
+	assert.Equal(t, 1.0, response["data"].(map[string]any)["revision"])
+
+	response = e2e.ValidateManagedRequest(t, "GET", test_utils.GetEndpoint("projectRevisions", id), nil, http.StatusOK, "successfully got project revisions")
+	revisions := response["data"].(map[string]any)["revisions"].([]any)
+	assert.Len(t, revisions, 1)
+	revision := revisions[0].(map[string]any)
+	assert.Equal(t, 0.0, revision["revision"])
+	assert.Equal(t, "admin@admin.com", revision["author"])
+	assert.Equal(t, false, revision["project"].(map[string]any)["showAvg"])
+
+	e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("restoreProjectRevision", id, 5), nil, http.StatusNotFound, "Revision 5 not found")
+
+	response = e2e.ValidateManagedRequest(t, "POST", test_utils.GetEndpoint("restoreProjectRevision", id, 0), nil, http.StatusOK, "successfully restored project")
+	project := response["data"].(map[string]any)
+	assert.Equal(t, false, project["showAvg"])
+	assert.Equal(t, 2.0, project["revision"])
+
+	// the restored version is also kept
+	response = e2e.ValidateManagedRequest(t, "GET", test_utils.GetEndpoint("projectRevisions", id), nil, http.StatusOK, "successfully got project revisions")
+	revisions = response["data"].(map[string]any)["revisions"].([]any)
+	assert.Len(t, revisions, 2)
+	assert.Equal(t, 1.0, revisions[0].(map[string]any)["revision"])
+}
+
+func createResultProject(t *testing.T) string {
+	_, id := integration.CreateTestProjectWithObjects(t, "resultProject", []string{"height", "heightUnit"},
+		[]string{"site-project.building-result-1", "site-project.building-result-2", "site-project.unknown"}, true)
+	return id
+}
+
+func TestGetProjectResultWithInvalidFormatRespondsWithError(t *testing.T) {
+	id := createResultProject(t)
+	e2e.ValidateManagedRequest(t, "GET", projectsEndpoint+"/"+id+"/result?format=pdf", nil, http.StatusBadRequest, "Invalid format, it must be one of: json, csv, xlsx")
+}
+
+func TestGetProjectResultOfProjectNotSharedRespondsWithError(t *testing.T) {
+	id := createResultProject(t)
+	e2e.ValidateRequestWithUser(t, "GET", projectsEndpoint+"/"+id+"/result", nil, "viewer", http.StatusUnauthorized, "User does not have permission to see this project")
+}
+
+func TestGetProjectResult(t *testing.T) {
+	id := createResultProject(t)
+	response := e2e.ValidateManagedRequest(t, "GET", projectsEndpoint+"/"+id+"/result", nil, http.StatusOK, "successfully got project result")
+
+	result := response["data"].(map[string]any)
+	assert.Equal(t, []any{"height", "heightUnit"}, result["attributes"])
+	rows := result["rows"].([]any)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, map[string]any{"height": 5.0, "heightUnit": "m"}, rows[0].(map[string]any)["values"])
+	assert.Equal(t, map[string]any{}, rows[2].(map[string]any)["values"])
+	assert.Equal(t, map[string]any{"height": 10.0}, result["sum"])
+	assert.Equal(t, map[string]any{"height": 5.0}, result["avg"])
+}
+
+func TestGetProjectResultFiltersByDateRangeAndNamespace(t *testing.T) {
+	tests := []struct {
+		name      string
+		dateRange string
+		namespace string
+		hasValues bool
+	}{
+		{"InDateRange", "01/01/2023 - " + time.Now().UTC().Format("02/01/2006"), "Physical", true},
+		{"BeforeDateRange", "01/01/2023-02/02/2023", "physical", false},
+		{"OtherNamespace", "", "organisational", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requestBody, _ := json.Marshal(map[string]any{
+				"attributes":       []string{"height"},
+				"authorLastUpdate": "admin@admin.com",
+				"dateRange":        tt.dateRange,
+				"lastUpdate":       "02/02/2023",
+				"name":             "filteredResultProject",
+				"namespace":        tt.namespace,
+				"objects":          []string{"site-project.building-result-1"},
+				"permissions":      []string{"admin@admin.com"},
+			})
+			response := e2e.ValidateManagedRequest(t, "POST", projectsEndpoint, requestBody, http.StatusOK, "successfully handled project request")
+			id := response["data"].(map[string]any)["Id"].(string)
+			t.Cleanup(func() {
+				e2e.MakeRequest("DELETE", projectsEndpoint+"/"+id, nil)
+			})
+
+			response = e2e.ValidateManagedRequest(t, "GET", projectsEndpoint+"/"+id+"/result", nil, http.StatusOK, "successfully got project result")
+			values := response["data"].(map[string]any)["rows"].([]any)[0].(map[string]any)["values"]
+			if tt.hasValues {
+				assert.Equal(t, map[string]any{"height": 5.0}, values)
+			} else {
+				assert.Equal(t, map[string]any{}, values)
+			}
+		})
+	}
+}
+
+func TestGetProjectResultAsCSV(t *testing.T) {
+	id := createResultProject(t)
+	recorder := e2e.MakeRequest("GET", projectsEndpoint+"/"+id+"/result?format=csv", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/csv", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "Objects,height,heightUnit\n"+
+		"site-project.building-result-1,5,m\n"+
+		"site-project.building-result-2,5,m\n"+
+		"site-project.unknown,-,-\n"+
+		"Sum,10.00,-\n"+
+		"Average,5.00,-\n", recorder.Body.String())
+}
+
+func TestGetProjectResultAsXLSX(t *testing.T) {
+	id := createResultProject(t)
+	recorder := e2e.MakeRequest("GET", projectsEndpoint+"/"+id+"/result?format=xlsx", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `attachment; filename="resultProject.xlsx"`, recorder.Header().Get("Content-Disposition"))
+	assert.Equal(t, "PK", recorder.Body.String()[:2]) // zip archive
+}
+
 func TestCreateAlertInvalidBody(t *testing.T) {
 	e2e.TestInvalidBody(t, "POST", alertsEndpoint, "Invalid request")
 }
@@ -108,6 +315,18 @@ func TestCreateAlert(t *testing.T) {
 	e2e.ValidateManagedRequest(t, "POST", alertsEndpoint, requestBody, http.StatusOK, "successfully created alert")
 }
 
+func TestCreateAlertWithExistingIdRespondsWithConflict(t *testing.T) {
+	requestBody, _ := json.Marshal(map[string]any{"id": "alert-conflict", "title": "This is the title"})
+	e2e.ValidateManagedRequest(t, "POST", alertsEndpoint, requestBody, http.StatusOK, "successfully created alert")
+	e2e.ValidateManagedRequest(t, "POST", alertsEndpoint, requestBody, http.StatusConflict, "Alert alert-conflict already exists")
+	e2e.ValidateManagedRequest(t, "DELETE", alertsEndpoint+"/alert-conflict", nil, http.StatusOK, "successfully removed alert")
+}
+
+func TestOnlyAdminsCanCreateAlertsWithoutObjects(t *testing.T) {
+	requestBody, _ := json.Marshal(map[string]any{"id": "alert-without-objects", "title": "This is the title"})
+	e2e.ValidateRequestWithUser(t, "POST", alertsEndpoint, requestBody, "user", http.StatusUnauthorized, "User does not have permission to create an alert on these objects")
+}
+
 func TestGetAlerts(t *testing.T) {
 	_, id := integration.CreateTestAlert(t, "temporaryAlert", false)
 	response := e2e.ValidateManagedRequest(t, "GET", alertsEndpoint, nil, http.StatusOK, "successfully got alerts")
@@ -141,3 +360,91 @@ func TestDeleteAlert(t *testing.T) {
 	// if we try to delete again we get an error
 	// e2e.ValidateManagedRequest(t, "DELETE", alertsEndpoint+"/"+id, nil, http.StatusNotFound, "Alert not found")
 }
+
+func createLinkedAlert(t *testing.T) string {
+	requestBody, _ := json.Marshal(map[string]any{
+		"severity": "major",
+		"title":    "Door open",
+		"objects":  []string{"site-project"},
+	})
+	response := e2e.ValidateManagedRequest(t, "POST", alertsEndpoint, requestBody, http.StatusOK, "successfully created alert")
+	id := response["data"].(map[string]any)["id"].(string)
+	t.Cleanup(func() {
+		e2e.ValidateManagedRequest(t, "DELETE", alertsEndpoint+"/"+id, nil, http.StatusOK, "successfully removed alert")
+	})
+	return id
+}
+
+func TestCreateAlertWithUnknownObjectRespondsWithError(t *testing.T) {
+	requestBody := []byte(`{"title": "Door open", "objects": ["unknown-site"]}`)
+	e2e.ValidateManagedRequest(t, "POST", alertsEndpoint, requestBody, http.StatusNotFound, "Unable to find object unknown-site")
+}
+
+func TestCreateAlertWithInvalidSeverityRespondsWithError(t *testing.T) {
+	requestBody := []byte(`{"title": "Door open", "severity": "urgent"}`)
+	e2e.ValidateManagedRequest(t, "POST", alertsEndpoint, requestBody, http.StatusBadRequest, "severity must be one of: info, minor, major, critical")
+}
+
+func TestViewerCannotCreateAlertOnObjects(t *testing.T) {
+	requestBody := []byte(`{"title": "Door open", "objects": ["site-project"]}`)
+	e2e.ValidateRequestWithUser(t, "POST", alertsEndpoint, requestBody, "viewer", http.StatusUnauthorized, "User does not have permission to create an alert on these objects")
+}
+
+func TestUpdateAlertLifecycle(t *testing.T) {
+	id := createLinkedAlert(t)
+
+	requestBody := []byte(`{"status": "acknowledged", "assignee": "admin@admin.com", "comment": "Technician on the way"}`)
+	response := e2e.ValidateManagedRequest(t, "PATCH", alertsEndpoint+"/"+id, requestBody, http.StatusOK, "successfully updated alert")
+	alert := response["data"].(map[string]any)
+	assert.Equal(t, "acknowledged", alert["status"])
+	assert.Equal(t, "admin@admin.com", alert["assignee"])
+	assert.NotNil(t, alert["acknowledgedAt"])
+	assert.Len(t, alert["comments"], 1)
+	assert.Len(t, alert["history"], 2) // status and assignee
+
+	requestBody = []byte(`{"status": "resolved"}`)
+	response = e2e.ValidateManagedRequest(t, "PATCH", alertsEndpoint+"/"+id, requestBody, http.StatusOK, "successfully updated alert")
+	alert = response["data"].(map[string]any)
+	assert.Equal(t, "resolved", alert["status"])
+	assert.NotNil(t, alert["resolvedAt"])
+	assert.Len(t, alert["history"], 3)
+}
+
+func TestUpdateAlertWithInvalidStatusRespondsWithError(t *testing.T) {
+	id := createLinkedAlert(t)
+	requestBody := []byte(`{"status": "closed"}`)
+	e2e.ValidateManagedRequest(t, "PATCH", alertsEndpoint+"/"+id, requestBody, http.StatusBadRequest, "status must be one of: open, acknowledged, resolved")
+}
+
+func TestUpdateAlertWithUnknownAssigneeRespondsWithError(t *testing.T) {
+	id := createLinkedAlert(t)
+	requestBody := []byte(`{"assignee": "unknown@test.com"}`)
+	e2e.ValidateManagedRequest(t, "PATCH", alertsEndpoint+"/"+id, requestBody, http.StatusBadRequest, "Unable to find user unknown@test.com")
+}
+
+func TestViewerCannotUpdateAlert(t *testing.T) {
+	id := createLinkedAlert(t)
+	e2e.ValidateRequestWithUser(t, "GET", alertsEndpoint+"/"+id, nil, "viewer", http.StatusOK, "successfully got alert")
+	requestBody := []byte(`{"status": "acknowledged"}`)
+	e2e.ValidateRequestWithUser(t, "PATCH", alertsEndpoint+"/"+id, requestBody, "viewer", http.StatusUnauthorized, "User does not have permission to modify this alert")
+}
+
+func TestGetAlertsWithFilters(t *testing.T) {
+	id := createLinkedAlert(t)
+
+	response := e2e.ValidateManagedRequest(t, "GET", alertsEndpoint+"?object=site-project&severity=major", nil, http.StatusOK, "successfully got alerts")
+	alerts := response["data"].(map[string]any)["alerts"].([]any)
+	assert.Len(t, alerts, 1)
+	assert.Equal(t, id, alerts[0].(map[string]any)["id"])
+
+	response = e2e.ValidateManagedRequest(t, "GET", alertsEndpoint+"?object=site-project&severity=minor", nil, http.StatusOK, "successfully got alerts")
+	assert.Len(t, response["data"].(map[string]any)["alerts"], 0)
+
+	e2e.ValidateManagedRequest(t, "PATCH", alertsEndpoint+"/"+id, []byte(`{"status": "resolved"}`), http.StatusOK, "successfully updated alert")
+
+	response = e2e.ValidateManagedRequest(t, "GET", alertsEndpoint+"?object=site-project", nil, http.StatusOK, "successfully got alerts")
+	assert.Len(t, response["data"].(map[string]any)["alerts"], 0)
+
+	response = e2e.ValidateManagedRequest(t, "GET", alertsEndpoint+"?object=site-project&status=resolved", nil, http.StatusOK, "successfully got alerts")
+	assert.Len(t, response["data"].(map[string]any)["alerts"], 1)
+}
@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"log"
 	"p3/app"
+	"p3/controllers"
 	"p3/repository"
 	"p3/router"
 
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/gorilla/handlers"
 	"github.com/joho/godotenv"
@@ -35,8 +37,38 @@ func connectToDB() {
 	log.Println("Successfully connected to DB")
 }
 
+// startAlertRulesEngine: alert rules are evaluated every alert_rules_interval
+// (1m by default, 0 to only evaluate them after changes)
+func startAlertRulesEngine() {
+	interval := time.Minute
+	if value := os.Getenv("alert_rules_interval"); value != "" {
+		var err error
+		if interval, err = time.ParseDuration(value); err != nil {
+			log.Fatalln("Invalid alert_rules_interval: " + err.Error())
+		}
+	}
+
+	controllers.StartAlertRulesEngine(interval)
+}
+
+// startSnapshotsEngine: the snapshot schedules are run every
+// snapshot_schedules_interval (1m by default, 0 to never run them)
+func startSnapshotsEngine() {
+	interval := time.Minute
+	if value := os.Getenv("snapshot_schedules_interval"); value != "" {
+		var err error
+		if interval, err = time.ParseDuration(value); err != nil {
+			log.Fatalln("Invalid snapshot_schedules_interval: " + err.Error())
+		}
+	}
+
+	controllers.StartSnapshotsEngine(interval)
+}
+
 func main() {
 	connectToDB()
+	startAlertRulesEngine()
+	startSnapshotsEngine()
 	//TODO:
 	//Use the URL below to help make the router functions more
 	//flexible and thus implement the http OPTIONS method
@@ -56,7 +88,7 @@ func main() {
 
 	//Start app, localhost:8000/api
 	corsObj := handlers.AllowedOrigins([]string{"*"})
-	headersOk := handlers.AllowedHeaders([]string{"X-Requested-With", "Content-Type", "Authorization", "Origin", "Accept"})
+	headersOk := handlers.AllowedHeaders([]string{"X-Requested-With", "Content-Type", "Authorization", "Origin", "Accept", controllers.IdempotencyKeyHeader})
 	methodsOk := handlers.AllowedMethods([]string{"GET", "HEAD", "OPTIONS", "POST", "PUT", "DELETE", "PATCH"})
 	err := http.ListenAndServe(":"+port, handlers.CORS(corsObj, headersOk, methodsOk)(router))
 	if err != nil {
@@ -0,0 +1,212 @@
+package models
+
+import (
+	"fmt"
+	"p3/repository"
+	u "p3/utils"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Levels of the physical hierarchy that can be used to group by parent,
+// with the amount of elements of the id they correspond to
+var aggregateParentLevels = map[string]int{"site": 1, "building": 2, "room": 3, "rack": 4}
+
+var aggregateMetricOps = []string{"count", "sum", "avg", "min", "max"}
+
+type AggregateMetric struct {
+	Op    string `json:"op"`    // count, sum, avg, min or max
+	Field string `json:"field"` // numeric field, not used by count
+	As    string `json:"as"`    // name of the metric in the response
+}
+
+type AggregateRequest struct {
+	Filter  string            `json:"filter"`
+	GroupBy []string          `json:"groupBy"`
+	Metrics []AggregateMetric `json:"metrics"`
+}
+
+// Name: name of the metric in the response, e.g. sum_height
+func (metric AggregateMetric) Name() string {
+	if metric.As != "" {
+		return metric.As
+	} else if metric.Op == "count" {
+		return metric.Op
+	}
+	return metric.Op + "_" + metric.Field
+}
+
+// AggregateObjects: groups the objects of the namespace matching the filter
+// and computes the metrics of each group. Only the objects
+// the user can read are taken into account.
+func AggregateObjects(request AggregateRequest, namespace u.Namespace, userRoles map[string]Role) ([]map[string]any, *u.Error) {
+	if err := validateAggregateRequest(request); err != nil {
+		return nil, err
+	}
+
+	complexFilter := map[string]any{}
+	if err := ApplyComplexFilter(request.Filter, complexFilter); err != nil {
+		return nil, err
+	}
+
+	entities := u.GetEntitiesById(namespace, "")
+	if len(entities) == 0 {
+		return []map[string]any{}, nil
+	}
+
+	pipeline := bson.A{getAggregateMatch(entities[0], complexFilter, userRoles)}
+	for _, entityStr := range entities[1:] {
+		pipeline = append(pipeline, bson.M{"$unionWith": bson.M{
+			"coll":     entityStr,
+			"pipeline": bson.A{getAggregateMatch(entityStr, complexFilter, userRoles)},
+		}})
+	}
+	pipeline = append(pipeline,
+		bson.M{"$group": getAggregateGroup(request)},
+		bson.M{"$sort": bson.M{"_id": 1}},
+	)
+
+	ctx, cancel := u.Connect()
+	defer cancel()
+	c, err := repository.GetDB().Collection(entities[0]).Aggregate(ctx, pipeline)
+	if err != nil {
+		fmt.Println(err)
+		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
+
+	results := []map[string]any{}
+	for c.Next(ctx) {
+		var group bson.M
+		if err := c.Decode(&group); err != nil {
+			return nil, &u.Error{Type: u.ErrInternal, Message: err.Error()}
+		}
+		results = append(results, formatAggregateGroup(request, group))
+	}
+
+	return results, nil
+}
+
+func validateAggregateRequest(request AggregateRequest) *u.Error {
+	if len(request.Metrics) == 0 {
+		return &u.Error{Type: u.ErrBadFormat, Message: "At least one metric must be given"}
+	}
+
+	for _, groupBy := range request.GroupBy {
+		if level, isParent := strings.CutPrefix(groupBy, "parent."); isParent {
+			if _, ok := aggregateParentLevels[level]; !ok {
+				return &u.Error{Type: u.ErrBadFormat,
+					Message: "Invalid parent level " + level + ", must be one of: site, building, room, rack"}
+			}
+		} else if !filterFieldRegex.MatchString(groupBy) {
+			return &u.Error{Type: u.ErrBadFormat, Message: "Invalid groupBy field " + groupBy}
+		}
+	}
+
+	names := map[string]bool{}
+	for _, metric := range request.Metrics {
+		if !u.StrSliceContains(aggregateMetricOps, metric.Op) {
+			return &u.Error{Type: u.ErrBadFormat,
+				Message: "Invalid metric " + metric.Op + ", must be one of: " + strings.Join(aggregateMetricOps, ", ")}
+		}
+		if metric.Op != "count" && !filterFieldRegex.MatchString(metric.Field) {
+			return &u.Error{Type: u.ErrBadFormat, Message: "Metric " + metric.Op + " requires a valid field"}
+		}
+		if names[metric.Name()] {
+			return &u.Error{Type: u.ErrBadFormat, Message: "Duplicated metric " + metric.Name()}
+		}
+		names[metric.Name()] = true
+	}
+
+	return nil
+}
+
+func getAggregateMatch(entityStr string, complexFilter map[string]any, userRoles map[string]Role) bson.M {
+	match := bson.M{}
+	for key, value := range complexFilter {
+		match[key] = value
+	}
+	entity := u.EntityStrToInt(entityStr)
+	if u.IsEntityHierarchical(entity) {
+		readableFilter := GetReadableObjectsFilter(userRoles, entity)
+		if len(readableFilter) > 0 {
+			match = bson.M{"$and": bson.A{match, readableFilter}}
+		}
+	}
+	return bson.M{"$match": match}
+}
+
+// getAggregateGroup: $group stage, group keys and metrics are named by their
+// index as the requested names can contain dots
+func getAggregateGroup(request AggregateRequest) bson.M {
+	var id any
+	if len(request.GroupBy) > 0 {
+		groupId := bson.M{}
+		for i, groupBy := range request.GroupBy {
+			groupId["g"+strconv.Itoa(i)] = getAggregateGroupExpression(groupBy)
+		}
+		id = groupId
+	}
+
+	group := bson.M{"_id": id}
+	for i, metric := range request.Metrics {
+		if metric.Op == "count" {
+			group["m"+strconv.Itoa(i)] = bson.M{"$sum": 1}
+		} else {
+			// non numeric values are ignored
+			group["m"+strconv.Itoa(i)] = bson.M{"$" + metric.Op: bson.M{"$convert": bson.M{
+				"input":   "$" + objectFieldPath(metric.Field),
+				"to":      "double",
+				"onError": nil,
+				"onNull":  nil,
+			}}}
+		}
+	}
+	return group
+}
+
+func getAggregateGroupExpression(groupBy string) any {
+	idParts := bson.M{"$split": bson.A{"$id", u.HN_DELIMETER}}
+	amount := bson.M{"$size": idParts}
+
+	if groupBy == "parent" {
+		return getAggregateJoinedIdParts(idParts, bson.M{"$subtract": bson.A{amount, 1}})
+	} else if level, isParent := strings.CutPrefix(groupBy, "parent."); isParent {
+		levelAmount := aggregateParentLevels[level]
+		// objects that are not under that level have no parent at this level
+		return bson.M{"$cond": bson.A{
+			bson.M{"$gt": bson.A{amount, levelAmount}},
+			getAggregateJoinedIdParts(idParts, levelAmount),
+			nil,
+		}}
+	}
+	return "$" + objectFieldPath(groupBy)
+}
+
+// getAggregateJoinedIdParts: joins the first amount elements of the id
+func getAggregateJoinedIdParts(idParts bson.M, amount any) bson.M {
+	return bson.M{"$reduce": bson.M{
+		"input":        bson.M{"$slice": bson.A{idParts, amount}},
+		"initialValue": "",
+		"in": bson.M{"$cond": bson.A{
+			bson.M{"$eq": bson.A{"$$value", ""}},
+			"$$this",
+			bson.M{"$concat": bson.A{"$$value", u.HN_DELIMETER, "$$this"}},
+		}},
+	}}
+}
+
+func formatAggregateGroup(request AggregateRequest, group bson.M) map[string]any {
+	groupKeys := map[string]any{}
+	groupId, _ := group["_id"].(bson.M)
+	for i, groupBy := range request.GroupBy {
+		groupKeys[groupBy] = groupId["g"+strconv.Itoa(i)]
+	}
+
+	result := map[string]any{"group": groupKeys}
+	for i, metric := range request.Metrics {
+		result[metric.Name()] = group["m"+strconv.Itoa(i)]
+	}
+	return result
+}
@@ -0,0 +1,394 @@
+package models
+
+import (
+	"fmt"
+	"p3/repository"
+	u "p3/utils"
+	"regexp"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const ALERT_RULE = "alert_rule"
+
+var AlertSeverities = []string{"info", "minor", "major", "critical"}
+
+var telemetryConditionOps = map[string]string{"<=": "$lte", ">=": "$gte", "<": "$lt", ">": "$gt", "=": "$eq", "!=": "$ne"}
+
+// rules are evaluated one at a time so that an alert is never raised twice
+var alertRulesMutex sync.Mutex
+
+// TelemetryCondition is true when the latest value of the sensor
+// of the object compared to the threshold with the operator is true
+type TelemetryCondition struct {
+	Sensor    string   `json:"sensor" bson:"sensor"`
+	Operator  string   `json:"operator" bson:"operator"` // one of < <= > >= = !=
+	Threshold *float64 `json:"threshold" bson:"threshold"`
+}
+
+// AlertRule raises an alert on each object of its domain matching the filter
+// and the telemetry condition. The alert is resolved once the object stops matching.
+type AlertRule struct {
+	Id          string              `json:"id" bson:"_id,omitempty"`
+	Name        string              `json:"name" bson:"name"` // title of the alerts
+	Description string              `json:"description" bson:"description"`
+	Filter      string              `json:"filter" bson:"filter"` // complex filter
+	Telemetry   *TelemetryCondition `json:"telemetry,omitempty" bson:"telemetry,omitempty"`
+	Severity    string              `json:"severity" bson:"severity"`
+	Domain      string              `json:"domain" bson:"domain"`
+	Disabled    bool                `json:"disabled" bson:"disabled"`
+}
+
+// AlertRuleEvaluation lists the alerts raised and resolved by an evaluation
+type AlertRuleEvaluation struct {
+	Raised   []Alert `json:"raised"`
+	Resolved []Alert `json:"resolved"`
+}
+
+func (rule AlertRule) validate() *u.Error {
+	if rule.Name == "" {
+		return &u.Error{Type: u.ErrBadFormat, Message: "name is mandatory"}
+	} else if !u.StrSliceContains(AlertSeverities, rule.Severity) {
+		return &u.Error{Type: u.ErrBadFormat, Message: "severity must be one of: info, minor, major, critical"}
+	} else if rule.Filter == "" && rule.Telemetry == nil {
+		return &u.Error{Type: u.ErrBadFormat, Message: "A filter or a telemetry condition is mandatory"}
+	} else if !CheckDomainExists(rule.Domain) {
+		return &u.Error{Type: u.ErrBadFormat, Message: "Domain not found: " + rule.Domain}
+	}
+
+	if rule.Telemetry != nil {
+		if !telemetrySensorRegex.MatchString(rule.Telemetry.Sensor) {
+			return &u.Error{Type: u.ErrBadFormat, Message: "telemetry sensor is mandatory"}
+		} else if _, ok := telemetryConditionOps[rule.Telemetry.Operator]; !ok {
+			return &u.Error{Type: u.ErrBadFormat, Message: "telemetry operator must be one of: <, <=, >, >=, =, !="}
+		} else if rule.Telemetry.Threshold == nil {
+			return &u.Error{Type: u.ErrBadFormat, Message: "telemetry threshold is mandatory"}
+		}
+	}
+
+	_, err := rule.getQuery()
+	return err
+}
+
+// getQuery: mongo query matching the objects that should have an alert
+func (rule AlertRule) getQuery() (bson.M, *u.Error) {
+	conditions := bson.A{}
+	if rule.Domain != ROOT_DOMAIN {
+		conditions = append(conditions, bson.M{"domain": primitive.Regex{
+			Pattern: "^" + regexp.QuoteMeta(rule.Domain) + `(\.|$)`,
+		}})
+	}
+
+	if rule.Filter != "" {
+		filter := bson.M{}
+		if err := ApplyComplexFilter(rule.Filter, filter); err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, filter)
+	}
+
+	if rule.Telemetry != nil {
+		conditions = append(conditions, bson.M{rule.telemetryField(): bson.M{
+			telemetryConditionOps[rule.Telemetry.Operator]: *rule.Telemetry.Threshold,
+		}})
+	}
+
+	return bson.M{"$and": conditions}, nil
+}
+
+func (rule AlertRule) telemetryField() string {
+	return "attributes.telemetry." + rule.Telemetry.Sensor + ".value"
+}
+
+// getSubtitle: description of the alert raised on the object
+func (rule AlertRule) getSubtitle(object bson.M) string {
+	if rule.Telemetry == nil {
+		return rule.Description
+	}
+
+	var value any
+	if attributes, ok := object["attributes"].(bson.M); ok {
+		if telemetry, ok := attributes["telemetry"].(bson.M); ok {
+			if sensor, ok := telemetry[rule.Telemetry.Sensor].(bson.M); ok {
+				value = sensor["value"]
+			}
+		}
+	}
+	return fmt.Sprintf("%s is %v (threshold %s %v)", rule.Telemetry.Sensor, value,
+		rule.Telemetry.Operator, *rule.Telemetry.Threshold)
+}
+
+// POST
+func CreateAlertRule(rule AlertRule, userRoles map[string]Role) (*AlertRule, *u.Error) {
+	if err := rule.validate(); err != nil {
+		return nil, err
+	}
+	if err := checkCanManageAlertRule(rule, userRoles); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	rule.Id = ""
+	result, err := repository.GetDB().Collection(ALERT_RULE).InsertOne(ctx, rule)
+	if err != nil {
+		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
+	rule.Id = result.InsertedID.(primitive.ObjectID).Hex()
+
+	return &rule, nil
+}
+
+// GET
+func GetAlertRules(userRoles map[string]Role) ([]AlertRule, *u.Error) {
+	rules, err := getAlertRules(bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	readableRules := []AlertRule{}
+	for _, rule := range rules {
+		if CheckUserPermissions(userRoles, u.SITE, rule.Domain) >= READ {
+			readableRules = append(readableRules, rule)
+		}
+	}
+	return readableRules, nil
+}
+
+func GetAlertRule(id string, userRoles map[string]Role) (*AlertRule, *u.Error) {
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, &u.Error{Type: u.ErrNotFound, Message: "Alert rule not found"}
+	}
+
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	rule := &AlertRule{}
+	err = repository.GetDB().Collection(ALERT_RULE).FindOne(ctx, bson.M{"_id": objId}).Decode(rule)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, &u.Error{Type: u.ErrNotFound, Message: "Alert rule not found"}
+		}
+		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
+
+	if CheckUserPermissions(userRoles, u.SITE, rule.Domain) < READ {
+		return nil, &u.Error{Type: u.ErrUnauthorized,
+			Message: "User does not have permission to see this alert rule"}
+	}
+
+	return rule, nil
+}
+
+func getAlertRules(filter bson.M) ([]AlertRule, *u.Error) {
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	rules := []AlertRule{}
+	cursor, err := repository.GetDB().Collection(ALERT_RULE).Find(ctx, filter)
+	if err != nil {
+		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	} else if err = cursor.All(ctx, &rules); err != nil {
+		return nil, &u.Error{Type: u.ErrInternal, Message: err.Error()}
+	}
+
+	return rules, nil
+}
+
+// PUT
+func UpdateAlertRule(id string, newRule AlertRule, userRoles map[string]Role) (*AlertRule, *u.Error) {
+	oldRule, err := GetAlertRule(id, userRoles)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCanManageAlertRule(*oldRule, userRoles); err != nil {
+		return nil, err
+	}
+	if err := newRule.validate(); err != nil {
+		return nil, err
+	}
+	if err := checkCanManageAlertRule(newRule, userRoles); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	objId, _ := primitive.ObjectIDFromHex(id)
+	newRule.Id = ""
+	_, mongoErr := repository.GetDB().Collection(ALERT_RULE).ReplaceOne(ctx, bson.M{"_id": objId}, newRule)
+	if mongoErr != nil {
+		return nil, &u.Error{Type: u.ErrDBError, Message: mongoErr.Error()}
+	}
+	newRule.Id = id
+
+	return &newRule, nil
+}
+
+// DELETE
+// DeleteAlertRule: deletes the rule and resolves its alerts
+func DeleteAlertRule(id string, userRoles map[string]Role) ([]Alert, *u.Error) {
+	rule, err := GetAlertRule(id, userRoles)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCanManageAlertRule(*rule, userRoles); err != nil {
+		return nil, err
+	}
+
+	alertRulesMutex.Lock()
+	defer alertRulesMutex.Unlock()
+
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	objId, _ := primitive.ObjectIDFromHex(id)
+	if _, err := repository.GetDB().Collection(ALERT_RULE).DeleteOne(ctx, bson.M{"_id": objId}); err != nil {
+		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
+
+	alerts, err := getNotResolvedAlertsOfRule(id)
+	if err != nil {
+		return nil, err
+	}
+	resolved := []Alert{}
+	for _, alert := range alerts {
+		resolvedAlert, err := resolveAlert(alert)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, *resolvedAlert)
+	}
+
+	return resolved, nil
+}
+
+// only managers of the domain can manage its rules
+func checkCanManageAlertRule(rule AlertRule, userRoles map[string]Role) *u.Error {
+	if CheckUserPermissions(userRoles, u.DOMAIN, rule.Domain) < WRITE {
+		return &u.Error{Type: u.ErrUnauthorized,
+			Message: "User does not have permission to manage alert rules of domain " + rule.Domain}
+	}
+	return nil
+}
+
+// EVALUATE
+// EvaluateAlertRules: evaluates all the enabled rules
+func EvaluateAlertRules() (AlertRuleEvaluation, *u.Error) {
+	evaluation := AlertRuleEvaluation{Raised: []Alert{}, Resolved: []Alert{}}
+
+	rules, err := getAlertRules(bson.M{"disabled": false})
+	if err != nil {
+		return evaluation, err
+	}
+
+	for _, rule := range rules {
+		ruleEvaluation, err := EvaluateAlertRule(rule)
+		if err != nil {
+			return evaluation, err
+		}
+		evaluation.Raised = append(evaluation.Raised, ruleEvaluation.Raised...)
+		evaluation.Resolved = append(evaluation.Resolved, ruleEvaluation.Resolved...)
+	}
+
+	return evaluation, nil
+}
+
+// EvaluateAlertRule: raises an alert on each object matching the rule that
+// does not have one yet and resolves the alerts of the objects that no longer match
+func EvaluateAlertRule(rule AlertRule) (AlertRuleEvaluation, *u.Error) {
+	alertRulesMutex.Lock()
+	defer alertRulesMutex.Unlock()
+
+	evaluation := AlertRuleEvaluation{Raised: []Alert{}, Resolved: []Alert{}}
+
+	offending := map[string]string{} // object id: subtitle
+	if !rule.Disabled {
+		var err *u.Error
+		if offending, err = getAlertRuleOffendingObjects(rule); err != nil {
+			return evaluation, err
+		}
+	}
+
+	alerts, err := getNotResolvedAlertsOfRule(rule.Id)
+	if err != nil {
+		return evaluation, err
+	}
+
+	for _, alert := range alerts {
+		objectId := alertRuleObjectId(alert)
+		if _, stillOffending := offending[objectId]; stillOffending {
+			delete(offending, objectId)
+			continue
+		}
+		resolvedAlert, err := resolveAlert(alert)
+		if err != nil {
+			return evaluation, err
+		}
+		evaluation.Resolved = append(evaluation.Resolved, *resolvedAlert)
+	}
+
+	now := time.Now()
+	for objectId, subtitle := range offending {
+		alert := Alert{
+			Severity: rule.Severity,
+			Title:    rule.Name,
+			Subtitle: subtitle,
+			Objects:  []string{objectId},
+			Rule:     rule.Id,
+			RaisedAt: &now,
+		}
+		if err := prepareAlert(&alert); err != nil {
+			return evaluation, err
+		}
+		if err := insertAlert(alert); err != nil {
+			return evaluation, err
+		}
+		evaluation.Raised = append(evaluation.Raised, alert)
+	}
+
+	return evaluation, nil
+}
+
+func getAlertRuleOffendingObjects(rule AlertRule) (map[string]string, *u.Error) {
+	query, err := rule.getQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	projection := bson.M{"id": 1}
+	if rule.Telemetry != nil {
+		projection[rule.telemetryField()] = 1
+	}
+
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	offending := map[string]string{}
+	for _, entityStr := range u.GetEntitiesById(u.Physical, "") {
+		cursor, err := repository.GetDB().Collection(entityStr).Find(ctx, query,
+			options.Find().SetProjection(projection))
+		if err != nil {
+			return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+		}
+
+		objects := []bson.M{}
+		if err := cursor.All(ctx, &objects); err != nil {
+			return nil, &u.Error{Type: u.ErrInternal, Message: err.Error()}
+		}
+		for _, object := range objects {
+			if id, ok := object["id"].(string); ok {
+				offending[id] = rule.getSubtitle(object)
+			}
+		}
+	}
+
+	return offending, nil
+}
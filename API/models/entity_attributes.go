@@ -13,14 +13,16 @@ import (
 
 const RACKUNIT = 0.04445 //meter
 
-func validateAttributes(entity int, data, parent map[string]any) *u.Error {
+// validateAttributes: checks the attributes of the object, the objects it
+// references can be one of the pending objects (validated but not yet created, by id)
+func validateAttributes(entity int, data, parent map[string]any, pending map[string]map[string]any) *u.Error {
 	attributes := data["attributes"].(map[string]any)
 	switch entity {
 	case u.CORRIDOR:
 		setCorridorColor(attributes)
 	case u.GROUP:
 		if err := validateGroupContent(attributes["content"].([]any),
-			data["parentId"].(string), parent["parent"].(string)); err != nil {
+			data["parentId"].(string), parent["parent"].(string), pending); err != nil {
 			return err
 		}
 	case u.DEVICE:
@@ -34,13 +36,13 @@ func validateAttributes(entity int, data, parent map[string]any) *u.Error {
 		}
 		// check if all requested slots are free
 		if err = validateDeviceSlots(deviceSlots,
-			data["name"].(string), data["parentId"].(string)); err != nil {
+			data["name"].(string), data["parentId"].(string), pending); err != nil {
 			return err
 		}
 	case u.VIRTUALOBJ:
 		if attributes["vlinks"] != nil {
 			// check if all vlinks point to valid objects
-			if err := validateVlinks(attributes["vlinks"].([]any)); err != nil {
+			if err := validateVlinks(attributes["vlinks"].([]any), pending); err != nil {
 				return err
 			}
 		}
@@ -48,7 +50,7 @@ func validateAttributes(entity int, data, parent map[string]any) *u.Error {
 	return nil
 }
 
-func validateDeviceSlots(deviceSlots []string, deviceName, deviceParentd string) *u.Error {
+func validateDeviceSlots(deviceSlots []string, deviceName, deviceParentd string, pending map[string]map[string]any) *u.Error {
 	// check if all requested slots are free
 	var siblings []map[string]any
 	var err *u.Error
@@ -60,6 +62,12 @@ func validateDeviceSlots(deviceSlots []string, deviceName, deviceParentd string)
 		u.RequestFilters{}, "", nil); err != nil {
 		return err
 	}
+	for id, obj := range pending {
+		if obj["category"] == u.EntityToString(u.DEVICE) &&
+			id == deviceParentd+u.HN_DELIMETER+obj["name"].(string) {
+			siblings = append(siblings, obj)
+		}
+	}
 
 	for _, obj := range siblings {
 		if obj["name"] == deviceName {
@@ -78,13 +86,18 @@ func validateDeviceSlots(deviceSlots []string, deviceName, deviceParentd string)
 	return nil
 }
 
-func validateVlinks(vlinks []any) *u.Error {
+func validateVlinks(vlinks []any, pending map[string]map[string]any) *u.Error {
 	for _, vlinkId := range vlinks {
+		id := strings.Split(vlinkId.(string), "#")[0]
 		count, err := repository.CountObjectsManyEntities([]int{u.DEVICE, u.VIRTUALOBJ},
-			bson.M{"id": strings.Split(vlinkId.(string), "#")[0]})
+			bson.M{"id": id})
 		if err != nil {
 			return err
 		}
+		if obj, isPending := pending[id]; isPending && (obj["category"] == u.EntityToString(u.DEVICE) ||
+			obj["category"] == u.EntityToString(u.VIRTUALOBJ)) {
+			count++
+		}
 
 		if count != 1 {
 			return &u.Error{
@@ -96,7 +109,7 @@ func validateVlinks(vlinks []any) *u.Error {
 	return nil
 }
 
-func validateGroupContent(content []any, parentId, parentCategory string) *u.Error {
+func validateGroupContent(content []any, parentId, parentCategory string, pending map[string]map[string]any) *u.Error {
 	if len(content) <= 1 && content[0] == "" {
 		return &u.Error{
 			Type:    u.ErrBadFormat,
@@ -113,14 +126,14 @@ func validateGroupContent(content []any, parentId, parentCategory string) *u.Err
 	}
 
 	// Ensure objects all exist
-	if err := checkGroupContentExists(content, parentId, parentCategory); err != nil {
+	if err := checkGroupContentExists(content, parentId, parentCategory, pending); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func checkGroupContentExists(content []any, parentId, parentCategory string) *u.Error {
+func checkGroupContentExists(content []any, parentId, parentCategory string, pending map[string]map[string]any) *u.Error {
 	// Get filter
 	filter := repository.GroupContentToOrFilter(content, parentId)
 
@@ -139,6 +152,12 @@ func checkGroupContentExists(content []any, parentId, parentCategory string) *u.
 	if err != nil {
 		return err
 	}
+	for _, name := range content {
+		obj, isPending := pending[parentId+u.HN_DELIMETER+name.(string)]
+		if isPending && pie.Contains(siblingsEnts, u.EntityStrToInt(obj["category"].(string))) {
+			count++
+		}
+	}
 	if count != len(content) {
 		return &u.Error{
 			Type:    u.ErrBadFormat,
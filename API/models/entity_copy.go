@@ -0,0 +1,278 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"p3/repository"
+	u "p3/utils"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/elliotchance/pie/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const maxCopies = 1000
+
+// {start..end} in a name pattern, e.g. R{01..10}
+var namePatternRange = regexp.MustCompile(`\{(\d+)\.\.(\d+)\}`)
+
+type ObjectCopy struct {
+	ParentId   string         `json:"parentId"`
+	Name       string         `json:"name"`
+	Offset     []float64      `json:"offset"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+// CopyObject: copies the object of given id and its whole subtree under
+// a parent (its own by default) with a new name. The name can be a pattern
+// such as R{01..10} to make several copies, the nth copy is moved by n
+// times the offset. Each copy is validated against its destination and
+// all are created in a single Mongo transaction. Returns the copied roots.
+func CopyObject(entityStr, id string, copyReq ObjectCopy, userRoles map[string]Role) ([]map[string]any, *u.Error) {
+	source, err := GetObjectById(id, entityStr, u.RequestFilters{}, userRoles)
+	if err != nil {
+		return nil, err
+	}
+	entityStr = source["category"].(string)
+	entity := u.EntityStrToInt(entityStr)
+
+	if !(entity >= u.BLDG && entity <= u.GROUP) && entity != u.VIRTUALOBJ {
+		return nil, &u.Error{Type: u.ErrBadFormat,
+			Message: "Objects of category " + entityStr + " cannot be copied"}
+	}
+	for attr := range copyReq.Attributes {
+		if !pie.Contains(PositionAttrs, attr) {
+			return nil, &u.Error{Type: u.ErrBadFormat,
+				Message: "Only position attributes can be given when copying an object",
+				Details: PositionAttrs}
+		}
+	}
+
+	names, err := ExpandNamePattern(copyReq.Name, source["name"].(string))
+	if err != nil {
+		return nil, err
+	}
+	parentId := copyReq.ParentId
+	if parentId == "" {
+		parentId, _ = source["parentId"].(string)
+	}
+	if parentId == id || strings.HasPrefix(parentId, id+u.HN_DELIMETER) {
+		return nil, &u.Error{Type: u.ErrBadFormat,
+			Message: "An object cannot be copied under itself"}
+	}
+
+	subtree, err := getSubtreeToCopy(source, userRoles)
+	if err != nil {
+		return nil, err
+	}
+
+	// objects validated, by id, used as parents of the next ones
+	pending := map[string]map[string]any{}
+	toCreate := []map[string]any{}
+	roots := []map[string]any{}
+	for i, name := range names {
+		newRootId := parentId + u.HN_DELIMETER + name
+		for _, obj := range subtree {
+			objCopy := prepareObjectCopy(obj, id, newRootId, name, parentId)
+			if obj["id"] == id {
+				setCopyPosition(objCopy, copyReq, i+1)
+			}
+			if err := validateObjectCopy(objCopy, pending, userRoles); err != nil {
+				return nil, err
+			}
+			pending[objCopy["id"].(string)] = objCopy
+			toCreate = append(toCreate, objCopy)
+			if obj["id"] == id {
+				roots = append(roots, objCopy)
+			}
+		}
+	}
+
+	_, err = WithTransaction(func(ctx mongo.SessionContext) (any, error) {
+		for _, obj := range toCreate {
+			if _, err := repository.CreateObject(ctx, obj["category"].(string), obj); err != nil {
+				if err.Type == u.ErrDuplicate {
+					// created since the validation
+					err.Type = u.ErrConflict
+				}
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, root := range roots {
+		fixID(root)
+	}
+	return roots, nil
+}
+
+// ExpandNamePattern: gives the names of a pattern with a {start..end} range,
+// keeping the zero padding of start, e.g. R{08..10} gives R08, R09 and R10.
+// An empty pattern gives the default name
+func ExpandNamePattern(pattern, defaultName string) ([]string, *u.Error) {
+	if pattern == "" {
+		return []string{defaultName}, nil
+	}
+	match := namePatternRange.FindStringSubmatchIndex(pattern)
+	if match == nil {
+		return []string{pattern}, nil
+	}
+
+	startStr := pattern[match[2]:match[3]]
+	start, _ := strconv.Atoi(startStr)
+	end, _ := strconv.Atoi(pattern[match[4]:match[5]])
+	if end < start {
+		return nil, &u.Error{Type: u.ErrBadFormat,
+			Message: "Invalid name pattern: the end of the range must be greater than its start"}
+	} else if end-start+1 > maxCopies {
+		return nil, &u.Error{Type: u.ErrBadFormat,
+			Message: fmt.Sprintf("Invalid name pattern: at most %d copies can be made at once", maxCopies)}
+	}
+
+	width := 0
+	if len(startStr) > 1 && startStr[0] == '0' {
+		width = len(startStr)
+	}
+	names := []string{}
+	for n := start; n <= end; n++ {
+		names = append(names, pattern[:match[0]]+fmt.Sprintf("%0*d", width, n)+pattern[match[1]:])
+	}
+	return names, nil
+}
+
+// getSubtreeToCopy: returns the object and all its descendants,
+// parents always before their children
+func getSubtreeToCopy(source map[string]any, userRoles map[string]Role) ([]map[string]any, *u.Error) {
+	subtree := []map[string]any{source}
+	pattern := primitive.Regex{
+		Pattern: "^" + regexp.QuoteMeta(source["id"].(string)+u.HN_DELIMETER), Options: ""}
+	entities := []int{u.VIRTUALOBJ}
+	for entity := u.BLDG; entity <= u.GROUP; entity++ {
+		entities = append(entities, entity)
+	}
+	for _, entity := range entities {
+		children, err := GetManyObjects(u.EntityToString(entity), bson.M{"id": pattern},
+			u.RequestFilters{}, "", userRoles)
+		if err != nil {
+			return nil, err
+		}
+		subtree = append(subtree, children...)
+	}
+
+	for _, obj := range subtree {
+		// Description is always present, unless GetEntity was called with readonly permission
+		if obj["description"] == nil {
+			return nil, &u.Error{Type: u.ErrUnauthorized,
+				Message: "User does not have permission to copy " + obj["id"].(string)}
+		}
+	}
+
+	sort.SliceStable(subtree, func(i, j int) bool {
+		return strings.Count(subtree[i]["id"].(string), u.HN_DELIMETER) <
+			strings.Count(subtree[j]["id"].(string), u.HN_DELIMETER)
+	})
+	return subtree, nil
+}
+
+// prepareObjectCopy: returns the object as it should be created in the copied subtree
+func prepareObjectCopy(obj map[string]any, rootId, newRootId, newRootName, newRootParentId string) map[string]any {
+	var objCopy map[string]any
+	// Convert primitive.A and similar types
+	bytes, _ := json.Marshal(obj)
+	json.Unmarshal(bytes, &objCopy)
+
+	newId := repository.ReplaceIdPrefix(obj["id"].(string), rootId, newRootId)
+	if obj["id"] == rootId {
+		objCopy["name"] = newRootName
+		objCopy["parentId"] = newRootParentId
+	} else {
+		objCopy["parentId"] = newId[:strings.LastIndex(newId, u.HN_DELIMETER)]
+	}
+
+	// vlinks to objects of the subtree point to their copies
+	if attributes, ok := objCopy["attributes"].(map[string]any); ok {
+		if vlinks, ok := attributes["vlinks"].([]any); ok {
+			for i, vlink := range vlinks {
+				vlinks[i] = repository.ReplaceIdPrefix(vlink.(string), rootId, newRootId, "#")
+			}
+		}
+	}
+
+	// Remove API set fields
+	delete(objCopy, "_id")
+	delete(objCopy, "id")
+	delete(objCopy, "createdDate")
+	delete(objCopy, "lastUpdated")
+	return objCopy
+}
+
+// setCopyPosition: sets the position attributes given for the copies
+// and moves the nth copy by n times the offset
+func setCopyPosition(objCopy map[string]any, copyReq ObjectCopy, n int) {
+	attributes, _ := objCopy["attributes"].(map[string]any)
+	if attributes == nil {
+		attributes = map[string]any{}
+		objCopy["attributes"] = attributes
+	}
+	if objCopy["category"] == u.EntityToString(u.DEVICE) &&
+		(copyReq.Attributes["posU"] != nil || copyReq.Attributes["slot"] != nil) {
+		// a new position in the parent replaces the old one entirely
+		delete(attributes, "posU")
+		delete(attributes, "slot")
+	}
+	for attr, value := range copyReq.Attributes {
+		attributes[attr] = value
+	}
+
+	if len(copyReq.Offset) == 0 {
+		return
+	}
+	for _, attr := range []string{"posXYZ", "posXY", "posU"} {
+		switch position := attributes[attr].(type) {
+		case []any:
+			for i := range position {
+				if value, isNumber := position[i].(float64); isNumber && i < len(copyReq.Offset) {
+					position[i] = value + float64(n)*copyReq.Offset[i]
+				}
+			}
+			return
+		case float64:
+			attributes[attr] = position + float64(n)*copyReq.Offset[0]
+			return
+		}
+	}
+}
+
+// validateObjectCopy: validates the copy as a creation, its parent can be a pending copy
+func validateObjectCopy(objCopy map[string]any, pending map[string]map[string]any, userRoles map[string]Role) *u.Error {
+	entity := u.EntityStrToInt(objCopy["category"].(string))
+	if err := validateEntity(entity, objCopy, pending); err != nil {
+		return err
+	}
+
+	id := objCopy["id"].(string)
+	if _, isPending := pending[id]; isPending {
+		return &u.Error{Type: u.ErrDuplicate, Message: "Object " + id + " is copied more than once"}
+	}
+	if count, err := repository.CountObjects(entity, bson.M{"id": id}); err != nil {
+		return err
+	} else if count > 0 {
+		return &u.Error{Type: u.ErrConflict, Message: "Object " + id + " already exists"}
+	}
+
+	if permission := CheckUserPermissionsWithObject(userRoles, entity, objCopy); permission < WRITE {
+		return &u.Error{Type: u.ErrUnauthorized,
+			Message: "User does not have permission to create " + id}
+	}
+	delete(objCopy, "parentId")
+	return nil
+}
@@ -0,0 +1,91 @@
+package models_test
+
+import (
+	"p3/models"
+	"p3/test/integration"
+	u "p3/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandNamePattern(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		expected []string
+	}{
+		{"EmptyGivesDefault", "", []string{"rack"}},
+		{"WithoutRange", "R1", []string{"R1"}},
+		{"WithPadding", "R{08..10}", []string{"R08", "R09", "R10"}},
+		{"WithoutPadding", "R{8..10}-A", []string{"R8-A", "R9-A", "R10-A"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names, err := models.ExpandNamePattern(tt.pattern, "rack")
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+
+	_, err := models.ExpandNamePattern("R{10..01}", "rack")
+	assert.NotNil(t, err)
+}
+
+func TestCopyRackWithPatternAndOffset(t *testing.T) {
+	integration.RequireCreateSite("copy-site")
+	integration.RequireCreateBuilding("copy-site", "building")
+	integration.RequireCreateRoom("copy-site.building", "room1")
+	integration.RequireCreateRoom("copy-site.building", "room2")
+	integration.RequireCreateRack("copy-site.building.room1", "rack")
+	integration.RequireCreateDevice("copy-site.building.room1.rack", "device")
+	integration.RequireCreateGroup("copy-site.building.room1.rack", "group", []any{"device"})
+
+	copies, err := models.CopyObject(
+		u.EntityToString(u.RACK),
+		"copy-site.building.room1.rack",
+		models.ObjectCopy{ParentId: "copy-site.building.room2", Name: "R{01..03}", Offset: []float64{0, 2, 0}},
+		integration.ManagerUserRoles,
+	)
+	require.Nil(t, err)
+	require.Len(t, copies, 3)
+	assert.Equal(t, "copy-site.building.room2.R01", copies[0]["id"])
+	assert.Equal(t, "copy-site.building.room2", copies[0]["parentId"])
+	assert.Equal(t, "copy-site.building.room2.R03", copies[2]["id"])
+
+	firstPos := copies[0]["attributes"].(map[string]any)["posXYZ"].([]any)
+	lastPos := copies[2]["attributes"].(map[string]any)["posXYZ"].([]any)
+	assert.Equal(t, firstPos[1].(float64)+4, lastPos[1])
+
+	for _, name := range []string{"R01", "R02", "R03"} {
+		_, err := models.GetObjectById("copy-site.building.room2."+name+".device",
+			u.EntityToString(u.DEVICE), u.RequestFilters{}, integration.ManagerUserRoles)
+		assert.Nil(t, err)
+		_, err = models.GetObjectById("copy-site.building.room2."+name+".group",
+			u.EntityToString(u.GROUP), u.RequestFilters{}, integration.ManagerUserRoles)
+		assert.Nil(t, err)
+	}
+}
+
+func TestCopyRackToExistingIdReturnsError(t *testing.T) {
+	integration.RequireCreateSite("copy-dup-site")
+	integration.RequireCreateBuilding("copy-dup-site", "building")
+	integration.RequireCreateRoom("copy-dup-site.building", "room")
+	integration.RequireCreateRack("copy-dup-site.building.room", "rack1")
+	integration.RequireCreateRack("copy-dup-site.building.room", "rack2")
+
+	_, err := models.CopyObject(
+		u.EntityToString(u.RACK),
+		"copy-dup-site.building.room.rack1",
+		models.ObjectCopy{Name: "rack{1..3}"},
+		integration.ManagerUserRoles,
+	)
+	require.NotNil(t, err)
+	assert.Equal(t, u.ErrDuplicate, err.Type)
+
+	// nothing was created
+	_, err = models.GetObjectById("copy-dup-site.building.room.rack3",
+		u.EntityToString(u.RACK), u.RequestFilters{}, integration.ManagerUserRoles)
+	assert.NotNil(t, err)
+}
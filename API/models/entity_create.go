@@ -41,6 +41,12 @@ func CreateEntity(entity int, t map[string]interface{}, userRoles map[string]Rol
 }
 
 func prepareCreateEntity(entity int, t map[string]interface{}, userRoles map[string]Role) *u.Error {
+	if entity == u.OBJTMPL {
+		t["version"] = 1
+	} else if err := setObjTemplateVersion(entity, t); err != nil {
+		return err
+	}
+
 	if err := ValidateEntity(entity, t); err != nil {
 		return err
 	}
@@ -10,40 +10,44 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func DeleteObject(entityStr string, id string, userRoles map[string]Role) *u.Error {
+// DeleteObject: delete the object of given id (or slug), the references
+// pruned because of the deletion are returned
+func DeleteObject(entityStr string, id string, userRoles map[string]Role) ([]ReferenceChange, *u.Error) {
 	entity := u.EntityStrToInt(entityStr)
 	if entity == u.TAG {
-		return DeleteTag(id)
+		return nil, DeleteTag(id)
 	} else if u.IsEntityNonHierarchical(entity) {
-		return DeleteNonHierarchicalObject(entityStr, id)
+		return nil, DeleteNonHierarchicalObject(entityStr, id)
 	} else {
 		return DeleteHierarchicalObject(entityStr, id, userRoles)
 	}
 }
 
 // DeleteHierarchicalObject: delete object of given hierarchyName
-// search for all its children and delete them too, return:
+// search for all its children and delete them too. The group contents,
+// vlinks and clusterIds referencing them are pruned, return:
+// - the objects whose references were pruned
 // - success or fail message map
-func DeleteHierarchicalObject(entity string, id string, userRoles map[string]Role) *u.Error {
+func DeleteHierarchicalObject(entity string, id string, userRoles map[string]Role) ([]ReferenceChange, *u.Error) {
 	// Special check for delete domain
 	if entity == "domain" {
 		if id == os.Getenv("db") {
-			return &u.Error{Type: u.ErrForbidden, Message: "Cannot delete tenant's default domain"}
+			return nil, &u.Error{Type: u.ErrForbidden, Message: "Cannot delete tenant's default domain"}
 		}
 		if domainHasObjects(id) {
-			return &u.Error{Type: u.ErrForbidden, Message: "Cannot delete domain if it has at least one object"}
+			return nil, &u.Error{Type: u.ErrForbidden, Message: "Cannot delete domain if it has at least one object"}
 		}
 	}
 
 	// Delete with given id
 	req, ok := GetRequestFilterByDomain(userRoles)
 	if !ok {
-		return &u.Error{Type: u.ErrUnauthorized, Message: "User does not have permission to delete"}
+		return nil, &u.Error{Type: u.ErrUnauthorized, Message: "User does not have permission to delete"}
 	}
 
 	req["id"] = id
 
-	_, err := WithTransaction(func(ctx mongo.SessionContext) (any, error) {
+	return WithTransaction(func(ctx mongo.SessionContext) ([]ReferenceChange, error) {
 		err := repository.DeleteObject(ctx, entity, req)
 		if err != nil {
 			// Unable to delete given id
@@ -60,17 +64,28 @@ func DeleteHierarchicalObject(entity string, id string, userRoles map[string]Rol
 				bson.M{"id": pattern})
 		}
 
-		return nil, nil
+		if entity == u.EntityToString(u.DOMAIN) {
+			return nil, nil
+		}
+		return updateReferences(ctx, id, "")
 	})
-
-	return err
 }
 
 func DeleteNonHierarchicalObject(entity, slug string) *u.Error {
 	req := bson.M{"slug": slug}
 	ctx, cancel := u.Connect()
 	defer cancel()
-	return repository.DeleteObject(ctx, entity, req)
+	if err := repository.DeleteObject(ctx, entity, req); err != nil {
+		return err
+	}
+
+	if entity == u.EntityToString(u.OBJTMPL) {
+		_, err := repository.GetDB().Collection(OBJ_TEMPLATE_VERSIONS).DeleteMany(ctx, req)
+		if err != nil {
+			return &u.Error{Type: u.ErrDBError, Message: err.Error()}
+		}
+	}
+	return nil
 }
 
 // Helper functions
@@ -0,0 +1,70 @@
+package models_test
+
+import (
+	"p3/models"
+	"p3/test/integration"
+	u "p3/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteDevicePrunesReferences(t *testing.T) {
+	integration.RequireCreateSite("delete-refs-site")
+	integration.RequireCreateBuilding("delete-refs-site", "building")
+	integration.RequireCreateRoom("delete-refs-site.building", "room")
+	integration.RequireCreateRack("delete-refs-site.building.room", "rack")
+	integration.RequireCreateDevice("delete-refs-site.building.room.rack", "device1")
+	integration.RequireCreateDevice("delete-refs-site.building.room.rack", "device2")
+	integration.RequireCreateGroup("delete-refs-site.building.room.rack", "group1", []any{"device1", "device2"})
+	integration.RequireCreateGroup("delete-refs-site.building.room.rack", "group2", []any{"device1"})
+	integration.RequireCreateVirtualObject("", "delete-refs-vobj", map[string]any{
+		"vlinks": []any{
+			"delete-refs-site.building.room.rack.device1",
+			"delete-refs-site.building.room.rack.device2",
+		},
+		"virtual_config": map[string]any{"type": "node"},
+	})
+
+	changes, err := models.DeleteHierarchicalObject(u.EntityToString(u.DEVICE),
+		"delete-refs-site.building.room.rack.device1", integration.ManagerUserRoles)
+	require.Nil(t, err)
+	assert.Len(t, changes, 3)
+
+	group, err := models.GetObjectById("delete-refs-site.building.room.rack.group1",
+		u.EntityToString(u.GROUP), u.RequestFilters{}, integration.ManagerUserRoles)
+	require.Nil(t, err)
+	assert.Equal(t, []any{"device2"}, group["attributes"].(map[string]any)["content"])
+
+	// group left empty is deleted
+	_, err = models.GetObjectById("delete-refs-site.building.room.rack.group2",
+		u.EntityToString(u.GROUP), u.RequestFilters{}, integration.ManagerUserRoles)
+	assert.NotNil(t, err)
+
+	vobj, err := models.GetObjectById("delete-refs-vobj",
+		u.EntityToString(u.VIRTUALOBJ), u.RequestFilters{}, integration.ManagerUserRoles)
+	require.Nil(t, err)
+	assert.Equal(t, []any{"delete-refs-site.building.room.rack.device2"},
+		vobj["attributes"].(map[string]any)["vlinks"])
+}
+
+func TestDeleteClusterRemovesClusterId(t *testing.T) {
+	integration.RequireCreateVirtualObject("", "delete-cluster", map[string]any{
+		"virtual_config": map[string]any{"type": "cluster"},
+	})
+	integration.RequireCreateVirtualObject("", "delete-cluster-vm", map[string]any{
+		"virtual_config": map[string]any{"type": "vm", "clusterId": "delete-cluster"},
+	})
+
+	changes, err := models.DeleteHierarchicalObject(u.EntityToString(u.VIRTUALOBJ),
+		"delete-cluster", integration.ManagerUserRoles)
+	require.Nil(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "delete-cluster-vm", changes[0].Id)
+
+	vm, err := models.GetObjectById("delete-cluster-vm",
+		u.EntityToString(u.VIRTUALOBJ), u.RequestFilters{}, integration.ManagerUserRoles)
+	require.Nil(t, err)
+	assert.NotContains(t, vm["attributes"].(map[string]any)["virtual_config"], "clusterId")
+}
@@ -65,6 +65,14 @@ func GetHierarchicalObjectById(hierarchyName string, filters u.RequestFilters, u
 	return nil, &u.Error{Type: u.ErrNotFound, Message: "Unable to find object"}
 }
 
+// ApplyRequestFilters: adds the date and complex filters to the mongo query
+func ApplyRequestFilters(req bson.M, filters u.RequestFilters, complexFilterExp string) *u.Error {
+	if err := repository.GetDateFilters(req, filters.StartDate, filters.EndDate); err != nil {
+		return &u.Error{Type: u.ErrBadFormat, Message: err.Error()}
+	}
+	return ApplyComplexFilter(complexFilterExp, req)
+}
+
 func GetManyObjects(entityStr string, req bson.M, filters u.RequestFilters, complexFilterExp string, userRoles map[string]Role) ([]map[string]interface{}, *u.Error) {
 	ctx, cancel := u.Connect()
 	var err error
@@ -72,10 +80,7 @@ func GetManyObjects(entityStr string, req bson.M, filters u.RequestFilters, comp
 
 	// Filters
 	opts := repository.GetFieldsToShowFilter(filters.FieldsToShow)
-	if err := repository.GetDateFilters(req, filters.StartDate, filters.EndDate); err != nil {
-		return nil, &u.Error{Type: u.ErrBadFormat, Message: err.Error()}
-	}
-	if err := ApplyComplexFilter(complexFilterExp, req); err != nil {
+	if err := ApplyRequestFilters(req, filters, complexFilterExp); err != nil {
 		return nil, err
 	}
 
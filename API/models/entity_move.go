@@ -0,0 +1,172 @@
+package models
+
+import (
+	"encoding/json"
+	"p3/repository"
+	u "p3/utils"
+	"strings"
+	"time"
+
+	"github.com/elliotchance/pie/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Attributes that can be given to set the position of a moved object
+var PositionAttrs = []string{"posXY", "posXYUnit", "posXYZ", "posZUnit", "posU", "slot", "rotation"}
+
+type ObjectMove struct {
+	ParentId   string         `json:"parentId"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+// MoveObject: moves the object of given id under a new parent, optionally
+// changing its position. The ids of the whole subtree are rewritten and
+// the references to them (group contents, vlinks, clusterId and breakers)
+// are updated and returned. All is done in a Mongo transaction
+func MoveObject(entityStr, id string, move ObjectMove, userRoles map[string]Role) (map[string]any, []ReferenceChange, *u.Error) {
+	oldObj, err := GetObjectById(id, entityStr, u.RequestFilters{}, userRoles)
+	if err != nil {
+		return nil, nil, err
+	}
+	entityStr = oldObj["category"].(string)
+	entity := u.EntityStrToInt(entityStr)
+
+	if !(entity >= u.BLDG && entity <= u.GROUP) && entity != u.VIRTUALOBJ {
+		return nil, nil, &u.Error{Type: u.ErrBadFormat,
+			Message: "Objects of category " + entityStr + " cannot be moved"}
+	}
+
+	// Description is always present, unless GetEntity was called with readonly permission
+	if oldObj["description"] == nil {
+		return nil, nil, &u.Error{Type: u.ErrUnauthorized,
+			Message: "User does not have permission to move this object"}
+	}
+
+	newObj, err := prepareMoveObject(entity, id, move, oldObj)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := ValidateEntity(entity, newObj); err != nil {
+		return nil, nil, err
+	}
+
+	if CheckUserPermissionsWithObject(userRoles, entity, newObj) < WRITE {
+		return nil, nil, &u.Error{Type: u.ErrUnauthorized,
+			Message: "User does not have permission to move this object"}
+	}
+
+	newId := newObj["id"].(string)
+	if newId != id {
+		if count, err := repository.CountObjects(entity, bson.M{"id": newId}); err != nil {
+			return nil, nil, err
+		} else if count > 0 {
+			return nil, nil, &u.Error{Type: u.ErrConflict,
+				Message: "An object with id " + newId + " already exists"}
+		}
+	}
+
+	newObj["lastUpdated"] = primitive.NewDateTimeFromTime(time.Now())
+	newObj["createdDate"] = oldObj["createdDate"]
+	delete(newObj, "parentId")
+
+	var changes []ReferenceChange
+	moved, err := WithTransaction(func(ctx mongo.SessionContext) (map[string]any, error) {
+		var moved map[string]any
+		err := repository.GetDB().Collection(entityStr).FindOneAndReplace(
+			ctx,
+			bson.M{"id": id}, newObj,
+			options.FindOneAndReplace().SetReturnDocument(options.After),
+		).Decode(&moved)
+		if mongo.IsDuplicateKeyError(err) {
+			// created at the destination since the check
+			return nil, &u.Error{Type: u.ErrConflict,
+				Message: "An object with id " + newId + " already exists"}
+		} else if err != nil {
+			return nil, err
+		}
+
+		if newId == id {
+			// only the position changed
+			return moved, nil
+		}
+
+		changes, err = propagateMove(ctx, id, newId)
+		if err != nil {
+			return nil, err
+		}
+
+		return moved, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return fixID(moved), changes, nil
+}
+
+// prepareMoveObject: returns the object as it should be after the move,
+// without api set fields, so it can be validated
+func prepareMoveObject(entity int, id string, move ObjectMove, oldObj map[string]any) (map[string]any, *u.Error) {
+	if move.ParentId == "" {
+		return nil, &u.Error{Type: u.ErrBadFormat,
+			Message: "Error while decoding request body: must contain parentId"}
+	}
+	if move.ParentId == id || strings.HasPrefix(move.ParentId, id+u.HN_DELIMETER) {
+		return nil, &u.Error{Type: u.ErrBadFormat,
+			Message: "An object cannot be moved under itself"}
+	}
+	for attr := range move.Attributes {
+		if !pie.Contains(PositionAttrs, attr) {
+			return nil, &u.Error{Type: u.ErrBadFormat,
+				Message: "Only position attributes can be given when moving an object",
+				Details: PositionAttrs}
+		}
+	}
+
+	var newObj map[string]any
+	// Convert primitive.A and similar types
+	bytes, _ := json.Marshal(oldObj)
+	json.Unmarshal(bytes, &newObj)
+
+	// Remove API set fields
+	delete(newObj, "id")
+	delete(newObj, "lastUpdated")
+	delete(newObj, "createdDate")
+	newObj["parentId"] = move.ParentId
+
+	if len(move.Attributes) > 0 {
+		attributes, _ := newObj["attributes"].(map[string]any)
+		if attributes == nil {
+			attributes = map[string]any{}
+			newObj["attributes"] = attributes
+		}
+		if entity == u.DEVICE && (move.Attributes["posU"] != nil || move.Attributes["slot"] != nil) {
+			// a new position in the parent replaces the old one entirely
+			delete(attributes, "posU")
+			delete(attributes, "slot")
+		}
+		for attr, value := range move.Attributes {
+			attributes[attr] = value
+		}
+	}
+
+	return newObj, nil
+}
+
+// propagateMove: rewrites the ids of the subtree of the moved object
+// and the references to it and to its children
+func propagateMove(ctx mongo.SessionContext, oldId, newId string) ([]ReferenceChange, error) {
+	descendants := []int{u.VIRTUALOBJ}
+	for entity := u.BLDG; entity <= u.GROUP; entity++ {
+		descendants = append(descendants, entity)
+	}
+	if err := repository.PropagateSubtreeIdChange(ctx, oldId, newId, descendants); err != nil {
+		return nil, err
+	}
+
+	return updateReferences(ctx, oldId, newId)
+}
@@ -0,0 +1,138 @@
+package models_test
+
+import (
+	"p3/models"
+	"p3/test/integration"
+	u "p3/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveRackRewritesSubtreeIds(t *testing.T) {
+	integration.RequireCreateSite("move-site")
+	integration.RequireCreateBuilding("move-site", "building")
+	integration.RequireCreateRoom("move-site.building", "room1")
+	integration.RequireCreateRoom("move-site.building", "room2")
+	integration.RequireCreateRack("move-site.building.room1", "rack")
+	integration.RequireCreateDevice("move-site.building.room1.rack", "device")
+
+	moved, _, err := models.MoveObject(
+		u.EntityToString(u.RACK),
+		"move-site.building.room1.rack",
+		models.ObjectMove{ParentId: "move-site.building.room2"},
+		integration.ManagerUserRoles,
+	)
+	require.Nil(t, err)
+	assert.Equal(t, "move-site.building.room2.rack", moved["id"])
+	assert.Equal(t, "move-site.building.room2", moved["parentId"])
+
+	_, err = models.GetObjectById("move-site.building.room1.rack.device",
+		u.EntityToString(u.DEVICE), u.RequestFilters{}, integration.ManagerUserRoles)
+	assert.NotNil(t, err)
+	device, err := models.GetObjectById("move-site.building.room2.rack.device",
+		u.EntityToString(u.DEVICE), u.RequestFilters{}, integration.ManagerUserRoles)
+	assert.Nil(t, err)
+	assert.Equal(t, "move-site.building.room2.rack", device["parentId"])
+}
+
+func TestMoveRackRemovesItFromOldGroups(t *testing.T) {
+	integration.RequireCreateSite("move-group-site")
+	integration.RequireCreateBuilding("move-group-site", "building")
+	integration.RequireCreateRoom("move-group-site.building", "room1")
+	integration.RequireCreateRoom("move-group-site.building", "room2")
+	integration.RequireCreateRack("move-group-site.building.room1", "rack1")
+	integration.RequireCreateRack("move-group-site.building.room1", "rack2")
+	integration.RequireCreateGroup("move-group-site.building.room1", "group1", []any{"rack1", "rack2"})
+	integration.RequireCreateGroup("move-group-site.building.room1", "group2", []any{"rack1"})
+
+	_, _, err := models.MoveObject(
+		u.EntityToString(u.RACK),
+		"move-group-site.building.room1.rack1",
+		models.ObjectMove{ParentId: "move-group-site.building.room2"},
+		integration.ManagerUserRoles,
+	)
+	require.Nil(t, err)
+
+	group, err := models.GetObjectById("move-group-site.building.room1.group1",
+		u.EntityToString(u.GROUP), u.RequestFilters{}, integration.ManagerUserRoles)
+	require.Nil(t, err)
+	assert.ElementsMatch(t, []any{"rack2"}, group["attributes"].(map[string]any)["content"])
+
+	// group left empty is deleted
+	_, err = models.GetObjectById("move-group-site.building.room1.group2",
+		u.EntityToString(u.GROUP), u.RequestFilters{}, integration.ManagerUserRoles)
+	assert.NotNil(t, err)
+}
+
+func TestMoveRackToExistingIdReturnsConflict(t *testing.T) {
+	integration.RequireCreateSite("move-conflict-site")
+	integration.RequireCreateBuilding("move-conflict-site", "building")
+	integration.RequireCreateRoom("move-conflict-site.building", "room1")
+	integration.RequireCreateRoom("move-conflict-site.building", "room2")
+	integration.RequireCreateRack("move-conflict-site.building.room1", "rack")
+	integration.RequireCreateRack("move-conflict-site.building.room2", "rack")
+
+	_, _, err := models.MoveObject(
+		u.EntityToString(u.RACK),
+		"move-conflict-site.building.room1.rack",
+		models.ObjectMove{ParentId: "move-conflict-site.building.room2"},
+		integration.ManagerUserRoles,
+	)
+	require.NotNil(t, err)
+	assert.Equal(t, u.ErrConflict, err.Type)
+	assert.Equal(t, "An object with id move-conflict-site.building.room2.rack already exists", err.Message)
+}
+
+func TestMoveObjectUnderItselfReturnsError(t *testing.T) {
+	integration.RequireCreateSite("move-itself-site")
+	integration.RequireCreateBuilding("move-itself-site", "building")
+	integration.RequireCreateRoom("move-itself-site.building", "room")
+
+	_, _, err := models.MoveObject(
+		u.EntityToString(u.ROOM),
+		"move-itself-site.building.room",
+		models.ObjectMove{ParentId: "move-itself-site.building.room"},
+		integration.ManagerUserRoles,
+	)
+	require.NotNil(t, err)
+	assert.Equal(t, u.ErrBadFormat, err.Type)
+	assert.Equal(t, "An object cannot be moved under itself", err.Message)
+}
+
+func TestMoveObjectWithNonPositionAttributeReturnsError(t *testing.T) {
+	integration.RequireCreateSite("move-attr-site")
+	integration.RequireCreateBuilding("move-attr-site", "building")
+	integration.RequireCreateRoom("move-attr-site.building", "room1")
+	integration.RequireCreateRoom("move-attr-site.building", "room2")
+	integration.RequireCreateRack("move-attr-site.building.room1", "rack")
+
+	_, _, err := models.MoveObject(
+		u.EntityToString(u.RACK),
+		"move-attr-site.building.room1.rack",
+		models.ObjectMove{
+			ParentId:   "move-attr-site.building.room2",
+			Attributes: map[string]any{"color": "ff0000"},
+		},
+		integration.ManagerUserRoles,
+	)
+	require.NotNil(t, err)
+	assert.Equal(t, u.ErrBadFormat, err.Type)
+}
+
+func TestMoveRackToInvalidParentReturnsError(t *testing.T) {
+	integration.RequireCreateSite("move-parent-site")
+	integration.RequireCreateBuilding("move-parent-site", "building")
+	integration.RequireCreateRoom("move-parent-site.building", "room")
+	integration.RequireCreateRack("move-parent-site.building.room", "rack")
+
+	_, _, err := models.MoveObject(
+		u.EntityToString(u.RACK),
+		"move-parent-site.building.room.rack",
+		models.ObjectMove{ParentId: "move-parent-site.building"},
+		integration.ManagerUserRoles,
+	)
+	require.NotNil(t, err)
+	assert.Equal(t, u.ErrInvalidValue, err.Type)
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"p3/repository"
+	u "p3/utils"
+	"strings"
+
+	"github.com/elliotchance/pie/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ReferenceChange: an object whose references (group content, vlinks,
+// clusterId or breakers) or a relation that were updated because the referenced
+// object was linked, renamed, moved or deleted. Object is nil if the object itself
+// was deleted, which happens to groups left without content and to relations
+type ReferenceChange struct {
+	Category string
+	Id       string
+	Object   any
+}
+
+// updateReferences: makes the references and relations to the object of oldId,
+// and to its children, point to newId. If newId is empty, the object was deleted and
+// the references are pruned, except for breakers that need a manual repair.
+// Objects are removed from the groups of their old parent when they leave it
+func updateReferences(ctx mongo.SessionContext, oldId, newId string) ([]ReferenceChange, error) {
+	changes := []ReferenceChange{}
+
+	if oldParentId, oldName := splitId(oldId); oldParentId != "" {
+		newParentId, newName := splitId(newId)
+		if newParentId != oldParentId {
+			// group contents are relative to the parent, the object left it
+			newName = ""
+		}
+		updated, deleted, err := repository.ReplaceInGroupsContent(ctx, oldParentId, oldName, newName)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, toReferenceChanges(updated)...)
+		for _, id := range deleted {
+			changes = append(changes, ReferenceChange{Category: u.EntityToString(u.GROUP), Id: id})
+		}
+	}
+
+	updated, err := repository.ReplaceVlinksPrefix(ctx, oldId, newId)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, toReferenceChanges(updated)...)
+
+	updated, err = repository.ReplaceClusterIdPrefix(ctx, oldId, newId)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, toReferenceChanges(updated)...)
+
+	if newId != "" {
+		updated, err = repository.ReplaceBreakersPowerpanel(ctx, oldId, newId)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, toReferenceChanges(updated)...)
+	}
+
+	relationChanges, err := updateRelations(ctx, oldId, newId)
+	if err != nil {
+		return nil, err
+	}
+	return append(changes, relationChanges...), nil
+}
+
+// splitId: parent id and name of the object of given id
+func splitId(id string) (string, string) {
+	if i := strings.LastIndex(id, u.HN_DELIMETER); i > 0 {
+		return id[:i], id[i+len(u.HN_DELIMETER):]
+	}
+	return "", id
+}
+
+func toReferenceChanges(objects []map[string]any) []ReferenceChange {
+	return pie.Map(objects, func(object map[string]any) ReferenceChange {
+		object = fixID(object)
+		return ReferenceChange{
+			Category: object["category"].(string),
+			Id:       object["id"].(string),
+			Object:   object,
+		}
+	})
+}
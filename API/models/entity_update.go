@@ -18,11 +18,11 @@ import (
 
 var AttrsWithInnerObj = []string{"pillars", "separators", "breakers"}
 
-func UpdateObject(entityStr string, id string, updateData map[string]interface{}, isPatch bool, userRoles map[string]Role, isRecursive bool) (map[string]interface{}, *u.Error) {
+func UpdateObject(entityStr string, id string, updateData map[string]interface{}, isPatch bool, userRoles map[string]Role, isRecursive bool) (map[string]interface{}, []ReferenceChange, *u.Error) {
 	// Update timestamp requires, first, obj retrieval
 	oldObj, err := GetObjectById(id, entityStr, u.RequestFilters{}, userRoles)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	} else if entityStr == u.HIERARCHYOBJS_ENT {
 		// overwrite category
 		entityStr = oldObj["category"].(string)
@@ -32,7 +32,7 @@ func UpdateObject(entityStr string, id string, updateData map[string]interface{}
 	// Check if permission is only readonly
 	if u.IsEntityHierarchical(entity) && oldObj["description"] == nil {
 		// Description is always present, unless GetEntity was called with readonly permission
-		return nil, &u.Error{Type: u.ErrUnauthorized,
+		return nil, nil, &u.Error{Type: u.ErrUnauthorized,
 			Message: "User does not have permission to change this object"}
 	}
 
@@ -42,31 +42,34 @@ func UpdateObject(entityStr string, id string, updateData map[string]interface{}
 	if isPatch {
 		println("is PATCH")
 		if patchData, err := preparePatch(tagsPresent, updateData, oldObj); err != nil {
-			return nil, err
+			return nil, nil, err
 		} else {
 			updateData = patchData
 		}
 	} else if tagsPresent {
 		if err := verifyTagList(tags); err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
 	fmt.Println(updateData)
-	result, err := UpdateTransaction(entity, id, isRecursive, updateData, oldObj, userRoles)
+	result, changes, err := UpdateTransaction(entity, id, isRecursive, updateData, oldObj, userRoles)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var updatedDoc map[string]interface{}
 	result.(*mongo.SingleResult).Decode(&updatedDoc)
 
-	return fixID(updatedDoc), nil
+	return fixID(updatedDoc), changes, nil
 }
 
-func UpdateTransaction(entity int, id string, isRecursive bool, updateData, oldObj map[string]any, userRoles map[string]Role) (any, *u.Error) {
+// UpdateTransaction: replaces the object by updateData and propagates the changes,
+// the references updated because of an id change are returned
+func UpdateTransaction(entity int, id string, isRecursive bool, updateData, oldObj map[string]any, userRoles map[string]Role) (any, []ReferenceChange, *u.Error) {
 	entityStr := u.EntityToString(entity)
-	return WithTransaction(func(ctx mongo.SessionContext) (interface{}, error) {
+	var changes []ReferenceChange
+	result, err := WithTransaction(func(ctx mongo.SessionContext) (interface{}, error) {
 		err := prepareUpdateObject(ctx, entity, id, updateData, oldObj, userRoles)
 		if err != nil {
 			return nil, err
@@ -82,12 +85,15 @@ func UpdateTransaction(entity int, id string, isRecursive bool, updateData, oldO
 			return nil, mongoRes.Err()
 		}
 
-		if err := propagateUpdateChanges(ctx, entity, oldObj, updateData, isRecursive); err != nil {
-			return nil, err
+		referenceChanges, e := propagateUpdateChanges(ctx, entity, oldObj, updateData, isRecursive)
+		if e != nil {
+			return nil, e
 		}
+		changes = referenceChanges
 
 		return mongoRes, nil
 	})
+	return result, changes, err
 }
 
 func preparePatch(tagsPresent bool, updateData, oldObj map[string]any) (map[string]any, *u.Error) {
@@ -131,6 +137,15 @@ func prepareUpdateObject(ctx mongo.SessionContext, entity int, id string, update
 		return err
 	}
 
+	// template versions support
+	if entity == u.OBJTMPL {
+		if err := saveObjTemplateVersion(ctx, oldObject, updateData); err != nil {
+			return err
+		}
+	} else if err := updateObjTemplateVersion(entity, updateData, oldObject); err != nil {
+		return err
+	}
+
 	// Ensure the update is valid
 	err = ValidateEntity(entity, updateData)
 	if err != nil {
@@ -187,7 +202,8 @@ func updateOldObjWithPatch(old map[string]interface{}, patch map[string]interfac
 	return nil
 }
 
-func propagateUpdateChanges(ctx mongo.SessionContext, entity int, oldObj, updateData map[string]any, isRecursive bool) error {
+func propagateUpdateChanges(ctx mongo.SessionContext, entity int, oldObj, updateData map[string]any, isRecursive bool) ([]ReferenceChange, error) {
+	var changes []ReferenceChange
 	if oldObj["id"] != updateData["id"] {
 		// Changes to id should be propagated
 		if err := repository.PropagateParentIdChange(
@@ -196,23 +212,30 @@ func propagateUpdateChanges(ctx mongo.SessionContext, entity int, oldObj, update
 			updateData["id"].(string),
 			entity,
 		); err != nil {
-			return err
+			return nil, err
 		}
 		if entity == u.DOMAIN {
 			if err := repository.PropagateDomainChange(ctx,
 				oldObj["id"].(string),
 				updateData["id"].(string),
 			); err != nil {
-				return err
+				return nil, err
+			}
+		} else {
+			// References to the object should follow it
+			var err error
+			changes, err = updateReferences(ctx, oldObj["id"].(string), updateData["id"].(string))
+			if err != nil {
+				return nil, err
 			}
 		}
 	}
 	if u.IsEntityHierarchical(entity) && (oldObj["domain"] != updateData["domain"]) {
 		if err := propagateObjDomainChange(ctx, entity, isRecursive, updateData); err != nil {
-			return err
+			return nil, err
 		}
 	}
-	return nil
+	return changes, nil
 }
 
 func propagateObjDomainChange(ctx mongo.SessionContext, entity int, isRecursive bool, updateData map[string]any) error {
@@ -236,13 +259,14 @@ func propagateObjDomainChange(ctx mongo.SessionContext, entity int, isRecursive
 }
 
 // SwapEntity: use id to remove object from deleteEnt and then use data to create it in createEnt.
-// Propagates id changes to children objects. For atomicity, all is done in a Mongo transaction.
-func SwapEntity(createEnt, deleteEnt, id string, data map[string]interface{}, userRoles map[string]Role) *u.Error {
+// Propagates id changes to children objects and to the references to them, the updated
+// references are returned. For atomicity, all is done in a Mongo transaction.
+func SwapEntity(createEnt, deleteEnt, id string, data map[string]interface{}, userRoles map[string]Role) ([]ReferenceChange, *u.Error) {
 	if err := prepareCreateEntity(u.EntityStrToInt(createEnt), data, userRoles); err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err := WithTransaction(func(ctx mongo.SessionContext) (any, error) {
+	return WithTransaction(func(ctx mongo.SessionContext) ([]ReferenceChange, error) {
 		// Create
 		if _, err := repository.CreateObject(ctx, createEnt, data); err != nil {
 			return nil, err
@@ -261,8 +285,6 @@ func SwapEntity(createEnt, deleteEnt, id string, data map[string]interface{}, us
 			return nil, errors.New("Error deleting object: not found")
 		}
 
-		return nil, nil
+		return updateReferences(ctx, id, data["id"].(string))
 	})
-
-	return err
 }
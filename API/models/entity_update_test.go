@@ -6,7 +6,9 @@ import (
 	u "p3/utils"
 	"testing"
 
+	"github.com/elliotchance/pie/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var device map[string]any
@@ -29,7 +31,7 @@ func init() {
 func TestUpdateGenericWorks(t *testing.T) {
 	generic := integration.RequireCreateGeneric("", "update-object-1")
 
-	_, err := models.UpdateObject(
+	_, _, err := models.UpdateObject(
 		u.EntityToString(u.GENERIC),
 		generic["id"].(string),
 		map[string]any{
@@ -49,7 +51,7 @@ func TestUpdateGenericWorks(t *testing.T) {
 // region device's sizeU & height
 
 func TestUpdateDeviceSizeUAndHeightmm(t *testing.T) {
-	_, err := models.UpdateObject(
+	_, _, err := models.UpdateObject(
 		u.EntityToString(u.DEVICE),
 		device["id"].(string),
 		map[string]any{
@@ -66,7 +68,7 @@ func TestUpdateDeviceSizeUAndHeightmm(t *testing.T) {
 	assert.Nil(t, err)
 }
 func TestUpdateDeviceSizeUAndHeightcm(t *testing.T) {
-	_, err := models.UpdateObject(
+	_, _, err := models.UpdateObject(
 		u.EntityToString(u.DEVICE),
 		device["id"].(string),
 		map[string]any{
@@ -84,7 +86,7 @@ func TestUpdateDeviceSizeUAndHeightcm(t *testing.T) {
 }
 
 func TestUpdateDeviceSizeUAndHeightmmError(t *testing.T) {
-	_, err := models.UpdateObject(
+	_, _, err := models.UpdateObject(
 		u.EntityToString(u.DEVICE),
 		device["id"].(string),
 		map[string]any{
@@ -103,3 +105,58 @@ func TestUpdateDeviceSizeUAndHeightmmError(t *testing.T) {
 }
 
 // endregion device's sizeU & height
+
+// region references
+
+func TestUpdateRackNameUpdatesReferences(t *testing.T) {
+	integration.RequireCreateSite("rename-site")
+	integration.RequireCreateBuilding("rename-site", "building")
+	integration.RequireCreateRoom("rename-site.building", "room")
+	integration.RequireCreateRack("rename-site.building.room", "rack1")
+	integration.RequireCreateRack("rename-site.building.room", "rack2")
+	integration.RequireCreateDevice("rename-site.building.room.rack1", "device")
+	integration.RequireCreateGroup("rename-site.building.room", "group", []any{"rack1", "rack2"})
+	integration.RequireCreateVirtualObject("", "rename-vobj", map[string]any{
+		"vlinks":         []any{"rename-site.building.room.rack1.device"},
+		"virtual_config": map[string]any{"type": "node"},
+	})
+
+	_, changes, err := models.UpdateObject(u.EntityToString(u.RACK), "rename-site.building.room.rack1",
+		map[string]any{"name": "rack3"}, true, integration.ManagerUserRoles, false)
+	require.Nil(t, err)
+	assert.ElementsMatch(t, []string{"rename-site.building.room.group", "rename-vobj"},
+		pie.Map(changes, func(change models.ReferenceChange) string { return change.Id }))
+
+	group, err := models.GetObjectById("rename-site.building.room.group",
+		u.EntityToString(u.GROUP), u.RequestFilters{}, integration.ManagerUserRoles)
+	require.Nil(t, err)
+	assert.ElementsMatch(t, []any{"rack3", "rack2"}, group["attributes"].(map[string]any)["content"])
+
+	vobj, err := models.GetObjectById("rename-vobj",
+		u.EntityToString(u.VIRTUALOBJ), u.RequestFilters{}, integration.ManagerUserRoles)
+	require.Nil(t, err)
+	assert.Equal(t, []any{"rename-site.building.room.rack3.device"}, vobj["attributes"].(map[string]any)["vlinks"])
+}
+
+func TestUpdateClusterNameUpdatesClusterId(t *testing.T) {
+	integration.RequireCreateVirtualObject("", "rename-cluster", map[string]any{
+		"virtual_config": map[string]any{"type": "cluster"},
+	})
+	integration.RequireCreateVirtualObject("", "rename-vm", map[string]any{
+		"virtual_config": map[string]any{"type": "vm", "clusterId": "rename-cluster"},
+	})
+
+	_, changes, err := models.UpdateObject(u.EntityToString(u.VIRTUALOBJ), "rename-cluster",
+		map[string]any{"name": "renamed-cluster"}, true, integration.ManagerUserRoles, false)
+	require.Nil(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "rename-vm", changes[0].Id)
+
+	vm, err := models.GetObjectById("rename-vm",
+		u.EntityToString(u.VIRTUALOBJ), u.RequestFilters{}, integration.ManagerUserRoles)
+	require.Nil(t, err)
+	assert.Equal(t, "renamed-cluster",
+		vm["attributes"].(map[string]any)["virtual_config"].(map[string]any)["clusterId"])
+}
+
+// endregion references
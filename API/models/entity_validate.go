@@ -92,11 +92,11 @@ func validateDomain(entity int, obj, parent map[string]any) *u.Error {
 	return nil
 }
 
-func getParentSetId(entity int, obj map[string]any) (map[string]any, *u.Error) {
+func getParentSetId(entity int, obj map[string]any, pending map[string]map[string]any) (map[string]any, *u.Error) {
 	var parent map[string]interface{}
 	if u.IsEntityHierarchical(entity) {
 		var err *u.Error
-		parent, err = validateParent(u.EntityToString(entity), entity, obj)
+		parent, err = validateParent(u.EntityToString(entity), entity, obj, pending)
 		if err != nil {
 			return parent, err
 		} else if parent["id"] != nil {
@@ -125,20 +125,20 @@ func validateParentId(entNum int, parentId any) (bool, *u.Error) {
 	return true, nil
 }
 
-func validateParent(ent string, entNum int, t map[string]interface{}) (map[string]interface{}, *u.Error) {
+func validateParent(ent string, entNum int, t map[string]interface{}, pending map[string]map[string]any) (map[string]interface{}, *u.Error) {
 	if hasParentId, err := validateParentId(entNum, t["parentId"]); !hasParentId {
 		return nil, err
 	}
 
 	// Anyone can have a stray parent
-	if parent := getParent([]string{"stray_object"}, t); parent != nil {
+	if parent := getParent([]string{"stray_object"}, t, pending); parent != nil {
 		return parent, nil
 	}
 
 	// If not, search specific possibilities
 	switch entNum {
 	case u.DEVICE:
-		if parent := getParent([]string{"rack", "device"}, t); parent != nil {
+		if parent := getParent([]string{"rack", "device"}, t, pending); parent != nil {
 			if err := validateDeviceSlotExists(t, parent); err != nil {
 				return nil, err
 			}
@@ -150,7 +150,7 @@ func validateParent(ent string, entNum int, t map[string]interface{}) (map[strin
 			Message: "ParentID should correspond to existing rack or device ID"}
 
 	case u.GROUP:
-		if parent := getParent([]string{"rack", "room"}, t); parent != nil {
+		if parent := getParent([]string{"rack", "room"}, t, pending); parent != nil {
 			return parent, nil
 		}
 
@@ -158,7 +158,7 @@ func validateParent(ent string, entNum int, t map[string]interface{}) (map[strin
 			Message: "Group parent should correspond to existing rack or room"}
 
 	case u.VIRTUALOBJ:
-		if parent := getParent([]string{"device", "virtual_obj"}, t); parent != nil {
+		if parent := getParent([]string{"device", "virtual_obj"}, t, pending); parent != nil {
 			return parent, nil
 		}
 
@@ -166,7 +166,7 @@ func validateParent(ent string, entNum int, t map[string]interface{}) (map[strin
 			Message: "Group parent should correspond to existing device or virtual_obj"}
 	default:
 		parentStr := u.EntityToString(u.GetParentOfEntityByInt(entNum))
-		if parent := getParent([]string{parentStr}, t); parent != nil {
+		if parent := getParent([]string{parentStr}, t, pending); parent != nil {
 			return parent, nil
 		}
 
@@ -175,11 +175,17 @@ func validateParent(ent string, entNum int, t map[string]interface{}) (map[strin
 	}
 }
 
-func getParent(parentEntities []string, t map[string]any) map[string]any {
+// getParent: searches the parent among the objects of the given entities,
+// in the database and in the pending objects (validated but not yet created, by id)
+func getParent(parentEntities []string, t map[string]any, pending map[string]map[string]any) map[string]any {
 	parent := map[string]any{"parent": ""}
 	req := bson.M{"id": t["parentId"].(string)}
 	for _, parentEnt := range parentEntities {
 		obj, _ := GetObject(req, parentEnt, u.RequestFilters{}, nil)
+		if pendingObj, isPending := pending[t["parentId"].(string)]; obj == nil && isPending &&
+			pendingObj["category"] == parentEnt {
+			obj = pendingObj
+		}
 		if obj != nil {
 			parent["parent"] = parentEnt
 			parent["domain"] = obj["domain"]
@@ -286,6 +292,12 @@ func ValidateJsonSchema(entity int, t map[string]interface{}) (bool, *u.Error) {
 }
 
 func ValidateEntity(entity int, t map[string]interface{}) *u.Error {
+	return validateEntity(entity, t, nil)
+}
+
+// validateEntity: validates the object, its parent can be one of the pending
+// objects (validated but not yet created, by id)
+func validateEntity(entity int, t map[string]interface{}, pending map[string]map[string]any) *u.Error {
 	if shouldFillTags(entity, u.RequestFilters{}) {
 		t = fillTags(t)
 	}
@@ -297,7 +309,7 @@ func ValidateEntity(entity int, t map[string]interface{}) *u.Error {
 
 	// Check parent and domain for objects
 	var parent map[string]interface{}
-	parent, err := getParentSetId(entity, t)
+	parent, err := getParentSetId(entity, t, pending)
 	if err != nil {
 		return err
 	}
@@ -312,7 +324,7 @@ func ValidateEntity(entity int, t map[string]interface{}) *u.Error {
 
 	// Check attributes
 	if pie.Contains(u.EntitiesWithAttributeCheck, entity) {
-		if err := validateAttributes(entity, t, parent); err != nil {
+		if err := validateAttributes(entity, t, parent, pending); err != nil {
 			return err
 		}
 	}
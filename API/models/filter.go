@@ -1,153 +1,515 @@
 package models
 
 import (
-	"errors"
 	"fmt"
 	"maps"
 	u "p3/utils"
 	"regexp"
 	"strings"
 	"time"
+	"unicode"
 )
 
+// Complex filter grammar, & has precedence over |:
+//
+//	expression := and ( "|" and )*
+//	and        := unary ( "&" unary )*
+//	unary      := "!" unary | "(" expression ")" | condition
+//	condition  := field ( "=" | "!=" | "<" | ">" | "<=" | ">=" ) value
+//	            | field "~=" string
+//	            | field ["!"] "in" "[" value ( "," value )* "]"
+//	            | field ["!"] "contains" string
+//	            | field ["!"] "exists"
+//	value      := word | "quoted string" | "[" value ( "," value )* "]"
+//
+// Unquoted values are converted to numbers or booleans when possible and
+// can contain wildcards, quoted values are always taken literally.
+
+const filterDateFormat = "2006-01-02"
+
+var filterFieldRegex = regexp.MustCompile(`^[\w-]+(\.[\w-]+)*$`)
+
+// Fields that are not attributes
+var filterObjectFields = []string{"id", "name", "category", "description", "domain",
+	"createdDate", "lastUpdated", "slug", "tags", "attributes"}
+
+var filterComparisonOps = map[string]string{"<=": "$lte", ">=": "$gte", "<": "$lt", ">": "$gt"}
+
+// FilterSyntaxError is returned when a complex filter can not be parsed.
+// Position is the index, starting at 1, of the character where the error was found
+type FilterSyntaxError struct {
+	Position int
+	Message  string
+}
+
+func (err FilterSyntaxError) Error() string {
+	return fmt.Sprintf("invalid filter expression at position %d: %s", err.Position, err.Message)
+}
+
 func ApplyComplexFilter(complexFilterExp string, req map[string]any) *u.Error {
-	if complexFilterExp != "" {
-		if complexFilters, err := ComplexFilterToMap(complexFilterExp); err != nil {
-			return &u.Error{Type: u.ErrBadFormat, Message: err.Error()}
-		} else {
-			err = getDatesFromComplexFilters(complexFilters)
-			if err != nil {
-				return &u.Error{Type: u.ErrBadFormat, Message: err.Error()}
-			}
-			u.ApplyWildcardsOnComplexFilter(complexFilters)
-			maps.Copy(req, complexFilters)
-		}
+	if complexFilterExp == "" {
+		return nil
+	}
+
+	complexFilters, err := ComplexFilterToMap(complexFilterExp)
+	if err != nil {
+		return &u.Error{Type: u.ErrBadFormat, Message: err.Error()}
 	}
+
+	maps.Copy(req, complexFilters)
+
 	return nil
 }
 
+// ComplexFilterToMap: translates a complex filter expression
+// (e.g. "category=rack & (name~=^R1 | height>=42)") into a mongo query
 func ComplexFilterToMap(complexFilter string) (map[string]any, error) {
-	// Split the input string into individual filter expressions
-	chars := []string{"(", ")", "&", "|"}
-	for _, char := range chars {
-		complexFilter = strings.ReplaceAll(complexFilter, char, " "+char+" ")
-	}
-	return complexExpressionToMap(strings.Fields(complexFilter))
-}
-
-func complexExpressionToMap(expressions []string) (map[string]any, error) {
-	// Find the rightmost operator (AND, OR) outside of parentheses
-	parenCount := 0
-	for i := len(expressions) - 1; i >= 0; i-- {
-		switch expressions[i] {
-		case "(":
-			parenCount++
-		case ")":
-			parenCount--
-		case "&":
-			if parenCount == 0 {
-				first, _ := complexExpressionToMap(expressions[:i])
-				second, _ := complexExpressionToMap(expressions[i+1:])
-				return map[string]any{"$and": []map[string]any{
-					first,
-					second,
-				}}, nil
+	tokens, err := lexComplexFilter(complexFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &filterParser{tokens: tokens}
+	if p.peek().kind == filterTokEOF {
+		return nil, p.errorf(p.peek(), "empty filter")
+	}
+
+	result, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != filterTokEOF {
+		return nil, p.errorf(tok, "unexpected %s", tok)
+	}
+
+	return result, nil
+}
+
+type filterTokenKind int
+
+const (
+	filterTokEOF filterTokenKind = iota
+	filterTokWord
+	filterTokString
+	filterTokOp
+	filterTokAnd
+	filterTokOr
+	filterTokNot
+	filterTokLParen
+	filterTokRParen
+	filterTokLBracket
+	filterTokRBracket
+	filterTokComma
+)
+
+type filterToken struct {
+	kind  filterTokenKind
+	value string
+	pos   int // index in the expression, starting at 0
+}
+
+func (tok filterToken) String() string {
+	switch tok.kind {
+	case filterTokEOF:
+		return "end of filter"
+	case filterTokString:
+		return fmt.Sprintf("string %q", tok.value)
+	default:
+		return fmt.Sprintf("'%s'", tok.value)
+	}
+}
+
+const filterSpecialChars = "()[],&|!=<>~\"'"
+
+func lexComplexFilter(expression string) ([]filterToken, error) {
+	chars := []rune(expression)
+	tokens := []filterToken{}
+
+	for i := 0; i < len(chars); {
+		c := chars[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"' || c == '\'':
+			value, end, err := lexFilterString(chars, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, filterToken{kind: filterTokString, value: value, pos: i})
+			i = end
+		case c == '<' || c == '>' || c == '!' || c == '=' || c == '~':
+			if i+1 < len(chars) && chars[i+1] == '=' && c != '=' {
+				tokens = append(tokens, filterToken{kind: filterTokOp, value: string(chars[i : i+2]), pos: i})
+				i += 2
+			} else if c == '!' {
+				tokens = append(tokens, filterToken{kind: filterTokNot, value: "!", pos: i})
+				i++
+			} else if c == '~' {
+				return nil, FilterSyntaxError{Position: i + 1, Message: "unexpected '~', did you mean '~='?"}
+			} else {
+				tokens = append(tokens, filterToken{kind: filterTokOp, value: string(c), pos: i})
+				i++
 			}
-		case "|":
-			if parenCount == 0 {
-				first, _ := complexExpressionToMap(expressions[:i])
-				second, _ := complexExpressionToMap(expressions[i+1:])
-				return map[string]any{"$or": []map[string]any{
-					first,
-					second,
-				}}, nil
+		case strings.ContainsRune(filterSpecialChars, c):
+			kinds := map[rune]filterTokenKind{'(': filterTokLParen, ')': filterTokRParen,
+				'[': filterTokLBracket, ']': filterTokRBracket, ',': filterTokComma,
+				'&': filterTokAnd, '|': filterTokOr}
+			tokens = append(tokens, filterToken{kind: kinds[c], value: string(c), pos: i})
+			i++
+		default:
+			start := i
+			braces := 0 // commas are allowed inside wildcard depths, e.g. **{1,3}
+			for ; i < len(chars) && !unicode.IsSpace(chars[i]); i++ {
+				if chars[i] == '{' {
+					braces++
+				} else if chars[i] == '}' && braces > 0 {
+					braces--
+				} else if strings.ContainsRune(filterSpecialChars, chars[i]) && !(chars[i] == ',' && braces > 0) {
+					break
+				}
 			}
+			tokens = append(tokens, filterToken{kind: filterTokWord, value: string(chars[start:i]), pos: start})
 		}
 	}
 
-	// If there are no operators outside of parentheses, look for the innermost pair of parentheses
-	for i := 0; i < len(expressions); i++ {
-		if expressions[i] == "(" {
-			start, end := i+1, i+1
-			for parenCount := 1; end < len(expressions) && parenCount > 0; end++ {
-				switch expressions[end] {
-				case "(":
-					parenCount++
-				case ")":
-					parenCount--
-				}
+	return append(tokens, filterToken{kind: filterTokEOF, pos: len(chars)}), nil
+}
+
+// lexFilterString: reads the quoted string starting at start,
+// returns its value and the index after the closing quote
+func lexFilterString(chars []rune, start int) (string, int, error) {
+	quote := chars[start]
+	var value strings.Builder
+	for i := start + 1; i < len(chars); i++ {
+		switch chars[i] {
+		case '\\':
+			if i+1 < len(chars) && (chars[i+1] == quote || chars[i+1] == '\\') {
+				i++
 			}
-			return complexExpressionToMap(append(expressions[:start-1], expressions[start:end-1]...))
+			value.WriteRune(chars[i])
+		case quote:
+			return value.String(), i + 1, nil
+		default:
+			value.WriteRune(chars[i])
 		}
 	}
+	return "", 0, FilterSyntaxError{Position: start + 1, Message: "unterminated quoted string"}
+}
 
-	// Base case: single filter expression
-	return singleExpressionToMap(expressions)
+type filterParser struct {
+	tokens []filterToken
+	cursor int
 }
 
-func singleExpressionToMap(expressions []string) (map[string]any, error) {
-	re := regexp.MustCompile(`^([\w-.]+)\s*(<=|>=|<|>|!=|=)\s*((\[)*[\w-,.*]+(\])*)$`)
-	ops := map[string]string{"<=": "$lte", ">=": "$gte", "<": "$lt", ">": "$gt", "!=": "$not"}
+// filterValue is a value of a condition, before being converted to its type
+type filterValue struct {
+	token  filterToken
+	quoted bool
+	list   []filterValue
+	isList bool
+}
 
-	if len(expressions) <= 3 {
-		expression := strings.Join(expressions[:], "")
-		if match := re.FindStringSubmatch(expression); match != nil {
-			// convert filter value to proper type
-			filterName := match[1]                   // e.g. category
-			filterOp := match[2]                     // e.g. !=
-			filterValue := u.ConvertString(match[3]) // e.g. device
-			switch filterName {
-			case "startDate":
-				return map[string]any{"lastUpdated": map[string]any{"$gte": filterValue}}, nil
-			case "endDate":
-				return map[string]any{"lastUpdated": map[string]any{"$lte": filterValue}}, nil
-			case "id", "name", "category", "description", "domain", "createdDate", "lastUpdated", "slug":
-				if filterOp == "=" {
-					return map[string]any{filterName: filterValue}, nil
-				}
-				return map[string]any{filterName: map[string]any{ops[filterOp]: filterValue}}, nil
-			default:
-				if filterOp == "=" {
-					return map[string]any{"attributes." + filterName: filterValue}, nil
-				}
-				return map[string]any{"attributes." + filterName: map[string]any{ops[filterOp]: filterValue}}, nil
-			}
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.cursor]
+}
+
+func (p *filterParser) next() filterToken {
+	tok := p.tokens[p.cursor]
+	if tok.kind != filterTokEOF {
+		p.cursor++
+	}
+	return tok
+}
+
+func (p *filterParser) errorf(tok filterToken, format string, args ...any) error {
+	return FilterSyntaxError{Position: tok.pos + 1, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *filterParser) expect(kind filterTokenKind, expected string) (filterToken, error) {
+	tok := p.next()
+	if tok.kind != kind {
+		return tok, p.errorf(tok, "expected %s but found %s", expected, tok)
+	}
+	return tok, nil
+}
+
+func (p *filterParser) parseOr() (map[string]any, error) {
+	return p.parseBinary(filterTokOr, "$or", p.parseAnd)
+}
+
+func (p *filterParser) parseAnd() (map[string]any, error) {
+	return p.parseBinary(filterTokAnd, "$and", p.parseUnary)
+}
+
+func (p *filterParser) parseBinary(opKind filterTokenKind, mongoOp string,
+	parseOperand func() (map[string]any, error)) (map[string]any, error) {
+	first, err := parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	operands := []map[string]any{first}
+	for p.peek().kind == opKind {
+		p.next()
+		operand, err := parseOperand()
+		if err != nil {
+			return nil, err
 		}
+		operands = append(operands, operand)
 	}
 
-	fmt.Println("Error: Invalid filter expression")
-	return nil, errors.New("invalid filter expression")
+	if len(operands) == 1 {
+		return first, nil
+	}
+	return map[string]any{mongoOp: operands}, nil
 }
 
-func getDatesFromComplexFilters(req map[string]any) error {
-	for k, v := range req {
-		if k == "$and" || k == "$or" {
-			for _, complexFilter := range v.([]map[string]any) {
-				err := getDatesFromComplexFilters(complexFilter)
-				if err != nil {
-					return err
-				}
-			}
-		} else if k == "lastUpdated" {
-			var err error
-			if req[k], err = parseLastUpdatedFilter(v.(map[string]any)); err != nil {
-				return err
-			}
+func (p *filterParser) parseUnary() (map[string]any, error) {
+	switch tok := p.peek(); tok.kind {
+	case filterTokNot:
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"$nor": []map[string]any{operand}}, nil
+	case filterTokLParen:
+		p.next()
+		expression, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(filterTokRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return expression, nil
+	default:
+		return p.parseCondition()
+	}
+}
+
+func (p *filterParser) parseCondition() (map[string]any, error) {
+	fieldTok := p.next()
+	if fieldTok.kind != filterTokWord {
+		return nil, p.errorf(fieldTok, "expected field name but found %s", fieldTok)
+	}
+	if !filterFieldRegex.MatchString(fieldTok.value) {
+		return nil, p.errorf(fieldTok, "invalid field name '%s'", fieldTok.value)
+	}
+
+	negated := false
+	if p.peek().kind == filterTokNot {
+		p.next()
+		negated = true
+	}
+
+	opTok := p.next()
+	if opTok.kind == filterTokWord {
+		switch opTok.value {
+		case "in":
+			return p.parseIn(fieldTok, negated)
+		case "contains":
+			return p.parseContains(fieldTok, negated)
+		case "exists":
+			return fieldCondition(fieldTok, map[string]any{"$exists": !negated}), nil
+		}
+	}
+	if negated || opTok.kind != filterTokOp {
+		return nil, p.errorf(opTok, "expected operator after '%s' but found %s", fieldTok.value, opTok)
+	}
+
+	if opTok.value == "~=" {
+		return p.parseRegex(fieldTok)
+	}
+
+	value, err := p.parseValue(opTok.value == "=" || opTok.value == "!=")
+	if err != nil {
+		return nil, err
+	}
+	return p.comparisonToMap(fieldTok, opTok.value, value)
+}
+
+func (p *filterParser) parseIn(fieldTok filterToken, negated bool) (map[string]any, error) {
+	if p.peek().kind != filterTokLBracket {
+		tok := p.next()
+		return nil, p.errorf(tok, "expected list after 'in' but found %s", tok)
+	}
+	list, err := p.parseValue(true)
+	if err != nil {
+		return nil, err
+	}
+
+	op := "$in"
+	if negated {
+		op = "$nin"
+	}
+	return fieldCondition(fieldTok, map[string]any{op: convertFilterValue(list)}), nil
+}
+
+func (p *filterParser) parseContains(fieldTok filterToken, negated bool) (map[string]any, error) {
+	value, err := p.parseValue(false)
+	if err != nil {
+		return nil, err
+	}
+
+	regex := map[string]any{"$regex": regexp.QuoteMeta(value.token.value)}
+	if negated {
+		return fieldCondition(fieldTok, map[string]any{"$not": regex}), nil
+	}
+	return fieldCondition(fieldTok, regex), nil
+}
+
+func (p *filterParser) parseRegex(fieldTok filterToken) (map[string]any, error) {
+	value, err := p.parseValue(false)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := regexp.Compile(value.token.value); err != nil {
+		return nil, p.errorf(value.token, "invalid regular expression: %s", err.Error())
+	}
+	return fieldCondition(fieldTok, map[string]any{"$regex": value.token.value}), nil
+}
+
+func (p *filterParser) parseValue(allowList bool) (filterValue, error) {
+	tok := p.next()
+	switch tok.kind {
+	case filterTokWord:
+		return filterValue{token: tok}, nil
+	case filterTokString:
+		return filterValue{token: tok, quoted: true}, nil
+	case filterTokLBracket:
+		if allowList {
+			return p.parseList(tok)
+		}
+	}
+	return filterValue{}, p.errorf(tok, "expected value but found %s", tok)
+}
+
+func (p *filterParser) parseList(openTok filterToken) (filterValue, error) {
+	list := filterValue{token: openTok, isList: true}
+	for {
+		value, err := p.parseValue(false)
+		if err != nil {
+			return filterValue{}, err
+		}
+		list.list = append(list.list, value)
+
+		tok := p.next()
+		if tok.kind == filterTokRBracket {
+			return list, nil
+		} else if tok.kind != filterTokComma {
+			return filterValue{}, p.errorf(tok, "expected ',' or ']' but found %s", tok)
 		}
 	}
-	return nil
 }
 
-func parseLastUpdatedFilter(lastUpdatedMap map[string]any) (map[string]any, error) {
-	for op, date := range lastUpdatedMap {
-		parsedDate, err := time.Parse("2006-01-02", date.(string))
+func (p *filterParser) comparisonToMap(fieldTok filterToken, op string, value filterValue) (map[string]any, error) {
+	switch fieldTok.value {
+	case "startDate", "endDate":
+		if op != "=" {
+			return nil, p.errorf(fieldTok, "only '=' can be used with %s", fieldTok.value)
+		}
+		date, err := p.parseDate(value)
 		if err != nil {
 			return nil, err
 		}
-		if op == "$lte" {
-			parsedDate = parsedDate.Add(time.Hour * 24)
+		if fieldTok.value == "startDate" {
+			return map[string]any{"lastUpdated": map[string]any{"$gte": date}}, nil
+		}
+		return map[string]any{"lastUpdated": map[string]any{"$lte": date.Add(24 * time.Hour)}}, nil
+	case "createdDate", "lastUpdated":
+		return p.dateComparisonToMap(fieldTok, op, value)
+	}
+
+	converted := convertFilterValue(value)
+	strValue, isString := converted.(string)
+	hasWildcards := isString && !value.quoted && strings.Contains(strValue, "*")
+
+	switch op {
+	case "=":
+		if hasWildcards {
+			return fieldCondition(fieldTok, u.WildcardsToMongoRegex(strValue)), nil
+		}
+		return fieldCondition(fieldTok, converted), nil
+	case "!=":
+		if isString && !value.quoted {
+			return fieldCondition(fieldTok, map[string]any{"$not": u.WildcardsToMongoRegex(strValue)}), nil
+		}
+		return fieldCondition(fieldTok, map[string]any{"$ne": converted}), nil
+	default:
+		if value.isList {
+			return nil, p.errorf(value.token, "lists can not be compared with %s", op)
+		}
+		return fieldCondition(fieldTok, map[string]any{filterComparisonOps[op]: converted}), nil
+	}
+}
+
+// dateComparisonToMap: dates are compared by day, e.g. lastUpdated=2024-01-31
+// matches any time during that day
+func (p *filterParser) dateComparisonToMap(fieldTok filterToken, op string, value filterValue) (map[string]any, error) {
+	date, err := p.parseDate(value)
+	if err != nil {
+		return nil, err
+	}
+	nextDay := date.Add(24 * time.Hour)
+
+	var condition map[string]any
+	switch op {
+	case "=":
+		condition = map[string]any{"$gte": date, "$lt": nextDay}
+	case "!=":
+		condition = map[string]any{"$not": map[string]any{"$gte": date, "$lt": nextDay}}
+	case "<":
+		condition = map[string]any{"$lt": date}
+	case "<=":
+		condition = map[string]any{"$lt": nextDay}
+	case ">":
+		condition = map[string]any{"$gte": nextDay}
+	case ">=":
+		condition = map[string]any{"$gte": date}
+	}
+	return fieldCondition(fieldTok, condition), nil
+}
+
+func (p *filterParser) parseDate(value filterValue) (time.Time, error) {
+	if value.isList {
+		return time.Time{}, p.errorf(value.token, "expected a date but found a list")
+	}
+	date, err := time.Parse(filterDateFormat, value.token.value)
+	if err != nil {
+		return time.Time{}, p.errorf(value.token, "invalid date '%s', format must be yyyy-mm-dd", value.token.value)
+	}
+	return date, nil
+}
+
+func convertFilterValue(value filterValue) any {
+	if value.isList {
+		values := []any{}
+		for _, item := range value.list {
+			values = append(values, convertFilterValue(item))
+		}
+		return values
+	}
+	if value.quoted {
+		return value.token.value
+	}
+	return u.ConvertString(value.token.value)
+}
+
+// fieldCondition: condition on the field of the object
+func fieldCondition(fieldTok filterToken, condition any) map[string]any {
+	return map[string]any{objectFieldPath(fieldTok.value): condition}
+}
+
+// objectFieldPath: path of the field in the object, fields that
+// are not properties of the object are looked for in its attributes
+func objectFieldPath(field string) string {
+	root := strings.Split(field, ".")[0]
+	for _, objectField := range filterObjectFields {
+		if root == objectField {
+			return field
 		}
-		return map[string]any{op: parsedDate}, nil
 	}
-	return nil, nil
+	return "attributes." + field
 }
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestComplexFilterToMap(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   string
+		expected map[string]any
+	}{
+		{"Equality", "category=rack", map[string]any{"category": "rack"}},
+		{"Attribute", "height >= 42", map[string]any{"attributes.height": map[string]any{"$gte": 42.0}}},
+		{"NestedAttribute", "virtual_config.type=node", map[string]any{"attributes.virtual_config.type": "node"}},
+		{"AttributesPrefix", "attributes.vendor=ibm", map[string]any{"attributes.vendor": "ibm"}},
+		{"Wildcard", "id=site.*", map[string]any{"id": bson.M{"$regex": `^site\.\w(\w|\-)*$`}}},
+		{"NotEqualString", "name!=rack1", map[string]any{"name": map[string]any{"$not": bson.M{"$regex": "^rack1$"}}}},
+		{"NotEqualNumber", "height!=42", map[string]any{"attributes.height": map[string]any{"$ne": 42.0}}},
+		{"QuotedValue", `description="rack * with spaces & symbols"`,
+			map[string]any{"description": "rack * with spaces & symbols"}},
+		{"QuotedEscapedQuote", `name='it\'s'`, map[string]any{"name": "it's"}},
+		{"ListEquality", "size=[1,2]", map[string]any{"attributes.size": []any{1.0, 2.0}}},
+		{"In", "vendor in [ibm, \"hewlett packard\"]",
+			map[string]any{"attributes.vendor": map[string]any{"$in": []any{"ibm", "hewlett packard"}}}},
+		{"NotIn", "vendor !in [ibm]", map[string]any{"attributes.vendor": map[string]any{"$nin": []any{"ibm"}}}},
+		{"Contains", "name contains a.b", map[string]any{"name": map[string]any{"$regex": `a\.b`}}},
+		{"NotContains", "tags !contains old",
+			map[string]any{"tags": map[string]any{"$not": map[string]any{"$regex": "old"}}}},
+		{"Regex", `name~="^R[0-9]+$"`, map[string]any{"name": map[string]any{"$regex": "^R[0-9]+$"}}},
+		{"Exists", "vendor exists", map[string]any{"attributes.vendor": map[string]any{"$exists": true}}},
+		{"NotExists", "vendor !exists", map[string]any{"attributes.vendor": map[string]any{"$exists": false}}},
+		{"AndOr", "category=rack & name=R1 | name=R2", map[string]any{"$or": []map[string]any{
+			{"$and": []map[string]any{{"category": "rack"}, {"name": "R1"}}},
+			{"name": "R2"},
+		}}},
+		{"Parentheses", "category=rack & (name=R1 | name=R2)", map[string]any{"$and": []map[string]any{
+			{"category": "rack"},
+			{"$or": []map[string]any{{"name": "R1"}, {"name": "R2"}}},
+		}}},
+		{"NegatedGroup", "!(category=rack | category=device)", map[string]any{"$nor": []map[string]any{
+			{"$or": []map[string]any{{"category": "rack"}, {"category": "device"}}},
+		}}},
+		{"StartDate", "startDate=2024-01-31",
+			map[string]any{"lastUpdated": map[string]any{"$gte": time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)}}},
+		{"EndDate", "endDate=2024-01-31",
+			map[string]any{"lastUpdated": map[string]any{"$lte": time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)}}},
+		{"DateEquality", "createdDate=2024-01-31", map[string]any{"createdDate": map[string]any{
+			"$gte": time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+			"$lt":  time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ComplexFilterToMap(tt.filter)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestComplexFilterToMapErrorsHavePosition(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   string
+		position int
+	}{
+		{"Empty", "  ", 3},
+		{"MissingValue", "category=", 10},
+		{"MissingOperator", "category rack", 10},
+		{"MissingRightOperand", "category=rack &", 16},
+		{"UnclosedParenthesis", "(category=rack", 15},
+		{"ExtraParenthesis", "category=rack)", 14},
+		{"UnterminatedString", `name="rack`, 6},
+		{"InvalidRegex", `name~="R["`, 7},
+		{"InvalidDate", "lastUpdated>yesterday", 13},
+		{"InListNotClosed", "vendor in [ibm dell]", 16},
+		{"SingleTilde", "name~R1", 5},
+		{"InvalidField", "$where=1", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ComplexFilterToMap(tt.filter)
+			assert.NotNil(t, err)
+			syntaxErr, ok := err.(FilterSyntaxError)
+			assert.True(t, ok)
+			assert.Equal(t, tt.position, syntaxErr.Position)
+		})
+	}
+}
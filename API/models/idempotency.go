@@ -0,0 +1,112 @@
+package models
+
+import (
+	"os"
+	"p3/repository"
+	u "p3/utils"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const defaultIdempotencyWindow = 24 * time.Hour
+
+// IdempotencyRecord stores the first response given to a request
+// sent with an Idempotency-Key header, so that retries can be replayed
+type IdempotencyRecord struct {
+	UserId      string    `bson:"userId"`
+	Key         string    `bson:"key"`
+	Method      string    `bson:"method"`
+	Path        string    `bson:"path"`
+	RequestHash string    `bson:"requestHash"`
+	Status      int       `bson:"status"` // 0 while the first request is being processed
+	Response    []byte    `bson:"response"`
+	CreatedAt   time.Time `bson:"createdAt"`
+	ExpiresAt   time.Time `bson:"expiresAt"`
+}
+
+func (record IdempotencyRecord) IsCompleted() bool {
+	return record.Status != 0
+}
+
+// GetIdempotencyWindow: time during which a response is kept for replay,
+// configurable with the idempotency_window env variable (e.g. 12h)
+func GetIdempotencyWindow() time.Duration {
+	if window, err := time.ParseDuration(os.Getenv("idempotency_window")); err == nil && window > 0 {
+		return window
+	}
+	return defaultIdempotencyWindow
+}
+
+// StartIdempotentRequest: reserve the key for this request. If the key was
+// already used by the same request, the stored record is returned to be replayed.
+// A key reused with a different request or still in progress returns an error.
+func StartIdempotentRequest(userId, key, method, path, requestHash string) (*IdempotencyRecord, *u.Error) {
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	now := time.Now()
+	newRecord := IdempotencyRecord{
+		UserId:      userId,
+		Key:         key,
+		Method:      method,
+		Path:        path,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(GetIdempotencyWindow()),
+	}
+
+	err := repository.CreateIdempotencyRecord(ctx, newRecord)
+	if err == nil {
+		return nil, nil
+	} else if err.Type != u.ErrDuplicate {
+		return nil, err
+	}
+
+	existing, err := repository.GetIdempotencyRecord[IdempotencyRecord](ctx, userId, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing.ExpiresAt.Before(now) {
+		// expired but not yet removed by mongo, the key can be reused
+		if err := repository.DeleteIdempotencyRecord(ctx, userId, key); err != nil && err.Type != u.ErrNotFound {
+			return nil, err
+		}
+		if err := repository.CreateIdempotencyRecord(ctx, newRecord); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	if existing.Method != method || existing.Path != path || existing.RequestHash != requestHash {
+		return nil, &u.Error{Type: u.ErrBadFormat,
+			Message: "Idempotency-Key has already been used with a different request"}
+	}
+
+	if !existing.IsCompleted() {
+		return nil, &u.Error{Type: u.ErrConflict,
+			Message: "A request with this Idempotency-Key is still being processed"}
+	}
+
+	return existing, nil
+}
+
+// CompleteIdempotentRequest: store the response given to the request
+func CompleteIdempotentRequest(userId, key string, status int, response []byte) *u.Error {
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	return repository.UpdateIdempotencyRecord(ctx, userId, key, bson.M{
+		"status":   status,
+		"response": response,
+	})
+}
+
+// ReleaseIdempotencyKey: forget the key so that the request can be retried
+func ReleaseIdempotencyKey(userId, key string) *u.Error {
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	return repository.DeleteIdempotencyRecord(ctx, userId, key)
+}
@@ -13,6 +13,7 @@ type ImpactFilters struct {
 	Categories []string `schema:"categories"`
 	Ptypes     []string `schema:"ptypes"`
 	Vtypes     []string `schema:"vtypes"`
+	Relations  []string `schema:"relations"` // types of relations to follow
 }
 
 func GetImpact(id string, userRoles map[string]Role, filters ImpactFilters) (map[string]any, *u.Error) {
@@ -57,8 +58,16 @@ func GetImpact(id string, userRoles map[string]Role, filters ImpactFilters) (map
 		return nil, err
 	}
 
+	// handle typed relations to the target and its children
+	typedRelations, err := followImpactRelations(filters.Relations,
+		append([]string{id}, pie.Keys(allChildren)...), indirectChildren, userRoles)
+	if err != nil {
+		return nil, err
+	}
+
 	// send response
-	data := map[string]any{"direct": directChildren, "indirect": indirectChildren, "relations": clusterRelations}
+	data := map[string]any{"direct": directChildren, "indirect": indirectChildren, "relations": clusterRelations,
+		"typedRelations": typedRelations}
 	return data, nil
 }
 
@@ -116,3 +125,43 @@ func clusterRelationsToIndirect(filters ImpactFilters, clusterRelations map[stri
 	}
 	return nil
 }
+
+// followImpactRelations: objects with a relation of one of the given types to an
+// impacted object are impacted too, and so on. They are added to the indirect
+// children and the relations followed are returned
+func followImpactRelations(relationTypes, impacted []string, indirectChildren map[string]any,
+	userRoles map[string]Role) ([]Relation, *u.Error) {
+	followed := []Relation{}
+	if len(relationTypes) == 0 {
+		return followed, nil
+	}
+
+	visited := map[string]bool{}
+	for _, id := range impacted {
+		visited[id] = true
+	}
+	for len(impacted) > 0 {
+		relations, err := getRelations(bson.M{
+			"type": bson.M{"$in": relationTypes},
+			"to":   bson.M{"$in": impacted},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		impacted = []string{}
+		for _, relation := range relations {
+			entity, object := getRelationObject(relation.From)
+			if object == nil || CheckUserPermissionsWithObject(userRoles, entity, object) < READ {
+				continue
+			}
+			followed = append(followed, relation)
+			if !visited[relation.From] {
+				visited[relation.From] = true
+				indirectChildren[relation.From] = fixID(object)
+				impacted = append(impacted, relation.From)
+			}
+		}
+	}
+	return followed, nil
+}
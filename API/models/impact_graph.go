@@ -0,0 +1,236 @@
+package models
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	u "p3/utils"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/elliotchance/pie/v2"
+)
+
+var ImpactGraphFormats = []string{"dot", "graphml", "mermaid", "json-graph"}
+
+// Types of the edges that are not typed relations
+const (
+	ImpactEdgeContains = "contains" // from a parent to its child
+	ImpactEdgeCluster  = "cluster"  // from an object to its cluster
+)
+
+// ImpactGraph: nodes and edges of the impact of the target object
+type ImpactGraph struct {
+	Nodes []ImpactNode `json:"nodes"`
+	Edges []ImpactEdge `json:"edges"`
+}
+
+type ImpactNode struct {
+	Id       string `json:"id"`
+	Label    string `json:"label"`
+	Category string `json:"category"`
+	Impact   string `json:"impact"` // target, direct or indirect
+	Color    string `json:"color"`
+	Shape    string `json:"shape"` // graphviz shape
+}
+
+type ImpactEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"` // contains, cluster or the type of a relation
+}
+
+type impactGraphStyle struct {
+	Color string
+	Shape string
+}
+
+// impactGraphStyles: style of the nodes of each category
+var impactGraphStyles = map[string]impactGraphStyle{
+	u.EntityToString(u.SITE):       {"#6a3d9a", "folder"},
+	u.EntityToString(u.BLDG):       {"#1f78b4", "house"},
+	u.EntityToString(u.ROOM):       {"#33a02c", "box"},
+	u.EntityToString(u.RACK):       {"#ff7f00", "box3d"},
+	u.EntityToString(u.DEVICE):     {"#e31a1c", "component"},
+	u.EntityToString(u.AC):         {"#a6cee3", "octagon"},
+	u.EntityToString(u.PWRPNL):     {"#fb9a99", "octagon"},
+	u.EntityToString(u.CABINET):    {"#fdbf6f", "box3d"},
+	u.EntityToString(u.CORRIDOR):   {"#b2df8a", "box"},
+	u.EntityToString(u.GENERIC):    {"#cab2d6", "box"},
+	u.EntityToString(u.GROUP):      {"#ffff99", "tab"},
+	u.EntityToString(u.STRAYOBJ):   {"#bdbdbd", "box"},
+	u.EntityToString(u.VIRTUALOBJ): {"#b15928", "ellipse"},
+}
+
+var defaultImpactGraphStyle = impactGraphStyle{"#d9d9d9", "box"}
+
+// GetImpactGraph: the impact of the object as a graph of the target, its direct
+// and indirect children, the clusters they belong to and the typed relations followed
+func GetImpactGraph(id string, userRoles map[string]Role, filters ImpactFilters) (*ImpactGraph, *u.Error) {
+	target, err := GetObjectById(id, u.HIERARCHYOBJS_ENT, u.RequestFilters{}, userRoles)
+	if err != nil {
+		return nil, err
+	}
+	impact, err := GetImpact(id, userRoles, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &ImpactGraph{Nodes: []ImpactNode{}, Edges: []ImpactEdge{}}
+	nodes := map[string]bool{}
+	addNode := func(nodeId, impactType string, object map[string]any) {
+		if nodes[nodeId] {
+			return
+		}
+		nodes[nodeId] = true
+		category, _ := object["category"].(string)
+		label, _ := object["name"].(string)
+		if label == "" {
+			_, label = splitId(nodeId)
+		}
+		style, ok := impactGraphStyles[category]
+		if !ok {
+			style = defaultImpactGraphStyle
+		}
+		graph.Nodes = append(graph.Nodes, ImpactNode{Id: nodeId, Label: label, Category: category,
+			Impact: impactType, Color: style.Color, Shape: style.Shape})
+	}
+
+	addNode(id, "target", target)
+	for _, impactType := range []string{"direct", "indirect"} {
+		for childId, child := range impact[impactType].(map[string]any) {
+			childMap, _ := child.(map[string]any)
+			addNode(childId, impactType, childMap)
+		}
+	}
+	for clusterId := range impact["relations"].(map[string][]string) {
+		addNode(clusterId, "indirect", map[string]any{"category": u.EntityToString(u.VIRTUALOBJ)})
+	}
+	sort.Slice(graph.Nodes[1:], func(i, j int) bool {
+		return graph.Nodes[i+1].Id < graph.Nodes[j+1].Id
+	})
+
+	// nearest: the node itself or its nearest ancestor in the graph
+	nearest := func(nodeId string) string {
+		for nodeId != "" && !nodes[nodeId] {
+			nodeId, _ = splitId(nodeId)
+		}
+		return nodeId
+	}
+	for _, node := range graph.Nodes[1:] {
+		if parentId, _ := splitId(node.Id); parentId != "" {
+			if ancestor := nearest(parentId); ancestor != "" {
+				graph.Edges = append(graph.Edges, ImpactEdge{From: ancestor, To: node.Id, Type: ImpactEdgeContains})
+			}
+		}
+	}
+	clusterRelations := impact["relations"].(map[string][]string)
+	for _, clusterId := range pie.Sort(pie.Keys(clusterRelations)) {
+		for _, memberId := range clusterRelations[clusterId] {
+			if member := nearest(memberId); member != "" {
+				graph.Edges = append(graph.Edges, ImpactEdge{From: member, To: clusterId, Type: ImpactEdgeCluster})
+			}
+		}
+	}
+	for _, relation := range impact["typedRelations"].([]Relation) {
+		from, to := nearest(relation.From), nearest(relation.To)
+		if from != "" && to != "" {
+			graph.Edges = append(graph.Edges, ImpactEdge{From: from, To: to, Type: relation.Type})
+		}
+	}
+
+	return graph, nil
+}
+
+// Dot: the graph in the graphviz format
+func (graph ImpactGraph) Dot() string {
+	var builder strings.Builder
+	builder.WriteString("digraph impact {\n\trankdir=LR;\n\tnode [style=filled];\n")
+	for _, node := range graph.Nodes {
+		penwidth := 1
+		if node.Impact == "target" {
+			penwidth = 3
+		}
+		fmt.Fprintf(&builder, "\t%s [label=%s, shape=%s, fillcolor=%s, penwidth=%d];\n",
+			strconv.Quote(node.Id), strconv.Quote(node.Label), node.Shape, strconv.Quote(node.Color), penwidth)
+	}
+	for _, edge := range graph.Edges {
+		style := "solid"
+		if edge.Type != ImpactEdgeContains {
+			style = "dashed"
+		}
+		fmt.Fprintf(&builder, "\t%s -> %s [label=%s, style=%s];\n",
+			strconv.Quote(edge.From), strconv.Quote(edge.To), strconv.Quote(edge.Type), style)
+	}
+	builder.WriteString("}\n")
+	return builder.String()
+}
+
+// GraphML: the graph in the GraphML format, the style is given as node data
+func (graph ImpactGraph) GraphML() string {
+	var builder strings.Builder
+	builder.WriteString(xml.Header)
+	builder.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	for _, key := range []string{"label", "category", "impact", "color", "shape"} {
+		fmt.Fprintf(&builder, "\t<key id=%q for=\"node\" attr.name=%q attr.type=\"string\"/>\n", key, key)
+	}
+	builder.WriteString("\t<key id=\"type\" for=\"edge\" attr.name=\"type\" attr.type=\"string\"/>\n")
+	builder.WriteString("\t<graph id=\"impact\" edgedefault=\"directed\">\n")
+	for _, node := range graph.Nodes {
+		fmt.Fprintf(&builder, "\t\t<node id=\"%s\">\n", xmlEscape(node.Id))
+		for _, data := range [][2]string{{"label", node.Label}, {"category", node.Category},
+			{"impact", node.Impact}, {"color", node.Color}, {"shape", node.Shape}} {
+			fmt.Fprintf(&builder, "\t\t\t<data key=%q>%s</data>\n", data[0], xmlEscape(data[1]))
+		}
+		builder.WriteString("\t\t</node>\n")
+	}
+	for _, edge := range graph.Edges {
+		fmt.Fprintf(&builder, "\t\t<edge source=\"%s\" target=\"%s\">\n\t\t\t<data key=\"type\">%s</data>\n\t\t</edge>\n",
+			xmlEscape(edge.From), xmlEscape(edge.To), xmlEscape(edge.Type))
+	}
+	builder.WriteString("\t</graph>\n</graphml>\n")
+	return builder.String()
+}
+
+// Mermaid: the graph as a mermaid flowchart, with a class per category
+func (graph ImpactGraph) Mermaid() string {
+	var builder strings.Builder
+	builder.WriteString("flowchart LR\n")
+	mermaidIds := map[string]string{}
+	classes := map[string][]string{} // category: mermaid ids of its nodes
+	for i, node := range graph.Nodes {
+		mermaidId := "n" + strconv.Itoa(i)
+		mermaidIds[node.Id] = mermaidId
+		fmt.Fprintf(&builder, "\t%s[\"%s\"]\n", mermaidId, strings.ReplaceAll(node.Label, `"`, "#quot;"))
+		classes[node.Category] = append(classes[node.Category], mermaidId)
+	}
+	for _, edge := range graph.Edges {
+		arrow := "-->"
+		if edge.Type != ImpactEdgeContains {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&builder, "\t%s %s|%s| %s\n", mermaidIds[edge.From], arrow, edge.Type, mermaidIds[edge.To])
+	}
+	for _, category := range pie.Sort(pie.Keys(classes)) {
+		if category == "" {
+			continue
+		}
+		style, ok := impactGraphStyles[category]
+		if !ok {
+			style = defaultImpactGraphStyle
+		}
+		fmt.Fprintf(&builder, "\tclassDef %s fill:%s\n", category, style.Color)
+		fmt.Fprintf(&builder, "\tclass %s %s\n", strings.Join(classes[category], ","), category)
+	}
+	if len(graph.Nodes) > 0 {
+		fmt.Fprintf(&builder, "\tstyle %s stroke-width:4px\n", mermaidIds[graph.Nodes[0].Id])
+	}
+	return builder.String()
+}
+
+func xmlEscape(str string) string {
+	var buffer bytes.Buffer
+	xml.EscapeText(&buffer, []byte(str))
+	return buffer.String()
+}
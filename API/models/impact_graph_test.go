@@ -0,0 +1,68 @@
+package models_test
+
+import (
+	"p3/models"
+	"p3/test/integration"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImpactGraphHasChildrenAndRelations(t *testing.T) {
+	integration.RequireCreateSite("impact-graph-site")
+	integration.RequireCreateBuilding("impact-graph-site", "building")
+	integration.RequireCreateRoom("impact-graph-site.building", "room")
+	integration.RequireCreateRack("impact-graph-site.building.room", "rack")
+	integration.RequireCreateDevice("impact-graph-site.building.room.rack", "server")
+	integration.RequireCreateVirtualObject("", "impact-graph-app", map[string]any{
+		"virtual_config": map[string]any{"type": "application"},
+	})
+	_, err := models.CreateRelation(models.Relation{
+		Type: "depends-on",
+		From: "impact-graph-app",
+		To:   "impact-graph-site.building.room.rack.server",
+	}, integration.ManagerUserRoles)
+	require.Nil(t, err)
+
+	graph, err := models.GetImpactGraph("impact-graph-site.building.room.rack", integration.ManagerUserRoles,
+		models.ImpactFilters{Relations: []string{"depends-on"}})
+	require.Nil(t, err)
+	require.Len(t, graph.Nodes, 3)
+	assert.Equal(t, "impact-graph-site.building.room.rack", graph.Nodes[0].Id)
+	assert.Equal(t, "target", graph.Nodes[0].Impact)
+	assert.Equal(t, "impact-graph-app", graph.Nodes[1].Id)
+	assert.Equal(t, "indirect", graph.Nodes[1].Impact)
+	assert.Equal(t, "direct", graph.Nodes[2].Impact)
+	assert.Equal(t, "device", graph.Nodes[2].Category)
+	assert.ElementsMatch(t, []models.ImpactEdge{
+		{From: "impact-graph-site.building.room.rack", To: "impact-graph-site.building.room.rack.server", Type: models.ImpactEdgeContains},
+		{From: "impact-graph-app", To: "impact-graph-site.building.room.rack.server", Type: "depends-on"},
+	}, graph.Edges)
+}
+
+func TestImpactGraphFormats(t *testing.T) {
+	graph := models.ImpactGraph{
+		Nodes: []models.ImpactNode{
+			{Id: "site.bldg.room.rack", Label: "rack", Category: "rack", Impact: "target", Color: "#ff7f00", Shape: "box3d"},
+			{Id: "site.bldg.room.rack.srv", Label: "srv", Category: "device", Impact: "direct", Color: "#e31a1c", Shape: "component"},
+		},
+		Edges: []models.ImpactEdge{
+			{From: "site.bldg.room.rack", To: "site.bldg.room.rack.srv", Type: models.ImpactEdgeContains},
+		},
+	}
+
+	dot := graph.Dot()
+	assert.Contains(t, dot, `"site.bldg.room.rack" [label="rack", shape=box3d, fillcolor="#ff7f00", penwidth=3];`)
+	assert.Contains(t, dot, `"site.bldg.room.rack" -> "site.bldg.room.rack.srv" [label="contains", style=solid];`)
+
+	graphML := graph.GraphML()
+	assert.Contains(t, graphML, `<node id="site.bldg.room.rack.srv">`)
+	assert.Contains(t, graphML, `<data key="category">device</data>`)
+	assert.Contains(t, graphML, `<edge source="site.bldg.room.rack" target="site.bldg.room.rack.srv">`)
+
+	mermaid := graph.Mermaid()
+	assert.Contains(t, mermaid, "n0 -->|contains| n1")
+	assert.Contains(t, mermaid, "classDef device fill:#e31a1c")
+	assert.Contains(t, mermaid, "style n0 stroke-width:4px")
+}
@@ -0,0 +1,307 @@
+package models
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"p3/repository"
+	u "p3/utils"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Entities that can be imported from a CSV file
+var csvImportEntities = []int{u.BLDG, u.ROOM, u.RACK, u.DEVICE, u.CORRIDOR, u.GENERIC}
+
+// Fields of the objects that are not attributes
+var csvImportObjectFields = []string{"name", "category", "description", "domain"}
+
+// CSVImport is a CSV file with the way to convert each of its rows to an object
+type CSVImport struct {
+	File      string `json:"file"`      // content of the CSV file, with a header row
+	Separator string `json:"separator"` // default: ","
+	// Category of the objects, used if no column is mapped to category
+	Category string `json:"category"`
+	// Column: field of the object (name, category, description, domain) or
+	// attribute (attributes.<attribute> or <attribute>)
+	Mapping map[string]string `json:"mapping"`
+	// Column with the parent of each object, as an id (site.building)
+	// or as a path (/P/site/building)
+	ParentColumn string `json:"parentColumn"`
+	// Column with the slug of the template of each object (racks, devices and generics)
+	TemplateColumn string `json:"templateColumn"`
+	// Values of the fields and attributes not given by the file (same keys as the mapping values)
+	Defaults map[string]any `json:"defaults"`
+}
+
+type CSVImportRowReport struct {
+	Row      int      `json:"row"` // line of the file, the header being the line 1
+	Id       string   `json:"id,omitempty"`
+	Category string   `json:"category,omitempty"`
+	Status   string   `json:"status"` // created, valid (not created due to other rows) or error
+	Error    string   `json:"error,omitempty"`
+	Details  []string `json:"details,omitempty"`
+}
+
+type CSVImportReport struct {
+	Created int                  `json:"created"`
+	Errors  int                  `json:"errors"`
+	Rows    []CSVImportRowReport `json:"rows"`
+}
+
+const (
+	CSVImportRowCreated = "created"
+	CSVImportRowValid   = "valid"
+	CSVImportRowError   = "error"
+)
+
+type csvImportRow struct {
+	entity int
+	object map[string]any
+	report *CSVImportRowReport
+}
+
+// ImportCSV: converts each row of the file to an object and validates it.
+// Rows can be children of the rows above them. If all the rows are valid,
+// the objects are created in a single transaction, otherwise none is created.
+// The created objects are returned with the report.
+func ImportCSV(csvImport CSVImport, userRoles map[string]Role) (*CSVImportReport, []map[string]any, *u.Error) {
+	records, err := csvImport.read()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report := &CSVImportReport{Rows: []CSVImportRowReport{}}
+	for i := range records[1:] {
+		report.Rows = append(report.Rows, CSVImportRowReport{Row: i + 2})
+	}
+
+	// objects validated, by id, used as parents of the next rows
+	pending := map[string]map[string]any{}
+	rows := []csvImportRow{}
+	for i, record := range records[1:] {
+		row := csvImportRow{report: &report.Rows[i]}
+		if err := csvImport.prepareRow(&row, records[0], record, pending, userRoles); err != nil {
+			row.report.Status = CSVImportRowError
+			row.report.Error = err.Message
+			row.report.Details = err.Details
+			report.Errors++
+			continue
+		}
+
+		row.report.Status = CSVImportRowValid
+		pending[row.report.Id] = row.object
+		rows = append(rows, row)
+	}
+
+	if report.Errors > 0 || len(rows) == 0 {
+		return report, nil, nil
+	}
+
+	_, err = WithTransaction(func(ctx mongo.SessionContext) (any, error) {
+		for _, row := range rows {
+			if _, err := repository.CreateObject(ctx, u.EntityToString(row.entity), row.object); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	created := []map[string]any{}
+	for _, row := range rows {
+		row.report.Status = CSVImportRowCreated
+		report.Created++
+		created = append(created, fixID(row.object))
+	}
+
+	return report, created, nil
+}
+
+// read: parses the file and checks that the mapped columns exist
+func (csvImport CSVImport) read() ([][]string, *u.Error) {
+	reader := csv.NewReader(strings.NewReader(csvImport.File))
+	if csvImport.Separator != "" {
+		separator, size := utf8.DecodeRuneInString(csvImport.Separator)
+		if size != len(csvImport.Separator) {
+			return nil, &u.Error{Type: u.ErrBadFormat, Message: "The separator must be a single character"}
+		}
+		reader.Comma = separator
+	}
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, &u.Error{Type: u.ErrBadFormat, Message: "Invalid CSV file: " + err.Error()}
+	} else if len(records) < 2 {
+		return nil, &u.Error{Type: u.ErrBadFormat, Message: "The CSV file must have a header and at least one row"}
+	}
+
+	header := records[0]
+	columns := []string{csvImport.ParentColumn}
+	if csvImport.TemplateColumn != "" {
+		columns = append(columns, csvImport.TemplateColumn)
+	}
+	for column := range csvImport.Mapping {
+		columns = append(columns, column)
+	}
+	for _, column := range columns {
+		if column == "" {
+			return nil, &u.Error{Type: u.ErrBadFormat, Message: "parentColumn is mandatory"}
+		} else if !slices.Contains(header, column) {
+			return nil, &u.Error{Type: u.ErrBadFormat, Message: "Column " + column + " not found in the CSV file"}
+		}
+	}
+
+	if csvImport.Category == "" && !csvImport.isMapped("category") {
+		return nil, &u.Error{Type: u.ErrBadFormat,
+			Message: "A category or a column mapped to category is mandatory"}
+	}
+
+	return records, nil
+}
+
+func (csvImport CSVImport) isMapped(field string) bool {
+	for _, mapped := range csvImport.Mapping {
+		if mapped == field {
+			return true
+		}
+	}
+	return false
+}
+
+// prepareRow: converts the record to an object and validates it as a creation
+func (csvImport CSVImport) prepareRow(row *csvImportRow, header, record []string,
+	pending map[string]map[string]any, userRoles map[string]Role) *u.Error {
+	object := map[string]any{"attributes": map[string]any{}}
+	for key, value := range csvImport.Defaults {
+		setCSVImportValue(object, key, value)
+	}
+
+	cells := map[string]string{}
+	for i, column := range header {
+		cells[column] = strings.TrimSpace(record[i])
+	}
+	for column, field := range csvImport.Mapping {
+		if cells[column] != "" {
+			setCSVImportValue(object, field, cells[column])
+		}
+	}
+	if _, hasCategory := object["category"]; !hasCategory {
+		object["category"] = csvImport.Category
+	}
+
+	category, _ := object["category"].(string)
+	row.report.Category = category
+	row.entity = u.EntityStrToInt(category)
+	if !slices.Contains(csvImportEntities, row.entity) {
+		return &u.Error{Type: u.ErrBadFormat,
+			Message: "Invalid category " + category + ", must be one of: building, room, rack, device, corridor, generic"}
+	}
+
+	name, _ := object["name"].(string)
+	if name == "" {
+		return &u.Error{Type: u.ErrBadFormat, Message: "name is mandatory"}
+	}
+
+	parentId := csvImportParentId(cells[csvImport.ParentColumn])
+	if parentId == "" {
+		return &u.Error{Type: u.ErrBadFormat, Message: "parent is mandatory"}
+	}
+	object["parentId"] = parentId
+	row.report.Id = parentId + u.HN_DELIMETER + name
+	if _, hasDomain := object["domain"]; !hasDomain {
+		if domain := getCSVImportParentDomain(parentId, pending); domain != nil {
+			object["domain"] = domain
+		}
+	}
+
+	if csvImport.TemplateColumn != "" && cells[csvImport.TemplateColumn] != "" {
+		if err := applyObjTemplate(row.entity, object, cells[csvImport.TemplateColumn]); err != nil {
+			return err
+		}
+	}
+
+	if _, hasDescription := object["description"]; !hasDescription {
+		object["description"] = ""
+	}
+
+	// Convert back to json to avoid invalid types in json schema validation
+	bytes, _ := json.Marshal(object)
+	object = map[string]any{}
+	json.Unmarshal(bytes, &object)
+	row.object = object
+
+	if _, isPending := pending[row.report.Id]; isPending {
+		return &u.Error{Type: u.ErrDuplicate, Message: "Object " + row.report.Id + " is already in the file"}
+	}
+	if count, err := repository.CountObjects(row.entity, bson.M{"id": row.report.Id}); err != nil {
+		return err
+	} else if count > 0 {
+		return &u.Error{Type: u.ErrDuplicate, Message: "Object " + row.report.Id + " already exists"}
+	}
+
+	if err := validateEntity(row.entity, object, pending); err != nil {
+		return err
+	}
+	if permission := CheckUserPermissionsWithObject(userRoles, row.entity, object); permission < WRITE {
+		return &u.Error{Type: u.ErrUnauthorized,
+			Message: "User does not have permission to create this object"}
+	}
+	delete(object, "parentId")
+
+	return nil
+}
+
+// setCSVImportValue: sets the field or attribute of the object,
+// the values of the attributes given as text are parsed
+func setCSVImportValue(object map[string]any, field string, value any) {
+	if slices.Contains(csvImportObjectFields, field) {
+		object[field] = value
+	} else if text, isText := value.(string); isText {
+		object["attributes"].(map[string]any)[strings.TrimPrefix(field, "attributes.")] = parseCSVImportValue(text)
+	} else {
+		object["attributes"].(map[string]any)[strings.TrimPrefix(field, "attributes.")] = value
+	}
+}
+
+// parseCSVImportValue: numbers, booleans and arrays are given as JSON, e.g. 42 or [600, 1200]
+func parseCSVImportValue(cell string) any {
+	var value any
+	if err := json.Unmarshal([]byte(cell), &value); err == nil {
+		switch value.(type) {
+		case float64, bool, []any:
+			return value
+		}
+	}
+	return cell
+}
+
+// csvImportParentId: id of the parent given as an id or as a path of the physical hierarchy
+func csvImportParentId(parent string) string {
+	for _, prefix := range []string{"/P/", "/Physical/"} {
+		if strings.HasPrefix(parent, prefix) {
+			parent = strings.TrimPrefix(parent, prefix)
+			return strings.ReplaceAll(strings.Trim(parent, "/"), "/", u.HN_DELIMETER)
+		}
+	}
+	return parent
+}
+
+// getCSVImportParentDomain: domain of the parent, objects are in the domain of their parent by default
+func getCSVImportParentDomain(parentId string, pending map[string]map[string]any) any {
+	if parent, isPending := pending[parentId]; isPending {
+		return parent["domain"]
+	}
+	for _, entityStr := range u.GetEntitiesById(u.Physical, parentId) {
+		if parent, err := repository.GetObject(bson.M{"id": parentId}, entityStr, u.RequestFilters{}); err == nil {
+			return parent["domain"]
+		}
+	}
+	return nil
+}
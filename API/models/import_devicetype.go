@@ -0,0 +1,313 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"p3/repository"
+	u "p3/utils"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"gopkg.in/yaml.v3"
+)
+
+// Width of the devices mounted in a 19" rack
+const deviceTypeDefaultWidthMm = 482.6
+
+// Depth of the full depth devices, the others are half of it
+const deviceTypeDefaultDepthMm = 800.0
+
+// Size of the ports on the rear face of the device
+var deviceTypePortSizeMm = []float64{15, 10, 12}
+
+const deviceTypePortGapMm = 5.0
+
+// Depth of the module bays on the rear face of the device
+const deviceTypeModuleBayDepthMm = 50.0
+
+// DeviceTypeImport is a device type of the community device-type library,
+// as YAML, with the sizes that the library does not give
+type DeviceTypeImport struct {
+	File      string  `json:"file"`      // content of the YAML file, may contain several documents
+	WidthMm   float64 `json:"widthMm"`   // default: 482.6 (19")
+	DepthMm   float64 `json:"depthMm"`   // of the full depth devices, default: 800
+	ImagesUrl string  `json:"imagesUrl"` // base URL of the elevation images of the library
+}
+
+type deviceType struct {
+	Manufacturer  string           `yaml:"manufacturer"`
+	Model         string           `yaml:"model"`
+	Slug          string           `yaml:"slug"`
+	PartNumber    string           `yaml:"part_number"`
+	UHeight       float64          `yaml:"u_height"`
+	IsFullDepth   *bool            `yaml:"is_full_depth"`
+	SubdeviceRole string           `yaml:"subdevice_role"`
+	Airflow       string           `yaml:"airflow"`
+	Weight        float64          `yaml:"weight"`
+	WeightUnit    string           `yaml:"weight_unit"`
+	FrontImage    bool             `yaml:"front_image"`
+	RearImage     bool             `yaml:"rear_image"`
+	Comments      string           `yaml:"comments"`
+	Interfaces    []deviceTypePort `yaml:"interfaces"`
+	ConsolePorts  []deviceTypePort `yaml:"console-ports"`
+	PowerPorts    []deviceTypePort `yaml:"power-ports"`
+	FrontPorts    []deviceTypePort `yaml:"front-ports"`
+	RearPorts     []deviceTypePort `yaml:"rear-ports"`
+	ModuleBays    []deviceTypeBay  `yaml:"module-bays"`
+	DeviceBays    []deviceTypeBay  `yaml:"device-bays"`
+}
+
+type deviceTypePort struct {
+	Name     string `yaml:"name"`
+	Label    string `yaml:"label"`
+	Type     string `yaml:"type"`
+	MgmtOnly bool   `yaml:"mgmt_only"`
+}
+
+type deviceTypeBay struct {
+	Name     string `yaml:"name"`
+	Label    string `yaml:"label"`
+	Position string `yaml:"position"`
+}
+
+var invalidSlugCharacters = regexp.MustCompile(`[^a-z0-9-_]+`)
+
+// ImportDeviceTypes: converts the device types of the file to object templates,
+// validates them and creates them in a single transaction
+func ImportDeviceTypes(deviceTypeImport DeviceTypeImport, userRoles map[string]Role) ([]map[string]any, *u.Error) {
+	deviceTypes, err := deviceTypeImport.read()
+	if err != nil {
+		return nil, err
+	}
+
+	templates := []map[string]any{}
+	for _, deviceType := range deviceTypes {
+		template, err := deviceTypeImport.toObjTemplate(deviceType)
+		if err != nil {
+			return nil, err
+		}
+		if err := prepareCreateEntity(u.OBJTMPL, template, userRoles); err != nil {
+			err.Message = fmt.Sprintf("Invalid template %s: %s", template["slug"], err.Message)
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+
+	return WithTransaction(func(ctx mongo.SessionContext) ([]map[string]any, error) {
+		for _, template := range templates {
+			if _, err := repository.CreateObject(ctx, u.EntityToString(u.OBJTMPL), template); err != nil {
+				if err.Type == u.ErrDuplicate {
+					// the template of the slug already exists
+					err.Type = u.ErrConflict
+				}
+				return nil, err
+			}
+			fixID(template)
+		}
+		return templates, nil
+	})
+}
+
+// read: parses the documents of the file
+func (deviceTypeImport DeviceTypeImport) read() ([]deviceType, *u.Error) {
+	decoder := yaml.NewDecoder(bytes.NewBufferString(deviceTypeImport.File))
+	deviceTypes := []deviceType{}
+	for {
+		var deviceType deviceType
+		if err := decoder.Decode(&deviceType); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, &u.Error{Type: u.ErrBadFormat, Message: "Invalid YAML file: " + err.Error()}
+		}
+
+		if deviceType.Manufacturer == "" || deviceType.Model == "" {
+			return nil, &u.Error{Type: u.ErrBadFormat, Message: "manufacturer and model are mandatory"}
+		} else if deviceType.UHeight <= 0 {
+			return nil, &u.Error{Type: u.ErrBadFormat,
+				Message: "Device type " + deviceType.Model + " has no height (u_height), child devices are not supported"}
+		}
+		deviceTypes = append(deviceTypes, deviceType)
+	}
+
+	if len(deviceTypes) == 0 {
+		return nil, &u.Error{Type: u.ErrBadFormat, Message: "The YAML file has no device type"}
+	}
+	return deviceTypes, nil
+}
+
+// toObjTemplate: template of a device with the size of the device type,
+// its ports as components on its rear face and its bays as slots
+func (deviceTypeImport DeviceTypeImport) toObjTemplate(deviceType deviceType) (map[string]any, *u.Error) {
+	width := deviceTypeImport.WidthMm
+	if width == 0 {
+		width = deviceTypeDefaultWidthMm
+	}
+	depth := deviceTypeImport.DepthMm
+	if depth == 0 {
+		depth = deviceTypeDefaultDepthMm
+	}
+	if width < 0 || depth < 0 {
+		return nil, &u.Error{Type: u.ErrBadFormat, Message: "widthMm and depthMm must be positive"}
+	}
+	if deviceType.IsFullDepth != nil && !*deviceType.IsFullDepth {
+		depth /= 2
+	}
+	height := math.Round(deviceType.UHeight*RACKUNIT*1000*100) / 100
+
+	slug := deviceType.Slug
+	if slug == "" {
+		slug = deviceType.Manufacturer + "-" + deviceType.Model
+	}
+	slug = strings.Trim(invalidSlugCharacters.ReplaceAllString(strings.ToLower(slug), "-"), "-")
+
+	attributes := map[string]any{
+		"type":   "server",
+		"vendor": deviceType.Manufacturer,
+		"model":  deviceType.Model,
+	}
+	if len(deviceType.DeviceBays) > 0 || deviceType.SubdeviceRole == "parent" {
+		attributes["type"] = "chassis"
+	}
+	if deviceType.PartNumber != "" {
+		attributes["partNumber"] = deviceType.PartNumber
+	}
+	if deviceType.Airflow != "" {
+		attributes["airflow"] = deviceType.Airflow
+	}
+	if weight := deviceType.weightKg(); weight > 0 {
+		attributes["weightKg"] = strconv.FormatFloat(weight, 'f', -1, 64)
+	}
+	if deviceType.FrontImage {
+		attributes["frontImage"] = deviceTypeImport.imageUrl(deviceType, slug, "front")
+	}
+	if deviceType.RearImage {
+		attributes["rearImage"] = deviceTypeImport.imageUrl(deviceType, slug, "rear")
+	}
+
+	description := deviceType.Manufacturer + " " + deviceType.Model
+	if deviceType.Comments != "" {
+		description += " - " + strings.TrimSpace(deviceType.Comments)
+	}
+
+	components := []any{}
+	portIndex := 0
+	for _, ports := range []struct {
+		portType string
+		ports    []deviceTypePort
+	}{
+		{"interface", deviceType.Interfaces},
+		{"console", deviceType.ConsolePorts},
+		{"power", deviceType.PowerPorts},
+		{"front-port", deviceType.FrontPorts},
+		{"rear-port", deviceType.RearPorts},
+	} {
+		for _, port := range ports.ports {
+			components = append(components, deviceTypePortComponent(ports.portType, port, portIndex, width, depth))
+			portIndex++
+		}
+	}
+
+	slots := []any{}
+	for i, bay := range deviceType.DeviceBays {
+		// the blades take the whole depth, side by side
+		slotWidth := width / float64(len(deviceType.DeviceBays))
+		slots = append(slots, deviceTypeBaySlot("device-bay", bay,
+			[]float64{float64(i) * slotWidth, 0, 0}, []float64{slotWidth, depth, height}, "front"))
+	}
+	for i, bay := range deviceType.ModuleBays {
+		slotWidth := width / float64(len(deviceType.ModuleBays))
+		slots = append(slots, deviceTypeBaySlot("module-bay", bay,
+			[]float64{float64(i) * slotWidth, depth - deviceTypeModuleBayDepthMm, 0},
+			[]float64{slotWidth, deviceTypeModuleBayDepthMm, height}, "rear"))
+	}
+
+	return map[string]any{
+		"slug":        slug,
+		"description": description,
+		"category":    u.EntityToString(u.DEVICE),
+		"sizeWDHmm":   []any{width, depth, height},
+		"fbxModel":    "",
+		"attributes":  attributes,
+		"colors":      []any{},
+		"components":  components,
+		"slots":       slots,
+	}, nil
+}
+
+func (deviceType deviceType) weightKg() float64 {
+	switch deviceType.WeightUnit {
+	case "lb":
+		return math.Round(deviceType.Weight*0.45359237*100) / 100
+	case "g":
+		return deviceType.Weight / 1000
+	case "oz":
+		return math.Round(deviceType.Weight*0.028349523125*100) / 100
+	}
+	return deviceType.Weight
+}
+
+// imageUrl: URL of the elevation image in the library, or its file name if the URL is not given
+func (deviceTypeImport DeviceTypeImport) imageUrl(deviceType deviceType, slug, face string) string {
+	fileName := slug + "." + face + ".png"
+	if deviceTypeImport.ImagesUrl == "" {
+		return fileName
+	}
+	return strings.TrimSuffix(deviceTypeImport.ImagesUrl, "/") + "/" + url.PathEscape(deviceType.Manufacturer) + "/" + fileName
+}
+
+// deviceTypePortComponent: the ports are placed in rows on the rear face, from the bottom left
+func deviceTypePortComponent(portType string, port deviceTypePort, index int, width, depth float64) map[string]any {
+	portsByRow := int(math.Max(1, math.Floor((width-deviceTypePortGapMm)/(deviceTypePortSizeMm[0]+deviceTypePortGapMm))))
+	column, row := index%portsByRow, index/portsByRow
+
+	attributes := map[string]any{}
+	if port.Type != "" {
+		attributes["portType"] = port.Type
+	}
+	if port.Label != "" {
+		attributes["label"] = port.Label
+	}
+	if port.MgmtOnly {
+		attributes["mgmtOnly"] = "true"
+	}
+
+	return map[string]any{
+		"location":   port.Name,
+		"type":       portType,
+		"elemOrient": []any{0, 0, 0},
+		"elemPos": []any{
+			deviceTypePortGapMm + float64(column)*(deviceTypePortSizeMm[0]+deviceTypePortGapMm),
+			depth - deviceTypePortSizeMm[1],
+			deviceTypePortGapMm + float64(row)*(deviceTypePortSizeMm[2]+deviceTypePortGapMm),
+		},
+		"elemSize":   []any{deviceTypePortSizeMm[0], deviceTypePortSizeMm[1], deviceTypePortSizeMm[2]},
+		"labelPos":   "rear",
+		"attributes": attributes,
+	}
+}
+
+func deviceTypeBaySlot(slotType string, bay deviceTypeBay, position, size []float64, labelPos string) map[string]any {
+	attributes := map[string]any{}
+	if bay.Label != "" {
+		attributes["label"] = bay.Label
+	}
+	if bay.Position != "" {
+		attributes["position"] = bay.Position
+	}
+
+	return map[string]any{
+		"location":   bay.Name,
+		"type":       slotType,
+		"elemOrient": []any{0, 0, 0},
+		"elemPos":    []any{position[0], position[1], position[2]},
+		"elemSize":   []any{size[0], size[1], size[2]},
+		"labelPos":   labelPos,
+		"attributes": attributes,
+	}
+}
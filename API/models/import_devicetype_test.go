@@ -0,0 +1,112 @@
+package models
+
+import (
+	u "p3/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const dellR640DeviceType = `
+manufacturer: Dell
+model: PowerEdge R640
+slug: dell-poweredge-r640
+part_number: 0H28RR
+u_height: 1
+is_full_depth: true
+airflow: front-to-rear
+weight: 21.9
+weight_unit: kg
+front_image: true
+rear_image: true
+interfaces:
+  - name: iDRAC
+    type: 1000base-t
+    mgmt_only: true
+  - name: NIC1
+    type: 10gbase-t
+console-ports:
+  - name: Serial
+    type: de-9
+power-ports:
+  - name: PSU1
+    type: iec-60320-c14
+module-bays:
+  - name: PSU bay 1
+    position: "1"
+`
+
+func TestDeviceTypeToObjTemplate(t *testing.T) {
+	deviceTypeImport := DeviceTypeImport{File: dellR640DeviceType, ImagesUrl: "https://example.com/elevation-images/"}
+	deviceTypes, err := deviceTypeImport.read()
+	require.Nil(t, err)
+	require.Len(t, deviceTypes, 1)
+
+	template, err := deviceTypeImport.toObjTemplate(deviceTypes[0])
+	require.Nil(t, err)
+	assert.Equal(t, "dell-poweredge-r640", template["slug"])
+	assert.Equal(t, "device", template["category"])
+	assert.Equal(t, []any{482.6, 800.0, 44.45}, template["sizeWDHmm"])
+
+	attributes := template["attributes"].(map[string]any)
+	assert.Equal(t, "server", attributes["type"])
+	assert.Equal(t, "Dell", attributes["vendor"])
+	assert.Equal(t, "21.9", attributes["weightKg"])
+	assert.Equal(t, "https://example.com/elevation-images/Dell/dell-poweredge-r640.front.png", attributes["frontImage"])
+
+	components := template["components"].([]any)
+	require.Len(t, components, 4)
+	assert.Equal(t, "iDRAC", components[0].(map[string]any)["location"])
+	assert.Equal(t, "interface", components[0].(map[string]any)["type"])
+	assert.Equal(t, "true", components[0].(map[string]any)["attributes"].(map[string]any)["mgmtOnly"])
+	assert.Equal(t, "console", components[2].(map[string]any)["type"])
+	assert.Equal(t, "power", components[3].(map[string]any)["type"])
+
+	slots := template["slots"].([]any)
+	require.Len(t, slots, 1)
+	assert.Equal(t, "PSU bay 1", slots[0].(map[string]any)["location"])
+	assert.Equal(t, "module-bay", slots[0].(map[string]any)["type"])
+
+	ok, validationErr := ValidateJsonSchema(u.OBJTMPL, template)
+	assert.True(t, ok, validationErr)
+}
+
+func TestDeviceTypeHalfDepthParentToObjTemplate(t *testing.T) {
+	deviceTypeImport := DeviceTypeImport{File: `
+manufacturer: Super Micro
+model: SYS-2029BT (4 nodes)
+u_height: 2
+is_full_depth: false
+subdevice_role: parent
+device-bays:
+  - name: Node A
+  - name: Node B
+`}
+	deviceTypes, err := deviceTypeImport.read()
+	require.Nil(t, err)
+
+	template, err := deviceTypeImport.toObjTemplate(deviceTypes[0])
+	require.Nil(t, err)
+	assert.Equal(t, "super-micro-sys-2029bt-4-nodes", template["slug"])
+	assert.Equal(t, []any{482.6, 400.0, 88.9}, template["sizeWDHmm"])
+	assert.Equal(t, "chassis", template["attributes"].(map[string]any)["type"])
+
+	slots := template["slots"].([]any)
+	require.Len(t, slots, 2)
+	assert.Equal(t, []any{482.6 / 2, 0.0, 0.0}, slots[1].(map[string]any)["elemPos"])
+	assert.Equal(t, "device-bay", slots[1].(map[string]any)["type"])
+
+	ok, validationErr := ValidateJsonSchema(u.OBJTMPL, template)
+	assert.True(t, ok, validationErr)
+}
+
+func TestReadDeviceTypesWithoutHeightRespondsWithError(t *testing.T) {
+	_, err := DeviceTypeImport{File: "manufacturer: Dell\nmodel: Blade\nu_height: 0\n"}.read()
+	require.NotNil(t, err)
+	assert.Equal(t, "Device type Blade has no height (u_height), child devices are not supported", err.Message)
+
+	_, err = DeviceTypeImport{File: "manufacturer: [Dell\n"}.read()
+	require.NotNil(t, err)
+	assert.Equal(t, u.ErrBadFormat, err.Type)
+}
@@ -0,0 +1,278 @@
+package models
+
+import (
+	"p3/repository"
+	u "p3/utils"
+	"sort"
+	"strings"
+
+	"github.com/elliotchance/pie/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Types of integrity issues, an object referencing something that does not exist
+const (
+	IntegrityGroupContent = "group-content"      // name in the content of a group
+	IntegrityVlink        = "vlink"              // vlink of a virtual object
+	IntegrityTag          = "tag"                // slug in the tags of an object
+	IntegrityBreaker      = "breaker-powerpanel" // powerpanel of a rack breaker
+	IntegrityOrphan       = "orphan"             // parent of an object
+)
+
+// IntegrityIssue: the object of given id references, in field, something that does not exist.
+// Fixable issues are repaired by removing the reference
+type IntegrityIssue struct {
+	Type      string `json:"type"`
+	Id        string `json:"id"`
+	Category  string `json:"category"`
+	Domain    string `json:"domain"`
+	Field     string `json:"field"`     // dotted path of the reference in the object
+	Reference string `json:"reference"` // id, name or slug that does not exist
+	Fixable   bool   `json:"fixable"`
+}
+
+// IntegrityFix lists the issues repaired and those that need a manual repair
+type IntegrityFix struct {
+	Fixed     []IntegrityIssue `json:"fixed"`
+	Remaining []IntegrityIssue `json:"remaining"`
+}
+
+// integrityEntities: collections scanned for references
+var integrityEntities = []int{u.DOMAIN, u.STRAYOBJ, u.SITE, u.BLDG, u.ROOM, u.RACK, u.DEVICE,
+	u.AC, u.CABINET, u.CORRIDOR, u.GENERIC, u.PWRPNL, u.GROUP, u.VIRTUALOBJ}
+
+// integrityProjection: fields holding references
+var integrityProjection = bson.M{"id": 1, "category": 1, "domain": 1, "tags": 1,
+	"attributes.content": 1, "attributes.vlinks": 1, "attributes.breakers": 1}
+
+// CheckIntegrity: scans the collections for references to objects that do not exist.
+// Only the issues of the objects the user can read are returned
+func CheckIntegrity(userRoles map[string]Role) ([]IntegrityIssue, *u.Error) {
+	issues, err := findIntegrityIssues()
+	if err != nil {
+		return nil, err
+	}
+	return filterIntegrityIssues(issues, userRoles, READ), nil
+}
+
+// FixIntegrity: removes, in a single transaction, the dangling references of the
+// fixable issues of the objects the user can modify. Groups left empty by the fix are deleted
+func FixIntegrity(userRoles map[string]Role) (*IntegrityFix, *u.Error) {
+	issues, err := findIntegrityIssues()
+	if err != nil {
+		return nil, err
+	}
+
+	fix := &IntegrityFix{Fixed: []IntegrityIssue{}, Remaining: []IntegrityIssue{}}
+	for _, issue := range filterIntegrityIssues(issues, userRoles, READ) {
+		if issue.Fixable && checkIntegrityIssuePermission(issue, userRoles) >= WRITE {
+			fix.Fixed = append(fix.Fixed, issue)
+		} else {
+			fix.Remaining = append(fix.Remaining, issue)
+		}
+	}
+
+	_, err = WithTransaction(func(ctx mongo.SessionContext) (any, error) {
+		groupIds := []string{} // groups whose content was fixed, the user can modify them
+		for _, issue := range fix.Fixed {
+			_, err := repository.GetDB().Collection(issue.Category).UpdateOne(ctx,
+				bson.M{"id": issue.Id}, bson.M{"$pull": bson.M{issue.Field: issue.Reference}})
+			if err != nil {
+				return nil, err
+			}
+			if issue.Type == IntegrityGroupContent {
+				groupIds = append(groupIds, issue.Id)
+			}
+		}
+		// a group must have content
+		_, err := repository.GetDB().Collection(u.EntityToString(u.GROUP)).DeleteMany(ctx,
+			bson.M{"id": bson.M{"$in": groupIds}, "attributes.content": bson.M{"$size": 0}})
+		return nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return fix, nil
+}
+
+// findIntegrityIssues: all the issues of the database, sorted by type and id
+func findIntegrityIssues() ([]IntegrityIssue, *u.Error) {
+	objects, err := getIntegrityObjects()
+	if err != nil {
+		return nil, err
+	}
+	tags, err := getIntegrityTagSlugs()
+	if err != nil {
+		return nil, err
+	}
+
+	issues := []IntegrityIssue{}
+	for entity, entityObjects := range objects {
+		for _, obj := range entityObjects {
+			id := obj["id"].(string)
+			domain, _ := obj["domain"].(string)
+			if entity == u.DOMAIN {
+				domain = id
+			}
+			newIssue := func(issueType, field, reference string, fixable bool) IntegrityIssue {
+				return IntegrityIssue{Type: issueType, Id: id, Category: u.EntityToString(entity),
+					Domain: domain, Field: field, Reference: reference, Fixable: fixable}
+			}
+
+			if i := strings.LastIndex(id, u.HN_DELIMETER); i > 0 &&
+				!integrityObjectExists(objects, integrityParentEntities(entity), id[:i]) {
+				issues = append(issues, newIssue(IntegrityOrphan, "parentId", id[:i], false))
+			}
+
+			for _, tag := range integrityStrings(obj["tags"]) {
+				if !tags[tag] {
+					issues = append(issues, newIssue(IntegrityTag, "tags", tag, true))
+				}
+			}
+
+			attributes, _ := obj["attributes"].(map[string]any)
+			if entity == u.GROUP {
+				for _, name := range integrityStrings(attributes["content"]) {
+					if !integrityObjectExists(objects, append([]int{u.DEVICE}, u.RoomChildren...),
+						id[:strings.LastIndex(id, u.HN_DELIMETER)]+u.HN_DELIMETER+name) {
+						issues = append(issues, newIssue(IntegrityGroupContent, "attributes.content", name, true))
+					}
+				}
+			}
+			for _, vlink := range integrityStrings(attributes["vlinks"]) {
+				if !integrityObjectExists(objects, []int{u.DEVICE, u.VIRTUALOBJ}, strings.Split(vlink, "#")[0]) {
+					issues = append(issues, newIssue(IntegrityVlink, "attributes.vlinks", vlink, true))
+				}
+			}
+			if breakers, ok := attributes["breakers"].(map[string]any); ok {
+				for name, breaker := range breakers {
+					breakerMap, _ := breaker.(map[string]any)
+					powerpanel, ok := breakerMap["powerpanel"].(string)
+					if ok && !integrityPowerpanelExists(objects, id, powerpanel) {
+						issues = append(issues, newIssue(IntegrityBreaker,
+							"attributes.breakers."+name+".powerpanel", powerpanel, false))
+					}
+				}
+			}
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].Type != issues[j].Type {
+			return issues[i].Type < issues[j].Type
+		} else if issues[i].Id != issues[j].Id {
+			return issues[i].Id < issues[j].Id
+		}
+		return issues[i].Reference < issues[j].Reference
+	})
+	return issues, nil
+}
+
+// getIntegrityObjects: the references of the objects of each collection, by entity and id
+func getIntegrityObjects() (map[int]map[string]map[string]any, *u.Error) {
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	objects := map[int]map[string]map[string]any{}
+	opts := options.Find().SetProjection(integrityProjection)
+	for _, entity := range integrityEntities {
+		cursor, err := repository.GetDB().Collection(u.EntityToString(entity)).Find(ctx, bson.M{}, opts)
+		if err != nil {
+			return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+		}
+		results := []map[string]any{}
+		if err := cursor.All(ctx, &results); err != nil {
+			return nil, &u.Error{Type: u.ErrInternal, Message: err.Error()}
+		}
+
+		objects[entity] = map[string]map[string]any{}
+		for _, obj := range results {
+			if id, ok := obj["id"].(string); ok {
+				objects[entity][id] = obj
+			}
+		}
+	}
+	return objects, nil
+}
+
+func getIntegrityTagSlugs() (map[string]bool, *u.Error) {
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	slugs, err := repository.GetDB().Collection(u.EntityToString(u.TAG)).Distinct(ctx, "slug", bson.M{})
+	if err != nil {
+		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
+	tags := map[string]bool{}
+	for _, slug := range slugs {
+		if slugStr, ok := slug.(string); ok {
+			tags[slugStr] = true
+		}
+	}
+	return tags, nil
+}
+
+// integrityParentEntities: collections where the parent of an object of the entity can be
+func integrityParentEntities(entity int) []int {
+	switch entity {
+	case u.DOMAIN, u.STRAYOBJ, u.SITE:
+		return []int{entity}
+	case u.BLDG:
+		return []int{u.SITE}
+	case u.ROOM:
+		return []int{u.BLDG}
+	case u.VIRTUALOBJ:
+		return []int{u.DEVICE, u.VIRTUALOBJ}
+	case u.DEVICE:
+		return []int{u.RACK, u.DEVICE}
+	case u.GROUP:
+		return []int{u.ROOM, u.RACK}
+	default:
+		// rack, ac, cabinet, corridor, generic and panel
+		return []int{u.ROOM}
+	}
+}
+
+func integrityObjectExists(objects map[int]map[string]map[string]any, entities []int, id string) bool {
+	return pie.Any(entities, func(entity int) bool {
+		_, exists := objects[entity][id]
+		return exists
+	})
+}
+
+// integrityPowerpanelExists: the powerpanel of a breaker is the name of a panel,
+// or of a generic object, of the room of the rack. The id of a panel is also accepted
+func integrityPowerpanelExists(objects map[int]map[string]map[string]any, rackId, powerpanel string) bool {
+	roomId, _ := splitId(rackId)
+	return integrityObjectExists(objects, []int{u.PWRPNL, u.GENERIC}, roomId+u.HN_DELIMETER+powerpanel) ||
+		integrityObjectExists(objects, []int{u.PWRPNL}, powerpanel)
+}
+
+// integrityStrings: the strings of a decoded array, nil if it is not one
+func integrityStrings(value any) []string {
+	values, ok := value.(bson.A)
+	if !ok {
+		return nil
+	}
+	strs := []string{}
+	for _, v := range values {
+		if str, ok := v.(string); ok {
+			strs = append(strs, str)
+		}
+	}
+	return strs
+}
+
+// filterIntegrityIssues: issues of the objects on which the user has at least the permission
+func filterIntegrityIssues(issues []IntegrityIssue, userRoles map[string]Role, minPermission Permission) []IntegrityIssue {
+	return pie.Filter(issues, func(issue IntegrityIssue) bool {
+		return checkIntegrityIssuePermission(issue, userRoles) >= minPermission
+	})
+}
+
+func checkIntegrityIssuePermission(issue IntegrityIssue, userRoles map[string]Role) Permission {
+	return CheckUserPermissions(userRoles, u.EntityStrToInt(issue.Category), issue.Domain)
+}
@@ -0,0 +1,157 @@
+package models_test
+
+import (
+	"p3/models"
+	"p3/repository"
+	"p3/test/integration"
+	u "p3/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// deleteWithoutCleanup: deletes the object as if it was removed outside the API
+func deleteWithoutCleanup(t *testing.T, entity int, filter bson.M) {
+	ctx, cancel := u.Connect()
+	defer cancel()
+	_, err := repository.GetDB().Collection(u.EntityToString(entity)).DeleteOne(ctx, filter)
+	require.NoError(t, err)
+}
+
+func integrityIssuesOf(t *testing.T, id string) []models.IntegrityIssue {
+	issues, err := models.CheckIntegrity(integration.ManagerUserRoles)
+	require.Nil(t, err)
+	found := []models.IntegrityIssue{}
+	for _, issue := range issues {
+		if issue.Id == id {
+			found = append(found, issue)
+		}
+	}
+	return found
+}
+
+func TestCheckIntegrityFindsGroupContentOfDeletedRackAndFixesIt(t *testing.T) {
+	integration.RequireCreateSite("integrity-group-site")
+	integration.RequireCreateBuilding("integrity-group-site", "building")
+	integration.RequireCreateRoom("integrity-group-site.building", "room")
+	integration.RequireCreateRack("integrity-group-site.building.room", "rack1")
+	integration.RequireCreateRack("integrity-group-site.building.room", "rack2")
+	integration.RequireCreateGroup("integrity-group-site.building.room", "group", []any{"rack1", "rack2"})
+
+	deleteWithoutCleanup(t, u.RACK, bson.M{"id": "integrity-group-site.building.room.rack2"})
+
+	issues := integrityIssuesOf(t, "integrity-group-site.building.room.group")
+	require.Len(t, issues, 1)
+	assert.Equal(t, models.IntegrityGroupContent, issues[0].Type)
+	assert.Equal(t, "rack2", issues[0].Reference)
+	assert.True(t, issues[0].Fixable)
+
+	fix, err := models.FixIntegrity(integration.ManagerUserRoles)
+	require.Nil(t, err)
+	assert.Contains(t, fix.Fixed, issues[0])
+
+	group, err := models.GetObjectById("integrity-group-site.building.room.group",
+		u.EntityToString(u.GROUP), u.RequestFilters{}, integration.ManagerUserRoles)
+	require.Nil(t, err)
+	assert.Equal(t, []any{"rack1"}, group["attributes"].(map[string]any)["content"])
+	assert.Empty(t, integrityIssuesOf(t, "integrity-group-site.building.room.group"))
+}
+
+func TestCheckIntegrityFindsTagWhoseSlugWasDeleted(t *testing.T) {
+	require.Nil(t, createTag("integrity-tag"))
+	require.Nil(t, createSite("integrity-tag-site", []any{"integrity-tag"}))
+
+	deleteWithoutCleanup(t, u.TAG, bson.M{"slug": "integrity-tag"})
+
+	issues := integrityIssuesOf(t, "integrity-tag-site")
+	require.Len(t, issues, 1)
+	assert.Equal(t, models.IntegrityTag, issues[0].Type)
+	assert.Equal(t, "tags", issues[0].Field)
+
+	_, err := models.FixIntegrity(integration.ManagerUserRoles)
+	require.Nil(t, err)
+	site, err := models.GetObjectById("integrity-tag-site", u.EntityToString(u.SITE),
+		u.RequestFilters{}, integration.ManagerUserRoles)
+	require.Nil(t, err)
+	assert.Empty(t, site["tags"])
+}
+
+func TestCheckIntegrityReportsOrphansWithoutFixingThem(t *testing.T) {
+	integration.RequireCreateSite("integrity-orphan-site")
+	integration.RequireCreateBuilding("integrity-orphan-site", "building")
+	integration.RequireCreateRoom("integrity-orphan-site.building", "room")
+	integration.RequireCreateRack("integrity-orphan-site.building.room", "rack")
+
+	deleteWithoutCleanup(t, u.ROOM, bson.M{"id": "integrity-orphan-site.building.room"})
+
+	issues := integrityIssuesOf(t, "integrity-orphan-site.building.room.rack")
+	require.Len(t, issues, 1)
+	assert.Equal(t, models.IntegrityOrphan, issues[0].Type)
+	assert.Equal(t, "integrity-orphan-site.building.room", issues[0].Reference)
+	assert.False(t, issues[0].Fixable)
+
+	fix, err := models.FixIntegrity(integration.ManagerUserRoles)
+	require.Nil(t, err)
+	assert.Contains(t, fix.Remaining, issues[0])
+}
+
+func TestCheckIntegrityResolvesBreakerPowerpanelInTheRoomOfTheRack(t *testing.T) {
+	integration.RequireCreateSite("integrity-breaker-site")
+	integration.RequireCreateBuilding("integrity-breaker-site", "building")
+	integration.RequireCreateRoom("integrity-breaker-site.building", "room")
+	integration.RequireCreateRack("integrity-breaker-site.building.room", "rack")
+	integration.RequireCreateGeneric("integrity-breaker-site.building.room", "panel")
+
+	_, _, err := models.UpdateObject(u.EntityToString(u.RACK), "integrity-breaker-site.building.room.rack",
+		map[string]any{"attributes": map[string]any{"breakers": map[string]any{
+			"breaker1": map[string]any{"powerpanel": "panel"},
+			"breaker2": map[string]any{"powerpanel": "unknown"},
+		}}}, true, integration.ManagerUserRoles, false)
+	require.Nil(t, err)
+
+	issues := integrityIssuesOf(t, "integrity-breaker-site.building.room.rack")
+	require.Len(t, issues, 1)
+	assert.Equal(t, models.IntegrityBreaker, issues[0].Type)
+	assert.Equal(t, "unknown", issues[0].Reference)
+
+	_, _, err = models.UpdateObject(u.EntityToString(u.GENERIC), "integrity-breaker-site.building.room.panel",
+		map[string]any{"name": "panel2"}, true, integration.ManagerUserRoles, false)
+	require.Nil(t, err)
+
+	rack, err := models.GetObjectById("integrity-breaker-site.building.room.rack", u.EntityToString(u.RACK),
+		u.RequestFilters{}, integration.ManagerUserRoles)
+	require.Nil(t, err)
+	breakers := rack["attributes"].(map[string]any)["breakers"].(map[string]any)
+	assert.Equal(t, "panel2", breakers["breaker1"].(map[string]any)["powerpanel"])
+	assert.Len(t, integrityIssuesOf(t, "integrity-breaker-site.building.room.rack"), 1)
+}
+
+func TestFixIntegrityOnlyDeletesTheGroupsItEmptied(t *testing.T) {
+	integration.RequireCreateSite("integrity-empty-group-site")
+	integration.RequireCreateBuilding("integrity-empty-group-site", "building")
+	integration.RequireCreateRoom("integrity-empty-group-site.building", "room")
+	integration.RequireCreateRack("integrity-empty-group-site.building.room", "rack1")
+	integration.RequireCreateRack("integrity-empty-group-site.building.room", "rack2")
+	integration.RequireCreateGroup("integrity-empty-group-site.building.room", "fixed", []any{"rack1"})
+	integration.RequireCreateGroup("integrity-empty-group-site.building.room", "untouched", []any{"rack2"})
+
+	ctx, cancel := u.Connect()
+	defer cancel()
+	_, mongoErr := repository.GetDB().Collection(u.EntityToString(u.GROUP)).UpdateOne(ctx,
+		bson.M{"id": "integrity-empty-group-site.building.room.untouched"},
+		bson.M{"$set": bson.M{"attributes.content": bson.A{}}})
+	require.NoError(t, mongoErr)
+	deleteWithoutCleanup(t, u.RACK, bson.M{"id": "integrity-empty-group-site.building.room.rack1"})
+
+	_, err := models.FixIntegrity(integration.ManagerUserRoles)
+	require.Nil(t, err)
+
+	_, err = models.GetObjectById("integrity-empty-group-site.building.room.fixed",
+		u.EntityToString(u.GROUP), u.RequestFilters{}, integration.ManagerUserRoles)
+	assert.NotNil(t, err)
+	_, err = models.GetObjectById("integrity-empty-group-site.building.room.untouched",
+		u.EntityToString(u.GROUP), u.RequestFilters{}, integration.ManagerUserRoles)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,496 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"p3/repository"
+	u "p3/utils"
+	"regexp"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Previous versions of the object templates, used to upgrade their instances
+const OBJ_TEMPLATE_VERSIONS = "obj_template_version"
+
+// Entities that can be built from an object template
+var objTemplateEntities = []int{u.RACK, u.DEVICE, u.GENERIC}
+
+// Fields set from the template that replace the values of the object,
+// the other fields of the template are only set if the object does not have them
+var objTemplateOverriddenFields = []string{
+	"attributes.size", "attributes.height", "attributes.sizeUnit", "attributes.heightUnit",
+	"attributes.sizeU", "attributes.fbxModel", "attributes.orientation", "attributes.shape",
+}
+
+const (
+	ObjTemplateInstanceUpgraded = "upgraded"
+	ObjTemplateInstanceUpToDate = "up-to-date"
+	ObjTemplateInstanceConflict = "conflict"
+	ObjTemplateInstanceError    = "error"
+)
+
+type ObjTemplateInstance struct {
+	Id              string `json:"id"`
+	Category        string `json:"category"`
+	TemplateVersion int    `json:"templateVersion"` // 0 if unknown (created before templates had versions)
+	UpToDate        bool   `json:"upToDate"`
+}
+
+type ObjTemplateInstances struct {
+	Slug      string                `json:"slug"`
+	Version   int                   `json:"version"`
+	Instances []ObjTemplateInstance `json:"instances"`
+}
+
+type ObjTemplateUpgrade struct {
+	Ids   []string `json:"ids"`   // instances to upgrade, all the instances if not given
+	Force bool     `json:"force"` // replace the values modified on the instances
+}
+
+// ObjTemplateConflict is a value of an instance that cannot be replaced by the value of the template
+type ObjTemplateConflict struct {
+	Field         string `json:"field"`
+	Value         any    `json:"value"`
+	TemplateValue any    `json:"templateValue,omitempty"`
+	Message       string `json:"message"`
+}
+
+type ObjTemplateInstanceUpgrade struct {
+	Id          string                `json:"id"`
+	Category    string                `json:"category"`
+	FromVersion int                   `json:"fromVersion"`
+	Status      string                `json:"status"` // upgraded, up-to-date, conflict or error
+	Conflicts   []ObjTemplateConflict `json:"conflicts,omitempty"`
+	Error       string                `json:"error,omitempty"`
+}
+
+type ObjTemplateUpgradeReport struct {
+	Slug      string                       `json:"slug"`
+	Version   int                          `json:"version"`
+	Instances []ObjTemplateInstanceUpgrade `json:"instances"`
+}
+
+// objTemplateVersion: version of the template, templates created before versions are version 1
+func objTemplateVersion(template map[string]any) int {
+	switch version := template["version"].(type) {
+	case int32:
+		return int(version)
+	case int64:
+		return int(version)
+	case int:
+		return version
+	case float64:
+		return int(version)
+	}
+	return 1
+}
+
+func getObjTemplate(slug string) (map[string]any, *u.Error) {
+	template, err := GetObject(bson.M{"slug": slug}, u.EntityToString(u.OBJTMPL), u.RequestFilters{}, nil)
+	if err != nil {
+		if err.Type == u.ErrNotFound {
+			return nil, &u.Error{Type: u.ErrNotFound, Message: "Template " + slug + " not found"}
+		}
+		return nil, err
+	}
+	return template, nil
+}
+
+// getObjTemplateVersion: the template as it was in this version, nil if this version is unknown
+func getObjTemplateVersion(template map[string]any, version int) (map[string]any, *u.Error) {
+	if version == objTemplateVersion(template) {
+		return template, nil
+	}
+
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	templateVersion := struct {
+		Template map[string]any `bson:"template"`
+	}{}
+	err := repository.GetDB().Collection(OBJ_TEMPLATE_VERSIONS).FindOne(ctx,
+		bson.M{"slug": template["slug"], "version": version}).Decode(&templateVersion)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	} else if err != nil {
+		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
+	return templateVersion.Template, nil
+}
+
+// saveObjTemplateVersion: keeps the current version of the template when the update modifies it
+func saveObjTemplateVersion(ctx mongo.SessionContext, oldTemplate, newTemplate map[string]any) *u.Error {
+	version := objTemplateVersion(oldTemplate)
+	if !isObjTemplateModified(oldTemplate, newTemplate) {
+		newTemplate["version"] = version
+		return nil
+	}
+
+	_, err := repository.GetDB().Collection(OBJ_TEMPLATE_VERSIONS).InsertOne(ctx, bson.M{
+		"slug":     newTemplate["slug"],
+		"version":  version,
+		"template": oldTemplate,
+	})
+	if err != nil {
+		return &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
+
+	if oldTemplate["slug"] != newTemplate["slug"] {
+		// previous versions follow the template
+		_, err = repository.GetDB().Collection(OBJ_TEMPLATE_VERSIONS).UpdateMany(ctx,
+			bson.M{"slug": oldTemplate["slug"]}, bson.M{"$set": bson.M{"slug": newTemplate["slug"]}})
+		if err != nil {
+			return &u.Error{Type: u.ErrDBError, Message: err.Error()}
+		}
+	}
+
+	newTemplate["version"] = version + 1
+	return nil
+}
+
+// isObjTemplateModified: whether the templates differ, without the fields set by the API
+func isObjTemplateModified(oldTemplate, newTemplate map[string]any) bool {
+	oldFields, newFields := maps.Clone(oldTemplate), maps.Clone(newTemplate)
+	for _, field := range []string{"_id", "id", "version", "createdDate", "lastUpdated"} {
+		delete(oldFields, field)
+		delete(newFields, field)
+	}
+	return !jsonEqual(oldFields, newFields)
+}
+
+// setObjTemplateVersion: records the version of the template the object is built from
+func setObjTemplateVersion(entity int, object map[string]any) *u.Error {
+	attributes, _ := object["attributes"].(map[string]any)
+	slug, _ := attributes["template"].(string)
+	if !slices.Contains(objTemplateEntities, entity) || slug == "" {
+		return nil
+	}
+
+	template, err := getObjTemplate(slug)
+	if err != nil {
+		if err.Type == u.ErrNotFound {
+			// templates are applied by the clients, the object may not come from a stored one
+			return nil
+		}
+		return err
+	}
+	attributes["templateVersion"] = objTemplateVersion(template)
+	return nil
+}
+
+// updateObjTemplateVersion: records the version of the template when the template of the object changes,
+// otherwise keeps the version the object was built from
+func updateObjTemplateVersion(entity int, updateData, oldObject map[string]any) *u.Error {
+	attributes, _ := updateData["attributes"].(map[string]any)
+	oldAttributes, _ := oldObject["attributes"].(map[string]any)
+	if attributes == nil {
+		return nil
+	}
+
+	_, hasVersion := attributes["templateVersion"]
+	if attributes["template"] != oldAttributes["template"] || !hasVersion {
+		delete(attributes, "templateVersion")
+		if attributes["template"] == oldAttributes["template"] && oldAttributes["templateVersion"] != nil {
+			attributes["templateVersion"] = oldAttributes["templateVersion"]
+			return nil
+		}
+		return setObjTemplateVersion(entity, updateData)
+	}
+	return nil
+}
+
+// objTemplateValues: fields of the objects that are set from the template, as the CLI does
+func objTemplateValues(template map[string]any, entity int) (map[string]any, *u.Error) {
+	size, _ := template["sizeWDHmm"].(primitive.A)
+	if len(size) != 3 {
+		return nil, &u.Error{Type: u.ErrBadFormat, Message: fmt.Sprintf("Invalid size vector on template %s", template["slug"])}
+	}
+
+	values := map[string]any{
+		"attributes.size":       []any(size[:2]),
+		"attributes.height":     size[2],
+		"attributes.sizeUnit":   "mm",
+		"attributes.heightUnit": "mm",
+	}
+
+	templateAttributes, _ := template["attributes"].(map[string]any)
+	if templateType := templateAttributes["type"]; entity == u.DEVICE &&
+		(templateType == "chassis" || templateType == "server") {
+		if height, err := u.GetFloat(size[2]); err == nil {
+			values["attributes.sizeU"] = int(math.Ceil((height / 1000) / RACKUNIT))
+		}
+	}
+
+	if description, ok := template["description"]; ok {
+		values["description"] = description
+	}
+	for _, key := range []string{"fbxModel", "orientation", "shape"} {
+		if value, ok := template[key]; ok {
+			values["attributes."+key] = value
+		}
+	}
+	for key, value := range templateAttributes {
+		values["attributes."+key] = value
+	}
+
+	return values, nil
+}
+
+// applyObjTemplate: sets the size, model and attributes of the template to the object, as the CLI does
+func applyObjTemplate(entity int, object map[string]any, slug string) *u.Error {
+	if !slices.Contains(objTemplateEntities, entity) {
+		return &u.Error{Type: u.ErrBadFormat,
+			Message: "Templates are not applicable to " + u.EntityToString(entity)}
+	}
+
+	template, err := getObjTemplate(slug)
+	if err != nil {
+		return err
+	} else if template["category"] != u.EntityToString(entity) {
+		return &u.Error{Type: u.ErrBadFormat,
+			Message: fmt.Sprintf("Template of category %s is not applicable to %s", template["category"], u.EntityToString(entity))}
+	}
+
+	values, err := objTemplateValues(template, entity)
+	if err != nil {
+		return err
+	}
+	for field, value := range values {
+		if _, isSet := getObjectField(object, field); !isSet || slices.Contains(objTemplateOverriddenFields, field) {
+			setObjectField(object, field, value)
+		}
+	}
+
+	attributes := object["attributes"].(map[string]any)
+	attributes["template"] = slug
+	attributes["templateVersion"] = objTemplateVersion(template)
+	return nil
+}
+
+// getObjectField: value of a field of the object or of one of its attributes (attributes.<name>)
+func getObjectField(object map[string]any, field string) (any, bool) {
+	if attribute, isAttribute := strings.CutPrefix(field, "attributes."); isAttribute {
+		attributes, _ := object["attributes"].(map[string]any)
+		value, ok := attributes[attribute]
+		return value, ok
+	}
+	value, ok := object[field]
+	return value, ok
+}
+
+func setObjectField(object map[string]any, field string, value any) {
+	if attribute, isAttribute := strings.CutPrefix(field, "attributes."); isAttribute {
+		object["attributes"].(map[string]any)[attribute] = value
+	} else {
+		object[field] = value
+	}
+}
+
+// GetObjTemplateInstances: objects built from the template that the user can read
+func GetObjTemplateInstances(slug string, userRoles map[string]Role) (*ObjTemplateInstances, *u.Error) {
+	template, instances, err := getObjTemplateInstances(slug, nil, userRoles)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ObjTemplateInstances{
+		Slug:      slug,
+		Version:   objTemplateVersion(template),
+		Instances: []ObjTemplateInstance{},
+	}
+	for _, instance := range instances {
+		instanceVersion := getInstanceTemplateVersion(instance)
+		result.Instances = append(result.Instances, ObjTemplateInstance{
+			Id:              instance["id"].(string),
+			Category:        instance["category"].(string),
+			TemplateVersion: instanceVersion,
+			UpToDate:        instanceVersion == result.Version,
+		})
+	}
+
+	return result, nil
+}
+
+func getObjTemplateInstances(slug string, ids []string, userRoles map[string]Role) (map[string]any, []map[string]any, *u.Error) {
+	template, err := getObjTemplate(slug)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req := bson.M{"attributes.template": slug}
+	if len(ids) > 0 {
+		req["id"] = bson.M{"$in": ids}
+	}
+
+	instances, err := GetManyObjects(template["category"].(string), req, u.RequestFilters{}, "", userRoles)
+	if err != nil {
+		return nil, nil, err
+	}
+	return template, instances, nil
+}
+
+func getInstanceTemplateVersion(instance map[string]any) int {
+	attributes, _ := instance["attributes"].(map[string]any)
+	if _, hasVersion := attributes["templateVersion"]; !hasVersion {
+		return 0
+	}
+	return objTemplateVersion(map[string]any{"version": attributes["templateVersion"]})
+}
+
+// UpgradeObjTemplateInstances: applies the current version of the template to its instances.
+// A value of an instance that differs from the one of the version it was built from
+// was modified on the instance: it is a conflict and the instance is not upgraded,
+// unless force is given. The instances built from an unknown version have conflicts
+// for all the values that differ from the template.
+// A device using a slot the template no longer has is always a conflict.
+func UpgradeObjTemplateInstances(slug string, upgrade ObjTemplateUpgrade, userRoles map[string]Role) (*ObjTemplateUpgradeReport, *u.Error) {
+	template, instances, err := getObjTemplateInstances(slug, upgrade.Ids, userRoles)
+	if err != nil {
+		return nil, err
+	}
+
+	version := objTemplateVersion(template)
+	entity := u.EntityStrToInt(template["category"].(string))
+	values, err := objTemplateValues(template, entity)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ObjTemplateUpgradeReport{Slug: slug, Version: version, Instances: []ObjTemplateInstanceUpgrade{}}
+	previousValues := map[int]map[string]any{}
+	for _, instance := range instances {
+		instanceUpgrade := ObjTemplateInstanceUpgrade{
+			Id:          instance["id"].(string),
+			Category:    instance["category"].(string),
+			FromVersion: getInstanceTemplateVersion(instance),
+		}
+
+		if instanceUpgrade.FromVersion == version && !upgrade.Force {
+			instanceUpgrade.Status = ObjTemplateInstanceUpToDate
+			report.Instances = append(report.Instances, instanceUpgrade)
+			continue
+		}
+
+		if _, isKnown := previousValues[instanceUpgrade.FromVersion]; !isKnown {
+			previousValues[instanceUpgrade.FromVersion], err = getObjTemplateVersionValues(template, instanceUpgrade.FromVersion, entity)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		patch, conflicts := getObjTemplateUpgradePatch(instance, version, values, previousValues[instanceUpgrade.FromVersion], upgrade.Force)
+		slotConflicts, err := getObjTemplateSlotConflicts(instance, template, userRoles)
+		if err != nil {
+			return nil, err
+		}
+		instanceUpgrade.Conflicts = append(conflicts, slotConflicts...)
+
+		if len(instanceUpgrade.Conflicts) > 0 {
+			instanceUpgrade.Status = ObjTemplateInstanceConflict
+		} else if _, _, err := UpdateObject(instanceUpgrade.Category, instanceUpgrade.Id, patch, true, userRoles, false); err != nil {
+			instanceUpgrade.Status = ObjTemplateInstanceError
+			instanceUpgrade.Error = err.Message
+		} else {
+			instanceUpgrade.Status = ObjTemplateInstanceUpgraded
+		}
+		report.Instances = append(report.Instances, instanceUpgrade)
+	}
+
+	return report, nil
+}
+
+// getObjTemplateVersionValues: values set by this version of the template, nil if it is unknown
+func getObjTemplateVersionValues(template map[string]any, version, entity int) (map[string]any, *u.Error) {
+	if version == 0 {
+		return nil, nil
+	}
+
+	previousTemplate, err := getObjTemplateVersion(template, version)
+	if err != nil || previousTemplate == nil {
+		return nil, err
+	}
+
+	// versions are stored as given by the API, arrays need to be converted back
+	bytes, _ := bson.Marshal(previousTemplate)
+	previousTemplate = map[string]any{}
+	bson.Unmarshal(bytes, &previousTemplate)
+	return objTemplateValues(fixID(previousTemplate), entity)
+}
+
+// getObjTemplateUpgradePatch: patch setting the values of the template to the instance,
+// and the values modified on the instance that would be replaced
+func getObjTemplateUpgradePatch(instance map[string]any, version int, values, previousValues map[string]any, force bool) (map[string]any, []ObjTemplateConflict) {
+	patch := map[string]any{"attributes": map[string]any{"templateVersion": version}}
+	conflicts := []ObjTemplateConflict{}
+
+	for field, templateValue := range values {
+		value, isSet := getObjectField(instance, field)
+		if isSet && jsonEqual(value, templateValue) {
+			continue
+		}
+
+		previousValue, isPreviousKnown := previousValues[field]
+		if isSet && !force && !(isPreviousKnown && jsonEqual(value, previousValue)) {
+			conflicts = append(conflicts, ObjTemplateConflict{
+				Field:         field,
+				Value:         value,
+				TemplateValue: templateValue,
+				Message:       "The value was modified on the object",
+			})
+			continue
+		}
+		setObjectField(patch, field, templateValue)
+	}
+
+	return patch, conflicts
+}
+
+// getObjTemplateSlotConflicts: children of the instance in slots the template does not have
+func getObjTemplateSlotConflicts(instance, template map[string]any, userRoles map[string]Role) ([]ObjTemplateConflict, *u.Error) {
+	templateSlots, hasSlots := template["slots"].(primitive.A)
+	if !hasSlots || len(templateSlots) == 0 {
+		return nil, nil
+	}
+
+	locations := []string{}
+	for _, slot := range templateSlots {
+		if slotMap, ok := slot.(map[string]any); ok {
+			location, _ := slotMap["location"].(string)
+			locations = append(locations, location)
+		}
+	}
+
+	idPattern := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(instance["id"].(string)+u.HN_DELIMETER) + "(" + u.NAME_REGEX + ")$"}
+	children, err := GetManyObjects(u.EntityToString(u.DEVICE), bson.M{"id": idPattern}, u.RequestFilters{}, "", userRoles)
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := []ObjTemplateConflict{}
+	for _, child := range children {
+		childSlots, _ := slotToValidSlice(child["attributes"].(map[string]any))
+		for _, slot := range childSlots {
+			if !slices.Contains(locations, slot) {
+				conflicts = append(conflicts, ObjTemplateConflict{
+					Field:   "slots",
+					Value:   slot,
+					Message: "Slot used by " + child["id"].(string) + " is not in the template",
+				})
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+func jsonEqual(value1, value2 any) bool {
+	bytes1, err1 := json.Marshal(value1)
+	bytes2, err2 := json.Marshal(value2)
+	return err1 == nil && err2 == nil && string(bytes1) == string(bytes2)
+}
@@ -64,6 +64,32 @@ func GetRequestFilterByDomain(userRoles map[string]Role) (bson.M, bool) {
 	}
 }
 
+// GetReadableObjectsFilter: mongo filter matching the objects of the entity
+// for which the user has at least READ permission
+func GetReadableObjectsFilter(userRoles map[string]Role, entity int) bson.M {
+	field := "domain"
+	if entity == u.DOMAIN {
+		// only managers can see domains
+		field = "id"
+		if userRoles[ROOT_DOMAIN] == Manager {
+			return bson.M{}
+		}
+	} else if _, hasRootRole := userRoles[ROOT_DOMAIN]; hasRootRole {
+		return bson.M{}
+	}
+
+	patterns := []string{}
+	for domain, role := range userRoles {
+		if entity != u.DOMAIN || role == Manager {
+			patterns = append(patterns, "^"+regexp.QuoteMeta(domain)+`(\.|$)`)
+		}
+	}
+	if len(patterns) == 0 {
+		return bson.M{field: bson.M{"$in": bson.A{}}}
+	}
+	return bson.M{field: primitive.Regex{Pattern: strings.Join(patterns, "|")}}
+}
+
 func CheckUserPermissionsWithObject(userRoles map[string]Role, objEntity int, object map[string]any) Permission {
 	return CheckUserPermissions(userRoles, objEntity, getDomainFromObject(objEntity, object))
 }
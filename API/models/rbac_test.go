@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -174,3 +175,19 @@ func TestCheckUserPermissions(t *testing.T) {
 		t.Error("User with no roles should not have any permission")
 	}
 }
+
+func TestGetReadableObjectsFilter(t *testing.T) {
+	assert.Equal(t, bson.M{}, GetReadableObjectsFilter(map[string]Role{"*": Viewer}, u.RACK))
+
+	filter := GetReadableObjectsFilter(map[string]Role{"domain1": Viewer}, u.RACK)
+	regex := filter["domain"].(primitive.Regex)
+	assert.Regexp(t, regex.Pattern, "domain1")
+	assert.Regexp(t, regex.Pattern, "domain1.subdomain")
+	assert.NotRegexp(t, regex.Pattern, "domain10")
+	assert.NotRegexp(t, regex.Pattern, "other")
+
+	// only managers can read domains
+	filter = GetReadableObjectsFilter(map[string]Role{"domain1": Viewer}, u.DOMAIN)
+	assert.Equal(t, bson.M{"id": bson.M{"$in": bson.A{}}}, filter)
+	assert.Equal(t, bson.M{}, GetReadableObjectsFilter(map[string]Role{"*": Manager}, u.DOMAIN))
+}
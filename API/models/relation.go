@@ -0,0 +1,275 @@
+package models
+
+import (
+	"p3/repository"
+	u "p3/utils"
+	"regexp"
+
+	"github.com/elliotchance/pie/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const RELATION = "relation"
+
+var RelationTypes = []string{"depends-on", "backs-up", "connected-to", "managed-by"}
+
+// Relation: typed and directional relationship between two hierarchical
+// or virtual objects, e.g. an application (from) depends-on a vm (to)
+type Relation struct {
+	Id         string         `json:"id" bson:"_id,omitempty"`
+	Type       string         `json:"type" bson:"type"`
+	From       string         `json:"from" bson:"from"` // id of the object
+	To         string         `json:"to" bson:"to"`     // id of the object
+	Attributes map[string]any `json:"attributes" bson:"attributes"`
+}
+
+// RelationQuery: the relations matching all the given fields are returned
+type RelationQuery struct {
+	From string `schema:"from"`
+	To   string `schema:"to"`
+	Type string `schema:"type"`
+}
+
+func (relation Relation) validate() *u.Error {
+	if !pie.Contains(RelationTypes, relation.Type) {
+		return &u.Error{Type: u.ErrBadFormat,
+			Message: "type must be one of: depends-on, backs-up, connected-to, managed-by"}
+	} else if relation.From == "" || relation.To == "" {
+		return &u.Error{Type: u.ErrBadFormat, Message: "from and to are mandatory"}
+	} else if relation.From == relation.To {
+		return &u.Error{Type: u.ErrBadFormat, Message: "An object cannot be related to itself"}
+	}
+	return nil
+}
+
+// checkCanManageRelation: only users that can modify the from object and read
+// the to object can manage the relation. Both must exist
+func checkCanManageRelation(relation Relation, userRoles map[string]Role) *u.Error {
+	if err := checkRelationObject(relation.From, WRITE, userRoles); err != nil {
+		return err
+	}
+	return checkRelationObject(relation.To, READ, userRoles)
+}
+
+func checkRelationObject(id string, minPermission Permission, userRoles map[string]Role) *u.Error {
+	entity, object := getRelationObject(id)
+	if object == nil {
+		return &u.Error{Type: u.ErrBadFormat, Message: "Object not found: " + id}
+	} else if CheckUserPermissionsWithObject(userRoles, entity, object) < minPermission {
+		return &u.Error{Type: u.ErrUnauthorized,
+			Message: "User does not have permission to relate object " + id}
+	}
+	return nil
+}
+
+// getRelationObject: the hierarchical or virtual object of given id
+// and its entity, nil if it does not exist
+func getRelationObject(id string) (int, map[string]any) {
+	for _, entityStr := range u.GetEntitiesById(u.Physical, id) {
+		object, err := repository.GetObject(bson.M{"id": id}, entityStr, u.RequestFilters{})
+		if err == nil {
+			return u.EntityStrToInt(entityStr), object
+		}
+	}
+	return -1, nil
+}
+
+// POST
+func CreateRelation(relation Relation, userRoles map[string]Role) (*Relation, *u.Error) {
+	if err := relation.validate(); err != nil {
+		return nil, err
+	}
+	if err := checkCanManageRelation(relation, userRoles); err != nil {
+		return nil, err
+	}
+	if relation.Attributes == nil {
+		relation.Attributes = map[string]any{}
+	}
+
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	relation.Id = ""
+	result, err := repository.GetDB().Collection(RELATION).InsertOne(ctx, relation)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, &u.Error{Type: u.ErrDuplicate,
+				Message: "A relation " + relation.Type + " from " + relation.From + " to " + relation.To + " already exists"}
+		}
+		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
+	relation.Id = result.InsertedID.(primitive.ObjectID).Hex()
+
+	return &relation, nil
+}
+
+// GET
+// GetRelations: the relations matching the query between objects the user can read
+func GetRelations(query RelationQuery, userRoles map[string]Role) ([]Relation, *u.Error) {
+	filter := bson.M{}
+	if query.From != "" {
+		filter["from"] = query.From
+	}
+	if query.To != "" {
+		filter["to"] = query.To
+	}
+	if query.Type != "" {
+		filter["type"] = query.Type
+	}
+
+	relations, err := getRelations(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	readable := map[string]bool{} // object id: user can read it
+	canRead := func(id string) bool {
+		if _, checked := readable[id]; !checked {
+			readable[id] = checkRelationObject(id, READ, userRoles) == nil
+		}
+		return readable[id]
+	}
+	return pie.Filter(relations, func(relation Relation) bool {
+		return canRead(relation.From) && canRead(relation.To)
+	}), nil
+}
+
+func GetRelation(id string, userRoles map[string]Role) (*Relation, *u.Error) {
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, &u.Error{Type: u.ErrNotFound, Message: "Relation not found"}
+	}
+
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	relation := &Relation{}
+	err = repository.GetDB().Collection(RELATION).FindOne(ctx, bson.M{"_id": objId}).Decode(relation)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, &u.Error{Type: u.ErrNotFound, Message: "Relation not found"}
+		}
+		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
+
+	if checkRelationObject(relation.From, READ, userRoles) != nil ||
+		checkRelationObject(relation.To, READ, userRoles) != nil {
+		return nil, &u.Error{Type: u.ErrUnauthorized,
+			Message: "User does not have permission to see this relation"}
+	}
+
+	return relation, nil
+}
+
+func getRelations(filter bson.M) ([]Relation, *u.Error) {
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	relations := []Relation{}
+	cursor, err := repository.GetDB().Collection(RELATION).Find(ctx, filter)
+	if err != nil {
+		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	} else if err = cursor.All(ctx, &relations); err != nil {
+		return nil, &u.Error{Type: u.ErrInternal, Message: err.Error()}
+	}
+
+	return relations, nil
+}
+
+// PUT
+func UpdateRelation(id string, newRelation Relation, userRoles map[string]Role) (*Relation, *u.Error) {
+	oldRelation, err := GetRelation(id, userRoles)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCanManageRelation(*oldRelation, userRoles); err != nil {
+		return nil, err
+	}
+	if err := newRelation.validate(); err != nil {
+		return nil, err
+	}
+	if err := checkCanManageRelation(newRelation, userRoles); err != nil {
+		return nil, err
+	}
+	if newRelation.Attributes == nil {
+		newRelation.Attributes = map[string]any{}
+	}
+
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	objId, _ := primitive.ObjectIDFromHex(id)
+	newRelation.Id = ""
+	_, mongoErr := repository.GetDB().Collection(RELATION).ReplaceOne(ctx, bson.M{"_id": objId}, newRelation)
+	if mongoErr != nil {
+		if mongo.IsDuplicateKeyError(mongoErr) {
+			return nil, &u.Error{Type: u.ErrDuplicate,
+				Message: "A relation " + newRelation.Type + " from " + newRelation.From + " to " + newRelation.To + " already exists"}
+		}
+		return nil, &u.Error{Type: u.ErrDBError, Message: mongoErr.Error()}
+	}
+	newRelation.Id = id
+
+	return &newRelation, nil
+}
+
+// DELETE
+func DeleteRelation(id string, userRoles map[string]Role) *u.Error {
+	relation, err := GetRelation(id, userRoles)
+	if err != nil {
+		return err
+	}
+	if err := checkCanManageRelation(*relation, userRoles); err != nil {
+		return err
+	}
+
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	objId, _ := primitive.ObjectIDFromHex(id)
+	if _, err := repository.GetDB().Collection(RELATION).DeleteOne(ctx, bson.M{"_id": objId}); err != nil {
+		return &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
+	return nil
+}
+
+// updateRelations: makes the relations from or to the object of oldId, or one
+// of its children, point to newId. If newId is empty, these relations are deleted
+func updateRelations(ctx mongo.SessionContext, oldId, newId string) ([]ReferenceChange, error) {
+	collection := repository.GetDB().Collection(RELATION)
+	pattern := primitive.Regex{
+		Pattern: "^" + regexp.QuoteMeta(oldId) + "(" + regexp.QuoteMeta(u.HN_DELIMETER) + "|$)",
+		Options: "",
+	}
+	cursor, err := collection.Find(ctx, bson.M{"$or": bson.A{bson.M{"from": pattern}, bson.M{"to": pattern}}})
+	if err != nil {
+		return nil, err
+	}
+	relations := []Relation{}
+	if err := cursor.All(ctx, &relations); err != nil {
+		return nil, err
+	}
+
+	changes := []ReferenceChange{}
+	for _, relation := range relations {
+		objId, _ := primitive.ObjectIDFromHex(relation.Id)
+		if newId == "" {
+			if _, err := collection.DeleteOne(ctx, bson.M{"_id": objId}); err != nil {
+				return nil, err
+			}
+			changes = append(changes, ReferenceChange{Category: RELATION, Id: relation.Id})
+			continue
+		}
+
+		relation.From = repository.ReplaceIdPrefix(relation.From, oldId, newId)
+		relation.To = repository.ReplaceIdPrefix(relation.To, oldId, newId)
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": objId},
+			bson.M{"$set": bson.M{"from": relation.From, "to": relation.To}}); err != nil {
+			return nil, err
+		}
+		changes = append(changes, ReferenceChange{Category: RELATION, Id: relation.Id, Object: relation})
+	}
+	return changes, nil
+}
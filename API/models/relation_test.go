@@ -0,0 +1,96 @@
+package models_test
+
+import (
+	"p3/models"
+	"p3/test/integration"
+	u "p3/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelationFollowsRenamedObject(t *testing.T) {
+	integration.RequireCreateSite("relation-rename-site")
+	integration.RequireCreateBuilding("relation-rename-site", "building")
+	integration.RequireCreateRoom("relation-rename-site.building", "room")
+	integration.RequireCreateRack("relation-rename-site.building.room", "rack")
+	integration.RequireCreateDevice("relation-rename-site.building.room.rack", "server")
+	integration.RequireCreateVirtualObject("", "relation-rename-app", map[string]any{
+		"virtual_config": map[string]any{"type": "application"},
+	})
+
+	relation, err := models.CreateRelation(models.Relation{
+		Type: "depends-on",
+		From: "relation-rename-app",
+		To:   "relation-rename-site.building.room.rack.server",
+	}, integration.ManagerUserRoles)
+	require.Nil(t, err)
+
+	_, changes, err := models.UpdateObject(u.EntityToString(u.RACK), "relation-rename-site.building.room.rack",
+		map[string]any{"name": "rack2"}, true, integration.ManagerUserRoles, false)
+	require.Nil(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, models.RELATION, changes[0].Category)
+
+	relation, err = models.GetRelation(relation.Id, integration.ManagerUserRoles)
+	require.Nil(t, err)
+	assert.Equal(t, "relation-rename-site.building.room.rack2.server", relation.To)
+}
+
+func TestRelationIsDeletedWithObject(t *testing.T) {
+	integration.RequireCreateSite("relation-delete-site")
+	integration.RequireCreateBuilding("relation-delete-site", "building")
+	integration.RequireCreateRoom("relation-delete-site.building", "room")
+	integration.RequireCreateRack("relation-delete-site.building.room", "rack1")
+	integration.RequireCreateRack("relation-delete-site.building.room", "rack2")
+
+	relation, err := models.CreateRelation(models.Relation{
+		Type: "backs-up",
+		From: "relation-delete-site.building.room.rack2",
+		To:   "relation-delete-site.building.room.rack1",
+	}, integration.ManagerUserRoles)
+	require.Nil(t, err)
+
+	_, err = models.DeleteHierarchicalObject(u.EntityToString(u.RACK),
+		"relation-delete-site.building.room.rack1", integration.ManagerUserRoles)
+	require.Nil(t, err)
+
+	_, err = models.GetRelation(relation.Id, integration.ManagerUserRoles)
+	assert.NotNil(t, err)
+}
+
+func TestImpactFollowsRelations(t *testing.T) {
+	integration.RequireCreateSite("relation-impact-site")
+	integration.RequireCreateBuilding("relation-impact-site", "building")
+	integration.RequireCreateRoom("relation-impact-site.building", "room")
+	integration.RequireCreateRack("relation-impact-site.building.room", "rack")
+	integration.RequireCreateDevice("relation-impact-site.building.room.rack", "server")
+	integration.RequireCreateVirtualObject("", "relation-impact-db", map[string]any{
+		"virtual_config": map[string]any{"type": "application"},
+	})
+	integration.RequireCreateVirtualObject("", "relation-impact-app", map[string]any{
+		"virtual_config": map[string]any{"type": "application"},
+	})
+
+	for _, relation := range []models.Relation{
+		{Type: "depends-on", From: "relation-impact-db", To: "relation-impact-site.building.room.rack.server"},
+		{Type: "depends-on", From: "relation-impact-app", To: "relation-impact-db"},
+	} {
+		_, err := models.CreateRelation(relation, integration.ManagerUserRoles)
+		require.Nil(t, err)
+	}
+
+	impact, err := models.GetImpact("relation-impact-site.building.room.rack", integration.ManagerUserRoles,
+		models.ImpactFilters{Relations: []string{"depends-on"}})
+	require.Nil(t, err)
+	indirect := impact["indirect"].(map[string]any)
+	assert.Contains(t, indirect, "relation-impact-db")
+	assert.Contains(t, indirect, "relation-impact-app")
+	assert.Len(t, impact["typedRelations"], 2)
+
+	impact, err = models.GetImpact("relation-impact-site.building.room.rack", integration.ManagerUserRoles,
+		models.ImpactFilters{})
+	require.Nil(t, err)
+	assert.NotContains(t, impact["indirect"].(map[string]any), "relation-impact-app")
+}
@@ -0,0 +1,173 @@
+package models
+
+import (
+	"p3/repository"
+	u "p3/utils"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const defaultSearchLimit = 50
+
+// Fields looked for highlights, besides attributes
+var searchHighlightFields = []string{"id", "slug", "name", "description"}
+
+type SearchHighlight struct {
+	Field   string   `json:"field"`
+	Value   string   `json:"value"`
+	Matches []string `json:"matches"`
+}
+
+type SearchResult struct {
+	Entity     string            `json:"entity"`
+	Score      float64           `json:"score"`
+	Highlights []SearchHighlight `json:"highlights"`
+	Object     map[string]any    `json:"object"`
+}
+
+// SearchObjects: full text search on all collections of the namespace.
+// Results are ranked by text score and only objects the user can read are returned.
+func SearchObjects(filters u.SearchFilters, userRoles map[string]Role) ([]SearchResult, *u.Error) {
+	terms := getSearchTerms(filters.Query)
+	if len(terms) == 0 {
+		return nil, &u.Error{Type: u.ErrBadFormat, Message: "Query param q is mandatory"}
+	}
+
+	limit := filters.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	results := []SearchResult{}
+	for _, entityStr := range u.GetEntitiesById(filters.Namespace, "") {
+		entityResults, err := searchEntity(entityStr, filters.Query, terms, int64(limit), userRoles)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, entityResults...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+func searchEntity(entityStr, query string, terms []string, limit int64, userRoles map[string]Role) ([]SearchResult, *u.Error) {
+	entity := u.EntityStrToInt(entityStr)
+	req := bson.M{}
+	if u.IsEntityHierarchical(entity) && entity != u.DOMAIN {
+		// viewers have no filter, their permissions are checked object by object
+		if domainFilter, ok := GetRequestFilterByDomain(userRoles); ok {
+			req = domainFilter
+		}
+	}
+	req["$text"] = bson.M{"$search": query}
+
+	opts := options.Find().
+		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}}).
+		SetLimit(limit)
+
+	ctx, cancel := u.Connect()
+	defer cancel()
+	c, err := repository.GetDB().Collection(entityStr).Find(ctx, req, opts)
+	if err != nil {
+		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
+
+	results := []SearchResult{}
+	for c.Next(ctx) {
+		object := map[string]any{}
+		if err := c.Decode(object); err != nil {
+			return nil, &u.Error{Type: u.ErrInternal, Message: err.Error()}
+		}
+		score, _ := object["score"].(float64)
+		delete(object, "score")
+		object = fixID(object)
+
+		// Check permissions as ExtractCursor does. Highlights are computed on the
+		// object as returned, so matches on hidden attributes are not revealed.
+		if u.IsEntityHierarchical(entity) && userRoles != nil {
+			permission := CheckUserPermissionsWithObject(userRoles, entity, object)
+			if permission < READONLYNAME {
+				continue
+			} else if permission == READONLYNAME {
+				object = FixReadOnlyName(object)
+			}
+		}
+
+		results = append(results, SearchResult{
+			Entity:     entityStr,
+			Score:      score,
+			Highlights: getSearchHighlights(object, terms),
+			Object:     object,
+		})
+	}
+
+	return results, nil
+}
+
+// getSearchTerms: words of the query as matched by mongo,
+// ignoring negated terms and phrase quotes
+func getSearchTerms(query string) []string {
+	terms := []string{}
+	for _, word := range strings.Fields(strings.ReplaceAll(query, "\"", " ")) {
+		if strings.HasPrefix(word, "-") {
+			continue
+		}
+		terms = append(terms, strings.ToLower(word))
+	}
+	return terms
+}
+
+func getSearchHighlights(object map[string]any, terms []string) []SearchHighlight {
+	highlights := []SearchHighlight{}
+	for _, field := range searchHighlightFields {
+		highlights = appendSearchHighlights(highlights, field, object[field], terms)
+	}
+	if attributes, ok := object["attributes"].(map[string]any); ok {
+		keys := make([]string, 0, len(attributes))
+		for key := range attributes {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			highlights = appendSearchHighlights(highlights, "attributes."+key, attributes[key], terms)
+		}
+	}
+	return highlights
+}
+
+func appendSearchHighlights(highlights []SearchHighlight, field string, value any, terms []string) []SearchHighlight {
+	switch v := value.(type) {
+	case string:
+		matches := []string{}
+		lowerValue := strings.ToLower(v)
+		for _, term := range terms {
+			if strings.Contains(lowerValue, term) {
+				matches = append(matches, term)
+			}
+		}
+		if len(matches) > 0 {
+			highlights = append(highlights, SearchHighlight{Field: field, Value: v, Matches: matches})
+		}
+	case map[string]any:
+		for key, inner := range v {
+			highlights = appendSearchHighlights(highlights, field+"."+key, inner, terms)
+		}
+	case primitive.A:
+		for _, inner := range v {
+			highlights = appendSearchHighlights(highlights, field, inner, terms)
+		}
+	}
+	return highlights
+}
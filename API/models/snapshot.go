@@ -0,0 +1,732 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"p3/repository"
+	u "p3/utils"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/elliotchance/pie/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const SNAPSHOT = "snapshot"
+const SNAPSHOT_OBJECT = "snapshot_object"
+const SNAPSHOT_SCHEDULE = "snapshot_schedule"
+
+const snapshotDateFormat = "2006-01-02"
+
+// names are used in URLs and as asOf values
+var snapshotNameRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// fields set by the API that are not compared in diffs
+var snapshotIgnoredFields = []string{"_id", "createdDate", "lastUpdated"}
+
+// Snapshot is a copy of the physical and virtual objects of the tenant
+// (empty root) or of the subtree of an object at a point in time
+type Snapshot struct {
+	Name        string    `json:"name" bson:"name"`
+	Root        string    `json:"root" bson:"root"`
+	Domain      string    `json:"domain" bson:"domain"`                         // domain of the root
+	Schedule    string    `json:"schedule,omitempty" bson:"schedule,omitempty"` // name of the schedule that took it
+	CreatedDate time.Time `json:"createdDate" bson:"createdDate"`
+	ObjectCount int       `json:"objectCount" bson:"objectCount"`
+}
+
+// SnapshotSchedule takes a snapshot named <name>-<date> of its root every
+// interval, keeping only the last keep ones (all if keep is 0)
+type SnapshotSchedule struct {
+	Id       string     `json:"id" bson:"_id,omitempty"`
+	Name     string     `json:"name" bson:"name"`
+	Root     string     `json:"root" bson:"root"`
+	Domain   string     `json:"domain" bson:"domain"`
+	Interval string     `json:"interval" bson:"interval"` // duration, e.g. 24h
+	Keep     int        `json:"keep" bson:"keep"`
+	LastRun  *time.Time `json:"lastRun,omitempty" bson:"lastRun,omitempty"`
+}
+
+// ObjectsDiff lists the objects added, removed and changed between two points in time
+type ObjectsDiff struct {
+	From    string       `json:"from"`
+	To      string       `json:"to"` // empty for the current state
+	Added   []ObjectDiff `json:"added"`
+	Removed []ObjectDiff `json:"removed"`
+	Changed []ObjectDiff `json:"changed"`
+}
+
+type ObjectDiff struct {
+	Id       string          `json:"id"`
+	Category string          `json:"category"`
+	Changes  []AttributeDiff `json:"changes,omitempty"`
+}
+
+// AttributeDiff: attribute is the dotted path of the field, e.g. attributes.height
+type AttributeDiff struct {
+	Attribute string `json:"attribute"`
+	From      any    `json:"from"`
+	To        any    `json:"to"`
+}
+
+// snapshotEntities: collections copied by a snapshot
+func snapshotEntities() []int {
+	entities := []int{}
+	for entity := u.SITE; entity <= u.GROUP; entity++ {
+		entities = append(entities, entity)
+	}
+	return append(entities, u.VIRTUALOBJ)
+}
+
+// covers: true if the object of given id is in the snapshot,
+// an empty id stands for the whole tenant
+func (snapshot Snapshot) covers(id string) bool {
+	return snapshot.Root == "" || snapshot.Root == id ||
+		(id != "" && strings.HasPrefix(id, snapshot.Root+u.HN_DELIMETER))
+}
+
+// snapshotPermission: permission of the user on the snapshots of a root,
+// only managers of the root domain have rights on the tenant snapshots
+func snapshotPermission(userRoles map[string]Role, root, domain string) Permission {
+	if root == "" {
+		return CheckUserPermissions(userRoles, u.DOMAIN, ROOT_DOMAIN)
+	}
+	return CheckUserPermissions(userRoles, u.SITE, domain)
+}
+
+// getSnapshotRootDomain: checks the root exists and returns its domain
+func getSnapshotRootDomain(root string, userRoles map[string]Role) (string, *u.Error) {
+	if root == "" {
+		return ROOT_DOMAIN, nil
+	}
+	obj, err := GetHierarchicalObjectById(root, u.RequestFilters{}, userRoles)
+	if err != nil {
+		return "", err
+	}
+	entity := u.EntityStrToInt(obj["category"].(string))
+	if !pie.Contains(snapshotEntities(), entity) {
+		return "", &u.Error{Type: u.ErrBadFormat,
+			Message: "Snapshots can only be taken of physical or virtual objects"}
+	}
+	return getDomainFromObject(entity, obj), nil
+}
+
+func validateSnapshotName(name string) *u.Error {
+	if !snapshotNameRegex.MatchString(name) {
+		return &u.Error{Type: u.ErrBadFormat,
+			Message: "name is mandatory and can only contain letters, digits, - and _"}
+	}
+	if _, err := time.Parse(snapshotDateFormat, name); err == nil {
+		return &u.Error{Type: u.ErrBadFormat, Message: "name cannot be a date"}
+	}
+	return nil
+}
+
+// POST
+// TakeSnapshot: copies the objects of the root (whole tenant if empty).
+// The user must be able to read the root, or manage the root domain for the tenant
+func TakeSnapshot(name, root string, userRoles map[string]Role) (*Snapshot, *u.Error) {
+	if err := validateSnapshotName(name); err != nil {
+		return nil, err
+	}
+	domain, err := getSnapshotRootDomain(root, userRoles)
+	if err != nil {
+		return nil, err
+	}
+	if root == "" && snapshotPermission(userRoles, root, domain) < WRITE {
+		return nil, &u.Error{Type: u.ErrUnauthorized,
+			Message: "Only managers of the root domain can take snapshots of the whole tenant"}
+	} else if snapshotPermission(userRoles, root, domain) < READ {
+		return nil, &u.Error{Type: u.ErrUnauthorized,
+			Message: "User does not have permission to take a snapshot of " + root}
+	}
+
+	return takeSnapshot(Snapshot{Name: name, Root: root, Domain: domain})
+}
+
+// takeSnapshot: stores the snapshot and the current state of its objects in a transaction
+func takeSnapshot(snapshot Snapshot) (*Snapshot, *u.Error) {
+	objects, err := getCurrentObjects(snapshot.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.CreatedDate = time.Now().UTC().Truncate(time.Millisecond)
+	snapshot.ObjectCount = len(objects)
+	documents := []any{}
+	for _, obj := range objects {
+		documents = append(documents, bson.M{
+			"snapshot": snapshot.Name,
+			"id":       obj["id"],
+			"category": obj["category"],
+			"object":   obj,
+		})
+	}
+
+	_, err = WithTransaction(func(ctx mongo.SessionContext) (any, error) {
+		if _, err := repository.GetDB().Collection(SNAPSHOT).InsertOne(ctx, snapshot); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				return nil, &u.Error{Type: u.ErrDuplicate, Message: "Snapshot " + snapshot.Name + " already exists"}
+			}
+			return nil, err
+		}
+		if len(documents) > 0 {
+			if _, err := repository.GetDB().Collection(SNAPSHOT_OBJECT).InsertMany(ctx, documents); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+// getCurrentObjects: current objects of the root and its subtree (whole tenant if empty), by id
+func getCurrentObjects(root string) (map[string]map[string]any, *u.Error) {
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	objects := map[string]map[string]any{}
+	for _, entity := range snapshotEntities() {
+		cursor, err := repository.GetDB().Collection(u.EntityToString(entity)).Find(ctx, rootObjectsFilter(root))
+		if err != nil {
+			return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+		}
+		results := []map[string]any{}
+		if err := cursor.All(ctx, &results); err != nil {
+			return nil, &u.Error{Type: u.ErrInternal, Message: err.Error()}
+		}
+		for _, obj := range results {
+			delete(obj, "_id")
+			objects[obj["id"].(string)] = obj
+		}
+	}
+
+	return objects, nil
+}
+
+// GET
+// GetSnapshots: snapshots of the tenant and of the objects the user can see, latest first
+func GetSnapshots(userRoles map[string]Role) ([]Snapshot, *u.Error) {
+	snapshots, err := getSnapshots(bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	visible := []Snapshot{}
+	for _, snapshot := range snapshots {
+		if snapshot.Root == "" || snapshotPermission(userRoles, snapshot.Root, snapshot.Domain) >= READONLYNAME {
+			visible = append(visible, snapshot)
+		}
+	}
+	return visible, nil
+}
+
+func GetSnapshot(name string, userRoles map[string]Role) (*Snapshot, *u.Error) {
+	snapshot, err := getSnapshot(name)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.Root != "" && snapshotPermission(userRoles, snapshot.Root, snapshot.Domain) < READONLYNAME {
+		return nil, &u.Error{Type: u.ErrUnauthorized,
+			Message: "User does not have permission to see this snapshot"}
+	}
+	return snapshot, nil
+}
+
+func getSnapshot(name string) (*Snapshot, *u.Error) {
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	snapshot := &Snapshot{}
+	err := repository.GetDB().Collection(SNAPSHOT).FindOne(ctx, bson.M{"name": name}).Decode(snapshot)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, &u.Error{Type: u.ErrNotFound, Message: "Snapshot " + name + " not found"}
+		}
+		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
+	return snapshot, nil
+}
+
+func getSnapshots(filter bson.M) ([]Snapshot, *u.Error) {
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	snapshots := []Snapshot{}
+	opts := options.Find().SetSort(bson.M{"createdDate": -1})
+	cursor, err := repository.GetDB().Collection(SNAPSHOT).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	} else if err = cursor.All(ctx, &snapshots); err != nil {
+		return nil, &u.Error{Type: u.ErrInternal, Message: err.Error()}
+	}
+	return snapshots, nil
+}
+
+// ResolveSnapshot: snapshot of an asOf value containing the object of given id
+// (whole tenant if empty). The value is a date, yyyy-mm-dd or RFC3339, giving the
+// latest snapshot taken at or before it (until the end of the day for yyyy-mm-dd),
+// or else the name of a snapshot
+func ResolveSnapshot(asOf, id string) (*Snapshot, *u.Error) {
+	filter := bson.M{}
+	if date, err := time.Parse(snapshotDateFormat, asOf); err == nil {
+		filter["createdDate"] = bson.M{"$lt": date.Add(24 * time.Hour)}
+	} else if date, err := time.Parse(time.RFC3339, asOf); err == nil {
+		filter["createdDate"] = bson.M{"$lte": date}
+	} else {
+		snapshot, err := getSnapshot(asOf)
+		if err != nil {
+			return nil, err
+		}
+		if !snapshot.covers(id) {
+			return nil, &u.Error{Type: u.ErrNotFound,
+				Message: "Snapshot " + asOf + " does not contain " + describeSnapshotRoot(id)}
+		}
+		return snapshot, nil
+	}
+
+	snapshots, err := getSnapshots(filter)
+	if err != nil {
+		return nil, err
+	}
+	for _, snapshot := range snapshots {
+		if snapshot.covers(id) {
+			return &snapshot, nil
+		}
+	}
+	return nil, &u.Error{Type: u.ErrNotFound,
+		Message: "No snapshot of " + describeSnapshotRoot(id) + " was taken at or before " + asOf}
+}
+
+func describeSnapshotRoot(id string) string {
+	if id == "" {
+		return "the whole tenant"
+	}
+	return id
+}
+
+// GetSnapshotObjectById: the object of given id as it was in the snapshot,
+// with the same permissions as GetObject
+func GetSnapshotObjectById(snapshotName, id, entityStr string, userRoles map[string]Role) (map[string]any, *u.Error) {
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	filter := bson.M{"snapshot": snapshotName, "id": id}
+	if entityStr != u.HIERARCHYOBJS_ENT {
+		filter["category"] = entityStr
+	}
+	var document struct {
+		Object map[string]any `bson:"object"`
+	}
+	err := repository.GetDB().Collection(SNAPSHOT_OBJECT).FindOne(ctx, filter).Decode(&document)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, &u.Error{Type: u.ErrNotFound,
+				Message: "Nothing matches this request in snapshot " + snapshotName}
+		}
+		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
+
+	object := fixID(document.Object)
+	entity := u.EntityStrToInt(object["category"].(string))
+	if permission := CheckUserPermissionsWithObject(userRoles, entity, object); permission == NONE {
+		return nil, &u.Error{Type: u.ErrUnauthorized,
+			Message: "User does not have permission to see this object"}
+	} else if permission == READONLYNAME {
+		object = FixReadOnlyName(object)
+	}
+	return object, nil
+}
+
+// GetSnapshotHierarchy: children of the object of given id as they were in the snapshot,
+// nested up to limit levels. Only the children the user can see are returned
+func GetSnapshotHierarchy(snapshotName, id string, limit int, userRoles map[string]Role) ([]map[string]any, *u.Error) {
+	pattern := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(id) +
+		"(." + u.NAME_REGEX + "){1," + fmt.Sprint(limit) + "}$", Options: ""}
+	objects, err := getSnapshotObjects(snapshotName, bson.M{"id": pattern})
+	if err != nil {
+		return nil, err
+	}
+
+	allChildren := map[string]any{}
+	hierarchy := map[string][]string{}
+	for childId, child := range filterReadableObjects(objects, userRoles, READONLYNAME) {
+		allChildren[childId] = fixID(child)
+		fillHierarchyMap(childId, hierarchy)
+	}
+	for _, children := range hierarchy {
+		sort.Strings(children)
+	}
+
+	return recursivelyGetChildrenFromMaps(id, hierarchy, allChildren), nil
+}
+
+// getSnapshotObjects: objects of the snapshot matching the filter on their id, by id
+func getSnapshotObjects(snapshotName string, filter bson.M) (map[string]map[string]any, *u.Error) {
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	filter["snapshot"] = snapshotName
+	cursor, err := repository.GetDB().Collection(SNAPSHOT_OBJECT).Find(ctx, filter)
+	if err != nil {
+		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
+	documents := []struct {
+		Object map[string]any `bson:"object"`
+	}{}
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, &u.Error{Type: u.ErrInternal, Message: err.Error()}
+	}
+
+	objects := map[string]map[string]any{}
+	for _, document := range documents {
+		objects[document.Object["id"].(string)] = document.Object
+	}
+	return objects, nil
+}
+
+// filterReadableObjects: objects on which the user has at least the given permission,
+// those with READONLYNAME only keep their name
+func filterReadableObjects(objects map[string]map[string]any, userRoles map[string]Role,
+	minPermission Permission) map[string]map[string]any {
+	readable := map[string]map[string]any{}
+	for id, obj := range objects {
+		entity := u.EntityStrToInt(obj["category"].(string))
+		permission := CheckUserPermissionsWithObject(userRoles, entity, obj)
+		if permission < minPermission {
+			continue
+		}
+		if permission == READONLYNAME {
+			obj = FixReadOnlyName(obj)
+		}
+		readable[id] = obj
+	}
+	return readable
+}
+
+// DELETE
+func DeleteSnapshot(name string, userRoles map[string]Role) *u.Error {
+	snapshot, err := getSnapshot(name)
+	if err != nil {
+		return err
+	}
+	if snapshotPermission(userRoles, snapshot.Root, snapshot.Domain) < WRITE {
+		return &u.Error{Type: u.ErrUnauthorized,
+			Message: "User does not have permission to delete this snapshot"}
+	}
+	return deleteSnapshots([]string{name})
+}
+
+func deleteSnapshots(names []string) *u.Error {
+	_, err := WithTransaction(func(ctx mongo.SessionContext) (any, error) {
+		filter := bson.M{"snapshot": bson.M{"$in": names}}
+		if _, err := repository.GetDB().Collection(SNAPSHOT_OBJECT).DeleteMany(ctx, filter); err != nil {
+			return nil, err
+		}
+		_, err := repository.GetDB().Collection(SNAPSHOT).DeleteMany(ctx, bson.M{"name": bson.M{"$in": names}})
+		return nil, err
+	})
+	return err
+}
+
+// DIFF
+// DiffObjects: objects of the root (whole tenant if empty) added, removed or changed
+// between from and to, asOf values as in ResolveSnapshot. An empty to stands for
+// the current state. Only the objects the user can read are compared
+func DiffObjects(from, to, root string, userRoles map[string]Role) (*ObjectsDiff, *u.Error) {
+	if from == "" {
+		return nil, &u.Error{Type: u.ErrBadFormat, Message: "from is mandatory"}
+	}
+	fromSnapshot, err := ResolveSnapshot(from, root)
+	if err != nil {
+		return nil, err
+	}
+	diff := &ObjectsDiff{From: fromSnapshot.Name}
+	fromObjects, err := getSnapshotObjects(fromSnapshot.Name, rootObjectsFilter(root))
+	if err != nil {
+		return nil, err
+	}
+
+	var toObjects map[string]map[string]any
+	if to == "" {
+		toObjects, err = getCurrentObjects(root)
+	} else {
+		var toSnapshot *Snapshot
+		if toSnapshot, err = ResolveSnapshot(to, root); err == nil {
+			diff.To = toSnapshot.Name
+			toObjects, err = getSnapshotObjects(toSnapshot.Name, rootObjectsFilter(root))
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fromObjects = filterReadableObjects(fromObjects, userRoles, READ)
+	toObjects = filterReadableObjects(toObjects, userRoles, READ)
+	diff.Added, diff.Removed, diff.Changed = compareObjects(fromObjects, toObjects)
+	return diff, nil
+}
+
+// rootObjectsFilter: filter on the id of the root and its subtree, all objects if empty
+func rootObjectsFilter(root string) bson.M {
+	if root == "" {
+		return bson.M{}
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{"id": root},
+		bson.M{"id": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(root+u.HN_DELIMETER)}},
+	}}
+}
+
+// compareObjects: added, removed and changed objects, sorted by id
+func compareObjects(fromObjects, toObjects map[string]map[string]any) (added, removed, changed []ObjectDiff) {
+	added, removed, changed = []ObjectDiff{}, []ObjectDiff{}, []ObjectDiff{}
+	for id, toObj := range toObjects {
+		category, _ := toObj["category"].(string)
+		fromObj, existed := fromObjects[id]
+		if !existed {
+			added = append(added, ObjectDiff{Id: id, Category: category})
+		} else if changes := compareAttributes(fromObj, toObj); len(changes) > 0 {
+			changed = append(changed, ObjectDiff{Id: id, Category: category, Changes: changes})
+		}
+	}
+	for id, fromObj := range fromObjects {
+		if _, exists := toObjects[id]; !exists {
+			category, _ := fromObj["category"].(string)
+			removed = append(removed, ObjectDiff{Id: id, Category: category})
+		}
+	}
+
+	for _, diffs := range [][]ObjectDiff{added, removed, changed} {
+		sort.Slice(diffs, func(i, j int) bool { return diffs[i].Id < diffs[j].Id })
+	}
+	return added, removed, changed
+}
+
+// compareAttributes: fields that differ between the two versions of an object,
+// nested objects are compared field by field, sorted by attribute
+func compareAttributes(fromObj, toObj map[string]any) []AttributeDiff {
+	fromFields := map[string]any{}
+	toFields := map[string]any{}
+	flattenObject("", normaliseObject(fromObj), fromFields)
+	flattenObject("", normaliseObject(toObj), toFields)
+
+	changes := []AttributeDiff{}
+	for attr, toValue := range toFields {
+		if fromValue := fromFields[attr]; !reflect.DeepEqual(fromValue, toValue) {
+			changes = append(changes, AttributeDiff{Attribute: attr, From: fromValue, To: toValue})
+		}
+	}
+	for attr, fromValue := range fromFields {
+		if _, exists := toFields[attr]; !exists {
+			changes = append(changes, AttributeDiff{Attribute: attr, From: fromValue, To: nil})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Attribute < changes[j].Attribute })
+	return changes
+}
+
+// normaliseObject: converts primitive.A and similar types, without the fields set by the API
+func normaliseObject(obj map[string]any) map[string]any {
+	var normalised map[string]any
+	bytes, _ := json.Marshal(obj)
+	json.Unmarshal(bytes, &normalised)
+	for _, field := range snapshotIgnoredFields {
+		delete(normalised, field)
+	}
+	return normalised
+}
+
+func flattenObject(prefix string, obj map[string]any, fields map[string]any) {
+	for key, value := range obj {
+		if nested, isMap := value.(map[string]any); isMap {
+			flattenObject(prefix+key+".", nested, fields)
+		} else {
+			fields[prefix+key] = value
+		}
+	}
+}
+
+// SCHEDULES
+// CreateSnapshotSchedule: the user must be able to modify the root,
+// or manage the root domain for the tenant
+func CreateSnapshotSchedule(schedule SnapshotSchedule, userRoles map[string]Role) (*SnapshotSchedule, *u.Error) {
+	if err := validateSnapshotName(schedule.Name); err != nil {
+		return nil, err
+	}
+	if interval, err := time.ParseDuration(schedule.Interval); err != nil || interval < time.Minute {
+		return nil, &u.Error{Type: u.ErrBadFormat,
+			Message: "interval must be a duration of at least 1m, e.g. 24h"}
+	}
+	if schedule.Keep < 0 {
+		return nil, &u.Error{Type: u.ErrBadFormat, Message: "keep cannot be negative"}
+	}
+
+	domain, err := getSnapshotRootDomain(schedule.Root, userRoles)
+	if err != nil {
+		return nil, err
+	}
+	if snapshotPermission(userRoles, schedule.Root, domain) < WRITE {
+		return nil, &u.Error{Type: u.ErrUnauthorized,
+			Message: "User does not have permission to schedule snapshots of " +
+				describeSnapshotRoot(schedule.Root)}
+	}
+
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	if count, err := repository.GetDB().Collection(SNAPSHOT_SCHEDULE).CountDocuments(ctx,
+		bson.M{"name": schedule.Name}); err != nil {
+		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	} else if count > 0 {
+		return nil, &u.Error{Type: u.ErrDuplicate, Message: "Snapshot schedule " + schedule.Name + " already exists"}
+	}
+
+	schedule.Id = ""
+	schedule.Domain = domain
+	schedule.LastRun = nil
+	result, mongoErr := repository.GetDB().Collection(SNAPSHOT_SCHEDULE).InsertOne(ctx, schedule)
+	if mongoErr != nil {
+		return nil, &u.Error{Type: u.ErrDBError, Message: mongoErr.Error()}
+	}
+	schedule.Id = result.InsertedID.(primitive.ObjectID).Hex()
+
+	return &schedule, nil
+}
+
+// GetSnapshotSchedules: schedules of the tenant and of the objects the user can see
+func GetSnapshotSchedules(userRoles map[string]Role) ([]SnapshotSchedule, *u.Error) {
+	schedules, err := getSnapshotSchedules(bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	visible := []SnapshotSchedule{}
+	for _, schedule := range schedules {
+		if schedule.Root == "" || snapshotPermission(userRoles, schedule.Root, schedule.Domain) >= READONLYNAME {
+			visible = append(visible, schedule)
+		}
+	}
+	return visible, nil
+}
+
+func getSnapshotSchedules(filter bson.M) ([]SnapshotSchedule, *u.Error) {
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	schedules := []SnapshotSchedule{}
+	cursor, err := repository.GetDB().Collection(SNAPSHOT_SCHEDULE).Find(ctx, filter)
+	if err != nil {
+		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	} else if err = cursor.All(ctx, &schedules); err != nil {
+		return nil, &u.Error{Type: u.ErrInternal, Message: err.Error()}
+	}
+	return schedules, nil
+}
+
+// DeleteSnapshotSchedule: the snapshots already taken are kept
+func DeleteSnapshotSchedule(id string, userRoles map[string]Role) *u.Error {
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return &u.Error{Type: u.ErrNotFound, Message: "Snapshot schedule not found"}
+	}
+
+	schedules, uErr := getSnapshotSchedules(bson.M{"_id": objId})
+	if uErr != nil {
+		return uErr
+	} else if len(schedules) == 0 {
+		return &u.Error{Type: u.ErrNotFound, Message: "Snapshot schedule not found"}
+	}
+	if snapshotPermission(userRoles, schedules[0].Root, schedules[0].Domain) < WRITE {
+		return &u.Error{Type: u.ErrUnauthorized,
+			Message: "User does not have permission to delete this snapshot schedule"}
+	}
+
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	if _, err := repository.GetDB().Collection(SNAPSHOT_SCHEDULE).DeleteOne(ctx, bson.M{"_id": objId}); err != nil {
+		return &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
+	return nil
+}
+
+// RunSnapshotSchedules: takes the snapshots of the schedules whose interval
+// has elapsed since their last run and removes their oldest snapshots
+// beyond keep. Returns the snapshots taken
+func RunSnapshotSchedules(now time.Time) ([]Snapshot, *u.Error) {
+	schedules, err := getSnapshotSchedules(bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	taken := []Snapshot{}
+	for _, schedule := range schedules {
+		interval, _ := time.ParseDuration(schedule.Interval)
+		if schedule.LastRun != nil && now.Before(schedule.LastRun.Add(interval)) {
+			continue
+		}
+
+		snapshot, err := takeSnapshot(Snapshot{
+			Name:     schedule.Name + "-" + now.UTC().Format("20060102T150405"),
+			Root:     schedule.Root,
+			Domain:   schedule.Domain,
+			Schedule: schedule.Name,
+		})
+		if err != nil {
+			return taken, err
+		}
+		taken = append(taken, *snapshot)
+
+		if err := setSnapshotScheduleLastRun(schedule.Id, now); err != nil {
+			return taken, err
+		}
+		if err := pruneScheduledSnapshots(schedule); err != nil {
+			return taken, err
+		}
+	}
+
+	return taken, nil
+}
+
+func setSnapshotScheduleLastRun(id string, lastRun time.Time) *u.Error {
+	objId, _ := primitive.ObjectIDFromHex(id)
+
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	_, err := repository.GetDB().Collection(SNAPSHOT_SCHEDULE).UpdateOne(ctx,
+		bson.M{"_id": objId}, bson.M{"$set": bson.M{"lastRun": lastRun}})
+	if err != nil {
+		return &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
+	return nil
+}
+
+// pruneScheduledSnapshots: deletes the snapshots of the schedule beyond the last keep ones
+func pruneScheduledSnapshots(schedule SnapshotSchedule) *u.Error {
+	if schedule.Keep == 0 {
+		return nil
+	}
+	snapshots, err := getSnapshots(bson.M{"schedule": schedule.Name})
+	if err != nil || len(snapshots) <= schedule.Keep {
+		return err
+	}
+
+	names := []string{}
+	for _, snapshot := range snapshots[schedule.Keep:] {
+		names = append(names, snapshot.Name)
+	}
+	return deleteSnapshots(names)
+}
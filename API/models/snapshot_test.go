@@ -0,0 +1,134 @@
+package models_test
+
+import (
+	"p3/models"
+	"p3/test/integration"
+	u "p3/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotKeepsObjectsAsTheyWere(t *testing.T) {
+	integration.RequireCreateSite("snapshot-site")
+	integration.RequireCreateBuilding("snapshot-site", "building")
+	integration.RequireCreateRoom("snapshot-site.building", "room")
+	integration.RequireCreateRack("snapshot-site.building.room", "rack")
+
+	snapshot, err := models.TakeSnapshot("snapshot-before", "snapshot-site.building.room", integration.ManagerUserRoles)
+	require.Nil(t, err)
+	assert.Equal(t, 2, snapshot.ObjectCount)
+
+	_, _, err = models.UpdateObject(u.EntityToString(u.RACK), "snapshot-site.building.room.rack",
+		map[string]any{"description": "changed"}, true, integration.ManagerUserRoles, false)
+	require.Nil(t, err)
+
+	resolved, err := models.ResolveSnapshot("snapshot-before", "snapshot-site.building.room.rack")
+	require.Nil(t, err)
+	rack, err := models.GetSnapshotObjectById(resolved.Name, "snapshot-site.building.room.rack",
+		u.EntityToString(u.RACK), integration.ManagerUserRoles)
+	require.Nil(t, err)
+	assert.NotEqual(t, "changed", rack["description"])
+
+	children, err := models.GetSnapshotHierarchy(resolved.Name, "snapshot-site.building.room", 1,
+		integration.ManagerUserRoles)
+	require.Nil(t, err)
+	require.Len(t, children, 1)
+	assert.Equal(t, "snapshot-site.building.room.rack", children[0]["id"])
+}
+
+func TestResolveSnapshotByDate(t *testing.T) {
+	integration.RequireCreateSite("snapshot-date-site")
+
+	_, err := models.TakeSnapshot("snapshot-date", "snapshot-date-site", integration.ManagerUserRoles)
+	require.Nil(t, err)
+
+	snapshot, err := models.ResolveSnapshot(time.Now().Format("2006-01-02"), "snapshot-date-site")
+	require.Nil(t, err)
+	assert.Equal(t, "snapshot-date", snapshot.Name)
+
+	_, err = models.ResolveSnapshot("2000-01-01", "snapshot-date-site")
+	require.NotNil(t, err)
+	assert.Equal(t, u.ErrNotFound, err.Type)
+
+	// the snapshot does not contain other sites
+	_, err = models.ResolveSnapshot("snapshot-date", "snapshot-site")
+	require.NotNil(t, err)
+	assert.Equal(t, u.ErrNotFound, err.Type)
+}
+
+func TestDiffObjectsListsAddedRemovedAndChangedObjects(t *testing.T) {
+	integration.RequireCreateSite("diff-site")
+	integration.RequireCreateBuilding("diff-site", "building")
+	integration.RequireCreateRoom("diff-site.building", "room")
+	integration.RequireCreateRack("diff-site.building.room", "rack1")
+	integration.RequireCreateRack("diff-site.building.room", "rack2")
+
+	_, err := models.TakeSnapshot("diff-before", "diff-site.building.room", integration.ManagerUserRoles)
+	require.Nil(t, err)
+
+	_, _, err = models.UpdateObject(u.EntityToString(u.RACK), "diff-site.building.room.rack1",
+		map[string]any{"attributes": map[string]any{"height": 50}}, true, integration.ManagerUserRoles, false)
+	require.Nil(t, err)
+	_, err = models.DeleteHierarchicalObject(u.EntityToString(u.RACK), "diff-site.building.room.rack2",
+		integration.ManagerUserRoles)
+	require.Nil(t, err)
+	integration.RequireCreateRack("diff-site.building.room", "rack3")
+
+	diff, err := models.DiffObjects("diff-before", "", "diff-site.building.room", integration.ManagerUserRoles)
+	require.Nil(t, err)
+	assert.Equal(t, []models.ObjectDiff{{Id: "diff-site.building.room.rack3", Category: "rack"}}, diff.Added)
+	assert.Equal(t, []models.ObjectDiff{{Id: "diff-site.building.room.rack2", Category: "rack"}}, diff.Removed)
+	require.Len(t, diff.Changed, 1)
+	assert.Equal(t, "diff-site.building.room.rack1", diff.Changed[0].Id)
+	assert.Contains(t, diff.Changed[0].Changes,
+		models.AttributeDiff{Attribute: "attributes.height", From: 47.0, To: 50.0})
+}
+
+func TestTakeSnapshotWithDuplicatedNameReturnsError(t *testing.T) {
+	integration.RequireCreateSite("snapshot-duplicate-site")
+
+	_, err := models.TakeSnapshot("snapshot-duplicate", "snapshot-duplicate-site", integration.ManagerUserRoles)
+	require.Nil(t, err)
+	_, err = models.TakeSnapshot("snapshot-duplicate", "snapshot-duplicate-site", integration.ManagerUserRoles)
+	require.NotNil(t, err)
+	assert.Equal(t, u.ErrDuplicate, err.Type)
+}
+
+func TestRunSnapshotSchedulesTakesDueSnapshotsAndKeepsTheLastOnes(t *testing.T) {
+	integration.RequireCreateSite("snapshot-schedule-site")
+
+	schedule, err := models.CreateSnapshotSchedule(models.SnapshotSchedule{
+		Name: "snapshot-schedule", Root: "snapshot-schedule-site", Interval: "1h", Keep: 1,
+	}, integration.ManagerUserRoles)
+	require.Nil(t, err)
+	defer models.DeleteSnapshotSchedule(schedule.Id, integration.ManagerUserRoles)
+
+	now := time.Now()
+	taken, err := models.RunSnapshotSchedules(now)
+	require.Nil(t, err)
+	assert.Contains(t, snapshotNames(taken), "snapshot-schedule-"+now.UTC().Format("20060102T150405"))
+
+	// not due yet
+	taken, err = models.RunSnapshotSchedules(now.Add(time.Minute))
+	require.Nil(t, err)
+	assert.NotContains(t, snapshotNames(taken), "snapshot-schedule-"+now.Add(time.Minute).UTC().Format("20060102T150405"))
+
+	later := now.Add(time.Hour)
+	_, err = models.RunSnapshotSchedules(later)
+	require.Nil(t, err)
+	_, err = models.GetSnapshot("snapshot-schedule-"+now.UTC().Format("20060102T150405"), integration.ManagerUserRoles)
+	assert.NotNil(t, err)
+	_, err = models.GetSnapshot("snapshot-schedule-"+later.UTC().Format("20060102T150405"), integration.ManagerUserRoles)
+	assert.Nil(t, err)
+}
+
+func snapshotNames(snapshots []models.Snapshot) []string {
+	names := []string{}
+	for _, snapshot := range snapshots {
+		names = append(names, snapshot.Name)
+	}
+	return names
+}
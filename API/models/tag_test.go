@@ -88,7 +88,7 @@ func TestRemoveTagFromObjectThatHasMultipleTags(t *testing.T) {
 }
 
 func TestUpdateTagNoExistentReturnsError(t *testing.T) {
-	_, err := models.UpdateObject(u.EntityToString(u.TAG), "update-tag", nil, false, nil, false)
+	_, _, err := models.UpdateObject(u.EntityToString(u.TAG), "update-tag", nil, false, nil, false)
 	assert.NotNil(t, err)
 	assert.Equal(t, "Nothing matches this request", err.Message)
 }
@@ -97,7 +97,7 @@ func TestUpdateTagNotPresentInAnyObjectWorks(t *testing.T) {
 	err := createTag("update-tag-1")
 	require.Nil(t, err)
 
-	updatedTag, err := models.UpdateObject(
+	updatedTag, _, err := models.UpdateObject(
 		u.EntityToString(u.TAG),
 		"update-tag-1",
 		map[string]any{
@@ -121,7 +121,7 @@ func TestUpdateTagPresentInOneObjectUpdatesItInList(t *testing.T) {
 	err = createSite("update-tag-2-site", []any{"update-tag-2", "update-tag-3"})
 	require.Nil(t, err)
 
-	updatedTag, err := models.UpdateObject(
+	updatedTag, _, err := models.UpdateObject(
 		u.EntityToString(u.TAG),
 		"update-tag-2",
 		map[string]any{
@@ -241,7 +241,7 @@ func TestUpdateTagWithImageWorks(t *testing.T) {
 	err := createTagWithImage("update-tag-4", image)
 	assert.Nil(t, err)
 
-	updatedTag, err := models.UpdateObject(
+	updatedTag, _, err := models.UpdateObject(
 		u.EntityToString(u.TAG),
 		"update-tag-4",
 		map[string]any{
@@ -266,7 +266,7 @@ func TestUpdateSetEmptyImageRemovesOldImage(t *testing.T) {
 	assert.True(t, imagePresent)
 	assert.NotEmpty(t, tagImage)
 
-	updatedTag, err := models.UpdateObject(
+	updatedTag, _, err := models.UpdateObject(
 		u.EntityToString(u.TAG),
 		"update-tag-5",
 		map[string]any{
@@ -305,7 +305,7 @@ func TestUpdateWithNewImageRemovesOldImage(t *testing.T) {
 	assert.True(t, imagePresent)
 	assert.NotEmpty(t, tagOldImage)
 
-	updatedTag, err := models.UpdateObject(
+	updatedTag, _, err := models.UpdateObject(
 		u.EntityToString(u.TAG),
 		"update-tag-6",
 		map[string]any{
@@ -445,7 +445,7 @@ func TestUpdateObjectWithTagsThatReturnsError(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err = models.UpdateObject(u.EntityToString(u.SITE), "update-object-tags-1", map[string]any{
+			_, _, err = models.UpdateObject(u.EntityToString(u.SITE), "update-object-tags-1", map[string]any{
 				"tags": []any{"not-exists"},
 			}, tt.isPatch, integration.ManagerUserRoles, false)
 			assert.NotNil(t, err)
@@ -534,7 +534,7 @@ func manageObjectTag(objectID string, tagSlug string, isAdd bool) (map[string]an
 	if !isAdd {
 		tagKey = "tags-"
 	}
-	return models.UpdateObject(
+	object, _, err := models.UpdateObject(
 		u.HIERARCHYOBJS_ENT,
 		objectID,
 		map[string]any{
@@ -544,6 +544,7 @@ func manageObjectTag(objectID string, tagSlug string, isAdd bool) (map[string]an
 		integration.ManagerUserRoles,
 		false,
 	)
+	return object, err
 }
 
 func addTagToObject(objectID string, tagSlug string) (map[string]any, *u.Error) {
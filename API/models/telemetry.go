@@ -0,0 +1,272 @@
+package models
+
+import (
+	"fmt"
+	"p3/repository"
+	u "p3/utils"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const defaultTelemetryPeriod = 24 * time.Hour
+
+// Maximum amount of points of a series when no step is given
+const telemetryMaxPoints = 1000
+
+// Sensor names are used as attribute names to store the latest value
+var telemetrySensorRegex = regexp.MustCompile(`^[\w-]+$`)
+
+type TelemetryReading struct {
+	ObjectId  string     `json:"objectId"`
+	Sensor    string     `json:"sensor"`
+	Value     *float64   `json:"value"`
+	Timestamp *time.Time `json:"timestamp"` // now if not given
+}
+
+type telemetryMeta struct {
+	ObjectId string `bson:"objectId"`
+	Sensor   string `bson:"sensor"`
+}
+
+type telemetryDocument struct {
+	Meta      telemetryMeta `bson:"meta"`
+	Timestamp time.Time     `bson:"timestamp"`
+	Value     float64       `bson:"value"`
+}
+
+type TelemetryPoint struct {
+	Timestamp time.Time `json:"timestamp" bson:"timestamp"`
+	Avg       float64   `json:"avg" bson:"avg"`
+	Min       float64   `json:"min" bson:"min"`
+	Max       float64   `json:"max" bson:"max"`
+	Count     int       `json:"count" bson:"count"`
+}
+
+type telemetryPointDocument struct {
+	Sensor         string `bson:"sensor"`
+	TelemetryPoint `bson:",inline"`
+}
+
+type TelemetrySeries struct {
+	ObjectId string                      `json:"objectId"`
+	From     time.Time                   `json:"from"`
+	To       time.Time                   `json:"to"`
+	Step     string                      `json:"step"`
+	Series   map[string][]TelemetryPoint `json:"series"`
+}
+
+type TelemetryQuery struct {
+	From   string `schema:"from"` // RFC3339, 24h before to by default
+	To     string `schema:"to"`   // RFC3339, now by default
+	Step   string `schema:"step"` // duration, e.g. 5m
+	Sensor string `schema:"sensor"`
+}
+
+// CreateTelemetryReadings: stores the readings and updates the latest value
+// of each sensor in the attribute telemetry of the object
+// (e.g. attributes.telemetry.temperature.value). The user must be able to modify the objects.
+// The objects whose latest values were updated are returned, to be notified.
+func CreateTelemetryReadings(readings []TelemetryReading, userRoles map[string]Role) (int, []ReferenceChange, *u.Error) {
+	if len(readings) == 0 {
+		return 0, nil, &u.Error{Type: u.ErrBadFormat, Message: "At least one reading must be given"}
+	}
+
+	now := time.Now()
+	documents := []any{}
+	latest := map[telemetryMeta]telemetryDocument{}
+	objectEntities := map[string]string{}
+	for i, reading := range readings {
+		if err := validateTelemetryReading(reading); err != nil {
+			err.Message = fmt.Sprintf("Reading %d: %s", i, err.Message)
+			return 0, nil, err
+		}
+
+		if _, checked := objectEntities[reading.ObjectId]; !checked {
+			entityStr, err := getTelemetryObjectEntity(reading.ObjectId, userRoles, WRITE)
+			if err != nil {
+				return 0, nil, err
+			}
+			objectEntities[reading.ObjectId] = entityStr
+		}
+
+		document := telemetryDocument{
+			Meta:      telemetryMeta{ObjectId: reading.ObjectId, Sensor: reading.Sensor},
+			Timestamp: now,
+			Value:     *reading.Value,
+		}
+		if reading.Timestamp != nil {
+			document.Timestamp = *reading.Timestamp
+		}
+		documents = append(documents, document)
+
+		if previous, ok := latest[document.Meta]; !ok || !document.Timestamp.Before(previous.Timestamp) {
+			latest[document.Meta] = document
+		}
+	}
+
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	if err := repository.CreateTelemetryReadings(ctx, documents); err != nil {
+		return 0, nil, err
+	}
+
+	updated := map[string]ReferenceChange{}
+	for meta, document := range latest {
+		entityStr := objectEntities[meta.ObjectId]
+		object, err := repository.UpdateLatestTelemetry(ctx, entityStr, meta.ObjectId,
+			meta.Sensor, document.Value, document.Timestamp)
+		if err != nil {
+			return 0, nil, err
+		} else if object != nil {
+			// the last update of the object holds all its sensors
+			updated[meta.ObjectId] = ReferenceChange{Category: entityStr, Id: meta.ObjectId, Object: fixID(object)}
+		}
+	}
+
+	changes := []ReferenceChange{}
+	for _, change := range updated {
+		changes = append(changes, change)
+	}
+	return len(documents), changes, nil
+}
+
+func validateTelemetryReading(reading TelemetryReading) *u.Error {
+	if reading.ObjectId == "" {
+		return &u.Error{Type: u.ErrBadFormat, Message: "objectId is mandatory"}
+	} else if !telemetrySensorRegex.MatchString(reading.Sensor) {
+		return &u.Error{Type: u.ErrBadFormat,
+			Message: "sensor is mandatory and can only contain letters, numbers, - and _"}
+	} else if reading.Value == nil {
+		return &u.Error{Type: u.ErrBadFormat, Message: "value is mandatory"}
+	}
+	return nil
+}
+
+// getTelemetryObjectEntity: entity of the physical object with this id,
+// if the user has at least the permission given on it
+func getTelemetryObjectEntity(id string, userRoles map[string]Role, permission Permission) (string, *u.Error) {
+	for _, entityStr := range u.GetEntitiesById(u.Physical, id) {
+		object, err := repository.GetObject(bson.M{"id": id}, entityStr, u.RequestFilters{})
+		if err != nil {
+			if err.Type == u.ErrNotFound {
+				continue
+			}
+			return "", err
+		}
+
+		if CheckUserPermissionsWithObject(userRoles, u.EntityStrToInt(entityStr), object) < permission {
+			return "", &u.Error{Type: u.ErrUnauthorized,
+				Message: "User does not have permission to access the telemetry of " + id}
+		}
+		return entityStr, nil
+	}
+
+	return "", &u.Error{Type: u.ErrNotFound, Message: "Unable to find object " + id}
+}
+
+// GetTelemetrySeries: readings of the object between from and to, downsampled
+// by step. Each point gives the avg, min and max of the readings of its step.
+func GetTelemetrySeries(id string, query TelemetryQuery, userRoles map[string]Role) (*TelemetrySeries, *u.Error) {
+	from, to, step, err := parseTelemetryQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := getTelemetryObjectEntity(id, userRoles, READ); err != nil {
+		return nil, err
+	}
+
+	match := bson.M{
+		"meta.objectId": id,
+		"timestamp":     bson.M{"$gte": from, "$lt": to},
+	}
+	if query.Sensor != "" {
+		match["meta.sensor"] = query.Sensor
+	}
+
+	// points are aligned on from
+	millis := bson.M{"$toLong": "$timestamp"}
+	bucket := bson.M{"$toDate": bson.M{"$subtract": bson.A{
+		millis,
+		bson.M{"$mod": bson.A{bson.M{"$subtract": bson.A{millis, from.UnixMilli()}}, step.Milliseconds()}},
+	}}}
+
+	pipeline := bson.A{
+		bson.M{"$match": match},
+		bson.M{"$group": bson.M{
+			"_id":   bson.M{"sensor": "$meta.sensor", "timestamp": bucket},
+			"avg":   bson.M{"$avg": "$value"},
+			"min":   bson.M{"$min": "$value"},
+			"max":   bson.M{"$max": "$value"},
+			"count": bson.M{"$sum": 1},
+		}},
+		bson.M{"$sort": bson.D{{Key: "_id.sensor", Value: 1}, {Key: "_id.timestamp", Value: 1}}},
+		bson.M{"$project": bson.M{
+			"_id":       0,
+			"sensor":    "$_id.sensor",
+			"timestamp": "$_id.timestamp",
+			"avg":       1,
+			"min":       1,
+			"max":       1,
+			"count":     1,
+		}},
+	}
+
+	ctx, cancel := u.Connect()
+	defer cancel()
+	points, err := repository.AggregateTelemetry[telemetryPointDocument](ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	series := &TelemetrySeries{
+		ObjectId: id,
+		From:     from,
+		To:       to,
+		Step:     step.String(),
+		Series:   map[string][]TelemetryPoint{},
+	}
+	for _, point := range points {
+		series.Series[point.Sensor] = append(series.Series[point.Sensor], point.TelemetryPoint)
+	}
+
+	return series, nil
+}
+
+func parseTelemetryQuery(query TelemetryQuery) (time.Time, time.Time, time.Duration, *u.Error) {
+	var err error
+	to := time.Now()
+	if query.To != "" {
+		if to, err = time.Parse(time.RFC3339, query.To); err != nil {
+			return time.Time{}, time.Time{}, 0, &u.Error{Type: u.ErrBadFormat,
+				Message: "Invalid to, format must be RFC3339 (e.g. 2024-01-31T10:00:00Z)"}
+		}
+	}
+
+	from := to.Add(-defaultTelemetryPeriod)
+	if query.From != "" {
+		if from, err = time.Parse(time.RFC3339, query.From); err != nil {
+			return time.Time{}, time.Time{}, 0, &u.Error{Type: u.ErrBadFormat,
+				Message: "Invalid from, format must be RFC3339 (e.g. 2024-01-31T10:00:00Z)"}
+		}
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, 0, &u.Error{Type: u.ErrBadFormat, Message: "from must be before to"}
+	}
+
+	var step time.Duration
+	if query.Step != "" {
+		if step, err = time.ParseDuration(query.Step); err != nil || step < time.Second {
+			return time.Time{}, time.Time{}, 0, &u.Error{Type: u.ErrBadFormat,
+				Message: "Invalid step, it must be a duration of at least 1s (e.g. 30s, 5m, 1h)"}
+		}
+	} else {
+		step = max(to.Sub(from)/telemetryMaxPoints, time.Second).Round(time.Second)
+	}
+
+	return from, to, step, nil
+}
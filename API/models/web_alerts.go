@@ -3,39 +3,241 @@ package models
 import (
 	"p3/repository"
 	u "p3/utils"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type Alert struct {
 	Id       string `json:"id"`
-	Type     string `json:"type"`
+	Type     string `json:"type"` // same as severity, kept for the APP
 	Title    string `json:"title"`
 	Subtitle string `json:"subtitle"`
+	Severity string `json:"severity" bson:"severity"`
+	Status   string `json:"status" bson:"status"`
+	Assignee string `json:"assignee" bson:"assignee"` // email of the user in charge
+	// Ids of the affected objects and their domains, used for permissions
+	Objects        []string           `json:"objects" bson:"objects"`
+	Domains        []string           `json:"domains" bson:"domains"`
+	Comments       []AlertComment     `json:"comments" bson:"comments"`
+	History        []AlertChange      `json:"history" bson:"history"`
+	Rule           string             `json:"rule,omitempty" bson:"rule,omitempty"` // set on the alerts raised by an alert rule
+	RaisedAt       *time.Time         `json:"raisedAt,omitempty" bson:"raisedAt,omitempty"`
+	AcknowledgedAt *time.Time         `json:"acknowledgedAt,omitempty" bson:"acknowledgedAt,omitempty"`
+	ResolvedAt     *time.Time         `json:"resolvedAt,omitempty" bson:"resolvedAt,omitempty"`
+	LastUpdated    *time.Time         `json:"lastUpdated,omitempty" bson:"lastUpdated,omitempty"`
+	MongoId        primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+}
+
+type AlertComment struct {
+	Author string    `json:"author" bson:"author"`
+	Date   time.Time `json:"date" bson:"date"`
+	Text   string    `json:"text" bson:"text"`
+}
+
+// AlertChange: change of the status, severity or assignee of an alert
+type AlertChange struct {
+	Author string    `json:"author" bson:"author"`
+	Date   time.Time `json:"date" bson:"date"`
+	Field  string    `json:"field" bson:"field"`
+	Value  string    `json:"value" bson:"value"`
+}
+
+// AlertPatch: fields of an alert that can be modified, nil if unchanged
+type AlertPatch struct {
+	Status   *string `json:"status"`
+	Severity *string `json:"severity"`
+	Assignee *string `json:"assignee"` // empty to unassign
+	Comment  string  `json:"comment"`
+}
+
+type AlertFilters struct {
+	Status   string `schema:"status"` // all but the resolved alerts by default
+	Severity string `schema:"severity"`
+	Object   string `schema:"object"`
 }
 
 const WEB_ALERT = "web_alert"
 
+const (
+	AlertOpen         = "open"
+	AlertAcknowledged = "acknowledged"
+	AlertResolved     = "resolved"
+)
+
+var AlertStatuses = []string{AlertOpen, AlertAcknowledged, AlertResolved}
+
+// Author of the changes made by the alert rules engine
+const alertRulesAuthor = "alert rules"
+
+var notResolvedAlertFilter = bson.M{"status": bson.M{"$ne": AlertResolved}}
+
 // POST
+// AddAlert: stores the alert without checking permissions
 func AddAlert(newAlert Alert) *u.Error {
-	// Add the new alert
-	ctx, cancel := u.Connect()
-	_, err := repository.GetDB().Collection(WEB_ALERT).InsertOne(ctx, newAlert)
-	if err != nil {
-		println(err.Error())
-		return &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	if err := prepareAlert(&newAlert); err != nil {
+		return err
 	}
+	return insertAlert(newAlert)
+}
 
+// CreateAlert: stores the alert, the user must be able to modify its objects
+func CreateAlert(newAlert Alert, userRoles map[string]Role) (*Alert, *u.Error) {
+	if err := prepareAlert(&newAlert); err != nil {
+		return nil, err
+	}
+	if getAlertPermission(newAlert, userRoles) < WRITE {
+		return nil, &u.Error{Type: u.ErrUnauthorized,
+			Message: "User does not have permission to create an alert on these objects"}
+	}
+	if err := insertAlert(newAlert); err != nil {
+		return nil, err
+	}
+	return &newAlert, nil
+}
+
+// prepareAlert: validates the alert and sets its default values.
+// An alert created with the id of an object and without objects is linked to it.
+func prepareAlert(alert *Alert) *u.Error {
+	if alert.Severity == "" {
+		alert.Severity = alert.Type
+	}
+	if alert.Severity == "" {
+		alert.Severity = "minor"
+	}
+	if !u.StrSliceContains(AlertSeverities, alert.Severity) {
+		return &u.Error{Type: u.ErrBadFormat, Message: "severity must be one of: info, minor, major, critical"}
+	}
+	alert.Type = alert.Severity
+
+	if alert.Status == "" {
+		alert.Status = AlertOpen
+	} else if !u.StrSliceContains(AlertStatuses, alert.Status) {
+		return &u.Error{Type: u.ErrBadFormat, Message: "status must be one of: open, acknowledged, resolved"}
+	}
+
+	if alert.Id == "" {
+		alert.Id = primitive.NewObjectID().Hex()
+	} else if len(alert.Objects) == 0 {
+		if domain, err := getAlertObjectDomain(alert.Id); err == nil {
+			alert.Objects = []string{alert.Id}
+			alert.Domains = []string{domain}
+		}
+	}
+
+	if len(alert.Domains) == 0 {
+		alert.Domains = []string{}
+		for _, objectId := range alert.Objects {
+			domain, err := getAlertObjectDomain(objectId)
+			if err != nil {
+				return err
+			}
+			if !u.StrSliceContains(alert.Domains, domain) {
+				alert.Domains = append(alert.Domains, domain)
+			}
+		}
+	}
+	if alert.Objects == nil {
+		alert.Objects = []string{}
+	}
+	if alert.Comments == nil {
+		alert.Comments = []AlertComment{}
+	}
+	if alert.History == nil {
+		alert.History = []AlertChange{}
+	}
+
+	now := time.Now()
+	if alert.RaisedAt == nil {
+		alert.RaisedAt = &now
+	}
+	alert.LastUpdated = &now
+	alert.MongoId = primitive.NilObjectID
+
+	return nil
+}
+
+func insertAlert(alert Alert) *u.Error {
+	ctx, cancel := u.Connect()
 	defer cancel()
+
+	if _, err := repository.GetDB().Collection(WEB_ALERT).InsertOne(ctx, alert); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return &u.Error{Type: u.ErrConflict, Message: "Alert " + alert.Id + " already exists"}
+		}
+		return &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
 	return nil
 }
 
+// getAlertObjectDomain: domain of the object an alert is linked to
+func getAlertObjectDomain(id string) (string, *u.Error) {
+	for _, entityStr := range u.GetEntitiesById(u.Any, id) {
+		entity := u.EntityStrToInt(entityStr)
+		if !u.IsEntityHierarchical(entity) {
+			continue
+		}
+
+		object, err := repository.GetObject(bson.M{"id": id}, entityStr, u.RequestFilters{})
+		if err != nil {
+			if err.Type == u.ErrNotFound {
+				continue
+			}
+			return "", err
+		}
+		return getDomainFromObject(entity, object), nil
+	}
+
+	return "", &u.Error{Type: u.ErrNotFound, Message: "Unable to find object " + id}
+}
+
+// getAlertPermission: an alert has the highest permission the user has on
+// the domains of its objects. Alerts not linked to objects can be read by all
+// users and only modified by administrators.
+func getAlertPermission(alert Alert, userRoles map[string]Role) Permission {
+	if len(alert.Domains) == 0 {
+		if userRoles[ROOT_DOMAIN] == Manager {
+			return WRITE
+		}
+		return READ
+	}
+
+	permission := NONE
+	for _, domain := range alert.Domains {
+		permission = max(permission, CheckUserPermissions(userRoles, u.SITE, domain))
+	}
+	return permission
+}
+
 // GET
-func GetAlerts() ([]Alert, *u.Error) {
+func GetAlerts(filters AlertFilters, userRoles map[string]Role) ([]Alert, *u.Error) {
+	filter := bson.M{}
+	if filters.Status == "" {
+		filter["status"] = notResolvedAlertFilter["status"]
+	} else {
+		filter["status"] = filters.Status
+	}
+	if filters.Severity != "" {
+		filter["severity"] = filters.Severity
+	}
+	if filters.Object != "" {
+		filter["objects"] = filters.Object
+	}
+
+	// only the alerts on objects of the domains of the user
+	readableFilter := GetReadableObjectsFilter(userRoles, u.SITE)
+	if len(readableFilter) > 0 {
+		filter["$or"] = bson.A{
+			bson.M{"domains": readableFilter["domain"]},
+			bson.M{"domains": bson.M{"$in": bson.A{nil, bson.A{}}}},
+		}
+	}
+
 	results := []Alert{}
-	filter := bson.D{}
 	ctx, cancel := u.Connect()
+	defer cancel()
 	cursor, err := repository.GetDB().Collection(WEB_ALERT).Find(ctx, filter)
 	if err != nil {
 		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
@@ -43,14 +245,14 @@ func GetAlerts() ([]Alert, *u.Error) {
 		return nil, &u.Error{Type: u.ErrInternal, Message: err.Error()}
 	}
 
-	defer cancel()
 	return results, nil
 }
 
-func GetAlert(id string) (Alert, *u.Error) {
+func GetAlert(id string, userRoles map[string]Role) (Alert, *u.Error) {
 	alert := &Alert{}
 	filter := bson.M{"id": id}
 	ctx, cancel := u.Connect()
+	defer cancel()
 	err := repository.GetDB().Collection(WEB_ALERT).FindOne(ctx, filter).Decode(alert)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
@@ -59,21 +261,158 @@ func GetAlert(id string) (Alert, *u.Error) {
 		return *alert, &u.Error{Type: u.ErrDBError, Message: err.Error()}
 	}
 
-	defer cancel()
+	if getAlertPermission(*alert, userRoles) < READ {
+		return Alert{}, &u.Error{Type: u.ErrNotFound, Message: "Alert does not exist"}
+	}
+
 	return *alert, nil
 }
 
-// DELETE
-func DeleteAlert(alertId string) *u.Error {
+// PATCH
+// UpdateAlert: changes the status, severity or assignee of the alert and
+// adds a comment. Each change is kept in the history of the alert.
+func UpdateAlert(id string, patch AlertPatch, author string, userRoles map[string]Role) (*Alert, *u.Error) {
+	alert, err := GetAlert(id, userRoles)
+	if err != nil {
+		return nil, err
+	}
+	if getAlertPermission(alert, userRoles) < WRITE {
+		return nil, &u.Error{Type: u.ErrUnauthorized,
+			Message: "User does not have permission to modify this alert"}
+	}
+
+	now := time.Now()
+	changed := false
+	if patch.Status != nil && *patch.Status != alert.Status {
+		if !u.StrSliceContains(AlertStatuses, *patch.Status) {
+			return nil, &u.Error{Type: u.ErrBadFormat, Message: "status must be one of: open, acknowledged, resolved"}
+		}
+		setAlertStatus(&alert, *patch.Status, now)
+		alert.History = append(alert.History, AlertChange{Author: author, Date: now, Field: "status", Value: alert.Status})
+		changed = true
+	}
+	if patch.Severity != nil && *patch.Severity != alert.Severity {
+		if !u.StrSliceContains(AlertSeverities, *patch.Severity) {
+			return nil, &u.Error{Type: u.ErrBadFormat, Message: "severity must be one of: info, minor, major, critical"}
+		}
+		alert.Severity = *patch.Severity
+		alert.Type = alert.Severity
+		alert.History = append(alert.History, AlertChange{Author: author, Date: now, Field: "severity", Value: alert.Severity})
+		changed = true
+	}
+	if patch.Assignee != nil && *patch.Assignee != alert.Assignee {
+		if *patch.Assignee != "" && GetUserByEmail(*patch.Assignee) == nil {
+			return nil, &u.Error{Type: u.ErrBadFormat, Message: "Unable to find user " + *patch.Assignee}
+		}
+		alert.Assignee = *patch.Assignee
+		alert.History = append(alert.History, AlertChange{Author: author, Date: now, Field: "assignee", Value: alert.Assignee})
+		changed = true
+	}
+	if patch.Comment != "" {
+		alert.Comments = append(alert.Comments, AlertComment{Author: author, Date: now, Text: patch.Comment})
+		changed = true
+	}
+
+	if !changed {
+		return nil, &u.Error{Type: u.ErrBadFormat,
+			Message: "Nothing to update, status, severity, assignee or comment must be given"}
+	}
+
+	alert.LastUpdated = &now
+	if err := replaceAlert(alert); err != nil {
+		return nil, err
+	}
+
+	return &alert, nil
+}
+
+func setAlertStatus(alert *Alert, status string, date time.Time) {
+	alert.Status = status
+	switch status {
+	case AlertOpen:
+		alert.AcknowledgedAt = nil
+		alert.ResolvedAt = nil
+	case AlertAcknowledged:
+		alert.AcknowledgedAt = &date
+		alert.ResolvedAt = nil
+	case AlertResolved:
+		alert.ResolvedAt = &date
+	}
+}
+
+func replaceAlert(alert Alert) *u.Error {
 	ctx, cancel := u.Connect()
-	res, err := repository.GetDB().Collection(WEB_ALERT).DeleteOne(ctx, bson.M{"id": alertId})
 	defer cancel()
 
+	_, err := repository.GetDB().Collection(WEB_ALERT).ReplaceOne(ctx, bson.M{"_id": alert.MongoId}, alert)
 	if err != nil {
 		return &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
+	return nil
+}
+
+// DELETE
+func DeleteAlert(alertId string, userRoles map[string]Role) *u.Error {
+	alert, err := GetAlert(alertId, userRoles)
+	if err != nil {
+		if err.Type == u.ErrNotFound {
+			err.Message = "Alert not found"
+		}
+		return err
+	}
+	if getAlertPermission(alert, userRoles) < WRITE {
+		return &u.Error{Type: u.ErrUnauthorized,
+			Message: "User does not have permission to delete this alert"}
+	}
+
+	ctx, cancel := u.Connect()
+	res, mongoErr := repository.GetDB().Collection(WEB_ALERT).DeleteOne(ctx, bson.M{"_id": alert.MongoId})
+	defer cancel()
+
+	if mongoErr != nil {
+		return &u.Error{Type: u.ErrDBError, Message: mongoErr.Error()}
 	} else if res.DeletedCount <= 0 {
 		return &u.Error{Type: u.ErrNotFound,
 			Message: "Alert not found"}
 	}
 	return nil
 }
+
+// getNotResolvedAlertsOfRule: alerts raised by the rule that are open or acknowledged
+func getNotResolvedAlertsOfRule(ruleId string) ([]Alert, *u.Error) {
+	results := []Alert{}
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	filter := bson.M{"rule": ruleId, "status": notResolvedAlertFilter["status"]}
+	cursor, err := repository.GetDB().Collection(WEB_ALERT).Find(ctx, filter)
+	if err != nil {
+		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	} else if err = cursor.All(ctx, &results); err != nil {
+		return nil, &u.Error{Type: u.ErrInternal, Message: err.Error()}
+	}
+
+	return results, nil
+}
+
+// resolveAlert: resolves an alert raised by a rule
+func resolveAlert(alert Alert) (*Alert, *u.Error) {
+	now := time.Now()
+	setAlertStatus(&alert, AlertResolved, now)
+	alert.History = append(alert.History, AlertChange{Author: alertRulesAuthor, Date: now,
+		Field: "status", Value: AlertResolved})
+	alert.LastUpdated = &now
+
+	if err := replaceAlert(alert); err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// alertRuleObjectId: the object an alert raised by a rule is about
+func alertRuleObjectId(alert Alert) string {
+	if len(alert.Objects) == 0 {
+		return ""
+	}
+	return alert.Objects[0]
+}
@@ -0,0 +1,250 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	u "p3/utils"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Value of the cells of the attributes an object does not have, as in the APP
+const projectResultMissingValue = "-"
+
+type ProjectResultRow struct {
+	Object string         `json:"object"`
+	Values map[string]any `json:"values"` // attribute: value, only the attributes the object has
+}
+
+// ProjectResult is the table of a project: the value of each attribute
+// of the project for each object of the project, and their sum and average
+type ProjectResult struct {
+	Name       string             `json:"name"`
+	Namespace  string             `json:"namespace"`
+	DateRange  string             `json:"dateRange"`
+	Attributes []string           `json:"attributes"`
+	Rows       []ProjectResultRow `json:"rows"`
+	Sum        map[string]float64 `json:"sum,omitempty"` // only if showSum, for numeric attributes
+	Avg        map[string]float64 `json:"avg,omitempty"` // only if showAvg, for numeric attributes
+}
+
+// GetProjectResult: computes the table of the project as the APP does.
+// The objects the user cannot read, that no longer exist, that are not in the namespace
+// of the project or that were not updated in its date range have no values.
+// If the project has no attributes, all the attributes of its objects are used.
+func GetProjectResult(projectId, userEmail string, userRoles map[string]Role) (*ProjectResult, *u.Error) {
+	project, err := GetProject(projectId, userEmail, userRoles)
+	if err != nil {
+		return nil, err
+	}
+
+	filters, err := getProjectDateFilters(project.DateRange)
+	if err != nil {
+		return nil, err
+	}
+	namespace := getProjectNamespace(project.Namespace)
+
+	result := &ProjectResult{
+		Name:       project.Name,
+		Namespace:  project.Namespace,
+		DateRange:  project.DateRange,
+		Attributes: project.Attributes,
+		Rows:       []ProjectResultRow{},
+	}
+
+	allAttributes := map[string]bool{}
+	for _, objectId := range project.Objects {
+		attributes, err := getProjectObjectAttributes(objectId, namespace, filters, userRoles)
+		if err != nil {
+			return nil, err
+		}
+		for attribute := range attributes {
+			allAttributes[attribute] = true
+		}
+		result.Rows = append(result.Rows, ProjectResultRow{Object: objectId, Values: attributes})
+	}
+
+	if len(result.Attributes) == 0 {
+		result.Attributes = []string{}
+		for attribute := range allAttributes {
+			result.Attributes = append(result.Attributes, attribute)
+		}
+		sort.Slice(result.Attributes, func(i, j int) bool {
+			return strings.ToLower(result.Attributes[i]) < strings.ToLower(result.Attributes[j])
+		})
+	}
+
+	// keep only the attributes of the project
+	for _, row := range result.Rows {
+		for attribute := range row.Values {
+			if !slices.Contains(result.Attributes, attribute) {
+				delete(row.Values, attribute)
+			}
+		}
+	}
+
+	sum, avg := computeProjectResultSumAndAvg(result)
+	if project.ShowSum {
+		result.Sum = sum
+	}
+	if project.ShowAvg {
+		result.Avg = avg
+	}
+
+	return result, nil
+}
+
+// getProjectDateFilters: filters on the last update of the objects given by the date range
+// of the project, as set by the APP: dd/mm/yyyy - dd/mm/yyyy, the end being optional
+func getProjectDateFilters(dateRange string) (u.RequestFilters, *u.Error) {
+	filters := u.RequestFilters{}
+	if strings.TrimSpace(dateRange) == "" {
+		return filters, nil
+	}
+
+	dates := strings.Split(dateRange, "-")
+	if len(dates) > 2 {
+		return filters, &u.Error{Type: u.ErrBadFormat,
+			Message: "Invalid dateRange " + dateRange + ", it must be dd/mm/yyyy - dd/mm/yyyy"}
+	}
+	for i, date := range dates {
+		parsed, err := time.Parse("02/01/2006", strings.TrimSpace(date))
+		if err != nil {
+			return filters, &u.Error{Type: u.ErrBadFormat,
+				Message: "Invalid dateRange " + dateRange + ", it must be dd/mm/yyyy - dd/mm/yyyy"}
+		}
+		if i == 0 {
+			filters.StartDate = parsed.Format("2006-01-02")
+		} else {
+			filters.EndDate = parsed.Format("2006-01-02")
+		}
+	}
+	return filters, nil
+}
+
+// getProjectNamespace: namespace of the objects of the project, the APP capitalizes them
+// (e.g. Physical). Any namespace if it is not one of the API.
+func getProjectNamespace(namespace string) u.Namespace {
+	projectNamespace := u.Namespace(strings.ToLower(namespace))
+	if slices.Contains([]u.Namespace{u.Physical, u.PStray, u.PHierarchy, u.Organisational, u.Logical,
+		u.LObjTemplate, u.LBldgTemplate, u.LRoomTemplate, u.LTags, u.LLayers}, projectNamespace) {
+		return projectNamespace
+	}
+	return u.Any
+}
+
+// getProjectObjectAttributes: attributes of the object with this id, empty if it
+// does not exist in the namespace, does not match the filters or the user cannot read it
+func getProjectObjectAttributes(id string, namespace u.Namespace, filters u.RequestFilters, userRoles map[string]Role) (map[string]any, *u.Error) {
+	for _, entityStr := range u.GetEntitiesById(namespace, id) {
+		object, err := GetObject(bson.M{"id": id}, entityStr, filters, userRoles)
+		if err != nil {
+			if err.Type == u.ErrNotFound || err.Type == u.ErrUnauthorized {
+				continue
+			}
+			return nil, err
+		}
+
+		if attributes, ok := object["attributes"].(map[string]any); ok {
+			return attributes, nil
+		}
+		return map[string]any{}, nil
+	}
+
+	return map[string]any{}, nil
+}
+
+// computeProjectResultSumAndAvg: sum and average of each attribute,
+// only the numeric values are taken into account
+func computeProjectResultSumAndAvg(result *ProjectResult) (map[string]float64, map[string]float64) {
+	sum := map[string]float64{}
+	avg := map[string]float64{}
+	for _, attribute := range result.Attributes {
+		count := 0
+		for _, row := range result.Rows {
+			if value, isNumeric := projectValueToFloat(row.Values[attribute]); isNumeric {
+				sum[attribute] += value
+				count++
+			}
+		}
+		if count > 0 {
+			avg[attribute] = sum[attribute] / float64(count)
+		}
+	}
+	return sum, avg
+}
+
+func projectValueToFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// Table: the result as rows of cells, the first row being the header.
+// The sum and average rows are added at the end.
+func (result ProjectResult) Table() [][]string {
+	table := [][]string{append([]string{"Objects"}, result.Attributes...)}
+
+	for _, row := range result.Rows {
+		cells := []string{row.Object}
+		for _, attribute := range result.Attributes {
+			cells = append(cells, formatProjectValue(row.Values[attribute]))
+		}
+		table = append(table, cells)
+	}
+
+	for _, computed := range []struct {
+		name   string
+		values map[string]float64
+	}{{"Sum", result.Sum}, {"Average", result.Avg}} {
+		if computed.values == nil {
+			continue
+		}
+		cells := []string{computed.name}
+		for _, attribute := range result.Attributes {
+			if value, ok := computed.values[attribute]; ok {
+				cells = append(cells, strconv.FormatFloat(value, 'f', 2, 64))
+			} else {
+				cells = append(cells, projectResultMissingValue)
+			}
+		}
+		table = append(table, cells)
+	}
+
+	return table
+}
+
+func formatProjectValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return projectResultMissingValue
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int32, int64, int, bool:
+		return fmt.Sprint(v)
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
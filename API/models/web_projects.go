@@ -5,49 +5,155 @@ import (
 	"fmt"
 	"p3/repository"
 	u "p3/utils"
+	"slices"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 const WEB_PROJECTS = "web_project"
+const WEB_PROJECT_REVISIONS = "web_project_revision"
+
+type ProjectRole string
+
+const (
+	ProjectViewer ProjectRole = "viewer" // can see the project and its result
+	ProjectEditor ProjectRole = "editor" // can also modify and restore the project
+	ProjectOwner  ProjectRole = "owner"  // can also share and delete the project
+)
+
+var projectRoleLevels = map[ProjectRole]int{ProjectViewer: 1, ProjectEditor: 2, ProjectOwner: 3}
 
 // Project represents data about a recorded web project
 type Project struct {
-	Id          string   `bson:"_id,omitempty"`
-	Name        string   `json:"name" binding:"required"`
-	DateRange   string   `json:"dateRange" binding:"required"`
-	Namespace   string   `json:"namespace" binding:"required"`
-	Attributes  []string `json:"attributes" binding:"required"`
-	Objects     []string `json:"objects" binding:"required"`
-	Permissions []string `json:"permissions" binding:"required,dive,email"`
-	Author      string   `json:"authorLastUpdate" binding:"required"`
-	LastUpdate  string   `json:"lastUpdate" binding:"required"`
-	ShowAvg     bool     `json:"showAvg"`
-	ShowSum     bool     `json:"showSum"`
-	IsPublic    bool     `json:"isPublic"`
-	IsImpact    bool     `json:"isImpact"`
+	Id         string   `bson:"_id,omitempty"`
+	Name       string   `json:"name" binding:"required"`
+	DateRange  string   `json:"dateRange" binding:"required"`
+	Namespace  string   `json:"namespace" binding:"required"`
+	Attributes []string `json:"attributes" binding:"required"`
+	Objects    []string `json:"objects" binding:"required"`
+	// Users that can edit the project, they are its owners if it has no owner
+	Permissions []string       `json:"permissions" binding:"required,dive,email"`
+	Author      string         `json:"authorLastUpdate" binding:"required"`
+	LastUpdate  string         `json:"lastUpdate" binding:"required"`
+	ShowAvg     bool           `json:"showAvg"`
+	ShowSum     bool           `json:"showSum"`
+	IsPublic    bool           `json:"isPublic"` // everybody is a viewer
+	IsImpact    bool           `json:"isImpact"`
+	Owner       string         `json:"owner"` // email of the creator
+	Sharing     []ProjectShare `json:"sharing"`
+	Revision    int            `json:"revision"`
+	// Role of the user that requested the project
+	UserRole ProjectRole `json:"userRole,omitempty" bson:"-"`
+}
+
+// ProjectShare gives a role on a project to a user or to all the users of a domain
+type ProjectShare struct {
+	User   string      `json:"user,omitempty" bson:"user,omitempty"` // email
+	Domain string      `json:"domain,omitempty" bson:"domain,omitempty"`
+	Role   ProjectRole `json:"role" bson:"role"`
+}
+
+// ProjectRevision is a previous version of a project, saved each time it is modified
+type ProjectRevision struct {
+	ProjectId string    `json:"projectId" bson:"projectId"`
+	Revision  int       `json:"revision" bson:"revision"`
+	Date      time.Time `json:"date" bson:"date"`     // date it was replaced
+	Author    string    `json:"author" bson:"author"` // user that replaced it
+	Project   Project   `json:"project" bson:"project"`
+}
+
+// getUserRole: highest role given to the user by the project, empty if none
+func (project Project) getUserRole(userEmail string, userRoles map[string]Role) ProjectRole {
+	if project.Owner == userEmail {
+		return ProjectOwner
+	}
+
+	var role ProjectRole
+	if project.IsPublic {
+		role = ProjectViewer
+	}
+	if slices.Contains(project.Permissions, userEmail) {
+		if project.Owner == "" {
+			// projects created before roles existed
+			return ProjectOwner
+		}
+		role = maxProjectRole(role, ProjectEditor)
+	}
+	for _, share := range project.Sharing {
+		if (share.User != "" && share.User == userEmail) ||
+			(share.Domain != "" && isUserOfDomain(userRoles, share.Domain)) {
+			role = maxProjectRole(role, share.Role)
+		}
+	}
+	return role
+}
+
+func maxProjectRole(role1, role2 ProjectRole) ProjectRole {
+	if projectRoleLevels[role1] >= projectRoleLevels[role2] {
+		return role1
+	}
+	return role2
+}
+
+// isUserOfDomain: the user has a role on the domain, one of its parents or one of its children
+func isUserOfDomain(userRoles map[string]Role, domain string) bool {
+	if _, hasRootRole := userRoles[ROOT_DOMAIN]; hasRootRole || (domain == ROOT_DOMAIN && len(userRoles) > 0) {
+		return true
+	}
+	for userDomain := range userRoles {
+		if DomainIsEqualOrChild(userDomain, domain) || DomainIsEqualOrChild(domain, userDomain) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkProjectRole(project Project, userEmail string, userRoles map[string]Role, required ProjectRole) *u.Error {
+	if projectRoleLevels[project.getUserRole(userEmail, userRoles)] < projectRoleLevels[required] {
+		action := map[ProjectRole]string{ProjectViewer: "see", ProjectEditor: "modify", ProjectOwner: "manage"}[required]
+		return &u.Error{Type: u.ErrUnauthorized,
+			Message: "User does not have permission to " + action + " this project"}
+	}
+	return nil
+}
+
+func validateProjectSharing(sharing []ProjectShare) *u.Error {
+	for _, share := range sharing {
+		if _, ok := projectRoleLevels[share.Role]; !ok {
+			return &u.Error{Type: u.ErrBadFormat, Message: "Invalid project role " + string(share.Role) +
+				", must be one of: owner, editor, viewer"}
+		}
+		if (share.User == "") == (share.Domain == "") {
+			return &u.Error{Type: u.ErrBadFormat, Message: "A project can be shared with either a user or a domain"}
+		}
+		if share.Domain != "" && !CheckDomainExists(share.Domain) {
+			return &u.Error{Type: u.ErrBadFormat, Message: "Domain not found: " + share.Domain}
+		}
+	}
+	return nil
 }
 
 // PROJECTS
 // GET
-func GetProjectsByUserEmail(userEmail string) ([]Project, *u.Error) {
-	response := make(map[string]interface{})
-	response["projects"] = make([]interface{}, 0)
+// GetProjectsByUserEmail: projects on which the user has a role
+func GetProjectsByUserEmail(userEmail string, userRoles map[string]Role) ([]Project, *u.Error) {
 	println("Get projects for " + userEmail)
 
-	// Get projects with user permitted
+	// Get projects with user permitted, domain sharing is checked afterwards
 	results := []Project{}
-	filter := bson.D{
-		{Key: "$or",
-			Value: bson.A{
-				bson.D{{Key: "permissions", Value: userEmail}},
-				bson.D{{Key: "isPublic", Value: true}},
-			},
-		},
-	}
+	filter := bson.M{"$or": bson.A{
+		bson.M{"permissions": userEmail},
+		bson.M{"isPublic": true},
+		bson.M{"owner": userEmail},
+		bson.M{"sharing.user": userEmail},
+		bson.M{"sharing.domain": bson.M{"$exists": true}},
+	}}
 	ctx, cancel := u.Connect()
+	defer cancel()
 	cursor, err := repository.GetDB().Collection(WEB_PROJECTS).Find(ctx, filter)
 	if err != nil {
 		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
@@ -55,58 +161,258 @@ func GetProjectsByUserEmail(userEmail string) ([]Project, *u.Error) {
 		return nil, &u.Error{Type: u.ErrInternal, Message: err.Error()}
 	}
 
+	projects := []Project{}
+	for _, project := range results {
+		if project.UserRole = project.getUserRole(userEmail, userRoles); project.UserRole != "" {
+			projects = append(projects, project)
+		}
+	}
+
+	return projects, nil
+}
+
+// GetProject: project with this id if the user has at least the viewer role on it
+func GetProject(projectId, userEmail string, userRoles map[string]Role) (*Project, *u.Error) {
+	project, err := getProject(projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkProjectRole(*project, userEmail, userRoles, ProjectViewer); err != nil {
+		return nil, err
+	}
+	project.UserRole = project.getUserRole(userEmail, userRoles)
+
+	return project, nil
+}
+
+func getProject(projectId string) (*Project, *u.Error) {
+	objId, err := primitive.ObjectIDFromHex(projectId)
+	if err != nil {
+		return nil, &u.Error{Type: u.ErrNotFound, Message: "Project not found"}
+	}
+
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	project := &Project{}
+	err = repository.GetDB().Collection(WEB_PROJECTS).FindOne(ctx, bson.M{"_id": objId}).Decode(project)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, &u.Error{Type: u.ErrNotFound, Message: "Project not found"}
+		}
+		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
+
+	return project, nil
+}
+
+// GetProjectRevisions: previous versions of the project, latest first
+func GetProjectRevisions(projectId, userEmail string, userRoles map[string]Role) ([]ProjectRevision, *u.Error) {
+	if _, err := GetProject(projectId, userEmail, userRoles); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := u.Connect()
 	defer cancel()
-	return results, nil
+
+	revisions := []ProjectRevision{}
+	opts := options.Find().SetSort(bson.M{"revision": -1})
+	cursor, err := repository.GetDB().Collection(WEB_PROJECT_REVISIONS).Find(ctx, bson.M{"projectId": projectId}, opts)
+	if err != nil {
+		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	} else if err = cursor.All(ctx, &revisions); err != nil {
+		return nil, &u.Error{Type: u.ErrInternal, Message: err.Error()}
+	}
+
+	return revisions, nil
 }
 
 // POST
+// AddProject: stores the project without owner
 func AddProject(newProject Project) *u.Error {
+	_, err := insertProject(newProject)
+	return err
+}
+
+// CreateProject: stores the project, owned by the user
+func CreateProject(newProject Project, userEmail string) (*Project, *u.Error) {
+	if err := validateProjectSharing(newProject.Sharing); err != nil {
+		return nil, err
+	}
+
+	newProject.Owner = userEmail
+	newProject.Revision = 0
+	id, err := insertProject(newProject)
+	if err != nil {
+		return nil, err
+	}
+	newProject.Id = id
+	newProject.UserRole = ProjectOwner
+
+	return &newProject, nil
+}
+
+func insertProject(newProject Project) (string, *u.Error) {
 	// Add the new project
 	ctx, cancel := u.Connect()
-	_, err := repository.GetDB().Collection(WEB_PROJECTS).InsertOne(ctx, newProject)
+	defer cancel()
+
+	newProject.Id = ""
+	result, err := repository.GetDB().Collection(WEB_PROJECTS).InsertOne(ctx, newProject)
 	if err != nil {
 		println(err.Error())
-		return &u.Error{Type: u.ErrDBError, Message: err.Error()}
+		return "", &u.Error{Type: u.ErrDBError, Message: err.Error()}
 	}
 
-	defer cancel()
-	return nil
+	return result.InsertedID.(primitive.ObjectID).Hex(), nil
 }
 
 // PUT
-func UpdateProject(newProject Project, projectId string) *u.Error {
-	// Update existing project, if exists
+// UpdateProject: replaces the project, keeping the previous version as a revision.
+// Only owners can change the owner and the sharing of the project,
+// the sharing is kept if not given.
+func UpdateProject(newProject Project, projectId, userEmail string, userRoles map[string]Role) (*Project, *u.Error) {
+	oldProject, err := getProject(projectId)
+	if err != nil {
+		if err.Type == u.ErrNotFound {
+			err.Message = "No project found with this ID"
+		}
+		return nil, err
+	}
+	if err := checkProjectRole(*oldProject, userEmail, userRoles, ProjectEditor); err != nil {
+		return nil, err
+	}
+
+	if oldProject.getUserRole(userEmail, userRoles) != ProjectOwner {
+		newProject.Owner = oldProject.Owner
+		newProject.Permissions = oldProject.Permissions
+		newProject.IsPublic = oldProject.IsPublic
+		newProject.Sharing = oldProject.Sharing
+	} else {
+		if newProject.Owner == "" {
+			newProject.Owner = oldProject.Owner
+		}
+		if newProject.Sharing == nil {
+			newProject.Sharing = oldProject.Sharing
+		}
+		if err := validateProjectSharing(newProject.Sharing); err != nil {
+			return nil, err
+		}
+	}
+
+	return replaceProject(*oldProject, newProject, userEmail, userRoles)
+}
+
+// RestoreProjectRevision: replaces the project by one of its revisions,
+// the current version is kept as a revision and the sharing is not changed
+func RestoreProjectRevision(projectId string, revision int, userEmail string, userRoles map[string]Role) (*Project, *u.Error) {
+	currentProject, err := getProject(projectId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkProjectRole(*currentProject, userEmail, userRoles, ProjectEditor); err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := u.Connect()
-	objId, _ := primitive.ObjectIDFromHex(projectId)
-	res, err := repository.GetDB().Collection(WEB_PROJECTS).UpdateOne(ctx,
-		bson.M{"_id": objId}, bson.M{"$set": newProject})
 	defer cancel()
 
-	if err != nil {
-		return &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	projectRevision := ProjectRevision{}
+	mongoErr := repository.GetDB().Collection(WEB_PROJECT_REVISIONS).FindOne(ctx,
+		bson.M{"projectId": projectId, "revision": revision}).Decode(&projectRevision)
+	if mongoErr != nil {
+		if mongoErr == mongo.ErrNoDocuments {
+			return nil, &u.Error{Type: u.ErrNotFound, Message: fmt.Sprintf("Revision %d not found", revision)}
+		}
+		return nil, &u.Error{Type: u.ErrDBError, Message: mongoErr.Error()}
 	}
-	if res.MatchedCount <= 0 {
-		return &u.Error{Type: u.ErrNotFound,
-			Message: "No project found with this ID"}
+
+	restoredProject := projectRevision.Project
+	restoredProject.Owner = currentProject.Owner
+	restoredProject.Permissions = currentProject.Permissions
+	restoredProject.IsPublic = currentProject.IsPublic
+	restoredProject.Sharing = currentProject.Sharing
+
+	return replaceProject(*currentProject, restoredProject, userEmail, userRoles)
+}
+
+// replaceProject: keeps the old project as a revision and replaces it by the new one,
+// unless the project was modified since the old one was read
+func replaceProject(oldProject, newProject Project, userEmail string, userRoles map[string]Role) (*Project, *u.Error) {
+	projectId := oldProject.Id
+	oldProject.Id = ""
+	newProject.Id = ""
+	newProject.Revision = oldProject.Revision + 1
+
+	_, err := WithTransaction(func(ctx mongo.SessionContext) (any, error) {
+		_, err := repository.GetDB().Collection(WEB_PROJECT_REVISIONS).InsertOne(ctx, ProjectRevision{
+			ProjectId: projectId,
+			Revision:  oldProject.Revision,
+			Date:      time.Now(),
+			Author:    userEmail,
+			Project:   oldProject,
+		})
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, &u.Error{Type: u.ErrConflict, Message: "The project was modified by another user"}
+		} else if err != nil {
+			return nil, err
+		}
+
+		// the projects created before the revisions have none
+		revisionFilter := any(oldProject.Revision)
+		if oldProject.Revision == 0 {
+			revisionFilter = bson.M{"$in": bson.A{0, nil}}
+		}
+		objId, _ := primitive.ObjectIDFromHex(projectId)
+		res, err := repository.GetDB().Collection(WEB_PROJECTS).ReplaceOne(ctx,
+			bson.M{"_id": objId, "revision": revisionFilter}, newProject)
+		if err != nil {
+			return nil, err
+		}
+		if res.MatchedCount <= 0 {
+			return nil, &u.Error{Type: u.ErrConflict, Message: "The project was modified by another user"}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil
+
+	newProject.Id = projectId
+	newProject.UserRole = newProject.getUserRole(userEmail, userRoles)
+	return &newProject, nil
 }
 
 // DELETE
-func DeleteProject(projectId string) *u.Error {
+// DeleteProject: deletes the project and its revisions, only owners can delete it
+func DeleteProject(projectId, userEmail string, userRoles map[string]Role) *u.Error {
 	println(projectId)
 
+	project, err := getProject(projectId)
+	if err != nil {
+		return err
+	}
+	if err := checkProjectRole(*project, userEmail, userRoles, ProjectOwner); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	objId, _ := primitive.ObjectIDFromHex(projectId)
-	res, err := repository.GetDB().Collection(WEB_PROJECTS).DeleteOne(ctx, bson.M{"_id": objId})
+	res, mongoErr := repository.GetDB().Collection(WEB_PROJECTS).DeleteOne(ctx, bson.M{"_id": objId})
 	defer cancel()
 
-	if err != nil {
-		return &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	if mongoErr != nil {
+		return &u.Error{Type: u.ErrDBError, Message: mongoErr.Error()}
 	} else if res.DeletedCount <= 0 {
 		return &u.Error{Type: u.ErrNotFound,
 			Message: "Project not found"}
 	}
+
+	_, mongoErr = repository.GetDB().Collection(WEB_PROJECT_REVISIONS).DeleteMany(ctx, bson.M{"projectId": projectId})
+	if mongoErr != nil {
+		return &u.Error{Type: u.ErrDBError, Message: mongoErr.Error()}
+	}
 	return nil
 }
 
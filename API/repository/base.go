@@ -13,6 +13,8 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
+const textIndexName = "text_search"
+
 // Database
 var globalDB *mongo.Database
 var globalClient *mongo.Client
@@ -55,6 +57,13 @@ func SetupDB(db *mongo.Database) error {
 		}
 	}
 
+	// Full text search on names, descriptions and attributes
+	for _, entity := range u.Entities {
+		if err := createTextIndex(db, u.EntityToString(entity)); err != nil {
+			return err
+		}
+	}
+
 	// Make slugs unique identifiers for templates
 	for _, entity := range []int{u.ROOMTMPL, u.OBJTMPL, u.BLDGTMPL, u.TAG, u.LAYER} {
 		if err := createUniqueIndex(db, u.EntityToString(entity), bson.M{"slug": 1}); err != nil {
@@ -69,6 +78,42 @@ func SetupDB(db *mongo.Database) error {
 		return err
 	}
 
+	// Snapshots are identified by their name, their objects are searched by snapshot and id
+	if err := createUniqueIndex(db, "snapshot", bson.M{"name": 1}); err != nil {
+		return err
+	}
+	if err := createIndex(db, "snapshot_object", bson.D{{Key: "snapshot", Value: 1}, {Key: "id", Value: 1}}); err != nil {
+		return err
+	}
+
+	// A single revision of each number by project
+	if err := createUniqueIndex(db, "web_project_revision", bson.D{{Key: "projectId", Value: 1}, {Key: "revision", Value: 1}}); err != nil {
+		return err
+	}
+
+	// Alerts are identified by their id
+	if err := createUniqueIndex(db, "web_alert", bson.M{"id": 1}); err != nil {
+		return err
+	}
+
+	// A single relation of each type from an object to another
+	if err := createUniqueIndex(db, "relation", bson.D{{Key: "from", Value: 1}, {Key: "to", Value: 1}, {Key: "type", Value: 1}}); err != nil {
+		return err
+	}
+
+	// Idempotency keys are unique per user and expire by themselves
+	if err := createUniqueIndex(db, idempotencyCollection, bson.D{{Key: "userId", Value: 1}, {Key: "key", Value: 1}}); err != nil {
+		return err
+	}
+	if err := createTTLIndex(db, idempotencyCollection, bson.M{"expiresAt": 1}); err != nil {
+		return err
+	}
+
+	// Sensor readings
+	if err := createTelemetryCollection(db); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -94,7 +139,7 @@ func createInitialData(db *mongo.Database, tenantName string) error {
 	return nil
 }
 
-func createUniqueIndex(db *mongo.Database, collection string, on bson.M) error {
+func createUniqueIndex(db *mongo.Database, collection string, on any) error {
 	indexCtx, indexCancel := u.Connect()
 	defer indexCancel()
 
@@ -109,6 +154,58 @@ func createUniqueIndex(db *mongo.Database, collection string, on bson.M) error {
 	return err
 }
 
+// createTextIndex: index all string fields for text search,
+// giving more weight to the identifying ones
+func createTextIndex(db *mongo.Database, collection string) error {
+	indexCtx, indexCancel := u.Connect()
+	defer indexCancel()
+
+	_, err := db.Collection(collection).Indexes().CreateOne(
+		indexCtx,
+		mongo.IndexModel{
+			Keys: bson.M{"$**": "text"},
+			Options: options.Index().
+				SetName(textIndexName).
+				SetDefaultLanguage("none").
+				SetWeights(bson.M{"name": 10, "slug": 10, "id": 5, "description": 3}),
+		},
+	)
+
+	return err
+}
+
+// createIndex: index to search the documents by the given fields
+func createIndex(db *mongo.Database, collection string, on any) error {
+	indexCtx, indexCancel := u.Connect()
+	defer indexCancel()
+
+	_, err := db.Collection(collection).Indexes().CreateOne(
+		indexCtx,
+		mongo.IndexModel{
+			Keys: on,
+		},
+	)
+
+	return err
+}
+
+// createTTLIndex: documents are removed by mongo once the date
+// stored in the indexed field is reached
+func createTTLIndex(db *mongo.Database, collection string, on bson.M) error {
+	indexCtx, indexCancel := u.Connect()
+	defer indexCancel()
+
+	_, err := db.Collection(collection).Indexes().CreateOne(
+		indexCtx,
+		mongo.IndexModel{
+			Keys:    on,
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	)
+
+	return err
+}
+
 func GetDatabase(client *mongo.Client, name string) (*mongo.Database, error) {
 	if name == "admin" || name == "config" || name == "local" {
 		return nil, fmt.Errorf("database %s not accessible", name)
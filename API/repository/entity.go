@@ -3,12 +3,14 @@ package repository
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	u "p3/utils"
 )
@@ -263,3 +265,247 @@ func CountObjectsManyEntities(entities []int, req bson.M) (int, *u.Error) {
 
 	return count, nil
 }
+
+// PropagateSubtreeIdChange: replace the oldId prefix by newId in the id
+// of all descendants of the moved object, in the given entities
+func PropagateSubtreeIdChange(ctx context.Context, oldId, newId string, entities []int) error {
+	req := bson.M{"id": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(oldId+u.HN_DELIMETER), Options: ""}}
+	update := bson.D{{
+		Key: "$set", Value: bson.M{
+			"id": bson.M{
+				"$replaceOne": bson.M{
+					"input":       "$id",
+					"find":        oldId + u.HN_DELIMETER,
+					"replacement": newId + u.HN_DELIMETER}}}}}
+	for _, entity := range entities {
+		_, err := GetDB().Collection(u.EntityToString(entity)).UpdateMany(ctx,
+			req, mongo.Pipeline{update})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplaceInGroupsContent: replace the given name by newName in the content of
+// the groups directly under parentId, or remove it if newName is empty.
+// Groups left empty are deleted. The updated groups and the ids of
+// the deleted ones are returned
+func ReplaceInGroupsContent(ctx context.Context, parentId, name, newName string) ([]map[string]any, []string, error) {
+	collection := GetDB().Collection(u.EntityToString(u.GROUP))
+	req := bson.M{
+		"id": primitive.Regex{
+			Pattern: "^" + regexp.QuoteMeta(parentId+u.HN_DELIMETER) + "[^" + regexp.QuoteMeta(u.HN_DELIMETER) + "]+$",
+			Options: "",
+		},
+		"attributes.content": name,
+	}
+
+	deleted := []string{}
+	updated, err := updateMatching(ctx, u.EntityToString(u.GROUP), req, func(group map[string]any) bson.M {
+		attributes, _ := group["attributes"].(map[string]any)
+		content, _ := attributes["content"].(primitive.A)
+		newContent := primitive.A{}
+		for _, contentName := range content {
+			if contentName != name {
+				newContent = append(newContent, contentName)
+			} else if newName != "" {
+				newContent = append(newContent, newName)
+			}
+		}
+		if len(newContent) == 0 {
+			// a group must have content, it is deleted below
+			deleted = append(deleted, group["id"].(string))
+			return nil
+		}
+		return bson.M{"$set": bson.M{"attributes.content": newContent}}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(deleted) > 0 {
+		if _, err := collection.DeleteMany(ctx, bson.M{"id": bson.M{"$in": deleted}}); err != nil {
+			return nil, nil, err
+		}
+	}
+	return updated, deleted, nil
+}
+
+// ReplaceVlinksPrefix: update the vlinks of virtual objects pointing to
+// oldId or to one of its descendants so that they point to newId.
+// If newId is empty, these vlinks are removed. The updated objects are returned
+func ReplaceVlinksPrefix(ctx context.Context, oldId, newId string) ([]map[string]any, error) {
+	req := bson.M{"attributes.vlinks": primitive.Regex{
+		Pattern: "^" + regexp.QuoteMeta(oldId) + "([.#]|$)",
+		Options: "",
+	}}
+	return updateMatching(ctx, u.EntityToString(u.VIRTUALOBJ), req, func(vobj map[string]any) bson.M {
+		attributes, _ := vobj["attributes"].(map[string]any)
+		vlinks, ok := attributes["vlinks"].(primitive.A)
+		if !ok {
+			return nil
+		}
+		newVlinks := primitive.A{}
+		for _, vlink := range vlinks {
+			vlinkStr, ok := vlink.(string)
+			if !ok || !HasIdPrefix(vlinkStr, oldId, "#") {
+				newVlinks = append(newVlinks, vlink)
+			} else if newId != "" {
+				newVlinks = append(newVlinks, ReplaceIdPrefix(vlinkStr, oldId, newId, "#"))
+			}
+		}
+		return bson.M{"$set": bson.M{"attributes.vlinks": newVlinks}}
+	})
+}
+
+// ReplaceClusterIdPrefix: update the clusterId of the devices and virtual
+// objects linked to oldId or to one of its descendants so that they are
+// linked to newId. If newId is empty, the clusterId is removed.
+// The updated objects are returned
+func ReplaceClusterIdPrefix(ctx context.Context, oldId, newId string) ([]map[string]any, error) {
+	req := bson.M{"attributes.virtual_config.clusterId": primitive.Regex{
+		Pattern: "^" + regexp.QuoteMeta(oldId) + "(" + regexp.QuoteMeta(u.HN_DELIMETER) + "|$)",
+		Options: "",
+	}}
+	updated := []map[string]any{}
+	for _, entity := range []int{u.DEVICE, u.VIRTUALOBJ} {
+		objs, err := updateMatching(ctx, u.EntityToString(entity), req, func(obj map[string]any) bson.M {
+			if newId == "" {
+				return bson.M{"$unset": bson.M{"attributes.virtual_config.clusterId": ""}}
+			}
+			attributes, _ := obj["attributes"].(map[string]any)
+			vconfig, _ := attributes["virtual_config"].(map[string]any)
+			clusterId, _ := vconfig["clusterId"].(string)
+			return bson.M{"$set": bson.M{
+				"attributes.virtual_config.clusterId": ReplaceIdPrefix(clusterId, oldId, newId),
+			}}
+		})
+		if err != nil {
+			return nil, err
+		}
+		updated = append(updated, objs...)
+	}
+	return updated, nil
+}
+
+// ReplaceBreakersPowerpanel: update the powerpanel of rack breakers
+// referencing oldId or one of its descendants so that they reference newId.
+// Breakers reference a panel by its name, relative to the room of the rack,
+// or by its id. The updated racks are returned
+func ReplaceBreakersPowerpanel(ctx context.Context, oldId, newId string) ([]map[string]any, error) {
+	roomId, oldName, newName := "", "", ""
+	if i, j := strings.LastIndex(oldId, u.HN_DELIMETER), strings.LastIndex(newId, u.HN_DELIMETER); i > 0 && j > 0 &&
+		oldId[:i] == newId[:j] {
+		isPanel, err := isPowerpanel(ctx, oldId, newId)
+		if err != nil {
+			return nil, err
+		} else if isPanel {
+			// panel renamed in its room
+			roomId, oldName, newName = oldId[:i], oldId[i+len(u.HN_DELIMETER):], newId[j+len(u.HN_DELIMETER):]
+		}
+	}
+
+	req := bson.M{"attributes.breakers": bson.M{"$exists": true}}
+	return updateMatching(ctx, u.EntityToString(u.RACK), req, func(rack map[string]any) bson.M {
+		rackId, _ := rack["id"].(string)
+		inRoom := roomId != "" && strings.HasPrefix(rackId, roomId+u.HN_DELIMETER)
+		attributes, _ := rack["attributes"].(map[string]any)
+		breakers, ok := attributes["breakers"].(map[string]any)
+		if !ok {
+			return nil
+		}
+		set := bson.M{}
+		for name, breaker := range breakers {
+			breakerMap, ok := breaker.(map[string]any)
+			if !ok {
+				continue
+			}
+			powerpanel, ok := breakerMap["powerpanel"].(string)
+			if !ok {
+				continue
+			}
+			if inRoom && powerpanel == oldName {
+				set["attributes.breakers."+name+".powerpanel"] = newName
+			} else if replaced := ReplaceIdPrefix(powerpanel, oldId, newId); replaced != powerpanel {
+				set["attributes.breakers."+name+".powerpanel"] = replaced
+			}
+		}
+		if len(set) == 0 {
+			return nil
+		}
+		return bson.M{"$set": set}
+	})
+}
+
+// isPowerpanel: whether the object of oldId, or of newId, is a panel
+// or a generic object that can be referenced by breakers
+func isPowerpanel(ctx context.Context, oldId, newId string) (bool, error) {
+	for _, entity := range []int{u.PWRPNL, u.GENERIC} {
+		count, err := GetDB().Collection(u.EntityToString(entity)).CountDocuments(ctx,
+			bson.M{"id": bson.M{"$in": bson.A{oldId, newId}}})
+		if err != nil {
+			return false, err
+		} else if count > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// updateMatching: for each object of the collection matching req, apply
+// the update returned by getUpdate, unless it is nil.
+// The updated objects are returned
+func updateMatching(ctx context.Context, collectionName string, req bson.M,
+	getUpdate func(obj map[string]any) bson.M) ([]map[string]any, error) {
+	collection := GetDB().Collection(collectionName)
+	c, err := collection.Find(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var objs []map[string]any
+	if err := c.All(ctx, &objs); err != nil {
+		return nil, err
+	}
+
+	updated := []map[string]any{}
+	for _, obj := range objs {
+		update := getUpdate(obj)
+		if update == nil {
+			continue
+		}
+		var updatedObj map[string]any
+		if err := collection.FindOneAndUpdate(ctx, bson.M{"_id": obj["_id"]}, update,
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&updatedObj); err != nil {
+			return nil, err
+		}
+		updated = append(updated, updatedObj)
+	}
+	return updated, nil
+}
+
+// HasIdPrefix: if ref is id or starts with id followed by the
+// hierarchy delimiter (or one of the extra separators)
+func HasIdPrefix(ref, id string, separators ...string) bool {
+	if ref == id {
+		return true
+	}
+	for _, sep := range append([]string{u.HN_DELIMETER}, separators...) {
+		if strings.HasPrefix(ref, id+sep) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReplaceIdPrefix: if ref is oldId or starts with oldId followed by
+// the hierarchy delimiter (or one of the extra separators), replace
+// the oldId part by newId. Otherwise, ref is returned unchanged
+func ReplaceIdPrefix(ref, oldId, newId string, separators ...string) string {
+	if HasIdPrefix(ref, oldId, separators...) {
+		return newId + ref[len(oldId):]
+	}
+	return ref
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	u "p3/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const idempotencyCollection = "idempotency_key"
+
+func CreateIdempotencyRecord(ctx context.Context, record any) *u.Error {
+	_, err := GetDB().Collection(idempotencyCollection).InsertOne(ctx, record)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return &u.Error{Type: u.ErrDuplicate, Message: "Idempotency key already in use"}
+		}
+		return &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
+
+	return nil
+}
+
+func GetIdempotencyRecord[T any](ctx context.Context, userId, key string) (*T, *u.Error) {
+	var record T
+	err := GetDB().Collection(idempotencyCollection).FindOne(
+		ctx,
+		bson.M{"userId": userId, "key": key},
+	).Decode(&record)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, &u.Error{Type: u.ErrNotFound, Message: "Idempotency key not found"}
+		}
+		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
+
+	return &record, nil
+}
+
+func UpdateIdempotencyRecord(ctx context.Context, userId, key string, update bson.M) *u.Error {
+	_, err := GetDB().Collection(idempotencyCollection).UpdateOne(
+		ctx,
+		bson.M{"userId": userId, "key": key},
+		bson.M{"$set": update},
+	)
+	if err != nil {
+		return &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
+
+	return nil
+}
+
+func DeleteIdempotencyRecord(ctx context.Context, userId, key string) *u.Error {
+	return DeleteObject(ctx, idempotencyCollection, bson.M{"userId": userId, "key": key})
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"os"
+	u "p3/utils"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const telemetryCollection = "telemetry"
+
+// mongo error code when creating a collection that already exists
+const namespaceExistsErrorCode = 48
+
+// createTelemetryCollection: time series collection of the sensor readings.
+// If the telemetry_retention env variable is set (e.g. 720h), older readings
+// are removed by mongo. It is only taken into account when the collection is created.
+func createTelemetryCollection(db *mongo.Database) error {
+	ctx, cancel := u.Connect()
+	defer cancel()
+
+	opts := options.CreateCollection().SetTimeSeriesOptions(
+		options.TimeSeries().
+			SetTimeField("timestamp").
+			SetMetaField("meta").
+			SetGranularity("seconds"),
+	)
+	if retention, err := time.ParseDuration(os.Getenv("telemetry_retention")); err == nil && retention > 0 {
+		opts.SetExpireAfterSeconds(int64(retention.Seconds()))
+	}
+
+	err := db.CreateCollection(ctx, telemetryCollection, opts)
+	if cmdErr, ok := err.(mongo.CommandError); ok && cmdErr.Code == namespaceExistsErrorCode {
+		return nil
+	}
+
+	return err
+}
+
+func CreateTelemetryReadings(ctx context.Context, readings []any) *u.Error {
+	_, err := GetDB().Collection(telemetryCollection).InsertMany(ctx, readings)
+	if err != nil {
+		return &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
+
+	return nil
+}
+
+func AggregateTelemetry[T any](ctx context.Context, pipeline bson.A) ([]T, *u.Error) {
+	c, err := GetDB().Collection(telemetryCollection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
+
+	results := []T{}
+	if err := c.All(ctx, &results); err != nil {
+		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
+
+	return results, nil
+}
+
+// UpdateLatestTelemetry: sets the value of the sensor on the object and its lastUpdated,
+// unless a more recent value is already stored. The updated object is returned, nil if
+// it was not updated.
+func UpdateLatestTelemetry(ctx context.Context, entityStr, objectId, sensor string, value float64, timestamp time.Time) (map[string]any, *u.Error) {
+	field := "attributes.telemetry." + sensor
+	var object map[string]any
+	err := GetDB().Collection(entityStr).FindOneAndUpdate(
+		ctx,
+		bson.M{"id": objectId, "$or": bson.A{
+			bson.M{field + ".timestamp": bson.M{"$lte": timestamp}},
+			bson.M{field: bson.M{"$exists": false}},
+		}},
+		bson.M{"$set": bson.M{
+			field:         bson.M{"value": value, "timestamp": timestamp},
+			"lastUpdated": primitive.NewDateTimeFromTime(time.Now()),
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&object)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	} else if err != nil {
+		return nil, &u.Error{Type: u.ErrDBError, Message: err.Error()}
+	}
+
+	return object, nil
+}
@@ -44,10 +44,10 @@ func Router(jwt func(next http.Handler) http.Handler) *mux.Router {
 		controllers.VerifyToken).Methods("GET", "OPTIONS", "HEAD")
 
 	router.HandleFunc("/api/users",
-		controllers.CreateAccount).Methods("POST", "OPTIONS")
+		controllers.WithIdempotency(controllers.CreateAccount)).Methods("POST", "OPTIONS")
 
 	router.HandleFunc("/api/users/bulk",
-		controllers.CreateBulkAccount).Methods("POST", "OPTIONS")
+		controllers.WithIdempotency(controllers.CreateBulkAccount)).Methods("POST", "OPTIONS")
 
 	router.HandleFunc("/api/users",
 		controllers.GetAllAccounts).Methods("GET", "OPTIONS", "HEAD")
@@ -91,7 +91,7 @@ func Router(jwt func(next http.Handler) http.Handler) *mux.Router {
 		controllers.GetProjects).Methods("HEAD", "GET", "OPTIONS")
 
 	router.HandleFunc("/api/projects",
-		controllers.CreateOrUpdateProject).Methods("POST")
+		controllers.WithIdempotency(controllers.CreateOrUpdateProject)).Methods("POST")
 
 	router.HandleFunc("/api/projects/{id:[a-zA-Z0-9]{24}}",
 		controllers.CreateOrUpdateProject).Methods("PUT")
@@ -99,8 +99,17 @@ func Router(jwt func(next http.Handler) http.Handler) *mux.Router {
 	router.HandleFunc("/api/projects/{id:[a-zA-Z0-9]{24}}",
 		controllers.DeleteProject).Methods("DELETE", "OPTIONS")
 
+	router.HandleFunc("/api/projects/{id:[a-zA-Z0-9]{24}}/result",
+		controllers.GetProjectResult).Methods("GET")
+
+	router.HandleFunc("/api/projects/{id:[a-zA-Z0-9]{24}}/revisions",
+		controllers.GetProjectRevisions).Methods("GET")
+
+	router.HandleFunc("/api/projects/{id:[a-zA-Z0-9]{24}}/revisions/{revision:[0-9]+}/restore",
+		controllers.RestoreProjectRevision).Methods("POST")
+
 	router.HandleFunc("/api/alerts",
-		controllers.CreateAlert).Methods("POST")
+		controllers.WithIdempotency(controllers.CreateAlert)).Methods("POST")
 
 	router.HandleFunc("/api/alerts",
 		controllers.GetAlerts).Methods("HEAD", "GET", "OPTIONS")
@@ -108,9 +117,108 @@ func Router(jwt func(next http.Handler) http.Handler) *mux.Router {
 	router.HandleFunc("/api/alerts/{id}",
 		controllers.GetAlert).Methods("HEAD", "GET", "OPTIONS")
 
+	router.HandleFunc("/api/alerts/{id}",
+		controllers.UpdateAlert).Methods("PATCH")
+
 	router.HandleFunc("/api/alerts/{id}",
 		controllers.DeleteAlert).Methods("DELETE", "OPTIONS")
 
+	// Alert rules
+	router.HandleFunc("/api/alert_rules",
+		controllers.WithIdempotency(controllers.CreateAlertRule)).Methods("POST")
+
+	router.HandleFunc("/api/alert_rules",
+		controllers.GetAlertRules).Methods("GET")
+
+	router.HandleFunc("/api/alert_rules/{id:[a-zA-Z0-9]{24}}",
+		controllers.GetAlertRule).Methods("GET")
+
+	router.HandleFunc("/api/alert_rules/{id:[a-zA-Z0-9]{24}}",
+		controllers.UpdateAlertRule).Methods("PUT")
+
+	router.HandleFunc("/api/alert_rules/{id:[a-zA-Z0-9]{24}}",
+		controllers.DeleteAlertRule).Methods("DELETE")
+
+	router.HandleFunc("/api/alert_rules/{id:[a-zA-Z0-9]{24}}/evaluate",
+		controllers.EvaluateAlertRule).Methods("POST")
+
+	// Snapshots
+	router.HandleFunc("/api/snapshots",
+		controllers.WithIdempotency(controllers.TakeSnapshot)).Methods("POST")
+
+	router.HandleFunc("/api/snapshots",
+		controllers.GetSnapshots).Methods("GET")
+
+	router.HandleFunc("/api/snapshots/{name}",
+		controllers.GetSnapshot).Methods("GET")
+
+	router.HandleFunc("/api/snapshots/{name}",
+		controllers.DeleteSnapshot).Methods("DELETE")
+
+	router.HandleFunc("/api/snapshot_schedules",
+		controllers.WithIdempotency(controllers.CreateSnapshotSchedule)).Methods("POST")
+
+	router.HandleFunc("/api/snapshot_schedules",
+		controllers.GetSnapshotSchedules).Methods("GET")
+
+	router.HandleFunc("/api/snapshot_schedules/{id:[a-zA-Z0-9]{24}}",
+		controllers.DeleteSnapshotSchedule).Methods("DELETE")
+
+	router.HandleFunc("/api/diff",
+		controllers.GetObjectsDiff).Methods("GET")
+
+	// Referential integrity
+	router.HandleFunc("/api/integrity",
+		controllers.CheckIntegrity).Methods("GET")
+
+	router.HandleFunc("/api/integrity/fix",
+		controllers.FixIntegrity).Methods("POST")
+
+	// Relations between objects
+	router.HandleFunc("/api/relations",
+		controllers.WithIdempotency(controllers.CreateRelation)).Methods("POST")
+
+	router.HandleFunc("/api/relations",
+		controllers.GetRelations).Methods("GET")
+
+	router.HandleFunc("/api/relations/{id:[a-zA-Z0-9]{24}}",
+		controllers.GetRelation).Methods("GET")
+
+	router.HandleFunc("/api/relations/{id:[a-zA-Z0-9]{24}}",
+		controllers.UpdateRelation).Methods("PUT")
+
+	router.HandleFunc("/api/relations/{id:[a-zA-Z0-9]{24}}",
+		controllers.DeleteRelation).Methods("DELETE")
+
+	// Full text search
+	router.HandleFunc(GenericObjectsURL+"/search",
+		controllers.SearchObjects).Methods("GET")
+
+	// Group by and metrics
+	router.HandleFunc(GenericObjectsURL+"/aggregate",
+		controllers.AggregateObjects).Methods("POST")
+
+	// Sensor readings
+	router.HandleFunc("/api/telemetry",
+		controllers.WithIdempotency(controllers.CreateTelemetry)).Methods("POST")
+
+	router.HandleFunc(GenericObjectsURL+"/{id}/telemetry",
+		controllers.GetObjectTelemetry).Methods("GET")
+
+	// Import of inventory files
+	router.HandleFunc("/api/import/csv",
+		controllers.WithIdempotency(controllers.ImportCSV)).Methods("POST")
+
+	router.HandleFunc("/api/obj_templates/import",
+		controllers.WithIdempotency(controllers.ImportDeviceTypes)).Methods("POST")
+
+	// Instances of the object templates
+	router.HandleFunc("/api/obj_templates/{slug}/instances",
+		controllers.GetObjTemplateInstances).Methods("GET", "OPTIONS", "HEAD")
+
+	router.HandleFunc("/api/obj_templates/{slug}/instances/upgrade",
+		controllers.UpgradeObjTemplateInstances).Methods("POST")
+
 	// For get or ls wih complex filters
 	router.HandleFunc(GenericObjectsURL+"/search",
 		controllers.HandleComplexFilters).Methods("POST", "HEAD", "OPTIONS", "DELETE")
@@ -149,10 +257,10 @@ func Router(jwt func(next http.Handler) http.Handler) *mux.Router {
 
 	// CREATE ENTITY
 	router.HandleFunc("/api/{entity}s",
-		controllers.CreateEntity).Methods("POST")
+		controllers.WithIdempotency(controllers.CreateEntity)).Methods("POST")
 
 	router.HandleFunc("/api/domains/bulk",
-		controllers.CreateBulkDomain).Methods("POST")
+		controllers.WithIdempotency(controllers.CreateBulkDomain)).Methods("POST")
 
 	//DELETE ENTITY
 	router.HandleFunc("/api/{entity}s/{id}",
@@ -173,6 +281,14 @@ func Router(jwt func(next http.Handler) http.Handler) *mux.Router {
 	router.HandleFunc("/api/stray_objects/{id}/link",
 		controllers.LinkEntity).Methods("PATCH")
 
+	// MOVE
+	router.HandleFunc("/api/{entity:building|room|ac|corridor|cabinet|panel|group|rack|device|generic|virtual_obj|hierarchy_object}s/{id}/move",
+		controllers.MoveEntity).Methods("POST")
+
+	// COPY
+	router.HandleFunc("/api/{entity:building|room|ac|corridor|cabinet|panel|group|rack|device|generic|virtual_obj|hierarchy_object}s/{id}/copy",
+		controllers.WithIdempotency(controllers.CopyEntity)).Methods("POST")
+
 	//VALIDATION
 	router.HandleFunc("/api/validate/{entity}s", controllers.ValidateEntity).Methods("POST", "OPTIONS")
 
@@ -80,6 +80,14 @@ func MakeRequest(method, url string, requestBody []byte) *httptest.ResponseRecor
 	return MakeRequestWithUser(method, url, requestBody, "admin")
 }
 
+func MakeRequestWithIdempotencyKey(method, url string, requestBody []byte, key string) *httptest.ResponseRecorder {
+	header := map[string]string{
+		"Authorization":   "Bearer " + users["admin"].(map[string]any)["token"].(string),
+		"Idempotency-Key": key,
+	}
+	return MakeRequestWithHeaders(method, url, requestBody, header)
+}
+
 func GetObjects(queryParams string) (*httptest.ResponseRecorder, []map[string]any) {
 	response := MakeRequest(http.MethodGet, router.GenericObjectsURL+"?"+queryParams, nil)
 
@@ -208,6 +208,33 @@ func CreateGroup(parentID, name string, content []any) (map[string]any, *utils.E
 	return internalCreateGroup(parentID, name, content, false)
 }
 
+func internalCreateVirtualObject(parentID, name string, attributes map[string]any, require bool) (map[string]any, *utils.Error) {
+	vobj := map[string]any{
+		"attributes":  attributes,
+		"category":    "virtual_obj",
+		"description": name,
+		"domain":      TestDBName,
+		"name":        name,
+	}
+	if parentID != "" {
+		vobj["parentId"] = parentID
+	}
+	return createObject(
+		utils.VIRTUALOBJ,
+		vobj,
+		require,
+	)
+}
+
+func RequireCreateVirtualObject(parentID, name string, attributes map[string]any) map[string]any {
+	obj, _ := internalCreateVirtualObject(parentID, name, attributes, true)
+	return obj
+}
+
+func CreateVirtualObject(parentID, name string, attributes map[string]any) (map[string]any, *utils.Error) {
+	return internalCreateVirtualObject(parentID, name, attributes, false)
+}
+
 func CreateTestDomain(t *testing.T, name string, parentId string, color string) {
 	// It creates a temporary domain that will be deleted at the end of the test t
 	domainColor := "ffffff"
@@ -231,7 +258,7 @@ func CreateTestDomain(t *testing.T, name string, parentId string, color string)
 		filters := utils.RequestFilters{}
 		domain, _ := models.GetObject(bson.M{"id": entity["id"]}, utils.EntityToString(utils.DOMAIN), filters, ManagerUserRoles)
 		if domain != nil {
-			err := models.DeleteObject(utils.EntityToString(utils.DOMAIN), entity["id"].(string), ManagerUserRoles)
+			_, err := models.DeleteObject(utils.EntityToString(utils.DOMAIN), entity["id"].(string), ManagerUserRoles)
 			assert.Nil(t, err)
 		}
 	})
@@ -274,7 +301,7 @@ func CreateTestPhysicalEntity(t *testing.T, entityType int, name string, parentI
 		filters := utils.RequestFilters{}
 		room, _ := models.GetObject(bson.M{"id": entity["id"]}, utils.EntityToString(entityType), filters, ManagerUserRoles)
 		if room != nil {
-			err := models.DeleteObject(utils.EntityToString(entityType), entity["id"].(string), ManagerUserRoles)
+			_, err := models.DeleteObject(utils.EntityToString(entityType), entity["id"].(string), ManagerUserRoles)
 			assert.Nil(t, err)
 		}
 	})
@@ -282,21 +309,26 @@ func CreateTestPhysicalEntity(t *testing.T, entityType int, name string, parentI
 }
 
 func CreateTestProject(t *testing.T, name string) (models.Project, string) {
+	return CreateTestProjectWithObjects(t, name, []string{"domain"}, nil, false)
+}
+
+func CreateTestProjectWithObjects(t *testing.T, name string, attributes, objects []string, showSumAndAvg bool) (models.Project, string) {
 	// Creates a temporary project that will be deleted at the end of the test
 	adminUser := "admin@admin.com"
 	project := models.Project{
 		Name:        name,
-		Attributes:  []string{"domain"},
+		Attributes:  attributes,
+		Objects:     objects,
 		Namespace:   "physical",
 		Permissions: []string{adminUser},
-		ShowAvg:     false,
-		ShowSum:     false,
+		ShowAvg:     showSumAndAvg,
+		ShowSum:     showSumAndAvg,
 	}
 	err := models.AddProject(project)
 	assert.Nil(t, err)
 
 	// we get the project ID
-	projects, _ := models.GetProjectsByUserEmail(adminUser)
+	projects, _ := models.GetProjectsByUserEmail(adminUser, ManagerUserRoles)
 	projectIndex := slices.IndexFunc(projects, func(p models.Project) bool {
 		return p.Name == project.Name
 	})
@@ -304,12 +336,12 @@ func CreateTestProject(t *testing.T, name string) (models.Project, string) {
 
 	t.Cleanup(func() {
 		// we get the room again as it may have been deleted in a test
-		projects, _ := models.GetProjectsByUserEmail(adminUser)
+		projects, _ := models.GetProjectsByUserEmail(adminUser, ManagerUserRoles)
 		projectExists := slices.ContainsFunc(projects, func(p models.Project) bool {
 			return p.Id == projectId
 		})
 		if projectExists {
-			err := models.DeleteProject(projectId)
+			err := models.DeleteProject(projectId, adminUser, ManagerUserRoles)
 			assert.Nil(t, err)
 		}
 	})
@@ -328,7 +360,7 @@ func CreateTestAlert(t *testing.T, name string, isDelete bool) (models.Alert, st
 
 	t.Cleanup(func() {
 		if !isDelete {
-			err := models.DeleteAlert(name)
+			err := models.DeleteAlert(name, ManagerUserRoles)
 			assert.Nil(t, err)
 		}
 	})
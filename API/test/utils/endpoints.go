@@ -9,29 +9,55 @@ const hierarchyEdnpoint = "/api/hierarchy"
 const entityEndpoint = "/api/%s"
 
 var endpoints = map[string]string{
-	"login":               "/api/login",
-	"users":               usersEndpoint,
-	"usersInstance":       usersEndpoint + "/%s",
-	"usersBulk":           usersEndpoint + "/bulk",
-	"changePassword":      usersEndpoint + "/password/change",
-	"resetPassword":       usersEndpoint + "/password/reset",
-	"entity":              entityEndpoint,
-	"entityInstance":      entityEndpoint + "/%s",
-	"entityAncestors":     entityEndpoint + "/%s/%s",
-	"entityUnlink":        entityEndpoint + "/%s/unlink",
-	"entityLink":          entityEndpoint + "/%s/link",
-	"domains":             domainsEndpoint,
-	"domainsBulk":         domainsEndpoint + "/bulk",
-	"getObject":           objectsEndpoint,
-	"complexFilterSearch": objectsEndpoint + "/search",
-	"validateEntity":      "/api/validate/%s",
-	"layersObjects":       "/api/layers/%s/objects",
-	"tokenValid":          "/api/token/valid",
-	"hierarchy":           hierarchyEdnpoint,
-	"hierarchyAttributes": hierarchyEdnpoint + "/attributes",
-	"tempunits":           "/api/tempunits/%s",
-	"projects":            "/api/projects",
-	"alerts":              "/api/alerts",
+	"login":                       "/api/login",
+	"users":                       usersEndpoint,
+	"usersInstance":               usersEndpoint + "/%s",
+	"usersBulk":                   usersEndpoint + "/bulk",
+	"changePassword":              usersEndpoint + "/password/change",
+	"resetPassword":               usersEndpoint + "/password/reset",
+	"entity":                      entityEndpoint,
+	"entityInstance":              entityEndpoint + "/%s",
+	"entityAncestors":             entityEndpoint + "/%s/%s",
+	"entityUnlink":                entityEndpoint + "/%s/unlink",
+	"entityLink":                  entityEndpoint + "/%s/link",
+	"entityMove":                  entityEndpoint + "/%s/move",
+	"entityCopy":                  entityEndpoint + "/%s/copy",
+	"domains":                     domainsEndpoint,
+	"domainsBulk":                 domainsEndpoint + "/bulk",
+	"getObject":                   objectsEndpoint,
+	"complexFilterSearch":         objectsEndpoint + "/search",
+	"aggregate":                   objectsEndpoint + "/aggregate",
+	"importCSV":                   "/api/import/csv",
+	"importDeviceTypes":           "/api/obj_templates/import",
+	"objTemplateInstances":        "/api/obj_templates/%s/instances",
+	"upgradeObjTemplateInstances": "/api/obj_templates/%s/instances/upgrade",
+	"telemetry":                   "/api/telemetry",
+	"objectTelemetry":             objectsEndpoint + "/%s/telemetry",
+	"alert":                       "/api/alerts/%s",
+	"alertRules":                  "/api/alert_rules",
+	"alertRule":                   "/api/alert_rules/%s",
+	"evaluateAlertRule":           "/api/alert_rules/%s/evaluate",
+	"textSearch":                  objectsEndpoint + "/search?q=%s",
+	"validateEntity":              "/api/validate/%s",
+	"layersObjects":               "/api/layers/%s/objects",
+	"tokenValid":                  "/api/token/valid",
+	"hierarchy":                   hierarchyEdnpoint,
+	"hierarchyAttributes":         hierarchyEdnpoint + "/attributes",
+	"tempunits":                   "/api/tempunits/%s",
+	"projects":                    "/api/projects",
+	"projectRevisions":            "/api/projects/%s/revisions",
+	"restoreProjectRevision":      "/api/projects/%s/revisions/%d/restore",
+	"alerts":                      "/api/alerts",
+	"snapshots":                   "/api/snapshots",
+	"snapshot":                    "/api/snapshots/%s",
+	"snapshotSchedules":           "/api/snapshot_schedules",
+	"snapshotSchedule":            "/api/snapshot_schedules/%s",
+	"diff":                        "/api/diff",
+	"integrity":                   "/api/integrity",
+	"integrityFix":                "/api/integrity/fix",
+	"relations":                   "/api/relations",
+	"relation":                    "/api/relations/%s",
+	"impact":                      "/api/impact/%s",
 }
 
 func GetEndpoint(endpointName string, pathParams ...any) string {
@@ -80,6 +80,7 @@ type RequestFilters struct {
 	Limit        string    `schema:"limit"`
 	Namespace    Namespace `schema:"namespace"`
 	Id           string    `schema:"id"`
+	Explain      bool      `schema:"explain"`
 }
 
 type LayerObjsFilters struct {
@@ -87,6 +88,12 @@ type LayerObjsFilters struct {
 	IsRecursive bool   `schema:"recursive"`
 }
 
+type SearchFilters struct {
+	Query     string    `schema:"q"`
+	Namespace Namespace `schema:"namespace"`
+	Limit     int       `schema:"limit"`
+}
+
 type HierarchyFilters struct {
 	Namespace      Namespace `schema:"namespace"`
 	StartDate      string    `schema:"startDate"`
@@ -107,6 +114,7 @@ const (
 	ErrDBError
 	ErrInternal
 	ErrNotFound
+	ErrConflict
 	WarnShouldChangePass
 )
 
@@ -194,7 +202,7 @@ func FilteredReqFromQueryParams(link *url.URL) bson.M {
 
 	for key := range queryValues {
 		if key != "fieldOnly" && key != "startDate" && key != "endDate" &&
-			key != "limit" && key != "namespace" {
+			key != "limit" && key != "namespace" && key != "explain" {
 			keyValue := ConvertString(queryValues.Get(key))
 			AddFilterToReq(bsonMap, key, keyValue)
 		}
@@ -239,6 +247,8 @@ func ErrTypeToStatusCode(errType ErrType) int {
 		return http.StatusInternalServerError
 	case ErrNotFound:
 		return http.StatusNotFound
+	case ErrConflict:
+		return http.StatusConflict
 	}
 	return http.StatusInternalServerError
 }
@@ -17,28 +17,9 @@ var doubleStarRegex = regexp.MustCompile(`\\\.\*\*\\\.`)                     //
 var pointStar = ".*"
 var starRegex = regexp.MustCompile(`([^\)]|^)\*+`) // * with something different to ")" before to avoid replacing the * written in the previous steps
 
-func ApplyWildcardsOnComplexFilter(filter map[string]interface{}) {
-	for key, val := range filter {
-		switch v := val.(type) {
-		case string:
-			if key == "$not" || (!strings.HasPrefix(key, "$") && strings.Contains(v, "*")) {
-				// only for '=' with * and always for '!='
-				filter[key] = regexToMongoFilter(applyWildcards(v))
-			}
-		case []interface{}:
-			for _, item := range v {
-				if m, ok := item.(map[string]interface{}); ok {
-					ApplyWildcardsOnComplexFilter(m)
-				}
-			}
-		case []map[string]any:
-			for _, item := range v {
-				ApplyWildcardsOnComplexFilter(item)
-			}
-		case map[string]interface{}:
-			ApplyWildcardsOnComplexFilter(v)
-		}
-	}
+// WildcardsToMongoRegex: mongo filter matching the value with its wildcards
+func WildcardsToMongoRegex(value string) bson.M {
+	return regexToMongoFilter(applyWildcards(value))
 }
 
 func applyWildcards(value string) string {
@@ -71,7 +71,7 @@ require (
 	google.golang.org/protobuf v1.30.0 // indirect
 	gopkg.in/inf.v0 v0.9.1 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 	k8s.io/api v0.28.0 // indirect
 	k8s.io/apimachinery v0.28.0 // indirect
 	k8s.io/client-go v0.28.0 // indirect
@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"back-admin/models"
+	"back-admin/services/dcim"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// swagger:operation POST /tools/dcim/export Tools ExportDcim
+// Export OGrEE sites to Netbox or Nautobot: the sites, their buildings and
+// rooms (locations), racks (with their height in U) and devices (with their
+// U position) are created or updated in the tool. The id of each record is
+// stored in the attribute netboxId or nautobotId of its OGrEE object, so that
+// exporting again updates the same records. The device types (Netbox slug or
+// Nautobot model: the template of the device) and the device role must exist
+// in the tool, and, in Nautobot, the location types Site, Building and Room.
+// With dryRun, the changes are only reported.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: body
+//     in: body
+//     description: 'Mandatory: tool (netbox or nautobot), target and ogree
+//     (url and token of their API), sites (ids of the OGrEE sites).
+//     Optional: deviceRole (default: server), dryRun.'
+//     required: true
+//     format: object
+//     example: '{"tool": "netbox", "target": {"url": "http://localhost:8000", "token": "0123456789abcdef"},
+//     "ogree": {"url": "http://localhost:3001", "token": "eyJhbGciOiJIUzI1NiIs"},
+//     "sites": ["paris"], "dryRun": true}'
+//
+// security:
+//   - Bearer: []
+//
+// responses:
+//
+//	'200':
+//	    description: 'Exported. A report with the action (create, update,
+//	    unchanged or error), the id in the tool and the changes of each
+//	    object is returned.'
+//	'400':
+//	    description: Bad request
+//	'502':
+//	    description: OGrEE API unreachable or site not found
+func ExportDcim(c *gin.Context) {
+	var export models.DcimExport
+	if err := c.ShouldBindJSON(&export); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	report, err := dcim.Export(export)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadGateway, gin.H{"message": err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, report)
+}
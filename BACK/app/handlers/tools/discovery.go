@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"back-admin/models"
+	"back-admin/services/discovery"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// swagger:operation POST /tools/kubernetes/discover Tools DiscoverKubernetes
+// Discover a Kubernetes cluster in an OGrEE tenant: the cluster, its
+// namespaces, deployments and nodes are created or updated as virtual
+// objects (virtual_config.type cluster, kube-namespace, kube-app and
+// kube-node) with the clusterId of the cluster and the attribute
+// discoveredBy kubernetes. The nodes are linked by their vlinks to the
+// devices having their name, whose clusterId is set. With prune, the
+// discovered virtual objects of the cluster that are no more in Kubernetes
+// are deleted. With dryRun, the changes are only reported.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: body
+//     in: body
+//     description: 'Mandatory: kubeconfig (content of the kubeconfig file, with
+//     certificates and keys inline as *-data, files are rejected),
+//     domain, ogree (url and token of its API). Optional: context (default:
+//     the current context), cluster (name of the cluster in OGrEE, default:
+//     its name in the kubeconfig), prune, dryRun.'
+//     required: true
+//     format: object
+//     example: '{"kubeconfig": "apiVersion: v1\nkind: Config\n...", "domain": "demo",
+//     "ogree": {"url": "http://localhost:3001", "token": "eyJhbGciOiJIUzI1NiIs"},
+//     "prune": true, "dryRun": true}'
+//
+// security:
+//   - Bearer: []
+//
+// responses:
+//
+//	'200':
+//	    description: 'Discovered. A report with the action (create, update,
+//	    unchanged, delete or error) and the changes of each object is returned.'
+//	'400':
+//	    description: Bad request
+//	'502':
+//	    description: Invalid kubeconfig or Kubernetes API unreachable
+func DiscoverKubernetes(c *gin.Context) {
+	var request models.KubeDiscovery
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	report, err := discovery.DiscoverKubernetes(request)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadGateway, gin.H{"message": err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, report)
+}
@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"back-admin/models"
+	"back-admin/services/netbox"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// swagger:operation POST /tools/netbox/sync Tools SyncNetbox
+// Sync the OGrEE objects of a tenant with Netbox: its sites, locations,
+// racks, devices and device types are created or updated in OGrEE as sites,
+// buildings, rooms, racks, devices and templates, in the domains of their
+// tenants. With dryRun, the changes are only reported. With incremental,
+// only the objects updated in Netbox since the last sync are written.
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: body
+//     in: body
+//     description: 'Mandatory: netbox and ogree (url and token of their API),
+//     mapping.domain (domain of the objects without tenant).
+//     Optional: dryRun, incremental and the other fields of mapping (tenants,
+//     sites, defaultBuilding, defaultRoom, deviceAttributes, defaults).'
+//     required: true
+//     format: object
+//     example: '{"netbox": {"url": "http://localhost:8000", "token": "0123456789abcdef"},
+//     "ogree": {"url": "http://localhost:3001", "token": "eyJhbGciOiJIUzI1NiIs"},
+//     "mapping": {"domain": "demo", "tenants": {"acme": "demo.acme"}}, "dryRun": true}'
+//
+// security:
+//   - Bearer: []
+//
+// responses:
+//
+//	'200':
+//	    description: 'Synced. A report with the action (create, update,
+//	    unchanged, skip or error) and the changes of each object is returned.'
+//	'400':
+//	    description: Bad request
+//	'502':
+//	    description: Netbox API unreachable or invalid response
+func SyncNetbox(c *gin.Context) {
+	var sync models.NetboxSync
+	if err := c.ShouldBindJSON(&sync); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	report, err := netbox.Sync(sync)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadGateway, gin.H{"message": err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, report)
+}
@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"back-admin/models"
+	"back-admin/services/opendcim"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// swagger:operation POST /tools/opendcim/import Tools ImportOpenDcim
+// Import the data of openDCIM in an OGrEE tenant: its data centers become
+// rooms of a building of the site, its cabinets racks, its devices devices,
+// its device templates object templates and its power panels panels. The
+// power strips of the cabinets are the breakers of the racks, linked to
+// their power panel by its name (by its id for a panel of another room). The data is read from the openDCIM
+// API (JSON body with api) or from a MySQL dump of its database (multipart
+// form with the dump as file and the JSON body as import).
+// With dryRun, the changes are only reported.
+// ---
+// consumes:
+// - application/json
+// - multipart/form-data
+// produces:
+// - application/json
+// parameters:
+//   - name: body
+//     in: body
+//     description: 'Mandatory: ogree (url and token of its API), mapping.domain
+//     and mapping.site, api (url, userId and apiKey of the openDCIM API) unless
+//     a dump is given. Optional: dryRun, mapping.building (default: main),
+//     mapping.defaults.'
+//     required: true
+//     format: object
+//     example: '{"api": {"url": "http://localhost:8080", "userId": "dcim", "apiKey": "0123456789abcdef"},
+//     "ogree": {"url": "http://localhost:3001", "token": "eyJhbGciOiJIUzI1NiIs"},
+//     "mapping": {"domain": "demo", "site": "paris"}, "dryRun": true}'
+//
+// security:
+//   - Bearer: []
+//
+// responses:
+//
+//	'200':
+//	    description: 'Imported. A report with the action (create, update,
+//	    unchanged, skip or error) and the changes of each object is returned.'
+//	'400':
+//	    description: Bad request
+//	'502':
+//	    description: openDCIM API unreachable or invalid dump
+func ImportOpenDcim(c *gin.Context) {
+	var request models.OpenDcimImport
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		formFile, err := c.FormFile("file")
+		if err != nil {
+			c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if err := json.Unmarshal([]byte(c.PostForm("import")), &request); err != nil {
+			c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "invalid import: " + err.Error()})
+			return
+		}
+		if err := binding.Validator.ValidateStruct(&request); err != nil {
+			c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		dir, err := os.MkdirTemp("", "opendcim")
+		if err != nil {
+			c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		defer os.RemoveAll(dir)
+		request.Dump = filepath.Join(dir, "dump.sql")
+		if err := c.SaveUploadedFile(formFile, request.Dump); err != nil {
+			c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+	} else if err := c.ShouldBindJSON(&request); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	} else if request.Api == nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "api or dump file is required"})
+		return
+	}
+
+	report, err := opendcim.Import(request)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadGateway, gin.H{"message": err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, report)
+}
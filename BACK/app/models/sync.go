@@ -0,0 +1,129 @@
+package models
+
+// Connection to the API of a tool (Netbox, Nautobot) or of an OGrEE tenant
+type ApiConnection struct {
+	Url   string `json:"url" binding:"required"`
+	Token string `json:"token" binding:"required"`
+}
+
+type NetboxSync struct {
+	Netbox  ApiConnection `json:"netbox" binding:"required"`
+	Ogree   ApiConnection `json:"ogree" binding:"required"`
+	Mapping NetboxMapping `json:"mapping"`
+	DryRun  bool          `json:"dryRun"`
+	// Only the objects updated in Netbox since the last sync are written
+	Incremental bool `json:"incremental"`
+}
+
+// NetboxMapping gives the OGrEE objects built from the Netbox objects:
+// tenants are domains, sites are sites, top level locations are buildings,
+// their child locations are rooms, racks are racks, devices are devices
+// and device types are object templates
+type NetboxMapping struct {
+	// Domain of the objects without tenant
+	Domain string `json:"domain" binding:"required"`
+	// Netbox tenant slug: OGrEE domain, default: the tenant slug
+	Tenants map[string]string `json:"tenants"`
+	// Netbox site slug: OGrEE site name, default: the site slug
+	Sites map[string]string `json:"sites"`
+	// Building of the racks without location, default: main
+	DefaultBuilding string `json:"defaultBuilding"`
+	// Room of the racks directly in a top level location, default: main
+	DefaultRoom string `json:"defaultRoom"`
+	// Netbox device field or custom field (custom_fields.<name>): OGrEE attribute,
+	// default: serial and asset_tag
+	DeviceAttributes map[string]string `json:"deviceAttributes"`
+	// Category: attributes of the created objects that Netbox does not give (size, position...)
+	Defaults map[string]map[string]any `json:"defaults"`
+}
+
+// DcimExport pushes OGrEE sites, with their rooms, racks and devices, to Netbox or Nautobot
+type DcimExport struct {
+	Tool   string        `json:"tool" binding:"required,oneof=netbox nautobot"`
+	Target ApiConnection `json:"target" binding:"required"`
+	Ogree  ApiConnection `json:"ogree" binding:"required"`
+	Sites  []string      `json:"sites" binding:"required,min=1"` // ids of the OGrEE sites
+	// Slug (Netbox) or name (Nautobot) of the role of the devices, default: server
+	DeviceRole string `json:"deviceRole"`
+	DryRun     bool   `json:"dryRun"`
+}
+
+// OpenDcimImport creates the OGrEE objects of the data centers, cabinets,
+// devices, power panels and templates of openDCIM, read from its API or
+// from a MySQL dump
+type OpenDcimImport struct {
+	Api     *OpenDcimApi    `json:"api"`
+	Dump    string          `json:"-"` // file of the MySQL dump, when there is no api
+	Ogree   ApiConnection   `json:"ogree" binding:"required"`
+	Mapping OpenDcimMapping `json:"mapping" binding:"required"`
+	DryRun  bool            `json:"dryRun"`
+}
+
+type OpenDcimApi struct {
+	Url    string `json:"url" binding:"required"`
+	UserId string `json:"userId" binding:"required"`
+	ApiKey string `json:"apiKey" binding:"required"`
+}
+
+// OpenDcimMapping gives the OGrEE objects built from the openDCIM objects:
+// data centers are rooms of a building of the site, cabinets are racks,
+// devices are devices, templates are object templates and power panels
+// are panels, linked to the racks by their breakers
+type OpenDcimMapping struct {
+	Domain string `json:"domain" binding:"required"`
+	Site   string `json:"site" binding:"required"`
+	// Building of the data centers, default: main
+	Building string `json:"building"`
+	// Category: attributes of the created objects that openDCIM does not give (size, position...)
+	Defaults map[string]map[string]any `json:"defaults"`
+}
+
+// KubeDiscovery creates or updates the virtual objects of a Kubernetes
+// cluster: the cluster, its nodes, namespaces and deployments
+type KubeDiscovery struct {
+	Kubeconfig string        `json:"kubeconfig" binding:"required"` // content of the kubeconfig file
+	Context    string        `json:"context"`                       // default: the current context
+	Cluster    string        `json:"cluster"`                       // name of the cluster in OGrEE, default: its name in the kubeconfig
+	Domain     string        `json:"domain" binding:"required"`
+	Ogree      ApiConnection `json:"ogree" binding:"required"`
+	// Delete the virtual objects of the cluster that are no more in Kubernetes
+	Prune  bool `json:"prune"`
+	DryRun bool `json:"dryRun"`
+}
+
+const (
+	SyncActionCreate    = "create"
+	SyncActionUpdate    = "update"
+	SyncActionUnchanged = "unchanged"
+	SyncActionSkip      = "skip"
+	SyncActionDelete    = "delete"
+	SyncActionError     = "error"
+)
+
+type SyncChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old,omitempty"`
+	New   any    `json:"new"`
+}
+
+type SyncObjectReport struct {
+	Category   string       `json:"category"`
+	Id         string       `json:"id"`
+	SourceId   int          `json:"sourceId,omitempty"`   // id of the object in the source tool
+	ExternalId string       `json:"externalId,omitempty"` // id of the object in the target tool
+	Action     string       `json:"action"`
+	Changes    []SyncChange `json:"changes,omitempty"`
+	Error      string       `json:"error,omitempty"`
+}
+
+type SyncReport struct {
+	DryRun    bool               `json:"dryRun"`
+	Since     string             `json:"since,omitempty"` // last sync of an incremental sync
+	Created   int                `json:"created"`
+	Updated   int                `json:"updated"`
+	Unchanged int                `json:"unchanged"`
+	Skipped   int                `json:"skipped"`
+	Deleted   int                `json:"deleted"`
+	Errors    int                `json:"errors"`
+	Objects   []SyncObjectReport `json:"objects"`
+}
@@ -0,0 +1,102 @@
+package dcim
+
+import (
+	"back-admin/models"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var errNotFound = errors.New("record not found")
+
+// client of the REST API of Netbox or Nautobot, that share the same conventions
+type client struct {
+	url        string
+	token      string
+	httpClient *http.Client
+}
+
+func newClient(connection models.ApiConnection) *client {
+	return &client{
+		url:        strings.TrimSuffix(connection.Url, "/"),
+		token:      connection.Token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// find returns the first record of the endpoint matching the query, nil if there is none
+func (client *client) find(endpoint string, query url.Values) (map[string]any, error) {
+	page := struct {
+		Results []map[string]any `json:"results"`
+	}{}
+	if err := client.request(http.MethodGet, "/api/"+endpoint+"/?"+query.Encode(), nil, &page); err != nil {
+		return nil, err
+	}
+	if len(page.Results) == 0 {
+		return nil, nil
+	}
+	return page.Results[0], nil
+}
+
+// get returns the record with this id, errNotFound if it does not exist
+func (client *client) get(endpoint, id string) (map[string]any, error) {
+	record := map[string]any{}
+	err := client.request(http.MethodGet, "/api/"+endpoint+"/"+id+"/", nil, &record)
+	return record, err
+}
+
+func (client *client) create(endpoint string, body map[string]any) (map[string]any, error) {
+	record := map[string]any{}
+	err := client.request(http.MethodPost, "/api/"+endpoint+"/", body, &record)
+	return record, err
+}
+
+func (client *client) patch(endpoint, id string, body map[string]any) error {
+	return client.request(http.MethodPatch, "/api/"+endpoint+"/"+id+"/", body, nil)
+}
+
+func (client *client) request(method, path string, body any, result any) error {
+	var reader io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(bodyBytes)
+	}
+
+	req, err := http.NewRequest(method, client.url+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Token "+client.token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errNotFound
+	} else if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s %s responded %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("invalid response on %s %s: %s", method, path, err.Error())
+	}
+	return nil
+}
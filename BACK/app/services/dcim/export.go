@@ -0,0 +1,475 @@
+package dcim
+
+import (
+	"back-admin/models"
+	"back-admin/services/ogree"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	toolNetbox   = "netbox"
+	toolNautobot = "nautobot"
+
+	defaultDeviceRole = "server"
+	rackUnitMm        = 44.45
+)
+
+// Depth of the hierarchy of a site: building, room, rack, device
+const siteHierarchyDepth = 4
+
+var invalidSlugCharacters = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// record is a Netbox or Nautobot record built from an OGrEE object
+type record struct {
+	category string // of the OGrEE object
+	id       string // of the OGrEE object
+	endpoint string
+	// id stored in the OGrEE object by a previous export, empty if there is none
+	externalId string
+	identity   map[string]any // fields only sent at creation
+	managed    map[string]any // fields compared and updated at each export
+	// query finding the record by its natural key, nil if its parent
+	// is not exported yet (dry run)
+	lookup url.Values
+	err    string // reason why the record can not be exported
+}
+
+type exporter struct {
+	tool       string
+	target     *client
+	ogree      *ogree.Client
+	deviceRole string
+	dryRun     bool
+	roleId     any
+	roleErr    error
+	types      map[string]any // device type id of each template
+	report     *models.SyncReport
+}
+
+// Export pushes the OGrEE sites, with their buildings, rooms, racks and
+// devices, to Netbox or Nautobot. The id of each record is stored in the
+// attribute <tool>Id of its OGrEE object so that the next exports update it
+// instead of creating a new one. With dry run, the changes are only reported.
+func Export(export models.DcimExport) (*models.SyncReport, error) {
+	exporter := &exporter{
+		tool:       export.Tool,
+		target:     newClient(export.Target),
+		ogree:      ogree.NewClient(export.Ogree),
+		deviceRole: export.DeviceRole,
+		dryRun:     export.DryRun,
+		types:      map[string]any{},
+		report:     &models.SyncReport{DryRun: export.DryRun, Objects: []models.SyncObjectReport{}},
+	}
+	if exporter.deviceRole == "" {
+		exporter.deviceRole = defaultDeviceRole
+	}
+
+	for _, siteId := range export.Sites {
+		site, err := exporter.ogree.GetHierarchy("site", siteId, siteHierarchyDepth)
+		if errors.Is(err, ogree.ErrNotFound) {
+			return nil, fmt.Errorf("site %s not found in OGrEE", siteId)
+		} else if err != nil {
+			return nil, err
+		}
+		exporter.exportSite(site)
+	}
+	return exporter.report, nil
+}
+
+func (exporter *exporter) isNautobot() bool {
+	return exporter.tool == toolNautobot
+}
+
+// attribute: the OGrEE attribute keeping the id of the records
+func (exporter *exporter) attribute() string {
+	return exporter.tool + "Id"
+}
+
+// status: the status of the created records
+func (exporter *exporter) status() any {
+	if exporter.isNautobot() {
+		return map[string]any{"name": "Active"}
+	}
+	return "active"
+}
+
+func (exporter *exporter) exportSite(site map[string]any) {
+	name := stringField(site, "name")
+	siteRecord := exporter.newRecord(site)
+	siteRecord.managed["name"] = name
+	siteRecord.managed["description"] = stringField(site, "description")
+	if exporter.isNautobot() {
+		siteRecord.endpoint = "dcim/locations"
+		siteRecord.identity["location_type"] = map[string]any{"name": "Site"}
+		siteRecord.lookup = url.Values{"location_type": {"Site"}, "name": {name}}
+	} else {
+		siteRecord.endpoint = "dcim/sites"
+		siteRecord.identity["slug"] = toSlug(name)
+		siteRecord.lookup = url.Values{"slug": {toSlug(name)}}
+	}
+	siteId := exporter.export(siteRecord)
+
+	for _, building := range children(site, "building") {
+		buildingId := exporter.exportLocation(building, "Building", siteId, nil)
+		for _, room := range children(building, "room") {
+			roomId := exporter.exportLocation(room, "Room", siteId, buildingId)
+			for _, rack := range children(room, "rack") {
+				rackId := exporter.exportRack(rack, siteId, roomId)
+				for _, device := range children(rack, "device") {
+					exporter.exportDevice(device, siteId, roomId, rackId)
+				}
+			}
+		}
+	}
+}
+
+// exportLocation: buildings and rooms are locations, in Netbox the buildings
+// are the top level locations of the site and the rooms their children, in
+// Nautobot they are locations of type Building and Room
+func (exporter *exporter) exportLocation(location map[string]any, locationType string, siteId, parentId any) any {
+	name := stringField(location, "name")
+	locationRecord := exporter.newRecord(location)
+	locationRecord.endpoint = "dcim/locations"
+	locationRecord.managed["name"] = name
+	locationRecord.managed["description"] = stringField(location, "description")
+
+	if exporter.isNautobot() {
+		if parentId == nil {
+			parentId = siteId
+		}
+		locationRecord.identity["location_type"] = map[string]any{"name": locationType}
+		locationRecord.managed["parent"] = parentId
+		if parentId != nil {
+			locationRecord.lookup = url.Values{"parent": {fmt.Sprint(parentId)}, "name": {name}}
+		}
+	} else {
+		locationRecord.identity["slug"] = toSlug(name)
+		locationRecord.managed["site"] = siteId
+		locationRecord.managed["parent"] = parentId
+		if parentId != nil {
+			locationRecord.lookup = url.Values{"parent_id": {fmt.Sprint(parentId)}, "slug": {toSlug(name)}}
+		} else if siteId != nil {
+			locationRecord.lookup = url.Values{"site_id": {fmt.Sprint(siteId)}, "slug": {toSlug(name)}}
+		}
+	}
+	return exporter.export(locationRecord)
+}
+
+func (exporter *exporter) exportRack(rack map[string]any, siteId, roomId any) any {
+	name := stringField(rack, "name")
+	rackRecord := exporter.newRecord(rack)
+	rackRecord.endpoint = "dcim/racks"
+	rackRecord.managed["name"] = name
+	rackRecord.managed["location"] = roomId
+	if height, err := rackUnits(attributes(rack)); err != nil {
+		rackRecord.err = err.Error()
+	} else {
+		rackRecord.managed["u_height"] = height
+	}
+
+	if exporter.isNautobot() {
+		if roomId != nil {
+			rackRecord.lookup = url.Values{"location": {fmt.Sprint(roomId)}, "name": {name}}
+		}
+	} else {
+		rackRecord.managed["site"] = siteId
+		if roomId != nil {
+			rackRecord.lookup = url.Values{"location_id": {fmt.Sprint(roomId)}, "name": {name}}
+		}
+	}
+	return exporter.export(rackRecord)
+}
+
+func (exporter *exporter) exportDevice(device map[string]any, siteId, roomId, rackId any) {
+	name := stringField(device, "name")
+	deviceAttributes := attributes(device)
+	deviceRecord := exporter.newRecord(device)
+	deviceRecord.endpoint = "dcim/devices"
+	deviceRecord.managed["name"] = name
+	deviceRecord.managed["location"] = roomId
+	deviceRecord.managed["rack"] = rackId
+	if position, ok := toNumber(deviceAttributes["posU"]); ok {
+		deviceRecord.managed["position"] = position
+		deviceRecord.managed["face"] = "front"
+		if strings.HasPrefix(fmt.Sprint(deviceAttributes["orientation"]), "rear") {
+			deviceRecord.managed["face"] = "rear"
+		}
+	}
+
+	if deviceTypeId, err := exporter.deviceTypeId(deviceAttributes); err != nil {
+		deviceRecord.err = err.Error()
+	} else if roleId, err := exporter.getRoleId(); err != nil {
+		deviceRecord.err = err.Error()
+	} else {
+		deviceRecord.managed["device_type"] = deviceTypeId
+		deviceRecord.identity["role"] = roleId
+	}
+
+	if exporter.isNautobot() {
+		if roomId != nil {
+			deviceRecord.lookup = url.Values{"location": {fmt.Sprint(roomId)}, "name": {name}}
+		}
+	} else {
+		deviceRecord.managed["site"] = siteId
+		if siteId != nil {
+			deviceRecord.lookup = url.Values{"site_id": {fmt.Sprint(siteId)}, "name": {name}}
+		}
+	}
+	exporter.export(deviceRecord)
+}
+
+// deviceTypeId: the device type of the template of the device, found by its
+// slug in Netbox and by its model in Nautobot
+func (exporter *exporter) deviceTypeId(deviceAttributes map[string]any) (any, error) {
+	template, _ := deviceAttributes["template"].(string)
+	if template == "" {
+		return nil, errors.New("Devices without template are not exported")
+	}
+	if id, ok := exporter.types[template]; ok {
+		return id, nil
+	}
+
+	query := url.Values{"slug": {template}}
+	if exporter.isNautobot() {
+		model, _ := deviceAttributes["model"].(string)
+		if model == "" {
+			model = template
+		}
+		query = url.Values{"model": {model}}
+	}
+	deviceType, err := exporter.target.find("dcim/device-types", query)
+	if err != nil {
+		return nil, err
+	} else if deviceType == nil {
+		return nil, fmt.Errorf("Device type %s not found in %s", template, exporter.tool)
+	}
+	exporter.types[template] = deviceType["id"]
+	return deviceType["id"], nil
+}
+
+// getRoleId: the role given to the created devices, looked up once
+func (exporter *exporter) getRoleId() (any, error) {
+	if exporter.roleId != nil || exporter.roleErr != nil {
+		return exporter.roleId, exporter.roleErr
+	}
+
+	var role map[string]any
+	if exporter.isNautobot() {
+		role, exporter.roleErr = exporter.target.find("extras/roles", url.Values{"name": {exporter.deviceRole}})
+	} else {
+		role, exporter.roleErr = exporter.target.find("dcim/device-roles", url.Values{"slug": {exporter.deviceRole}})
+	}
+	if exporter.roleErr == nil && role == nil {
+		exporter.roleErr = fmt.Errorf("Device role %s not found in %s", exporter.deviceRole, exporter.tool)
+	}
+	if exporter.roleErr == nil {
+		exporter.roleId = role["id"]
+	}
+	return exporter.roleId, exporter.roleErr
+}
+
+func (exporter *exporter) newRecord(object map[string]any) record {
+	externalId, _ := attributes(object)[exporter.attribute()]
+	stored := ""
+	if externalId != nil {
+		stored = fmt.Sprint(externalId)
+	}
+	return record{
+		category:   stringField(object, "category"),
+		id:         stringField(object, "id"),
+		externalId: stored,
+		identity:   map[string]any{"status": exporter.status()},
+		managed:    map[string]any{},
+	}
+}
+
+// export creates or updates the record, reports it and returns its id
+// in the target, nil if it does not exist there (dry run or error)
+func (exporter *exporter) export(record record) any {
+	report := models.SyncObjectReport{Category: record.category, Id: record.id, ExternalId: record.externalId}
+	var recordId any
+	defer func() {
+		switch report.Action {
+		case models.SyncActionCreate:
+			exporter.report.Created++
+		case models.SyncActionUpdate:
+			exporter.report.Updated++
+		case models.SyncActionUnchanged:
+			exporter.report.Unchanged++
+		case models.SyncActionError:
+			exporter.report.Errors++
+		}
+		exporter.report.Objects = append(exporter.report.Objects, report)
+	}()
+
+	if record.err != "" {
+		report.Action = models.SyncActionError
+		report.Error = record.err
+		return nil
+	}
+
+	current, err := exporter.getCurrent(record)
+	if err == nil && current == nil {
+		report.Action = models.SyncActionCreate
+		report.Changes = getChanges(record.managed, map[string]any{})
+		if !exporter.dryRun {
+			current, err = exporter.target.create(record.endpoint, creation(record))
+		}
+	} else if err == nil {
+		report.Changes = getChanges(record.managed, current)
+		if len(report.Changes) == 0 {
+			report.Action = models.SyncActionUnchanged
+		} else {
+			report.Action = models.SyncActionUpdate
+			if !exporter.dryRun {
+				err = exporter.target.patch(record.endpoint, fmt.Sprint(current["id"]), changesToPatch(report.Changes))
+			}
+		}
+	}
+
+	if err == nil && current != nil {
+		recordId = current["id"]
+		report.ExternalId = fmt.Sprint(recordId)
+		if !exporter.dryRun && report.ExternalId != record.externalId {
+			err = exporter.ogree.Patch(record.category, record.id, map[string]any{
+				"attributes": map[string]any{exporter.attribute(): report.ExternalId},
+			})
+		}
+	}
+
+	if err != nil {
+		report.Action = models.SyncActionError
+		report.Error = err.Error()
+	}
+	return recordId
+}
+
+// getCurrent: the record with the stored id, or else with the natural key,
+// nil if there is none
+func (exporter *exporter) getCurrent(record record) (map[string]any, error) {
+	if record.externalId != "" {
+		current, err := exporter.target.get(record.endpoint, record.externalId)
+		if !errors.Is(err, errNotFound) {
+			return current, err
+		}
+	}
+	if record.lookup == nil {
+		return nil, nil
+	}
+	return exporter.target.find(record.endpoint, record.lookup)
+}
+
+func creation(record record) map[string]any {
+	creation := map[string]any{}
+	for _, fields := range []map[string]any{record.identity, record.managed} {
+		for key, value := range fields {
+			creation[key] = value
+		}
+	}
+	return creation
+}
+
+// getChanges: managed fields that differ from the current record
+func getChanges(managed, current map[string]any) []models.SyncChange {
+	changes := []models.SyncChange{}
+	for key, value := range managed {
+		old := recordValue(current[key])
+		if fmt.Sprint(old) != fmt.Sprint(value) {
+			changes = append(changes, models.SyncChange{Field: key, Old: old, New: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+func changesToPatch(changes []models.SyncChange) map[string]any {
+	patch := map[string]any{}
+	for _, change := range changes {
+		patch[change.Field] = change.New
+	}
+	return patch
+}
+
+// recordValue: the id of a related record or the value of a choice
+func recordValue(value any) any {
+	if object, ok := value.(map[string]any); ok {
+		if id, ok := object["id"]; ok {
+			return id
+		}
+		if choice, ok := object["value"]; ok {
+			return choice
+		}
+	}
+	return value
+}
+
+// rackUnits: the height of the rack in U
+func rackUnits(rackAttributes map[string]any) (int, error) {
+	height, ok := toNumber(rackAttributes["height"])
+	if !ok {
+		return 0, errors.New("Racks without height are not exported")
+	}
+	switch rackAttributes["heightUnit"] {
+	case "U":
+	case "mm":
+		height /= rackUnitMm
+	case "cm":
+		height = height * 10 / rackUnitMm
+	case "m":
+		height = height * 1000 / rackUnitMm
+	default:
+		return 0, fmt.Errorf("Unknown height unit %v", rackAttributes["heightUnit"])
+	}
+	if height < 1 {
+		return 1, nil
+	}
+	return int(math.Floor(height + 0.01)), nil
+}
+
+// children: the children of the object of this category
+func children(object map[string]any, category string) []map[string]any {
+	list, _ := object["children"].([]any)
+	result := []map[string]any{}
+	for _, child := range list {
+		if childObject, ok := child.(map[string]any); ok && childObject["category"] == category {
+			result = append(result, childObject)
+		}
+	}
+	return result
+}
+
+func attributes(object map[string]any) map[string]any {
+	objectAttributes, _ := object["attributes"].(map[string]any)
+	if objectAttributes == nil {
+		return map[string]any{}
+	}
+	return objectAttributes
+}
+
+func stringField(object map[string]any, field string) string {
+	value, _ := object[field].(string)
+	return value
+}
+
+// toNumber: attributes may be given as numbers or strings
+func toNumber(value any) (float64, bool) {
+	switch number := value.(type) {
+	case float64:
+		return number, true
+	case string:
+		parsed, err := strconv.ParseFloat(number, 64)
+		return parsed, err == nil
+	}
+	return 0, false
+}
+
+func toSlug(name string) string {
+	return strings.Trim(invalidSlugCharacters.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
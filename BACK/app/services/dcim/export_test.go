@@ -0,0 +1,248 @@
+package dcim
+
+import (
+	"back-admin/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeDcim keeps the records of each endpoint of the Netbox API
+type fakeDcim struct {
+	records map[string][]map[string]any
+	nextId  int
+	writes  int
+}
+
+// filterField: the field of the records filtered by a query parameter
+var filterField = map[string]string{
+	"site_id": "site", "parent_id": "parent", "location_id": "location",
+}
+
+func (dcim *fakeDcim) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Token dcim-token" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	path := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/"), "/"), "/")
+	endpoint := path[0] + "/" + path[1]
+
+	var body map[string]any
+	json.NewDecoder(r.Body).Decode(&body)
+
+	switch {
+	case r.Method == http.MethodGet && len(path) == 2:
+		results := []map[string]any{}
+		for _, record := range dcim.records[endpoint] {
+			matches := true
+			for parameter, values := range r.URL.Query() {
+				field := parameter
+				if mapped, ok := filterField[parameter]; ok {
+					field = mapped
+				}
+				if fmt.Sprint(recordValue(record[field])) != values[0] {
+					matches = false
+				}
+			}
+			if matches {
+				results = append(results, record)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"count": len(results), "results": results})
+	case r.Method == http.MethodGet:
+		if record := dcim.get(endpoint, path[2]); record != nil {
+			json.NewEncoder(w).Encode(record)
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case r.Method == http.MethodPost:
+		dcim.nextId++
+		body["id"] = dcim.nextId
+		dcim.records[endpoint] = append(dcim.records[endpoint], body)
+		dcim.writes++
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(body)
+	case r.Method == http.MethodPatch:
+		record := dcim.get(endpoint, path[2])
+		for key, value := range body {
+			record[key] = value
+		}
+		dcim.writes++
+		json.NewEncoder(w).Encode(record)
+	}
+}
+
+func (dcim *fakeDcim) get(endpoint, id string) map[string]any {
+	for _, record := range dcim.records[endpoint] {
+		if fmt.Sprint(record["id"]) == id {
+			return record
+		}
+	}
+	return nil
+}
+
+// fakeOgree serves the hierarchy of a site and keeps the patched attributes
+type fakeOgree struct {
+	site    map[string]any
+	objects map[string]map[string]any
+	writes  int
+}
+
+func (ogree *fakeOgree) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/"), "/")
+	switch r.Method {
+	case http.MethodGet:
+		if path[1] != "paris" || path[2] != "all" {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]any{"message": "Nothing matches this request"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": ogree.site})
+	case http.MethodPatch:
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		for key, value := range body["attributes"].(map[string]any) {
+			ogree.objects[path[1]]["attributes"].(map[string]any)[key] = value
+		}
+		ogree.writes++
+		json.NewEncoder(w).Encode(map[string]any{"data": ogree.objects[path[1]]})
+	}
+}
+
+func newFakeOgree() *fakeOgree {
+	ogree := &fakeOgree{objects: map[string]map[string]any{}}
+	newObject := func(category, id string, attributes map[string]any, children ...map[string]any) map[string]any {
+		object := map[string]any{
+			"id": id, "name": id[strings.LastIndex(id, ".")+1:], "category": category,
+			"description": category + " " + id, "attributes": attributes, "children": children,
+		}
+		ogree.objects[id] = object
+		return object
+	}
+	ogree.site = newObject("site", "paris", map[string]any{},
+		newObject("building", "paris.BA", map[string]any{},
+			newObject("room", "paris.BA.R1", map[string]any{},
+				newObject("rack", "paris.BA.R1.A01", map[string]any{"height": 42.0, "heightUnit": "U"},
+					newObject("device", "paris.BA.R1.A01.srv01",
+						map[string]any{"template": "dell-r640", "posU": 10.0, "orientation": "rear"}),
+					newObject("device", "paris.BA.R1.A01.srv02", map[string]any{"template": "unknown"}),
+				),
+				newObject("rack", "paris.BA.R1.A02", map[string]any{"height": "2000", "heightUnit": "mm"}),
+				newObject("corridor", "paris.BA.R1.C1", map[string]any{}),
+			),
+		),
+	)
+	return ogree
+}
+
+func newTestExport(t *testing.T) (*fakeDcim, *fakeOgree, models.DcimExport) {
+	dcim := &fakeDcim{nextId: 100, records: map[string][]map[string]any{
+		"dcim/device-types": {{"id": 1, "slug": "dell-r640", "model": "PowerEdge R640"}},
+		"dcim/device-roles": {{"id": 2, "slug": "server"}},
+		// an existing site, not yet exported
+		"dcim/sites": {{"id": 3, "name": "paris", "slug": "paris", "description": "", "status": map[string]any{"value": "active"}}},
+	}}
+	dcimServer := httptest.NewServer(dcim)
+	t.Cleanup(dcimServer.Close)
+
+	ogree := newFakeOgree()
+	ogreeServer := httptest.NewServer(ogree)
+	t.Cleanup(ogreeServer.Close)
+
+	return dcim, ogree, models.DcimExport{
+		Tool:   "netbox",
+		Target: models.ApiConnection{Url: dcimServer.URL, Token: "dcim-token"},
+		Ogree:  models.ApiConnection{Url: ogreeServer.URL, Token: "ogree-token"},
+		Sites:  []string{"paris"},
+	}
+}
+
+func getObjectReport(t *testing.T, report *models.SyncReport, id string) models.SyncObjectReport {
+	for _, object := range report.Objects {
+		if object.Id == id {
+			return object
+		}
+	}
+	t.Fatalf("%s not found in the report", id)
+	return models.SyncObjectReport{}
+}
+
+func TestExportDryRunWritesNothing(t *testing.T) {
+	dcim, ogree, export := newTestExport(t)
+	export.DryRun = true
+
+	report, err := Export(export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dcim.writes != 0 || ogree.writes != 0 {
+		t.Errorf("dry run wrote %d records and %d objects", dcim.writes, ogree.writes)
+	}
+	if report.Updated != 1 || report.Created != 5 || report.Errors != 1 {
+		t.Errorf("expected 1 update, 5 creations and 1 error, got %+v", report)
+	}
+	if site := getObjectReport(t, report, "paris"); site.ExternalId != "3" || site.Action != models.SyncActionUpdate {
+		t.Errorf("existing site not found by its slug: %+v", site)
+	}
+}
+
+func TestExportCreatesThenUpdates(t *testing.T) {
+	dcim, ogree, export := newTestExport(t)
+
+	report, err := Export(export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Updated != 1 || report.Created != 5 || report.Errors != 1 {
+		t.Fatalf("expected 1 update, 5 creations and 1 error, got %+v", report)
+	}
+	if device := getObjectReport(t, report, "paris.BA.R1.A01.srv02"); device.Error != "Device type unknown not found in netbox" {
+		t.Errorf("unexpected device report %+v", device)
+	}
+
+	device := dcim.records["dcim/devices"][0]
+	rackId := ogree.objects["paris.BA.R1.A01"]["attributes"].(map[string]any)["netboxId"]
+	if device["name"] != "srv01" || device["position"] != 10.0 || device["face"] != "rear" ||
+		device["device_type"] != 1.0 || device["role"] != 2.0 || fmt.Sprint(device["rack"]) != rackId {
+		t.Errorf("unexpected device %+v", device)
+	}
+	if height := dcim.records["dcim/racks"][1]["u_height"]; height != 45.0 {
+		t.Errorf("rack height in mm converted to %v U", height)
+	}
+	if room := dcim.records["dcim/locations"][1]; room["name"] != "R1" ||
+		strconv.Itoa(int(room["parent"].(float64))) != ogree.objects["paris.BA"]["attributes"].(map[string]any)["netboxId"] {
+		t.Errorf("unexpected room %+v", room)
+	}
+
+	// the device is renamed in Netbox and moved in OGrEE
+	dcim.records["dcim/devices"][0]["name"] = "renamed"
+	ogree.objects["paris.BA.R1.A01.srv01"]["attributes"].(map[string]any)["posU"] = 20.0
+
+	report, err = Export(export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != 0 || report.Updated != 1 || report.Unchanged != 5 {
+		t.Fatalf("expected 1 update, got %+v", report)
+	}
+	changes := getObjectReport(t, report, "paris.BA.R1.A01.srv01").Changes
+	if len(changes) != 2 || changes[0].Field != "name" || changes[1].Field != "position" {
+		t.Errorf("unexpected device changes %+v", changes)
+	}
+	if device["name"] != "srv01" || device["position"] != 20.0 {
+		t.Errorf("device not updated %+v", device)
+	}
+}
+
+func TestExportUnknownSite(t *testing.T) {
+	_, _, export := newTestExport(t)
+	export.Sites = []string{"london"}
+
+	if _, err := Export(export); err == nil || err.Error() != "site london not found in OGrEE" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
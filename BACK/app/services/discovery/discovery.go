@@ -0,0 +1,229 @@
+package discovery
+
+import (
+	"back-admin/models"
+	"back-admin/services/ogree"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// virtual_config.type of the discovered virtual objects
+const (
+	vtypeCluster   = "cluster"
+	vtypeNamespace = "kube-namespace"
+	vtypeNode      = "kube-node"
+	vtypeApp       = "kube-app"
+)
+
+const controlPlaneLabel = "node-role.kubernetes.io/control-plane"
+
+// discoveredBy attribute of the discovered virtual objects, only they are pruned
+const discoveredBy = "kubernetes"
+
+// discoverer builds the virtual objects of a Kubernetes cluster
+type discoverer struct {
+	ogree   *ogree.Client
+	writer  *ogree.Writer
+	cluster string // OGrEE id of the cluster
+	domain  string
+	written map[string]bool // ids of the discovered virtual objects
+}
+
+// DiscoverKubernetes reads the namespaces, deployments and nodes of the
+// cluster of the kubeconfig and creates or updates their virtual objects:
+// the cluster is a root virtual object, its namespaces and nodes are its
+// children and the deployments are children of their namespace. The nodes
+// are linked (vlinks) to the devices having their name, whose clusterId is
+// set to the cluster. With prune, the virtual objects of the cluster that are
+// no more in Kubernetes are deleted. With dry run, the changes are only reported.
+func DiscoverKubernetes(discovery models.KubeDiscovery) (*models.SyncReport, error) {
+	kube, err := newKubeClient(discovery.Kubeconfig, discovery.Context)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := kube.namespaces()
+	if err != nil {
+		return nil, err
+	}
+	deployments, err := kube.deployments()
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := kube.nodes()
+	if err != nil {
+		return nil, err
+	}
+
+	cluster := discovery.Cluster
+	if cluster == "" {
+		cluster = kube.cluster
+	}
+	client := ogree.NewClient(discovery.Ogree)
+	discoverer := &discoverer{
+		ogree:   client,
+		writer:  ogree.NewWriter(client, discovery.DryRun, nil),
+		cluster: ogree.ToName(cluster),
+		domain:  discovery.Domain,
+		written: map[string]bool{},
+	}
+
+	discoverer.write(discoverer.virtualObject("", discoverer.cluster, "Kubernetes cluster "+cluster,
+		map[string]any{"type": vtypeCluster, "role": "kubernetes"}, nil))
+	for _, namespace := range namespaces {
+		discoverer.write(discoverer.virtualObject(discoverer.cluster, namespace.Metadata.Name, "Namespace "+namespace.Metadata.Name,
+			discoverer.virtualConfig(vtypeNamespace, "namespace"), nil))
+	}
+	for _, deployment := range deployments {
+		attributes := map[string]any{}
+		if deployment.Spec.Replicas != nil {
+			attributes["replicas"] = *deployment.Spec.Replicas
+		}
+		discoverer.write(discoverer.virtualObject(discoverer.cluster+"."+ogree.ToName(deployment.Metadata.Namespace),
+			deployment.Metadata.Name, "Deployment "+deployment.Metadata.Namespace+"/"+deployment.Metadata.Name,
+			discoverer.virtualConfig(vtypeApp, "deployment"), attributes))
+	}
+	for _, node := range nodes {
+		if err := discoverer.discoverNode(node); err != nil {
+			return nil, err
+		}
+	}
+
+	if discovery.Prune {
+		if err := discoverer.prune(); err != nil {
+			return nil, err
+		}
+	}
+	return discoverer.writer.Report(), nil
+}
+
+func (discoverer *discoverer) write(object ogree.Object) {
+	if object.Category == "virtual_obj" {
+		discoverer.written[object.Id] = true
+	}
+	discoverer.writer.Write(object)
+}
+
+func (discoverer *discoverer) virtualConfig(vtype, role string) map[string]any {
+	return map[string]any{"type": vtype, "role": role, "clusterId": discoverer.cluster}
+}
+
+// virtualObject: the virtual object named after the Kubernetes object,
+// with its virtual config and attributes
+func (discoverer *discoverer) virtualObject(parentId, kubeName, description string,
+	virtualConfig map[string]any, attributes map[string]any) ogree.Object {
+	name := ogree.ToName(kubeName)
+	id := name
+	identity := map[string]any{"name": name, "category": "virtual_obj"}
+	if parentId != "" {
+		id = parentId + "." + name
+		identity["parentId"] = parentId
+	}
+
+	if attributes == nil {
+		attributes = map[string]any{}
+	}
+	attributes["virtual_config"] = virtualConfig
+	attributes["discoveredBy"] = discoveredBy
+	return ogree.Object{
+		Category: "virtual_obj",
+		Id:       id,
+		Identity: identity,
+		Managed:  map[string]any{"description": description, "domain": discoverer.domain, "attributes": attributes},
+	}
+}
+
+// discoverNode: the virtual object of the node, linked to the devices having
+// its name (or its host name), whose clusterId is set to the cluster
+func (discoverer *discoverer) discoverNode(node kubeNode) error {
+	devices, err := discoverer.findDevices(node.Metadata.Name)
+	if err != nil {
+		return err
+	}
+
+	role := "worker"
+	if _, isControlPlane := node.Metadata.Labels[controlPlaneLabel]; isControlPlane {
+		role = "control-plane"
+	}
+	attributes := map[string]any{}
+	if version := node.Status.NodeInfo.KubeletVersion; version != "" {
+		attributes["kubeletVersion"] = version
+	}
+	vlinks := []any{}
+	for _, device := range devices {
+		vlinks = append(vlinks, device["id"])
+	}
+	if len(vlinks) > 0 {
+		attributes["vlinks"] = vlinks
+	}
+	discoverer.write(discoverer.virtualObject(discoverer.cluster, node.Metadata.Name, "Node "+node.Metadata.Name,
+		discoverer.virtualConfig(vtypeNode, role), attributes))
+
+	for _, device := range devices {
+		deviceAttributes, _ := device["attributes"].(map[string]any)
+		virtualConfig := map[string]any{}
+		if current, ok := deviceAttributes["virtual_config"].(map[string]any); ok {
+			for key, value := range current {
+				virtualConfig[key] = value
+			}
+		}
+		virtualConfig["clusterId"] = discoverer.cluster
+		id, _ := device["id"].(string)
+		discoverer.write(ogree.Object{
+			Category: "device",
+			Id:       id,
+			Managed:  map[string]any{"attributes": map[string]any{"virtual_config": virtualConfig}},
+		})
+	}
+	return nil
+}
+
+// findDevices: the devices named as the node or, for a fully qualified
+// node name, as its host name
+func (discoverer *discoverer) findDevices(nodeName string) ([]map[string]any, error) {
+	names := []string{ogree.ToName(nodeName)}
+	if hostName, _, isQualified := strings.Cut(nodeName, "."); isQualified {
+		names = append(names, ogree.ToName(hostName))
+	}
+	for _, name := range names {
+		devices, err := discoverer.ogree.Search(url.Values{"category": {"device"}, "name": {name}})
+		if err != nil || len(devices) > 0 {
+			return devices, err
+		}
+	}
+	return nil, nil
+}
+
+// prune deletes the discovered virtual objects of the cluster that are no
+// more in Kubernetes, children first. The objects created by hand, without
+// the discoveredBy attribute, are kept.
+func (discoverer *discoverer) prune() error {
+	existing, err := discoverer.ogree.Search(url.Values{"category": {"virtual_obj"}, "id": {discoverer.cluster + ".**"}})
+	if err != nil {
+		return err
+	}
+
+	ids := []string{}
+	for _, object := range existing {
+		id, _ := object["id"].(string)
+		attributes, _ := object["attributes"].(map[string]any)
+		virtualConfig, _ := attributes["virtual_config"].(map[string]any)
+		if attributes["discoveredBy"] != discoveredBy {
+			continue
+		}
+		switch virtualConfig["type"] {
+		case vtypeNamespace, vtypeNode, vtypeApp:
+			if !discoverer.written[id] {
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return strings.Count(ids[i], ".") > strings.Count(ids[j], ".") ||
+			(strings.Count(ids[i], ".") == strings.Count(ids[j], ".") && ids[i] < ids[j])
+	})
+	for _, id := range ids {
+		discoverer.writer.Delete("virtual_obj", id)
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package discovery
+
+import (
+	"back-admin/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeKubernetes serves the items of each path of the Kubernetes API
+type fakeKubernetes struct {
+	items map[string][]map[string]any
+}
+
+func (kube *fakeKubernetes) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer kube-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	items, ok := kube.items[r.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"kind": "List", "items": items})
+}
+
+// fakeOgree keeps the objects by id, and searches them by category, name and id prefix
+type fakeOgree struct {
+	objects map[string]map[string]any
+}
+
+func (ogree *fakeOgree) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/"), "/")
+	var body map[string]any
+	json.NewDecoder(r.Body).Decode(&body)
+
+	switch {
+	case path[0] == "objects":
+		query := r.URL.Query()
+		found := []any{}
+		for id, object := range ogree.objects {
+			prefix, isPrefix := strings.CutSuffix(query.Get("id"), "**")
+			if object["category"] == query.Get("category") &&
+				(query.Get("name") == "" || object["name"] == query.Get("name")) &&
+				(!isPrefix || strings.HasPrefix(id, prefix)) {
+				found = append(found, object)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": found})
+	case r.Method == http.MethodGet:
+		object, ok := ogree.objects[path[1]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]any{"message": "Nothing matches this request"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": object})
+	case r.Method == http.MethodPost:
+		id := body["name"].(string)
+		if parentId, _ := body["parentId"].(string); parentId != "" {
+			id = parentId + "." + id
+		}
+		body["id"] = id
+		ogree.objects[id] = body
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]any{"data": body})
+	case r.Method == http.MethodPatch:
+		object := ogree.objects[path[1]]
+		for key, value := range body {
+			if key == "attributes" {
+				for attribute, attributeValue := range value.(map[string]any) {
+					object["attributes"].(map[string]any)[attribute] = attributeValue
+				}
+			} else {
+				object[key] = value
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": object})
+	case r.Method == http.MethodDelete:
+		for id := range ogree.objects {
+			if id == path[1] || strings.HasPrefix(id, path[1]+".") {
+				delete(ogree.objects, id)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"message": "successfully deleted"})
+	}
+}
+
+func newTestDiscovery(t *testing.T) (*fakeKubernetes, *fakeOgree, models.KubeDiscovery) {
+	kube := &fakeKubernetes{items: map[string][]map[string]any{
+		"/api/v1/namespaces": {
+			{"metadata": map[string]any{"name": "default"}},
+			{"metadata": map[string]any{"name": "shop"}},
+		},
+		"/apis/apps/v1/deployments": {
+			{"metadata": map[string]any{"name": "front", "namespace": "shop"}, "spec": map[string]any{"replicas": 3}},
+			{"metadata": map[string]any{"name": "cart", "namespace": "shop"}, "spec": map[string]any{"replicas": 1}},
+		},
+		"/api/v1/nodes": {
+			{"metadata": map[string]any{"name": "srv01.example.com", "labels": map[string]any{controlPlaneLabel: ""}},
+				"status": map[string]any{"nodeInfo": map[string]any{"kubeletVersion": "v1.28.0"}}},
+			{"metadata": map[string]any{"name": "cloud-node"}},
+		},
+	}}
+	kubeServer := httptest.NewServer(kube)
+	t.Cleanup(kubeServer.Close)
+
+	ogree := &fakeOgree{objects: map[string]map[string]any{
+		"paris.BA.R1.A01.srv01": {"id": "paris.BA.R1.A01.srv01", "name": "srv01", "category": "device",
+			"attributes": map[string]any{"virtual_config": map[string]any{"type": "server"}}},
+	}}
+	ogreeServer := httptest.NewServer(ogree)
+	t.Cleanup(ogreeServer.Close)
+
+	kubeconfig := `apiVersion: v1
+kind: Config
+current-context: admin@prod
+clusters:
+- name: prod
+  cluster:
+    server: ` + kubeServer.URL + `
+contexts:
+- name: admin@prod
+  context:
+    cluster: prod
+    user: admin
+users:
+- name: admin
+  user:
+    token: kube-token
+`
+	return kube, ogree, models.KubeDiscovery{
+		Kubeconfig: kubeconfig,
+		Domain:     "demo",
+		Ogree:      models.ApiConnection{Url: ogreeServer.URL, Token: "ogree-token"},
+	}
+}
+
+func TestDiscoverDryRun(t *testing.T) {
+	_, ogree, discovery := newTestDiscovery(t)
+	discovery.DryRun = true
+
+	report, err := DiscoverKubernetes(discovery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ogree.objects) != 1 {
+		t.Errorf("dry run created %d objects", len(ogree.objects)-1)
+	}
+	// cluster, 2 namespaces, 2 deployments, 2 nodes and the device of a node
+	if report.Created != 7 || report.Updated != 1 {
+		t.Errorf("expected 7 creations and 1 update, got %+v", report)
+	}
+}
+
+func TestDiscoverCreatesVirtualObjects(t *testing.T) {
+	_, ogree, discovery := newTestDiscovery(t)
+
+	report, err := DiscoverKubernetes(discovery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Errors != 0 || report.Created != 7 {
+		t.Fatalf("expected 7 creations, got %+v", report)
+	}
+
+	app := ogree.objects["prod.shop.front"]
+	appConfig := app["attributes"].(map[string]any)["virtual_config"].(map[string]any)
+	if appConfig["type"] != "kube-app" || appConfig["clusterId"] != "prod" || app["domain"] != "demo" {
+		t.Errorf("unexpected deployment %+v", app)
+	}
+	node := ogree.objects["prod.srv01-example-com"]
+	nodeAttributes := node["attributes"].(map[string]any)
+	vlinks, _ := nodeAttributes["vlinks"].([]any)
+	if len(vlinks) != 1 || vlinks[0] != "paris.BA.R1.A01.srv01" ||
+		nodeAttributes["virtual_config"].(map[string]any)["role"] != "control-plane" {
+		t.Errorf("node not linked to its device: %+v", node)
+	}
+	if _, hasVlinks := ogree.objects["prod.cloud-node"]["attributes"].(map[string]any)["vlinks"]; hasVlinks {
+		t.Error("node without device has vlinks")
+	}
+	deviceConfig := ogree.objects["paris.BA.R1.A01.srv01"]["attributes"].(map[string]any)["virtual_config"].(map[string]any)
+	if deviceConfig["clusterId"] != "prod" || deviceConfig["type"] != "server" {
+		t.Errorf("unexpected device virtual config %+v", deviceConfig)
+	}
+
+	report, err = DiscoverKubernetes(discovery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != 0 || report.Updated != 0 || report.Unchanged != 8 {
+		t.Errorf("second discovery not unchanged: %+v", report)
+	}
+}
+
+func TestDiscoverPrunesDisappearedObjects(t *testing.T) {
+	kube, ogree, discovery := newTestDiscovery(t)
+	if _, err := DiscoverKubernetes(discovery); err != nil {
+		t.Fatal(err)
+	}
+	// an object added by hand in the cluster is kept
+	ogree.objects["prod.shop.db"] = map[string]any{"id": "prod.shop.db", "category": "virtual_obj",
+		"attributes": map[string]any{"virtual_config": map[string]any{"type": vtypeApp, "clusterId": "prod"}}}
+
+	kube.items["/apis/apps/v1/deployments"] = kube.items["/apis/apps/v1/deployments"][:1]
+	kube.items["/api/v1/nodes"] = kube.items["/api/v1/nodes"][:1]
+
+	report, err := DiscoverKubernetes(discovery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Deleted != 0 || ogree.objects["prod.shop.cart"] == nil {
+		t.Fatalf("objects deleted without prune: %+v", report)
+	}
+
+	discovery.Prune = true
+	report, err = DiscoverKubernetes(discovery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Deleted != 2 || ogree.objects["prod.shop.cart"] != nil || ogree.objects["prod.cloud-node"] != nil {
+		t.Errorf("disappeared objects not pruned: %+v", report)
+	}
+	if ogree.objects["prod.shop.db"] == nil || ogree.objects["prod.shop.front"] == nil {
+		t.Error("objects still in Kubernetes or added by hand pruned")
+	}
+}
+
+func TestDiscoverUnknownContext(t *testing.T) {
+	_, _, discovery := newTestDiscovery(t)
+	discovery.Context = "staging"
+
+	if _, err := DiscoverKubernetes(discovery); err == nil || err.Error() != `context "staging" not found in the kubeconfig` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestDiscoverRejectsFilesOfTheKubeconfig(t *testing.T) {
+	_, _, discovery := newTestDiscovery(t)
+	discovery.Kubeconfig = strings.Replace(discovery.Kubeconfig, "server:",
+		"certificate-authority: /etc/passwd\n    server:", 1)
+
+	if _, err := DiscoverKubernetes(discovery); err == nil || !strings.Contains(err.Error(), "files are not supported") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
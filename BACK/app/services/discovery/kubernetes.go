@@ -0,0 +1,233 @@
+package discovery
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// kubeconfig: the parts of a kubeconfig file used to reach the API server.
+// The fields referring to files are only read to be rejected: the kubeconfig
+// comes from the request and the files would be read on the server
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string `yaml:"token"`
+			TokenFile             string `yaml:"tokenFile"`
+			Username              string `yaml:"username"`
+			Password              string `yaml:"password"`
+			ClientCertificateData string `yaml:"client-certificate-data"`
+			ClientKeyData         string `yaml:"client-key-data"`
+			ClientCertificate     string `yaml:"client-certificate"`
+			ClientKey             string `yaml:"client-key"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster string `yaml:"cluster"`
+			User    string `yaml:"user"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+// kubeClient of the Kubernetes API server of a kubeconfig context
+type kubeClient struct {
+	cluster    string // name of the cluster in the kubeconfig
+	server     string
+	token      string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+func newKubeClient(content, contextName string) (*kubeClient, error) {
+	config := kubeconfig{}
+	if err := yaml.Unmarshal([]byte(content), &config); err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig: %s", err.Error())
+	}
+	if contextName == "" {
+		contextName = config.CurrentContext
+	}
+
+	client := &kubeClient{}
+	userName := ""
+	for _, context := range config.Contexts {
+		if context.Name == contextName {
+			client.cluster, userName = context.Context.Cluster, context.Context.User
+		}
+	}
+	if client.cluster == "" {
+		return nil, fmt.Errorf("context %q not found in the kubeconfig", contextName)
+	}
+
+	tlsConfig := &tls.Config{}
+	found := false
+	for _, cluster := range config.Clusters {
+		if cluster.Name != client.cluster {
+			continue
+		}
+		found = true
+		client.server = strings.TrimSuffix(cluster.Cluster.Server, "/")
+		tlsConfig.InsecureSkipVerify = cluster.Cluster.InsecureSkipTLSVerify
+		if cluster.Cluster.CertificateAuthority != "" {
+			return nil, errors.New("certificate-authority files are not supported, use certificate-authority-data")
+		}
+		ca, err := readData(cluster.Cluster.CertificateAuthorityData)
+		if err != nil {
+			return nil, err
+		} else if ca != nil {
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+				return nil, errors.New("invalid certificate authority in the kubeconfig")
+			}
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("cluster %q not found in the kubeconfig", client.cluster)
+	}
+
+	for _, user := range config.Users {
+		if user.Name != userName {
+			continue
+		}
+		if user.User.TokenFile != "" || user.User.ClientCertificate != "" || user.User.ClientKey != "" {
+			return nil, errors.New("tokenFile, client-certificate and client-key files are not supported, " +
+				"use token, client-certificate-data and client-key-data")
+		}
+		client.token, client.username, client.password = user.User.Token, user.User.Username, user.User.Password
+		if user.User.ClientCertificateData != "" {
+			cert, err := base64.StdEncoding.DecodeString(user.User.ClientCertificateData)
+			if err != nil {
+				return nil, errors.New("invalid client certificate in the kubeconfig")
+			}
+			key, err := base64.StdEncoding.DecodeString(user.User.ClientKeyData)
+			if err != nil {
+				return nil, errors.New("invalid client key in the kubeconfig")
+			}
+			certificate, err := tls.X509KeyPair(cert, key)
+			if err != nil {
+				return nil, fmt.Errorf("invalid client certificate in the kubeconfig: %s", err.Error())
+			}
+			tlsConfig.Certificates = []tls.Certificate{certificate}
+		}
+	}
+
+	client.httpClient = &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}
+	return client, nil
+}
+
+// readData: base64 data of the kubeconfig, nil if there is none
+func readData(data string) ([]byte, error) {
+	if data == "" {
+		return nil, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, errors.New("invalid certificate authority data in the kubeconfig")
+	}
+	return decoded, nil
+}
+
+// Kubernetes objects, with the fields used by the discovery
+type kubeMetadata struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"`
+	Labels    map[string]string `json:"labels"`
+}
+
+type kubeNamespace struct {
+	Metadata kubeMetadata `json:"metadata"`
+}
+
+type kubeNode struct {
+	Metadata kubeMetadata `json:"metadata"`
+	Status   struct {
+		Addresses []struct {
+			Type    string `json:"type"`
+			Address string `json:"address"`
+		} `json:"addresses"`
+		NodeInfo struct {
+			KubeletVersion string `json:"kubeletVersion"`
+		} `json:"nodeInfo"`
+	} `json:"status"`
+}
+
+type kubeDeployment struct {
+	Metadata kubeMetadata `json:"metadata"`
+	Spec     struct {
+		Replicas *int `json:"replicas"`
+	} `json:"spec"`
+}
+
+func (client *kubeClient) namespaces() ([]kubeNamespace, error) {
+	list := struct {
+		Items []kubeNamespace `json:"items"`
+	}{}
+	err := client.get("/api/v1/namespaces", &list)
+	return list.Items, err
+}
+
+func (client *kubeClient) nodes() ([]kubeNode, error) {
+	list := struct {
+		Items []kubeNode `json:"items"`
+	}{}
+	err := client.get("/api/v1/nodes", &list)
+	return list.Items, err
+}
+
+func (client *kubeClient) deployments() ([]kubeDeployment, error) {
+	list := struct {
+		Items []kubeDeployment `json:"items"`
+	}{}
+	err := client.get("/apis/apps/v1/deployments", &list)
+	return list.Items, err
+}
+
+func (client *kubeClient) get(path string, result any) error {
+	req, err := http.NewRequest(http.MethodGet, client.server+path, nil)
+	if err != nil {
+		return err
+	}
+	if client.token != "" {
+		req.Header.Set("Authorization", "Bearer "+client.token)
+	} else if client.username != "" {
+		req.SetBasicAuth(client.username, client.password)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("Kubernetes API responded %d on %s", resp.StatusCode, path)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("invalid response from Kubernetes API on %s: %s", path, err.Error())
+	}
+	return nil
+}
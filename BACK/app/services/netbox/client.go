@@ -0,0 +1,182 @@
+package netbox
+
+import (
+	"back-admin/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Number of objects by page of the Netbox API
+const pageSize = 500
+
+type Ref struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+type Choice struct {
+	Value string `json:"value"`
+}
+
+type Site struct {
+	Id          int       `json:"id"`
+	Name        string    `json:"name"`
+	Slug        string    `json:"slug"`
+	Description string    `json:"description"`
+	Tenant      *Ref      `json:"tenant"`
+	LastUpdated time.Time `json:"last_updated"`
+}
+
+type Location struct {
+	Id          int       `json:"id"`
+	Name        string    `json:"name"`
+	Slug        string    `json:"slug"`
+	Description string    `json:"description"`
+	Site        Ref       `json:"site"`
+	Parent      *Ref      `json:"parent"`
+	Tenant      *Ref      `json:"tenant"`
+	LastUpdated time.Time `json:"last_updated"`
+}
+
+type Rack struct {
+	Id          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Site        Ref       `json:"site"`
+	Location    *Ref      `json:"location"`
+	Tenant      *Ref      `json:"tenant"`
+	UHeight     float64   `json:"u_height"`
+	OuterWidth  *float64  `json:"outer_width"`
+	OuterDepth  *float64  `json:"outer_depth"`
+	OuterUnit   *Choice   `json:"outer_unit"`
+	LastUpdated time.Time `json:"last_updated"`
+}
+
+type DeviceType struct {
+	Id           int       `json:"id"`
+	Manufacturer Ref       `json:"manufacturer"`
+	Model        string    `json:"model"`
+	Slug         string    `json:"slug"`
+	PartNumber   string    `json:"part_number"`
+	UHeight      float64   `json:"u_height"`
+	IsFullDepth  bool      `json:"is_full_depth"`
+	LastUpdated  time.Time `json:"last_updated"`
+}
+
+type Device struct {
+	Id           int            `json:"id"`
+	Name         string         `json:"name"`
+	Description  string         `json:"description"`
+	DeviceType   Ref            `json:"device_type"`
+	Site         Ref            `json:"site"`
+	Rack         *Ref           `json:"rack"`
+	Position     *float64       `json:"position"`
+	Face         *Choice        `json:"face"`
+	ParentDevice *Ref           `json:"parent_device"`
+	Tenant       *Ref           `json:"tenant"`
+	Fields       map[string]any `json:"-"` // all the fields, to map the custom ones
+	LastUpdated  time.Time      `json:"last_updated"`
+}
+
+func (device *Device) UnmarshalJSON(data []byte) error {
+	type plainDevice Device
+	if err := json.Unmarshal(data, (*plainDevice)(device)); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &device.Fields)
+}
+
+// Client of the REST API of Netbox
+type Client struct {
+	url        string
+	token      string
+	httpClient *http.Client
+}
+
+func NewClient(connection models.ApiConnection) *Client {
+	return &Client{
+		url:        strings.TrimSuffix(connection.Url, "/"),
+		token:      connection.Token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (client *Client) Sites() ([]Site, error) {
+	return list[Site](client, "/api/dcim/sites/", nil)
+}
+
+func (client *Client) Locations() ([]Location, error) {
+	return list[Location](client, "/api/dcim/locations/", nil)
+}
+
+func (client *Client) Racks() ([]Rack, error) {
+	return list[Rack](client, "/api/dcim/racks/", nil)
+}
+
+func (client *Client) DeviceTypes() ([]DeviceType, error) {
+	return list[DeviceType](client, "/api/dcim/device-types/", nil)
+}
+
+// Devices returns the devices updated since this time, all of them if it is zero
+func (client *Client) Devices(since time.Time) ([]Device, error) {
+	query := url.Values{}
+	if !since.IsZero() {
+		query.Set("last_updated__gte", since.UTC().Format(time.RFC3339))
+	}
+	return list[Device](client, "/api/dcim/devices/", query)
+}
+
+// list gets all the pages of the objects of the endpoint
+func list[T any](client *Client, endpoint string, query url.Values) ([]T, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("limit", fmt.Sprint(pageSize))
+
+	objects := []T{}
+	next := client.url + endpoint + "?" + query.Encode()
+	for next != "" {
+		page := struct {
+			Next    *string `json:"next"`
+			Results []T     `json:"results"`
+		}{}
+		if err := client.get(next, &page); err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, page.Results...)
+		next = ""
+		if page.Next != nil {
+			next = *page.Next
+		}
+	}
+	return objects, nil
+}
+
+func (client *Client) get(url string, result any) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Token "+client.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Netbox API responded %d on %s", resp.StatusCode, url)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("invalid response from Netbox API on %s: %s", url, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,348 @@
+package netbox
+
+import (
+	"back-admin/models"
+	"back-admin/services/ogree"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Order of creation of the categories, parents first
+var categoriesOrder = []string{"domain", "obj_template", "site", "building", "room", "rack", "device"}
+
+// objectsBuilder builds the OGrEE objects from the Netbox objects
+type objectsBuilder struct {
+	mapping     models.NetboxMapping
+	since       time.Time // the objects not updated since are not synced
+	sites       map[int]Site
+	locations   map[int]Location
+	deviceTypes map[int]DeviceType
+	racks       map[int]string // OGrEE id of the racks
+	objects     []ogree.Object
+	ids         map[string]bool // category and id of the objects, added once
+}
+
+func newObjectsBuilder(mapping models.NetboxMapping, since time.Time,
+	sites []Site, locations []Location, deviceTypes []DeviceType) *objectsBuilder {
+	builder := &objectsBuilder{
+		mapping:     mapping,
+		since:       since,
+		sites:       map[int]Site{},
+		locations:   map[int]Location{},
+		deviceTypes: map[int]DeviceType{},
+		racks:       map[int]string{},
+		ids:         map[string]bool{},
+	}
+	for _, site := range sites {
+		builder.sites[site.Id] = site
+	}
+	for _, location := range locations {
+		builder.locations[location.Id] = location
+	}
+	for _, deviceType := range deviceTypes {
+		builder.deviceTypes[deviceType.Id] = deviceType
+	}
+	return builder
+}
+
+// add: adds the object, unless it was not updated in Netbox since the last
+// sync (zero updated: always synced)
+func (builder *objectsBuilder) add(object ogree.Object, updated time.Time) {
+	key := object.Category + " " + object.Id
+	if builder.ids[key] || (!updated.IsZero() && updated.Before(builder.since)) {
+		return
+	}
+	builder.ids[key] = true
+	builder.objects = append(builder.objects, object)
+}
+
+// sorted: the objects, parents before their children
+func (builder *objectsBuilder) sorted() []ogree.Object {
+	sorted := []ogree.Object{}
+	for _, category := range categoriesOrder {
+		for _, object := range builder.objects {
+			if object.Category == category {
+				sorted = append(sorted, object)
+			}
+		}
+	}
+	// objects not synced are reported at the end
+	for _, object := range builder.objects {
+		if !contains(categoriesOrder, object.Category) {
+			sorted = append(sorted, object)
+		}
+	}
+	return sorted
+}
+
+// domain: the domain of the tenant, that is created if needed
+func (builder *objectsBuilder) domain(tenant *Ref) string {
+	domain := builder.mapping.Domain
+	if tenant != nil {
+		domain = ogree.ToName(tenant.Slug)
+		if mapped, ok := builder.mapping.Tenants[tenant.Slug]; ok {
+			domain = mapped
+		}
+	}
+
+	name, parentId := domain, ""
+	if i := strings.LastIndex(domain, "."); i >= 0 {
+		name, parentId = domain[i+1:], domain[:i]
+	}
+	builder.add(ogree.Object{
+		Category: "domain",
+		Id:       domain,
+		Identity: map[string]any{"name": name, "parentId": parentId, "category": "domain", "description": name},
+		Managed:  map[string]any{},
+	}, time.Time{})
+	return domain
+}
+
+func (builder *objectsBuilder) siteName(site Ref) string {
+	if mapped, ok := builder.mapping.Sites[site.Slug]; ok {
+		return mapped
+	}
+	return ogree.ToName(site.Slug)
+}
+
+func (builder *objectsBuilder) addSite(site Site) {
+	name := builder.siteName(Ref{Id: site.Id, Name: site.Name, Slug: site.Slug})
+	builder.add(ogree.Object{
+		Category: "site",
+		Id:       name,
+		SourceId: site.Id,
+		Identity: map[string]any{"name": name, "category": "site"},
+		Managed:  map[string]any{"description": description(site.Description, site.Name), "domain": builder.domain(site.Tenant)},
+	}, site.LastUpdated)
+}
+
+// depth: 0 for the top level locations (buildings), 1 for their children (rooms)...
+func (builder *objectsBuilder) depth(location Location) int {
+	depth := 0
+	for location.Parent != nil {
+		location = builder.locations[location.Parent.Id]
+		depth++
+	}
+	return depth
+}
+
+// locationId: OGrEE id of a building or a room
+func (builder *objectsBuilder) locationId(location Location) string {
+	id := ogree.ToName(location.Slug)
+	for location.Parent != nil {
+		location = builder.locations[location.Parent.Id]
+		id = ogree.ToName(location.Slug) + "." + id
+	}
+	return builder.siteName(location.Site) + "." + id
+}
+
+func (builder *objectsBuilder) addLocation(location Location) {
+	category := "building"
+	switch depth := builder.depth(location); depth {
+	case 0:
+	case 1:
+		category = "room"
+	default:
+		builder.add(ogree.Object{
+			Category: "location",
+			Id:       builder.locationId(location),
+			SourceId: location.Id,
+			Skip:     "Locations below the rooms are not synced, their racks are in their room",
+		}, location.LastUpdated)
+		return
+	}
+
+	id := builder.locationId(location)
+	builder.add(ogree.Object{
+		Category: category,
+		Id:       id,
+		SourceId: location.Id,
+		Identity: map[string]any{"name": ogree.ToName(location.Slug), "parentId": id[:strings.LastIndex(id, ".")], "category": category},
+		Managed: map[string]any{
+			"description": description(location.Description, location.Name),
+			"domain":      builder.domain(location.Tenant),
+		},
+	}, location.LastUpdated)
+}
+
+// defaultLocation: the default building or room, created if needed
+func (builder *objectsBuilder) defaultLocation(category, parentId, name, domain string) string {
+	id := parentId + "." + name
+	builder.add(ogree.Object{
+		Category: category,
+		Id:       id,
+		Identity: map[string]any{"name": name, "parentId": parentId, "category": category, "description": name},
+		Managed:  map[string]any{"domain": domain},
+	}, time.Time{})
+	return id
+}
+
+// rackParent: the room of the rack
+func (builder *objectsBuilder) rackParent(rack Rack, domain string) string {
+	if rack.Location == nil {
+		building := builder.defaultLocation("building", builder.siteName(rack.Site), builder.mapping.DefaultBuilding, domain)
+		return builder.defaultLocation("room", building, builder.mapping.DefaultRoom, domain)
+	}
+
+	location := builder.locations[rack.Location.Id]
+	if builder.depth(location) == 0 {
+		return builder.defaultLocation("room", builder.locationId(location), builder.mapping.DefaultRoom, domain)
+	}
+	for builder.depth(location) > 1 {
+		location = builder.locations[location.Parent.Id]
+	}
+	return builder.locationId(location)
+}
+
+func (builder *objectsBuilder) addRack(rack Rack) {
+	domain := builder.domain(rack.Tenant)
+	parentId := builder.rackParent(rack, domain)
+	name := ogree.ToName(rack.Name)
+	builder.racks[rack.Id] = parentId + "." + name
+
+	attributes := map[string]any{"height": rack.UHeight, "heightUnit": "U"}
+	if rack.OuterWidth != nil && rack.OuterDepth != nil {
+		width, depth := *rack.OuterWidth, *rack.OuterDepth
+		if rack.OuterUnit != nil && rack.OuterUnit.Value == "in" {
+			width, depth = round(width*25.4), round(depth*25.4)
+		}
+		attributes["size"] = []any{width, depth}
+		attributes["sizeUnit"] = "mm"
+	}
+
+	builder.add(ogree.Object{
+		Category: "rack",
+		Id:       builder.racks[rack.Id],
+		SourceId: rack.Id,
+		Identity: map[string]any{"name": name, "parentId": parentId, "category": "rack"},
+		Managed: map[string]any{
+			"description": description(rack.Description, rack.Name),
+			"domain":      domain,
+			"attributes":  attributes,
+		},
+	}, rack.LastUpdated)
+}
+
+func (deviceType DeviceType) templateSlug() string {
+	return ogree.ToSlug(deviceType.Slug)
+}
+
+func (deviceType DeviceType) sizeWDHmm() []any {
+	depth := halfDepthMm
+	if deviceType.IsFullDepth {
+		depth = fullDepthMm
+	}
+	return []any{deviceWidthMm, depth, round(deviceType.UHeight * rackUnitMm)}
+}
+
+func (builder *objectsBuilder) addTemplate(deviceType DeviceType) {
+	template := ogree.Object{
+		Category: "obj_template",
+		Id:       deviceType.templateSlug(),
+		SourceId: deviceType.Id,
+	}
+	if deviceType.UHeight <= 0 {
+		template.Skip = "Device types of child devices are not synced"
+		builder.add(template, deviceType.LastUpdated)
+		return
+	}
+
+	attributes := map[string]any{"type": "server", "vendor": deviceType.Manufacturer.Name, "model": deviceType.Model}
+	if deviceType.PartNumber != "" {
+		attributes["partNumber"] = deviceType.PartNumber
+	}
+	template.Identity = map[string]any{
+		"slug": template.Id, "category": "device", "fbxModel": "", "components": []any{}, "slots": []any{},
+	}
+	template.Managed = map[string]any{
+		"description": deviceType.Manufacturer.Name + " " + deviceType.Model,
+		"sizeWDHmm":   deviceType.sizeWDHmm(),
+		"attributes":  attributes,
+	}
+	builder.add(template, deviceType.LastUpdated)
+}
+
+func (builder *objectsBuilder) addDevice(device Device) {
+	deviceObject := ogree.Object{Category: "device", Id: ogree.ToName(device.Name), SourceId: device.Id}
+	rackId, hasRack := "", false
+	if device.Rack != nil {
+		rackId, hasRack = builder.racks[device.Rack.Id]
+	}
+	deviceType, hasDeviceType := builder.deviceTypes[device.DeviceType.Id]
+
+	switch {
+	case device.Name == "":
+		deviceObject.Id = fmt.Sprint(device.Id)
+		deviceObject.Skip = "Devices without name are not synced"
+	case device.ParentDevice != nil || (hasDeviceType && deviceType.UHeight <= 0):
+		deviceObject.Skip = "Child devices are not synced"
+	case !hasRack:
+		deviceObject.Skip = "Devices without rack are not synced"
+	case !hasDeviceType:
+		deviceObject.Skip = "Device type " + device.DeviceType.Slug + " not found"
+	}
+	if deviceObject.Skip != "" {
+		builder.add(deviceObject, device.LastUpdated)
+		return
+	}
+
+	size := deviceType.sizeWDHmm()
+	attributes := map[string]any{
+		"template":    deviceType.templateSlug(),
+		"size":        size[:2],
+		"sizeUnit":    "mm",
+		"height":      size[2],
+		"heightUnit":  "mm",
+		"sizeU":       int(math.Ceil(deviceType.UHeight)),
+		"orientation": "front",
+	}
+	if device.Face != nil && device.Face.Value == "rear" {
+		attributes["orientation"] = "rear"
+	}
+	if device.Position != nil {
+		attributes["posU"] = *device.Position
+	}
+	for field, attribute := range builder.mapping.DeviceAttributes {
+		if value := device.field(field); value != nil && value != "" {
+			attributes[attribute] = value
+		}
+	}
+
+	name := ogree.ToName(device.Name)
+	deviceObject.Id = rackId + "." + name
+	deviceObject.Identity = map[string]any{"name": name, "parentId": rackId, "category": "device"}
+	deviceObject.Managed = map[string]any{
+		"description": description(device.Description, device.Name),
+		"domain":      builder.domain(device.Tenant),
+		"attributes":  attributes,
+	}
+	builder.add(deviceObject, device.LastUpdated)
+}
+
+// field: value of a field of the device, custom_fields.<name> for the custom fields
+func (device Device) field(field string) any {
+	if customField, isCustom := strings.CutPrefix(field, "custom_fields."); isCustom {
+		customFields, _ := device.Fields["custom_fields"].(map[string]any)
+		return customFields[customField]
+	}
+	return device.Fields[field]
+}
+
+// description: the description of the Netbox object, its name if it has none
+func description(description, name string) string {
+	if description != "" {
+		return description
+	}
+	return name
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package netbox
+
+import (
+	"back-admin/models"
+	"back-admin/services/ogree"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Environment variable giving the file keeping the time of the last sync of
+// each Netbox and OGrEE pair, to be put on a persistent volume. By default,
+// it is netbox-sync.json next to the executable
+const syncStateEnv = "NETBOX_SYNC_STATE"
+
+// Size of a rack unit in mm and width of the devices mounted in a 19" rack
+const (
+	rackUnitMm     = 44.45
+	deviceWidthMm  = 482.6
+	fullDepthMm    = 800.0
+	halfDepthMm    = 400.0
+	defaultMapping = "main"
+)
+
+var defaultDeviceAttributes = map[string]string{"serial": "serial", "asset_tag": "assetTag"}
+
+type syncer struct {
+	netbox  *Client
+	writer  *ogree.Writer
+	mapping models.NetboxMapping
+	since   time.Time
+}
+
+// Sync reads the sites, locations, racks, devices and device types of Netbox
+// and creates or updates the OGrEE objects built from them. With dry run,
+// the changes are only reported.
+func Sync(sync models.NetboxSync) (*models.SyncReport, error) {
+	start := time.Now()
+	mapping := withDefaultMapping(sync.Mapping)
+	syncer := &syncer{
+		netbox:  NewClient(sync.Netbox),
+		writer:  ogree.NewWriter(ogree.NewClient(sync.Ogree), sync.DryRun, mapping.Defaults),
+		mapping: mapping,
+	}
+	report := syncer.writer.Report()
+
+	stateKey := sync.Netbox.Url + " " + sync.Ogree.Url
+	if sync.Incremental {
+		syncer.since = getLastSync(stateKey)
+		if !syncer.since.IsZero() {
+			report.Since = syncer.since.Format(time.RFC3339)
+		}
+	}
+
+	objects, err := syncer.getObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, object := range objects {
+		syncer.writer.Write(object)
+	}
+
+	if !sync.DryRun && report.Errors == 0 {
+		if err := setLastSync(stateKey, start); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+func withDefaultMapping(mapping models.NetboxMapping) models.NetboxMapping {
+	if mapping.DefaultBuilding == "" {
+		mapping.DefaultBuilding = defaultMapping
+	}
+	if mapping.DefaultRoom == "" {
+		mapping.DefaultRoom = defaultMapping
+	}
+	if mapping.DeviceAttributes == nil {
+		mapping.DeviceAttributes = defaultDeviceAttributes
+	}
+	return mapping
+}
+
+// getObjects: the OGrEE objects, parents before their children
+func (syncer *syncer) getObjects() ([]ogree.Object, error) {
+	sites, err := syncer.netbox.Sites()
+	if err != nil {
+		return nil, err
+	}
+	locations, err := syncer.netbox.Locations()
+	if err != nil {
+		return nil, err
+	}
+	racks, err := syncer.netbox.Racks()
+	if err != nil {
+		return nil, err
+	}
+	deviceTypes, err := syncer.netbox.DeviceTypes()
+	if err != nil {
+		return nil, err
+	}
+	devices, err := syncer.netbox.Devices(syncer.since)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := newObjectsBuilder(syncer.mapping, syncer.since, sites, locations, deviceTypes)
+	for _, deviceType := range deviceTypes {
+		builder.addTemplate(deviceType)
+	}
+	for _, site := range sites {
+		builder.addSite(site)
+	}
+	for _, location := range locations {
+		builder.addLocation(location)
+	}
+	for _, rack := range racks {
+		builder.addRack(rack)
+	}
+	for _, device := range devices {
+		builder.addDevice(device)
+	}
+	return builder.sorted(), nil
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+func syncStateFile() string {
+	if file := os.Getenv(syncStateEnv); file != "" {
+		return file
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return "netbox-sync.json"
+	}
+	return filepath.Join(filepath.Dir(exe), "netbox-sync.json")
+}
+
+func getLastSync(key string) time.Time {
+	state := map[string]time.Time{}
+	if data, err := os.ReadFile(syncStateFile()); err == nil {
+		json.Unmarshal(data, &state)
+	}
+	return state[key]
+}
+
+func setLastSync(key string, lastSync time.Time) error {
+	file := syncStateFile()
+	state := map[string]time.Time{}
+	if data, err := os.ReadFile(file); err == nil {
+		json.Unmarshal(data, &state)
+	}
+	state[key] = lastSync
+
+	data, _ := json.Marshal(state)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("unable to save the time of the sync: %s", err.Error())
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return fmt.Errorf("unable to save the time of the sync: %s", err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+package netbox
+
+import (
+	"back-admin/models"
+	ogreeapi "back-admin/services/ogree"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeNetbox serves the objects of each endpoint of the Netbox API, two by page
+type fakeNetbox struct {
+	objects map[string][]map[string]any
+	queries []string
+}
+
+func (netbox *fakeNetbox) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Token netbox-token" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	netbox.queries = append(netbox.queries, r.URL.RawQuery)
+
+	objects := netbox.objects[r.URL.Path]
+	if since := r.URL.Query().Get("last_updated__gte"); since != "" {
+		sinceTime, _ := time.Parse(time.RFC3339, since)
+		updated := []map[string]any{}
+		for _, object := range objects {
+			lastUpdated, _ := time.Parse(time.RFC3339, object["last_updated"].(string))
+			if !lastUpdated.Before(sinceTime) {
+				updated = append(updated, object)
+			}
+		}
+		objects = updated
+	}
+
+	offset := 0
+	if r.URL.Query().Get("offset") != "" {
+		offset = 2
+	}
+	page := map[string]any{"count": len(objects), "next": nil}
+	if len(objects) > offset+2 {
+		page["next"] = "http://" + r.Host + r.URL.Path + "?offset=2&" + r.URL.RawQuery
+		objects = objects[offset : offset+2]
+	} else {
+		objects = objects[offset:]
+	}
+	page["results"] = objects
+	json.NewEncoder(w).Encode(page)
+}
+
+// fakeOgree keeps the objects created and patched through the OGrEE API
+type fakeOgree struct {
+	objects map[string]map[string]any
+	writes  int
+}
+
+func (ogree *fakeOgree) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/"), "/")
+	category := strings.TrimSuffix(path[0], "s")
+
+	var body map[string]any
+	json.NewDecoder(r.Body).Decode(&body)
+
+	switch r.Method {
+	case http.MethodGet:
+		object, ok := ogree.objects[category+" "+path[1]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]any{"message": "Nothing matches this request"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": object})
+	case http.MethodPost:
+		id, _ := body["name"].(string)
+		if parentId, _ := body["parentId"].(string); parentId != "" {
+			id = parentId + "." + id
+		}
+		if category == "obj_template" {
+			id = body["slug"].(string)
+		}
+		ogree.objects[category+" "+id] = body
+		ogree.writes++
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]any{"data": body})
+	case http.MethodPatch:
+		object := ogree.objects[category+" "+path[1]]
+		for key, value := range body {
+			if key == "attributes" {
+				for attribute, attributeValue := range value.(map[string]any) {
+					object["attributes"].(map[string]any)[attribute] = attributeValue
+				}
+			} else {
+				object[key] = value
+			}
+		}
+		ogree.writes++
+		json.NewEncoder(w).Encode(map[string]any{"data": object})
+	}
+}
+
+func newFakeNetbox() *fakeNetbox {
+	updated := "2024-01-01T00:00:00Z"
+	return &fakeNetbox{objects: map[string][]map[string]any{
+		"/api/dcim/sites/": {
+			{"id": 1, "name": "Paris", "slug": "paris", "description": "", "tenant": nil, "last_updated": updated},
+		},
+		"/api/dcim/locations/": {
+			{"id": 1, "name": "Building A", "slug": "building-a", "site": map[string]any{"id": 1, "slug": "paris"},
+				"parent": nil, "tenant": nil, "last_updated": updated},
+			{"id": 2, "name": "Room 1", "slug": "room-1", "site": map[string]any{"id": 1, "slug": "paris"},
+				"parent": map[string]any{"id": 1}, "tenant": nil, "last_updated": updated},
+			{"id": 3, "name": "Row 1", "slug": "row-1", "site": map[string]any{"id": 1, "slug": "paris"},
+				"parent": map[string]any{"id": 2}, "tenant": nil, "last_updated": updated},
+		},
+		"/api/dcim/racks/": {
+			{"id": 1, "name": "A01", "site": map[string]any{"id": 1, "slug": "paris"}, "location": map[string]any{"id": 3},
+				"tenant": map[string]any{"id": 1, "slug": "acme"}, "u_height": 42, "outer_width": 600, "outer_depth": 1200,
+				"outer_unit": map[string]any{"value": "mm"}, "last_updated": updated},
+			{"id": 2, "name": "Spare rack", "site": map[string]any{"id": 1, "slug": "paris"}, "location": nil,
+				"tenant": nil, "u_height": 42, "last_updated": updated},
+		},
+		"/api/dcim/device-types/": {
+			{"id": 1, "manufacturer": map[string]any{"name": "Dell"}, "model": "PowerEdge R640", "slug": "dell-r640",
+				"u_height": 1, "is_full_depth": true, "last_updated": updated},
+		},
+		"/api/dcim/devices/": {
+			{"id": 1, "name": "srv01", "device_type": map[string]any{"id": 1, "slug": "dell-r640"},
+				"site": map[string]any{"id": 1}, "rack": map[string]any{"id": 1}, "position": 10, "face": map[string]any{"value": "front"},
+				"tenant": map[string]any{"id": 1, "slug": "acme"}, "serial": "ABC123", "asset_tag": nil,
+				"custom_fields": map[string]any{"owner": "team-a"}, "last_updated": updated},
+			{"id": 2, "name": "srv02", "device_type": map[string]any{"id": 1, "slug": "dell-r640"},
+				"site": map[string]any{"id": 1}, "rack": nil, "tenant": nil, "last_updated": updated},
+		},
+	}}
+}
+
+func newTestSync(t *testing.T) (*fakeNetbox, *fakeOgree, models.NetboxSync) {
+	t.Setenv(syncStateEnv, filepath.Join(t.TempDir(), "state", "netbox-sync.json"))
+
+	netbox := newFakeNetbox()
+	netboxServer := httptest.NewServer(netbox)
+	t.Cleanup(netboxServer.Close)
+
+	ogree := &fakeOgree{objects: map[string]map[string]any{
+		"domain demo": {"name": "demo", "category": "domain", "attributes": map[string]any{}},
+	}}
+	ogreeServer := httptest.NewServer(ogree)
+	t.Cleanup(ogreeServer.Close)
+
+	return netbox, ogree, models.NetboxSync{
+		Netbox: models.ApiConnection{Url: netboxServer.URL, Token: "netbox-token"},
+		Ogree:  models.ApiConnection{Url: ogreeServer.URL, Token: "ogree-token"},
+		Mapping: models.NetboxMapping{
+			Domain:           "demo",
+			Tenants:          map[string]string{"acme": "demo.acme"},
+			DeviceAttributes: map[string]string{"serial": "serial", "asset_tag": "assetTag", "custom_fields.owner": "owner"},
+		},
+	}
+}
+
+func getObjectReport(t *testing.T, report *models.SyncReport, category, id string) models.SyncObjectReport {
+	for _, object := range report.Objects {
+		if object.Category == category && object.Id == id {
+			return object
+		}
+	}
+	t.Fatalf("%s %s not found in the report", category, id)
+	return models.SyncObjectReport{}
+}
+
+func TestSyncDryRunWritesNothing(t *testing.T) {
+	_, ogree, sync := newTestSync(t)
+	sync.DryRun = true
+
+	report, err := Sync(sync)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ogree.writes != 0 {
+		t.Errorf("dry run wrote %d objects", ogree.writes)
+	}
+	if report.Errors != 0 || report.Created != 10 {
+		t.Errorf("expected 10 creations, got %+v", report)
+	}
+
+	rack := getObjectReport(t, report, "rack", "paris.building-a.room-1.A01")
+	if rack.Action != models.SyncActionCreate || rack.SourceId != 1 {
+		t.Errorf("unexpected rack report %+v", rack)
+	}
+	if action := getObjectReport(t, report, "domain", "demo").Action; action != models.SyncActionUnchanged {
+		t.Errorf("existing domain reported as %s", action)
+	}
+	if skip := getObjectReport(t, report, "location", "paris.building-a.room-1.row-1"); skip.Action != models.SyncActionSkip {
+		t.Errorf("location below a room reported as %s", skip.Action)
+	}
+	if skip := getObjectReport(t, report, "device", "srv02"); skip.Error != "Devices without rack are not synced" {
+		t.Errorf("unexpected device report %+v", skip)
+	}
+}
+
+func TestSyncCreatesThenUpdates(t *testing.T) {
+	netbox, ogree, sync := newTestSync(t)
+
+	report, err := Sync(sync)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Errors != 0 || report.Created != 10 {
+		t.Fatalf("expected 10 creations, got %+v", report)
+	}
+
+	device := ogree.objects["device paris.building-a.room-1.A01.srv01"]
+	if device == nil {
+		t.Fatal("device not created")
+	}
+	attributes := device["attributes"].(map[string]any)
+	if device["domain"] != "demo.acme" || attributes["template"] != "dell-r640" ||
+		attributes["posU"] != 10.0 || attributes["serial"] != "ABC123" || attributes["owner"] != "team-a" {
+		t.Errorf("unexpected device %+v", device)
+	}
+	if _, hasAssetTag := attributes["assetTag"]; hasAssetTag {
+		t.Error("empty asset tag synced")
+	}
+	spareRack := ogree.objects["rack paris.main.main.Spare-rack"]
+	if spareRack == nil || spareRack["attributes"].(map[string]any)["posXYUnit"] != "t" {
+		t.Errorf("rack without location not created in the default room with the default attributes: %+v", spareRack)
+	}
+
+	// the rack is moved in OGrEE, its height is changed in Netbox
+	ogree.objects["rack paris.building-a.room-1.A01"]["attributes"].(map[string]any)["posXYZ"] = []any{4, 2, 0}
+	netbox.objects["/api/dcim/racks/"][0]["u_height"] = 48
+
+	report, err = Sync(sync)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != 0 || report.Updated != 1 {
+		t.Fatalf("expected 1 update, got %+v", report)
+	}
+	rack := getObjectReport(t, report, "rack", "paris.building-a.room-1.A01")
+	if len(rack.Changes) != 1 || rack.Changes[0].Field != "attributes.height" {
+		t.Errorf("unexpected rack changes %+v", rack.Changes)
+	}
+	rackAttributes := ogree.objects["rack paris.building-a.room-1.A01"]["attributes"].(map[string]any)
+	if rackAttributes["height"] != 48.0 || !ogreeapi.JsonEqual(rackAttributes["posXYZ"], []any{4, 2, 0}) {
+		t.Errorf("unexpected rack attributes %+v", rackAttributes)
+	}
+}
+
+func TestIncrementalSyncOnlyWritesUpdatedObjects(t *testing.T) {
+	netbox, _, sync := newTestSync(t)
+	sync.Incremental = true
+
+	if _, err := Sync(sync); err != nil {
+		t.Fatal(err)
+	}
+
+	netbox.objects["/api/dcim/devices/"][0]["last_updated"] = time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	netbox.objects["/api/dcim/devices/"][0]["serial"] = "DEF456"
+	netbox.queries = nil
+
+	report, err := Sync(sync)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Since == "" || report.Updated != 1 {
+		t.Fatalf("expected 1 update since the last sync, got %+v", report)
+	}
+	for _, object := range report.Objects {
+		if object.Category == "rack" || object.Category == "site" {
+			t.Errorf("%s %s not updated since the last sync", object.Category, object.Id)
+		}
+	}
+	if !strings.Contains(strings.Join(netbox.queries, " "), "last_updated__gte=") {
+		t.Error("devices not filtered by update time")
+	}
+}
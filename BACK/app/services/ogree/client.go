@@ -0,0 +1,125 @@
+package ogree
+
+import (
+	"back-admin/models"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// Client of the API of an OGrEE tenant
+type Client struct {
+	url        string
+	token      string
+	httpClient *http.Client
+}
+
+func NewClient(connection models.ApiConnection) *Client {
+	return &Client{
+		url:        strings.TrimSuffix(connection.Url, "/"),
+		token:      connection.Token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Get returns the object of the category with this id (slug for the templates),
+// ErrNotFound if it does not exist
+func (client *Client) Get(category, id string) (map[string]any, error) {
+	data, err := client.request(http.MethodGet, "/api/"+category+"s/"+id, nil)
+	if err != nil {
+		return nil, err
+	}
+	object, _ := data.(map[string]any)
+	return object, nil
+}
+
+// GetHierarchy returns the object with its children, up to this depth
+func (client *Client) GetHierarchy(category, id string, depth int) (map[string]any, error) {
+	data, err := client.request(http.MethodGet, fmt.Sprintf("/api/%ss/%s/all?limit=%d", category, id, depth), nil)
+	if err != nil {
+		return nil, err
+	}
+	object, _ := data.(map[string]any)
+	return object, nil
+}
+
+// Search returns the objects matching the query (name=..., category=...)
+func (client *Client) Search(query url.Values) ([]map[string]any, error) {
+	data, err := client.request(http.MethodGet, "/api/objects?"+query.Encode(), nil)
+	if errors.Is(err, ErrNotFound) {
+		return []map[string]any{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	list, _ := data.([]any)
+	objects := []map[string]any{}
+	for _, object := range list {
+		if object, ok := object.(map[string]any); ok {
+			objects = append(objects, object)
+		}
+	}
+	return objects, nil
+}
+
+func (client *Client) Create(category string, object map[string]any) error {
+	_, err := client.request(http.MethodPost, "/api/"+category+"s", object)
+	return err
+}
+
+func (client *Client) Patch(category, id string, patch map[string]any) error {
+	_, err := client.request(http.MethodPatch, "/api/"+category+"s/"+id, patch)
+	return err
+}
+
+func (client *Client) Delete(category, id string) error {
+	_, err := client.request(http.MethodDelete, "/api/"+category+"s/"+id, nil)
+	return err
+}
+
+// request sends the request and returns the data of the response
+func (client *Client) request(method, path string, body any) (any, error) {
+	var reader io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(bodyBytes)
+	}
+
+	req, err := http.NewRequest(method, client.url+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+client.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	response := struct {
+		Message string `json:"message"`
+		Data    any    `json:"data"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("invalid response from OGrEE API on %s %s: %s", method, path, err.Error())
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	} else if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("OGrEE API responded %d: %s", resp.StatusCode, response.Message)
+	}
+	return response.Data, nil
+}
@@ -0,0 +1,227 @@
+package ogree
+
+import (
+	"back-admin/models"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Attributes of the created objects that the other tools do not give
+var defaultAttributes = map[string]map[string]any{
+	"domain": {"color": "ffffff"},
+	"site":   {},
+	"building": {
+		"height": 5, "heightUnit": "m", "posXY": []any{0, 0}, "posXYUnit": "m",
+		"size": []any{10, 10}, "sizeUnit": "m", "rotation": 0,
+	},
+	"room": {
+		"floorUnit": "t", "height": 3, "heightUnit": "m", "posXY": []any{0, 0}, "posXYUnit": "m",
+		"size": []any{10, 10}, "sizeUnit": "m", "axisOrientation": "+x+y", "rotation": 0,
+	},
+	"rack": {
+		"posXYZ": []any{0, 0, 0}, "posXYUnit": "t", "rotation": []any{0, 0, 0},
+		"size": []any{600, 1200}, "sizeUnit": "mm",
+	},
+	"device": {},
+	"generic": {
+		"height": 200, "heightUnit": "cm", "posXYZ": []any{0, 0, 0}, "posXYUnit": "t", "rotation": []any{0, 0, 0},
+		"size": []any{80, 40}, "sizeUnit": "cm", "shape": "cube",
+	},
+}
+
+var invalidNameCharacters = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// Object is an OGrEE object built from an object of another tool
+type Object struct {
+	Category string
+	Id       string
+	SourceId int            // in the other tool
+	Identity map[string]any // fields that give the id, only sent at creation
+	Managed  map[string]any // fields and attributes given by the other tool, updated at each sync
+	Skip     string         // reason not to sync the object
+}
+
+// Writer creates or updates objects through the OGrEE API and reports the
+// changes. With dry run, the changes are only reported.
+type Writer struct {
+	client   *Client
+	dryRun   bool
+	defaults map[string]map[string]any // attributes of the created objects by category
+	report   *models.SyncReport
+}
+
+func NewWriter(client *Client, dryRun bool, defaults map[string]map[string]any) *Writer {
+	return &Writer{
+		client:   client,
+		dryRun:   dryRun,
+		defaults: defaults,
+		report:   &models.SyncReport{DryRun: dryRun, Objects: []models.SyncObjectReport{}},
+	}
+}
+
+func (writer *Writer) Report() *models.SyncReport {
+	return writer.report
+}
+
+// Write creates or updates the object and reports it
+func (writer *Writer) Write(object Object) {
+	report := models.SyncObjectReport{Category: object.Category, Id: object.Id, SourceId: object.SourceId}
+	defer func() {
+		switch report.Action {
+		case models.SyncActionCreate:
+			writer.report.Created++
+		case models.SyncActionUpdate:
+			writer.report.Updated++
+		case models.SyncActionUnchanged:
+			writer.report.Unchanged++
+		case models.SyncActionSkip:
+			writer.report.Skipped++
+		case models.SyncActionError:
+			writer.report.Errors++
+		}
+		writer.report.Objects = append(writer.report.Objects, report)
+	}()
+
+	if object.Skip != "" {
+		report.Action = models.SyncActionSkip
+		report.Error = object.Skip
+		return
+	}
+
+	current, err := writer.client.Get(object.Category, object.Id)
+	if errors.Is(err, ErrNotFound) {
+		report.Action = models.SyncActionCreate
+		report.Changes = getChanges(object.Managed, map[string]any{})
+		err = nil
+		if !writer.dryRun {
+			err = writer.client.Create(object.Category, writer.creation(object))
+		}
+	} else if err == nil {
+		report.Changes = getChanges(object.Managed, current)
+		if len(report.Changes) == 0 {
+			report.Action = models.SyncActionUnchanged
+		} else {
+			report.Action = models.SyncActionUpdate
+			if !writer.dryRun {
+				err = writer.client.Patch(object.Category, object.Id, changesToPatch(report.Changes))
+			}
+		}
+	}
+
+	if err != nil {
+		report.Action = models.SyncActionError
+		report.Error = err.Error()
+	}
+}
+
+// Delete deletes the object, that is no more in the other tool, and reports it
+func (writer *Writer) Delete(category, id string) {
+	report := models.SyncObjectReport{Category: category, Id: id, Action: models.SyncActionDelete}
+	var err error
+	if !writer.dryRun {
+		err = writer.client.Delete(category, id)
+	}
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		report.Action = models.SyncActionError
+		report.Error = err.Error()
+		writer.report.Errors++
+	} else {
+		writer.report.Deleted++
+	}
+	writer.report.Objects = append(writer.report.Objects, report)
+}
+
+// creation: the object to create, with the default attributes of its category
+func (writer *Writer) creation(object Object) map[string]any {
+	creation := map[string]any{}
+	for key, value := range object.Identity {
+		creation[key] = value
+	}
+	attributes := map[string]any{}
+	for _, defaults := range []map[string]any{defaultAttributes[object.Category], writer.defaults[object.Category]} {
+		for key, value := range defaults {
+			attributes[key] = value
+		}
+	}
+	for key, value := range object.Managed {
+		if key == "attributes" {
+			for attribute, attributeValue := range value.(map[string]any) {
+				attributes[attribute] = attributeValue
+			}
+		} else {
+			creation[key] = value
+		}
+	}
+	creation["attributes"] = attributes
+	return creation
+}
+
+// getChanges: managed fields and attributes that differ from the current object
+func getChanges(managed, current map[string]any) []models.SyncChange {
+	changes := []models.SyncChange{}
+	currentAttributes, _ := current["attributes"].(map[string]any)
+	for key, value := range managed {
+		if key == "attributes" {
+			for attribute, attributeValue := range value.(map[string]any) {
+				if !JsonEqual(currentAttributes[attribute], attributeValue) {
+					changes = append(changes, models.SyncChange{
+						Field: "attributes." + attribute, Old: currentAttributes[attribute], New: attributeValue,
+					})
+				}
+			}
+		} else if !JsonEqual(current[key], value) {
+			changes = append(changes, models.SyncChange{Field: key, Old: current[key], New: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+func changesToPatch(changes []models.SyncChange) map[string]any {
+	patch := map[string]any{}
+	for _, change := range changes {
+		if attribute, isAttribute := strings.CutPrefix(change.Field, "attributes."); isAttribute {
+			if _, ok := patch["attributes"]; !ok {
+				patch["attributes"] = map[string]any{}
+			}
+			patch["attributes"].(map[string]any)[attribute] = change.New
+		} else {
+			patch[change.Field] = change.New
+		}
+	}
+	return patch
+}
+
+func JsonEqual(value1, value2 any) bool {
+	if value1 == nil || value2 == nil {
+		return value1 == nil && value2 == nil
+	}
+	bytes1, err1 := json.Marshal(value1)
+	bytes2, err2 := json.Marshal(value2)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	// numbers are compared as floats (1 and 1.0)
+	var normalized1, normalized2 any
+	json.Unmarshal(bytes1, &normalized1)
+	json.Unmarshal(bytes2, &normalized2)
+	bytes1, _ = json.Marshal(normalized1)
+	bytes2, _ = json.Marshal(normalized2)
+	return string(bytes1) == string(bytes2)
+}
+
+// ToName: OGrEE names are made of letters, numbers, - and _
+func ToName(name string) string {
+	name = strings.Trim(invalidNameCharacters.ReplaceAllString(strings.TrimSpace(name), "-"), "-")
+	if name == "" {
+		return "_"
+	}
+	return name
+}
+
+func ToSlug(slug string) string {
+	return strings.ToLower(ToName(slug))
+}
@@ -0,0 +1,94 @@
+package opendcim
+
+import (
+	"back-admin/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// row of an openDCIM table, by column
+type row map[string]string
+
+// source of the openDCIM tables: a MySQL dump or the openDCIM API
+type source interface {
+	rows(table string) ([]row, error)
+}
+
+// Endpoint of the openDCIM API giving each table
+var tableEndpoints = map[string]string{
+	tableDataCenters:   "datacenter",
+	tableCabinets:      "cabinet",
+	tableDevices:       "device",
+	tablePowerPanels:   "powerpanel",
+	tableTemplates:     "devicetemplate",
+	tableManufacturers: "manufacturer",
+	tablePdus:          "powerdistribution",
+}
+
+// apiSource reads the openDCIM tables from the openDCIM API (v1)
+type apiSource struct {
+	url        string
+	userId     string
+	apiKey     string
+	httpClient *http.Client
+}
+
+func newApiSource(api models.OpenDcimApi) *apiSource {
+	return &apiSource{
+		url:        strings.TrimSuffix(api.Url, "/"),
+		userId:     api.UserId,
+		apiKey:     api.ApiKey,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (api *apiSource) rows(table string) ([]row, error) {
+	path := "/api/v1/" + tableEndpoints[table]
+	req, err := http.NewRequest(http.MethodGet, api.url+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("UserID", api.userId)
+	req.Header.Set("APIKey", api.apiKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := api.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("openDCIM API responded %d on %s", resp.StatusCode, path)
+	}
+
+	// the objects are in the only list of the response, with the error fields
+	response := map[string]any{}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("invalid response from openDCIM API on %s: %s", path, err.Error())
+	}
+	if isError, _ := response["error"].(bool); isError {
+		return nil, fmt.Errorf("openDCIM API error on %s: %v", path, response["message"])
+	}
+
+	rows := []row{}
+	for _, value := range response {
+		objects, isList := value.([]any)
+		if !isList {
+			continue
+		}
+		for _, object := range objects {
+			fields, _ := object.(map[string]any)
+			values := row{}
+			for field, value := range fields {
+				if value != nil {
+					values[field] = fmt.Sprint(value)
+				}
+			}
+			rows = append(rows, values)
+		}
+	}
+	return rows, nil
+}
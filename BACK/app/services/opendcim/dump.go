@@ -0,0 +1,175 @@
+package opendcim
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	createTableRegex = regexp.MustCompile("^CREATE TABLE (?:IF NOT EXISTS )?`?(\\w+)`?")
+	columnRegex      = regexp.MustCompile("^\\s*`(\\w+)`")
+	insertRegex      = regexp.MustCompile("^(?:INSERT|REPLACE)(?: IGNORE)? INTO `?(\\w+)`?\\s*(\\(([^)]*)\\))?\\s*VALUES\\s*")
+)
+
+// dumpSource reads the openDCIM tables from a MySQL dump (mysqldump):
+// the columns are given by the CREATE TABLE statements, or by the
+// INSERT statements when they list them
+type dumpSource struct {
+	tables map[string][]row
+}
+
+func readDump(reader io.Reader) (*dumpSource, error) {
+	dump := &dumpSource{tables: map[string][]row{}}
+	columns := map[string][]string{}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 1024*1024), 256*1024*1024)
+	creating := ""
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if creating != "" {
+			if strings.HasPrefix(line, ")") {
+				creating = ""
+			} else if match := columnRegex.FindStringSubmatch(line); match != nil {
+				columns[creating] = append(columns[creating], match[1])
+			}
+			continue
+		}
+
+		if match := createTableRegex.FindStringSubmatch(line); match != nil {
+			creating = match[1]
+			columns[creating] = nil
+			continue
+		}
+
+		match := insertRegex.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		table := match[1]
+		tableColumns := columns[table]
+		if match[2] != "" {
+			tableColumns = nil
+			for _, column := range strings.Split(match[3], ",") {
+				tableColumns = append(tableColumns, strings.Trim(strings.TrimSpace(column), "`"))
+			}
+		}
+
+		tuples, err := parseValues(line[len(match[0]):])
+		if err != nil {
+			return nil, fmt.Errorf("invalid insert in %s: %s", table, err.Error())
+		}
+		for _, tuple := range tuples {
+			if len(tuple) != len(tableColumns) {
+				return nil, fmt.Errorf("invalid insert in %s: %d values for %d columns", table, len(tuple), len(tableColumns))
+			}
+			values := row{}
+			for i, column := range tableColumns {
+				values[column] = tuple[i]
+			}
+			dump.tables[table] = append(dump.tables[table], values)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return dump, nil
+}
+
+func (dump *dumpSource) rows(table string) ([]row, error) {
+	return dump.tables[table], nil
+}
+
+// parseValues parses the tuples of values of an insert: ('a',1,NULL),('b',2,'c');
+// NULL is read as an empty value
+func parseValues(values string) ([][]string, error) {
+	tuples := [][]string{}
+	i := 0
+	for {
+		for i < len(values) && (values[i] == ' ' || values[i] == ',') {
+			i++
+		}
+		if i >= len(values) || values[i] == ';' {
+			return tuples, nil
+		}
+		if values[i] != '(' {
+			return nil, fmt.Errorf("( expected at %d", i)
+		}
+		i++
+
+		tuple := []string{}
+		for {
+			value, next, err := parseValue(values, i)
+			if err != nil {
+				return nil, err
+			}
+			tuple = append(tuple, value)
+			i = next
+			for i < len(values) && values[i] == ' ' {
+				i++
+			}
+			if i >= len(values) {
+				return nil, fmt.Errorf("unterminated tuple")
+			}
+			if values[i] == ')' {
+				i++
+				break
+			} else if values[i] != ',' {
+				return nil, fmt.Errorf(", or ) expected at %d", i)
+			}
+			i++
+		}
+		tuples = append(tuples, tuple)
+	}
+}
+
+var escapes = map[byte]string{'0': "\x00", 'n': "\n", 'r': "\r", 't': "\t", 'Z': "\x1a"}
+
+// parseValue parses a quoted string, a number or NULL starting at i,
+// and returns it with the index following it
+func parseValue(values string, i int) (string, int, error) {
+	for i < len(values) && values[i] == ' ' {
+		i++
+	}
+	if i < len(values) && values[i] == '\'' {
+		var value strings.Builder
+		for i++; i < len(values); i++ {
+			switch values[i] {
+			case '\\':
+				i++
+				if i >= len(values) {
+					return "", i, fmt.Errorf("unterminated string")
+				}
+				if escaped, ok := escapes[values[i]]; ok {
+					value.WriteString(escaped)
+				} else {
+					value.WriteByte(values[i])
+				}
+			case '\'':
+				if i+1 < len(values) && values[i+1] == '\'' {
+					value.WriteByte('\'')
+					i++
+				} else {
+					return value.String(), i + 1, nil
+				}
+			default:
+				value.WriteByte(values[i])
+			}
+		}
+		return "", i, fmt.Errorf("unterminated string")
+	}
+
+	start := i
+	for i < len(values) && values[i] != ',' && values[i] != ')' {
+		i++
+	}
+	value := strings.TrimSpace(values[start:i])
+	if strings.EqualFold(value, "NULL") {
+		value = ""
+	}
+	return value, i, nil
+}
@@ -0,0 +1,389 @@
+package opendcim
+
+import (
+	"back-admin/models"
+	"back-admin/services/ogree"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Tables of openDCIM
+const (
+	tableDataCenters   = "fac_DataCenter"
+	tableCabinets      = "fac_Cabinet"
+	tableDevices       = "fac_Device"
+	tablePowerPanels   = "fac_PowerPanel"
+	tableTemplates     = "fac_DeviceTemplate"
+	tableManufacturers = "fac_Manufacturer"
+	tablePdus          = "fac_PowerDistribution"
+)
+
+// Size of a rack unit in mm and size of the devices mounted in a 19" rack
+const (
+	rackUnitMm      = 44.45
+	deviceWidthMm   = 482.6
+	fullDepthMm     = 800.0
+	halfDepthMm     = 400.0
+	defaultBuilding = "main"
+)
+
+// importer builds the OGrEE objects from the openDCIM tables
+type importer struct {
+	mapping       models.OpenDcimMapping
+	tables        map[string][]row
+	buildingId    string
+	rooms         map[string]string // OGrEE id of the room of each data center
+	racks         map[string]string // OGrEE id of the rack of each cabinet
+	templates     map[string]string // slug of the template of each template id
+	panels        map[string]string // OGrEE id of each power panel
+	manufacturers map[string]string
+	objects       []ogree.Object
+}
+
+// Import reads the data centers, cabinets, devices, power panels and
+// templates of openDCIM and creates or updates the OGrEE objects built from
+// them. With dry run, the changes are only reported.
+func Import(request models.OpenDcimImport) (*models.SyncReport, error) {
+	var source source
+	if request.Api != nil {
+		source = newApiSource(*request.Api)
+	} else if request.Dump != "" {
+		file, err := os.Open(request.Dump)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		dump, err := readDump(file)
+		if err != nil {
+			return nil, err
+		}
+		source = dump
+	} else {
+		return nil, fmt.Errorf("an openDCIM api or dump is required")
+	}
+
+	importer := &importer{
+		mapping:       request.Mapping,
+		tables:        map[string][]row{},
+		rooms:         map[string]string{},
+		racks:         map[string]string{},
+		templates:     map[string]string{},
+		panels:        map[string]string{},
+		manufacturers: map[string]string{},
+	}
+	if importer.mapping.Building == "" {
+		importer.mapping.Building = defaultBuilding
+	}
+	for table := range tableEndpoints {
+		rows, err := source.rows(table)
+		if err != nil {
+			return nil, err
+		}
+		importer.tables[table] = rows
+	}
+
+	writer := ogree.NewWriter(ogree.NewClient(request.Ogree), request.DryRun, request.Mapping.Defaults)
+	for _, object := range importer.getObjects() {
+		writer.Write(object)
+	}
+	return writer.Report(), nil
+}
+
+// getObjects: the OGrEE objects, parents before their children
+func (importer *importer) getObjects() []ogree.Object {
+	importer.addHierarchy()
+	for _, manufacturer := range importer.tables[tableManufacturers] {
+		importer.manufacturers[manufacturer["ManufacturerID"]] = manufacturer["Name"]
+	}
+	for _, template := range importer.tables[tableTemplates] {
+		importer.addTemplate(template)
+	}
+	for _, dataCenter := range importer.tables[tableDataCenters] {
+		importer.addRoom(dataCenter)
+	}
+	for _, panel := range importer.tables[tablePowerPanels] {
+		importer.addPowerPanel(panel)
+	}
+	breakers := importer.getBreakers()
+	for _, cabinet := range importer.tables[tableCabinets] {
+		importer.addRack(cabinet, breakers[cabinet["CabinetID"]])
+	}
+	for _, device := range importer.tables[tableDevices] {
+		importer.addDevice(device)
+	}
+	return importer.objects
+}
+
+func (importer *importer) add(object ogree.Object) {
+	if object.Managed == nil {
+		object.Managed = map[string]any{}
+	}
+	if object.Skip == "" {
+		object.Managed["domain"] = importer.mapping.Domain
+	}
+	importer.objects = append(importer.objects, object)
+}
+
+// addHierarchy: the domain, the site and the building of the data centers
+func (importer *importer) addHierarchy() {
+	domain := importer.mapping.Domain
+	name, parentId := domain, ""
+	if i := strings.LastIndex(domain, "."); i >= 0 {
+		name, parentId = domain[i+1:], domain[:i]
+	}
+	importer.objects = append(importer.objects, ogree.Object{
+		Category: "domain",
+		Id:       domain,
+		Identity: map[string]any{"name": name, "parentId": parentId, "category": "domain", "description": name},
+		Managed:  map[string]any{},
+	})
+
+	site := ogree.ToName(importer.mapping.Site)
+	importer.add(ogree.Object{
+		Category: "site",
+		Id:       site,
+		Identity: map[string]any{"name": site, "category": "site", "description": importer.mapping.Site},
+	})
+	importer.buildingId = site + "." + ogree.ToName(importer.mapping.Building)
+	importer.add(ogree.Object{
+		Category: "building",
+		Id:       importer.buildingId,
+		Identity: map[string]any{
+			"name": ogree.ToName(importer.mapping.Building), "parentId": site,
+			"category": "building", "description": importer.mapping.Building,
+		},
+	})
+}
+
+func (importer *importer) addTemplate(template row) {
+	templateObject := ogree.Object{Category: "obj_template", SourceId: toInt(template["TemplateID"])}
+	vendor := importer.manufacturers[template["ManufacturerID"]]
+	templateObject.Id = ogree.ToSlug(strings.TrimSpace(vendor + " " + template["Model"]))
+	height := toFloat(template["Height"])
+	if height <= 0 {
+		templateObject.Skip = "Templates of child devices are not imported"
+		importer.objects = append(importer.objects, templateObject)
+		return
+	}
+	importer.templates[template["TemplateID"]] = templateObject.Id
+
+	attributes := map[string]any{"type": "server", "vendor": vendor, "model": template["Model"]}
+	if deviceType := ogree.ToSlug(template["DeviceType"]); deviceType != "_" {
+		attributes["type"] = deviceType
+	}
+	if weight := toFloat(template["Weight"]); weight > 0 {
+		attributes["weightKg"] = weight
+	}
+	templateObject.Identity = map[string]any{
+		"slug": templateObject.Id, "category": "device", "fbxModel": "", "components": []any{}, "slots": []any{},
+	}
+	templateObject.Managed = map[string]any{
+		"description": strings.TrimSpace(vendor + " " + template["Model"]),
+		"sizeWDHmm":   []any{deviceWidthMm, fullDepthMm, round(height * rackUnitMm)},
+		"attributes":  attributes,
+	}
+	// templates have no domain
+	importer.objects = append(importer.objects, templateObject)
+}
+
+func (importer *importer) addRoom(dataCenter row) {
+	name := ogree.ToName(dataCenter["Name"])
+	id := importer.buildingId + "." + name
+	importer.rooms[dataCenter["DataCenterID"]] = id
+	importer.add(ogree.Object{
+		Category: "room",
+		Id:       id,
+		SourceId: toInt(dataCenter["DataCenterID"]),
+		Identity: map[string]any{"name": name, "parentId": importer.buildingId, "category": "room"},
+		Managed:  map[string]any{"description": description(dataCenter["DeliveryAddress"], dataCenter["Name"])},
+	})
+}
+
+// panelDataCenter: the data center of a power panel, given by its map or by
+// the cabinets of its power strips
+func (importer *importer) panelDataCenter(panel row) string {
+	if dataCenter := panel["MapDataCenterID"]; dataCenter != "" && dataCenter != "0" {
+		return dataCenter
+	}
+	cabinets := map[string]row{}
+	for _, cabinet := range importer.tables[tableCabinets] {
+		cabinets[cabinet["CabinetID"]] = cabinet
+	}
+	for _, pdu := range importer.tables[tablePdus] {
+		if pdu["PanelID"] == panel["PanelID"] || pdu["PanelID2"] == panel["PanelID"] {
+			if cabinet, ok := cabinets[pdu["CabinetID"]]; ok {
+				return cabinet["DataCenterID"]
+			}
+		}
+	}
+	return ""
+}
+
+func (importer *importer) addPowerPanel(panel row) {
+	name := ogree.ToName(panel["PanelLabel"])
+	panelObject := ogree.Object{Category: "panel", Id: name, SourceId: toInt(panel["PanelID"])}
+	roomId, ok := importer.rooms[importer.panelDataCenter(panel)]
+	if !ok {
+		panelObject.Skip = "Power panels outside of the data centers are not imported"
+		importer.add(panelObject)
+		return
+	}
+
+	attributes := map[string]any{}
+	for column, attribute := range map[string]string{
+		"NumberOfPoles": "poles", "MainBreakerSize": "mainBreakerSize", "PanelVoltage": "voltage", "NumPhases": "phases",
+	} {
+		if value := toFloat(panel[column]); value > 0 {
+			attributes[attribute] = value
+		}
+	}
+	panelObject.Id = roomId + "." + name
+	importer.panels[panel["PanelID"]] = panelObject.Id
+	panelObject.Identity = map[string]any{"name": name, "parentId": roomId, "category": "panel"}
+	panelObject.Managed = map[string]any{"description": panel["PanelLabel"], "attributes": attributes}
+	importer.add(panelObject)
+}
+
+// getBreakers: the breakers of each cabinet, one by power strip feed,
+// linked to their power panel by its name when it is in the room of the
+// cabinet (as the breakers created from the CLI) and by its id otherwise
+func (importer *importer) getBreakers() map[string]map[string]any {
+	cabinetRooms := map[string]string{}
+	for _, cabinet := range importer.tables[tableCabinets] {
+		cabinetRooms[cabinet["CabinetID"]] = importer.rooms[cabinet["DataCenterID"]]
+	}
+	breakers := map[string]map[string]any{}
+	for _, pdu := range importer.tables[tablePdus] {
+		for i, feed := range [][2]string{{"PanelID", "PanelPole"}, {"PanelID2", "PanelPole2"}} {
+			panelId, ok := importer.panels[pdu[feed[0]]]
+			if !ok {
+				continue
+			}
+			panel := panelId
+			if roomId := cabinetRooms[pdu["CabinetID"]]; roomId != "" && strings.HasPrefix(panelId, roomId+".") {
+				panel = strings.TrimPrefix(panelId, roomId+".")
+			}
+			name := ogree.ToName(pdu["Label"])
+			if i > 0 {
+				name += "-2"
+			}
+			breaker := map[string]any{"powerpanel": panel}
+			if pole := pdu[feed[1]]; pole != "" && pole != "0" {
+				breaker["circuit"] = pole
+			}
+			if intensity := toFloat(pdu["InputAmperage"]); intensity > 0 {
+				breaker["intensity"] = intensity
+			}
+			if breakers[pdu["CabinetID"]] == nil {
+				breakers[pdu["CabinetID"]] = map[string]any{}
+			}
+			breakers[pdu["CabinetID"]][name] = breaker
+		}
+	}
+	return breakers
+}
+
+func (importer *importer) addRack(cabinet row, breakers map[string]any) {
+	name := ogree.ToName(cabinet["Location"])
+	rackObject := ogree.Object{Category: "rack", Id: name, SourceId: toInt(cabinet["CabinetID"])}
+	roomId, ok := importer.rooms[cabinet["DataCenterID"]]
+	if !ok {
+		rackObject.Skip = "Data center " + cabinet["DataCenterID"] + " not found"
+		importer.add(rackObject)
+		return
+	}
+
+	rackObject.Id = roomId + "." + name
+	importer.racks[cabinet["CabinetID"]] = rackObject.Id
+	attributes := map[string]any{"height": toFloat(cabinet["CabinetHeight"]), "heightUnit": "U"}
+	if len(breakers) > 0 {
+		attributes["breakers"] = breakers
+	}
+	rackObject.Identity = map[string]any{"name": name, "parentId": roomId, "category": "rack"}
+	rackObject.Managed = map[string]any{
+		"description": description(cabinet["Notes"], cabinet["Location"]),
+		"attributes":  attributes,
+	}
+	importer.add(rackObject)
+}
+
+func (importer *importer) addDevice(device row) {
+	name := ogree.ToName(device["Label"])
+	deviceObject := ogree.Object{Category: "device", Id: name, SourceId: toInt(device["DeviceID"])}
+	rackId, hasRack := importer.racks[device["Cabinet"]]
+	switch {
+	case device["Label"] == "":
+		deviceObject.Id = device["DeviceID"]
+		deviceObject.Skip = "Devices without label are not imported"
+	case device["ParentDevice"] != "" && device["ParentDevice"] != "0":
+		deviceObject.Skip = "Child devices are not imported"
+	case toInt(device["Cabinet"]) <= 0:
+		deviceObject.Skip = "Devices without cabinet are not imported"
+	case !hasRack:
+		deviceObject.Skip = "Cabinet " + device["Cabinet"] + " not found"
+	}
+	if deviceObject.Skip != "" {
+		importer.add(deviceObject)
+		return
+	}
+
+	height := toFloat(device["Height"])
+	depth := fullDepthMm
+	if device["HalfDepth"] == "1" {
+		depth = halfDepthMm
+	}
+	attributes := map[string]any{
+		"size":        []any{deviceWidthMm, depth},
+		"sizeUnit":    "mm",
+		"height":      round(height * rackUnitMm),
+		"heightUnit":  "mm",
+		"sizeU":       int(math.Ceil(height)),
+		"orientation": "front",
+	}
+	if device["BackSide"] == "1" {
+		attributes["orientation"] = "rear"
+	}
+	if position := toFloat(device["Position"]); position > 0 {
+		attributes["posU"] = position
+	}
+	if template, ok := importer.templates[device["TemplateID"]]; ok {
+		attributes["template"] = template
+	}
+	for column, attribute := range map[string]string{"SerialNo": "serial", "AssetTag": "assetTag", "PrimaryIP": "ip"} {
+		if value := device[column]; value != "" {
+			attributes[attribute] = value
+		}
+	}
+
+	deviceObject.Id = rackId + "." + name
+	deviceObject.Identity = map[string]any{"name": name, "parentId": rackId, "category": "device"}
+	deviceObject.Managed = map[string]any{
+		"description": description(device["Notes"], device["Label"]),
+		"attributes":  attributes,
+	}
+	importer.add(deviceObject)
+}
+
+// description: the description of the openDCIM object, its name if it has none
+func description(description, name string) string {
+	if description = strings.TrimSpace(description); description != "" {
+		return description
+	}
+	return name
+}
+
+func toFloat(value string) float64 {
+	number, _ := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	return number
+}
+
+func toInt(value string) int {
+	return int(toFloat(value))
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
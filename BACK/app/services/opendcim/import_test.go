@@ -0,0 +1,224 @@
+package opendcim
+
+import (
+	"back-admin/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testDump = "-- MySQL dump 10.13\n" +
+	"CREATE TABLE `fac_DataCenter` (\n" +
+	"  `DataCenterID` int(11) NOT NULL AUTO_INCREMENT,\n" +
+	"  `Name` varchar(255) NOT NULL,\n" +
+	"  `DeliveryAddress` varchar(255) NOT NULL,\n" +
+	"  PRIMARY KEY (`DataCenterID`)\n" +
+	") ENGINE=InnoDB DEFAULT CHARSET=utf8;\n" +
+	"INSERT INTO `fac_DataCenter` VALUES (1,'DC 1','1 rue de l\\'Usine'),(2,'Empty DC','');\n" +
+	"INSERT INTO `fac_Manufacturer` (`ManufacturerID`, `Name`) VALUES (1,'Dell');\n" +
+	"INSERT INTO `fac_DeviceTemplate` (`TemplateID`, `ManufacturerID`, `Model`, `Height`, `Weight`, `DeviceType`) VALUES " +
+	"(1,1,'PowerEdge R640',1,20,'Server'),(2,1,'Blade',0,2,'Server');\n" +
+	"INSERT INTO `fac_Cabinet` (`CabinetID`, `DataCenterID`, `Location`, `CabinetHeight`, `Notes`) VALUES " +
+	"(1,1,'A01',42,NULL),(2,3,'Lost',42,'');\n" +
+	"INSERT INTO `fac_Device` (`DeviceID`, `Label`, `SerialNo`, `AssetTag`, `Cabinet`, `Position`, `Height`, " +
+	"`TemplateID`, `HalfDepth`, `BackSide`, `ParentDevice`, `Notes`) VALUES " +
+	"(1,'srv01','ABC123','',1,10,1,1,0,1,0,'web, front'),(2,'blade01','','',1,0,0,2,0,0,1,''),(3,'spare','','',-1,0,1,1,0,0,0,'');\n" +
+	"INSERT INTO `fac_PowerPanel` (`PanelID`, `PanelLabel`, `NumberOfPoles`, `MainBreakerSize`, `PanelVoltage`, `MapDataCenterID`) VALUES " +
+	"(1,'PP 1',42,400,230,0);\n" +
+	"INSERT INTO `fac_PowerDistribution` (`PDUID`, `Label`, `CabinetID`, `PanelID`, `PanelPole`, `InputAmperage`, `PanelID2`, `PanelPole2`) VALUES " +
+	"(1,'A01-PDU-A',1,1,'3',32,1,'5');\n"
+
+// fakeOgree keeps the objects created through the OGrEE API
+type fakeOgree struct {
+	objects map[string]map[string]any
+}
+
+func (ogree *fakeOgree) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/"), "/")
+	category := strings.TrimSuffix(path[0], "s")
+
+	switch r.Method {
+	case http.MethodGet:
+		object, ok := ogree.objects[category+" "+path[1]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]any{"message": "Nothing matches this request"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": object})
+	case http.MethodPost:
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		id, _ := body["name"].(string)
+		if parentId, _ := body["parentId"].(string); parentId != "" {
+			id = parentId + "." + id
+		}
+		if category == "obj_template" {
+			id = body["slug"].(string)
+		}
+		ogree.objects[category+" "+id] = body
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]any{"data": body})
+	}
+}
+
+func newTestImport(t *testing.T) (*fakeOgree, models.OpenDcimImport) {
+	ogree := &fakeOgree{objects: map[string]map[string]any{}}
+	ogreeServer := httptest.NewServer(ogree)
+	t.Cleanup(ogreeServer.Close)
+
+	dump := filepath.Join(t.TempDir(), "dump.sql")
+	if err := os.WriteFile(dump, []byte(testDump), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return ogree, models.OpenDcimImport{
+		Dump:    dump,
+		Ogree:   models.ApiConnection{Url: ogreeServer.URL, Token: "ogree-token"},
+		Mapping: models.OpenDcimMapping{Domain: "demo", Site: "paris"},
+	}
+}
+
+func getObjectReport(t *testing.T, report *models.SyncReport, category, id string) models.SyncObjectReport {
+	for _, object := range report.Objects {
+		if object.Category == category && object.Id == id {
+			return object
+		}
+	}
+	t.Fatalf("%s %s not found in the report", category, id)
+	return models.SyncObjectReport{}
+}
+
+func TestReadDump(t *testing.T) {
+	dump, err := readDump(strings.NewReader(testDump))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataCenters, _ := dump.rows(tableDataCenters)
+	if len(dataCenters) != 2 || dataCenters[0]["DeliveryAddress"] != "1 rue de l'Usine" || dataCenters[1]["Name"] != "Empty DC" {
+		t.Errorf("unexpected data centers %+v", dataCenters)
+	}
+	devices, _ := dump.rows(tableDevices)
+	if len(devices) != 3 || devices[0]["Notes"] != "web, front" || devices[2]["Cabinet"] != "-1" {
+		t.Errorf("unexpected devices %+v", devices)
+	}
+	cabinets, _ := dump.rows(tableCabinets)
+	if cabinets[0]["Notes"] != "" {
+		t.Errorf("NULL read as %q", cabinets[0]["Notes"])
+	}
+}
+
+func TestImportDryRun(t *testing.T) {
+	ogree, request := newTestImport(t)
+	request.DryRun = true
+
+	report, err := Import(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ogree.objects) != 0 {
+		t.Errorf("dry run created %d objects", len(ogree.objects))
+	}
+	// domain, site, building, 2 rooms, template, power panel, rack, device
+	if report.Created != 9 || report.Skipped != 4 || report.Errors != 0 {
+		t.Errorf("expected 9 creations and 4 skipped objects, got %+v", report)
+	}
+	if skip := getObjectReport(t, report, "device", "spare"); skip.Error != "Devices without cabinet are not imported" {
+		t.Errorf("unexpected device report %+v", skip)
+	}
+}
+
+func TestImportCreatesHierarchy(t *testing.T) {
+	ogree, request := newTestImport(t)
+
+	report, err := Import(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != 9 || report.Errors != 0 {
+		t.Fatalf("expected 9 creations, got %+v", report)
+	}
+
+	panel := ogree.objects["panel paris.main.DC-1.PP-1"]
+	if panel == nil || panel["attributes"].(map[string]any)["voltage"] == nil {
+		t.Errorf("power panel not created in the room of its power strip cabinet: %+v", panel)
+	}
+	rack := ogree.objects["rack paris.main.DC-1.A01"]
+	breakers, _ := rack["attributes"].(map[string]any)["breakers"].(map[string]any)
+	breaker, _ := breakers["A01-PDU-A"].(map[string]any)
+	if len(breakers) != 2 || breaker["powerpanel"] != "PP-1" || breaker["circuit"] != "3" || breaker["intensity"] != 32.0 {
+		t.Errorf("unexpected rack breakers %+v", breakers)
+	}
+	device := ogree.objects["device paris.main.DC-1.A01.srv01"]
+	attributes := device["attributes"].(map[string]any)
+	if device["domain"] != "demo" || attributes["template"] != "dell-poweredge-r640" || attributes["posU"] != 10.0 ||
+		attributes["orientation"] != "rear" || attributes["serial"] != "ABC123" {
+		t.Errorf("unexpected device %+v", device)
+	}
+
+	report, err = Import(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != 0 || report.Updated != 0 || report.Unchanged != 9 {
+		t.Errorf("second import not unchanged: %+v", report)
+	}
+}
+
+func TestImportFromApi(t *testing.T) {
+	openDcim := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("UserID") != "dcim" || r.Header.Get("APIKey") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		response := map[string]any{"error": false, "errorcode": 200}
+		switch r.URL.Path {
+		case "/api/v1/datacenter":
+			response["datacenter"] = []any{map[string]any{"DataCenterID": 1, "Name": "DC 1"}}
+		case "/api/v1/cabinet":
+			response["cabinet"] = []any{map[string]any{"CabinetID": 1, "DataCenterID": 1, "Location": "A01", "CabinetHeight": 48}}
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer openDcim.Close()
+
+	ogree, request := newTestImport(t)
+	request.Api = &models.OpenDcimApi{Url: openDcim.URL, UserId: "dcim", ApiKey: "secret"}
+
+	report, err := Import(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != 5 {
+		t.Errorf("expected 5 creations, got %+v", report)
+	}
+	rack := ogree.objects["rack paris.main.DC-1.A01"]
+	if rack == nil || rack["attributes"].(map[string]any)["height"] != 48.0 {
+		t.Errorf("unexpected rack %+v", rack)
+	}
+}
+
+func TestBreakersReferToPanelsOfOtherRoomsById(t *testing.T) {
+	importer := &importer{
+		tables: map[string][]row{
+			tableCabinets: {{"CabinetID": "1", "DataCenterID": "1"}, {"CabinetID": "2", "DataCenterID": "2"}},
+			tablePdus: {
+				{"PDUID": "1", "Label": "PDU 1", "CabinetID": "1", "PanelID": "1"},
+				{"PDUID": "2", "Label": "PDU 2", "CabinetID": "2", "PanelID": "1"},
+			},
+		},
+		rooms:  map[string]string{"1": "site.bldg.DC-1", "2": "site.bldg.DC-2"},
+		panels: map[string]string{"1": "site.bldg.DC-1.PP-1"},
+	}
+
+	breakers := importer.getBreakers()
+	if powerpanel := breakers["1"]["PDU-1"].(map[string]any)["powerpanel"]; powerpanel != "PP-1" {
+		t.Errorf("expected the name of the panel of the room, got %v", powerpanel)
+	}
+	if powerpanel := breakers["2"]["PDU-2"].(map[string]any)["powerpanel"]; powerpanel != "site.bldg.DC-1.PP-1" {
+		t.Errorf("expected the id of the panel of another room, got %v", powerpanel)
+	}
+}
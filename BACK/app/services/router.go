@@ -4,6 +4,7 @@ import (
 	"back-admin/auth"
 	"back-admin/handlers/docker"
 	"back-admin/handlers/kube"
+	"back-admin/handlers/tools"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -83,6 +84,12 @@ func InitRouter(isKube bool) *gin.Engine {
 		protected.DELETE("/tools/nautobot", docker.RemoveNautobot)
 	}
 
+	// Tools that only call the APIs of OGrEE and of other tools
+	protected.POST("/tools/netbox/sync", tools.SyncNetbox)
+	protected.POST("/tools/dcim/export", tools.ExportDcim)
+	protected.POST("/tools/opendcim/import", tools.ImportOpenDcim)
+	protected.POST("/tools/kubernetes/discover", tools.DiscoverKubernetes)
+
 	swagger := kube.SwaggerHandler()
 	router.Use(gin.WrapH(swagger))
 
@@ -3,4 +3,10 @@ package commands
 const Connect3D = "connect3d"
 const Disconnect3D = "disconnect3d"
 const Cp = "cp"
+const Mv = "mv"
 const LsBuilding = "lsbuilding"
+const Search = "search"
+const Import = "import"
+const Plan = "plan"
+const Apply = "apply"
+const Check = "check"
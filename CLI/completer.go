@@ -226,6 +226,15 @@ func DrawCompleter(path string) func(string) []string {
 func GetPrefixCompleter() *readline.PrefixCompleter {
 	return readline.NewPrefixCompleter(false,
 		readline.PcItem(commands.Cp, false),
+		readline.PcItem(commands.Mv, true,
+			readline.PcItemDynamic(ListEntities, false)),
+		readline.PcItem(commands.Search, false),
+		readline.PcItem(commands.Import, false,
+			readline.PcItem("csv", false),
+			readline.PcItem("devicetype", false)),
+		readline.PcItem(commands.Plan, false),
+		readline.PcItem(commands.Apply, false),
+		readline.PcItem(commands.Check, false),
 		readline.PcItem(commands.Connect3D, false),
 		readline.PcItem(commands.Disconnect3D, false),
 		readline.PcItem("cd", true,
@@ -267,6 +276,12 @@ func GetPrefixCompleter() *readline.PrefixCompleter {
 			readline.PcItem("lssite", false),
 			readline.PcItem(commands.LsBuilding, false),
 			readline.PcItem(commands.Cp, false),
+			readline.PcItem(commands.Mv, false),
+			readline.PcItem(commands.Search, false),
+			readline.PcItem(commands.Import, false),
+			readline.PcItem(commands.Plan, false),
+			readline.PcItem(commands.Apply, false),
+			readline.PcItem(commands.Check, false),
 			readline.PcItem(commands.Connect3D, false),
 			readline.PcItem(commands.Disconnect3D, false),
 			readline.PcItem("lsroom", false),
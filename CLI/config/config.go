@@ -36,6 +36,9 @@ type Config struct {
 	User         string
 	Password     string
 	Variables    []Vardef
+	Command      string // plan or apply, run without the shell
+	Manifest     string // file of the manifest of the command
+	Prune        bool
 }
 
 // Used for parsing (via JSON) into conf after parsing TOML
@@ -91,6 +94,12 @@ func ReadConfig() *Config {
 		" by only executing an OCLI script file")
 	flag.StringVarP(&args.User, "user", "", conf.User, "User email")
 	flag.StringVarP(&args.Password, "password", "", conf.Password, "Password")
+	prune := flag.BoolP("prune", "", false, "With the plan and apply commands, "+
+		"delete the objects of the subtrees of the manifest that it does not list")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] [plan|apply manifest]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
 	configBytes, err := os.ReadFile(args.ConfigPath)
@@ -108,6 +117,13 @@ func ReadConfig() *Config {
 	argBytes, _ := json.Marshal(args)
 	json.Unmarshal(argBytes, &conf)
 
+	// plan and apply are run without the shell
+	if command := flag.Arg(0); command == "plan" || command == "apply" {
+		conf.Command = command
+		conf.Manifest = flag.Arg(1)
+		conf.Prune = *prune
+	}
+
 	conf.ConfigPath, _ = filepath.Abs(conf.ConfigPath)
 	conf.HistPath, _ = filepath.Abs(conf.HistPath)
 	return conf
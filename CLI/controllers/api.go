@@ -1,166 +1,155 @@
 package controllers
 
 import (
-	"bytes"
 	"cli/models"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
-)
 
-const (
-	baseURL   = "/api/"
-	LayersURL = baseURL + "layers"
+	sdk "ogree-sdk"
 )
 
-var API APIPort = &apiPortImpl{}
+var API APIPort = &apiPortImpl{transport: sdk.NewHTTPTransport("")}
 
+// APIPort is the transport of the requests of the controllers to the API,
+// through the client of the SDK (see Controller.Client)
 type APIPort interface {
 	Request(method string, endpoint string, body map[string]any, expectedStatus int) (*Response, error)
 }
 
-type apiPortImpl struct{}
+type Response = sdk.Response
+
+// apiPortImpl sends the requests to the API at State.APIURL with the
+// token of State.APIKEY, refreshed by the transport of the SDK when it expires
+type apiPortImpl struct {
+	transport *sdk.HTTPTransport
+}
 
 // Request
 func (api *apiPortImpl) Request(method string, endpoint string, body map[string]any, expectedStatus int) (*Response, error) {
-	URL := State.APIURL + endpoint
-	httpResponse, err := Send(method, URL, GetKey(), body)
-	if err != nil {
-		return nil, err
-	}
-	response, err := ParseResponse(httpResponse)
-	if err != nil {
-		return nil, fmt.Errorf("on %s %s : %s", method, endpoint, err.Error())
-	}
-	if response.Status != expectedStatus {
+	api.sync()
+	response, err := api.transport.Request(method, endpoint, body, expectedStatus)
+	if apiErr, ok := err.(*sdk.APIError); ok {
 		msg := ""
 		if State.DebugLvl >= DEBUG {
-			msg += fmt.Sprintf("%s %s\n", method, URL)
+			msg += fmt.Sprintf("%s %s\n", method, State.APIURL+endpoint)
 		}
-		msg += fmt.Sprintf("[Response From API] %s", response.message)
-		errorsAny, ok := response.Body["errors"]
-		if ok {
-			errorsList := errorsAny.([]any)
-			for _, err := range errorsList {
-				msg += "\n    " + err.(string)
-			}
+		msg += fmt.Sprintf("[Response From API] %s", apiErr.Message)
+		for _, detail := range apiErr.Errors {
+			msg += "\n    " + detail
 		}
 		return response, fmt.Errorf(msg)
 	}
-	return response, nil
+	return response, err
 }
 
-func Send(method, URL, key string, data map[string]any) (*http.Response, error) {
-	client := &http.Client{}
-	dataJSON, err := json.Marshal(data)
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequest(method, URL, bytes.NewBuffer(dataJSON))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", "Bearer "+key)
-	return client.Do(req)
+// SetCredentials keeps the credentials of the user, to refresh its token
+func (api *apiPortImpl) SetCredentials(email, password, token string) {
+	api.sync()
+	api.transport.SetCredentials(email, password, token)
+	State.APIKEY = token
 }
 
-// Response handling
-type Response struct {
-	Status  int
-	message string
-	Body    map[string]any
+// PasswordChanged sends the next requests with the token given on the change of the password
+func (api *apiPortImpl) PasswordChanged(password, token string) {
+	api.sync()
+	api.transport.PasswordChanged(password, token)
+	State.APIKEY = token
 }
 
-func ParseResponse(response *http.Response) (*Response, error) {
-	bodyBytes, err := io.ReadAll(response.Body)
-	if err != nil {
-		return nil, err
+// sync sets the URL and the token of the shell state on the transport
+func (api *apiPortImpl) sync() {
+	api.transport.URL = State.APIURL
+	if api.transport.Token() != GetKey() {
+		api.transport.SetToken(GetKey())
 	}
-	defer response.Body.Close()
-	responseBody := map[string]interface{}{}
-	message := ""
-	if len(bodyBytes) > 0 {
-		err = json.Unmarshal(bodyBytes, &responseBody)
-		if err != nil {
-			return nil, fmt.Errorf("cannot unmarshal json : \n%s", string(bodyBytes))
-		}
-		message, _ = responseBody["message"].(string)
+	api.transport.OnTokenRefresh = func(token string) {
+		State.APIKEY = token
 	}
-	return &Response{response.StatusCode, message, responseBody}, nil
 }
 
-// URL handling
-func (controller Controller) ObjectUrl(pathStr string, depth int) (string, error) {
+// Client of the API for the controller
+func (controller Controller) Client() *sdk.Client {
+	return sdk.NewClient(controller.API)
+}
+
+// Object location
+
+// ObjectLocation is where an object is requested to the API: in its
+// collection by its id, or by a query on all the objects
+type ObjectLocation struct {
+	Collection string // e.g. hierarchy_objects, layers
+	Id         string
+	Query      url.Values // if the object is requested by a query (Collection is empty)
+}
+
+func (controller Controller) ObjectLocation(pathStr string) (*ObjectLocation, error) {
 	path, err := controller.SplitPath(pathStr)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	useGeneric := false
 
-	var baseUrl string
+	var collection string
 	switch path.Prefix {
 	case models.StrayPath:
-		baseUrl = "/api/stray_objects"
+		collection = "stray_objects"
 	case models.PhysicalPath:
-		baseUrl = "/api/hierarchy_objects"
+		collection = "hierarchy_objects"
 	case models.ObjectTemplatesPath:
-		baseUrl = "/api/obj_templates"
+		collection = "obj_templates"
 	case models.RoomTemplatesPath:
-		baseUrl = "/api/room_templates"
+		collection = "room_templates"
 	case models.BuildingTemplatesPath:
-		baseUrl = "/api/bldg_templates"
+		collection = "bldg_templates"
 	case models.GroupsPath:
-		baseUrl = "/api/groups"
+		collection = "groups"
 	case models.TagsPath:
-		baseUrl = "/api/tags"
+		collection = "tags"
 	case models.LayersPath:
-		baseUrl = LayersURL
+		collection = "layers"
 	case models.DomainsPath:
-		baseUrl = "/api/domains"
+		collection = "domains"
 	case models.VirtualObjsPath:
 		if strings.Contains(path.ObjectID, ".Physical.") {
-			baseUrl = "/api/objects"
-			path.ObjectID = strings.Split(path.ObjectID, ".Physical.")[1]
-			useGeneric = true
-		} else {
-			baseUrl = "/api/virtual_objs"
+			return &ObjectLocation{
+				Query: url.Values{"id": {strings.Split(path.ObjectID, ".Physical.")[1]}},
+			}, nil
 		}
+		collection = "virtual_objs"
 	default:
-		return "", fmt.Errorf("invalid object path")
+		return nil, fmt.Errorf("invalid object path")
 	}
 
-	params := url.Values{}
-	if useGeneric {
-		params.Add("id", path.ObjectID)
-		if depth > 0 {
-			params.Add("limit", strconv.Itoa(depth))
-		}
-	} else {
-		baseUrl += "/" + path.ObjectID
-		if depth > 0 {
-			baseUrl += "/all"
-			params.Add("limit", strconv.Itoa(depth))
-		}
+	return &ObjectLocation{Collection: collection, Id: path.ObjectID}, nil
+}
+
+// objectInCollection gives the collection and the id of the object of the
+// path, to modify it
+func (controller Controller) objectInCollection(pathStr string) (string, string, error) {
+	location, err := controller.ObjectLocation(pathStr)
+	if err != nil {
+		return "", "", err
 	}
-	parsedUrl, _ := url.Parse(baseUrl)
-	parsedUrl.RawQuery = params.Encode()
-	return parsedUrl.String(), nil
+	if location.Query != nil {
+		return "", "", fmt.Errorf("this object can only be modified from its path in %s", models.PhysicalPath)
+	}
+	return location.Collection, location.Id, nil
 }
 
-func (controller Controller) ObjectUrlGeneric(pathStr string, depth int, filters map[string]string, recursive *RecursiveParams) (string, error) {
+// ObjectsQuery gives the query of the objects of the path, with the
+// filters. If the filters have a complex filter, the objects must be
+// searched with it (search is true).
+func (controller Controller) ObjectsQuery(pathStr string, depth int, filters map[string]string, recursive *RecursiveParams) (url.Values, bool, error) {
 	path, err := controller.SplitPath(pathStr)
 	if err != nil {
-		return "", err
+		return nil, false, err
 	}
 
 	if recursive != nil {
 		err = path.MakeRecursive(recursive.MinDepth, recursive.MaxDepth, recursive.PathEntered)
 		if err != nil {
-			return "", err
+			return nil, false, err
 		}
 	}
 
@@ -180,22 +169,36 @@ func (controller Controller) ObjectUrlGeneric(pathStr string, depth int, filters
 
 	params, err := getUrlParamsFromPath(path, isNodeLayerInVirtualPath)
 	if err != nil {
-		return "", err
+		return nil, false, err
 	}
 
 	if depth > 0 {
 		params.Add("limit", strconv.Itoa(depth))
 	}
 
-	endpoint := "/api/objects"
+	search := false
 	for key, value := range filters {
 		if key != "filter" {
 			params.Set(key, value)
 		} else {
-			endpoint = "/api/objects/search"
+			search = true
 		}
 	}
 
+	return params, search, nil
+}
+
+func (controller Controller) ObjectUrlGeneric(pathStr string, depth int, filters map[string]string, recursive *RecursiveParams) (string, error) {
+	params, search, err := controller.ObjectsQuery(pathStr, depth, filters, recursive)
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := "/api/objects"
+	if search {
+		endpoint = "/api/objects/search"
+	}
+
 	url, _ := url.Parse(endpoint)
 	url.RawQuery = params.Encode()
 
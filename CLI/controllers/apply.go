@@ -0,0 +1,326 @@
+package controllers
+
+import (
+	"cli/models"
+	"cli/views"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/elliotchance/pie/v2"
+	"gopkg.in/yaml.v3"
+	sdk "ogree-sdk"
+)
+
+// ReadManifest reads a manifest from a YAML or JSON file
+func ReadManifest(filePath string) (*models.Manifest, error) {
+	file, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is valid YAML
+	var manifest models.Manifest
+	if err := yaml.Unmarshal(file, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %s", filePath, err.Error())
+	}
+
+	keys := map[string]bool{}
+	for i, object := range manifest.Objects {
+		object, err = normalizeManifestObject(object)
+		if err != nil {
+			return nil, fmt.Errorf("invalid object %d of manifest %s: %s", i+1, filePath, err.Error())
+		}
+		key := object["category"].(string) + " " + manifestKey(object)
+		if keys[key] {
+			return nil, fmt.Errorf("%s is described twice in manifest %s", key, filePath)
+		}
+		keys[key] = true
+		manifest.Objects[i] = object
+	}
+
+	return &manifest, nil
+}
+
+// normalizeManifestObject checks the category and the key of the object,
+// deduces name and parentId from the id and converts the numbers as the API returns them
+func normalizeManifestObject(object map[string]any) (map[string]any, error) {
+	category, _ := object["category"].(string)
+	if !pie.Contains(models.ManifestCategories, category) {
+		return nil, fmt.Errorf("category must be one of %s", strings.Join(models.ManifestCategories, ", "))
+	}
+
+	objectJSON, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	normalized := map[string]any{}
+	if err := json.Unmarshal(objectJSON, &normalized); err != nil {
+		return nil, err
+	}
+
+	if isManifestSlugCategory(category) {
+		if slug, _ := normalized["slug"].(string); slug == "" {
+			return nil, fmt.Errorf("a %s must have a slug", category)
+		}
+		return normalized, nil
+	}
+
+	id, _ := normalized["id"].(string)
+	if id == "" {
+		return nil, fmt.Errorf("a %s must have an id", category)
+	}
+	if lastDot := strings.LastIndex(id, "."); lastDot != -1 {
+		normalized["parentId"] = id[:lastDot]
+		normalized["name"] = id[lastDot+1:]
+	} else {
+		normalized["name"] = id
+	}
+	return normalized, nil
+}
+
+func isManifestSlugCategory(category string) bool {
+	return category == models.EntityToString(models.TAG) || category == models.EntityToString(models.LAYER)
+}
+
+// manifestKey: the id of the object, or the slug of a tag or a layer
+func manifestKey(object map[string]any) string {
+	if isManifestSlugCategory(object["category"].(string)) {
+		return object["slug"].(string)
+	}
+	return object["id"].(string)
+}
+
+// manifestOrder: tags and layers first, then parents before their children
+func manifestOrder(object map[string]any) int {
+	if isManifestSlugCategory(object["category"].(string)) {
+		return 0
+	}
+	return strings.Count(manifestKey(object), ".") + 1
+}
+
+// Plan compares the objects of the manifest with the objects of the API and
+// returns the changes to apply to reach the manifest. With prune, the objects
+// of the subtrees of the manifest that it does not describe are deleted.
+func (controller Controller) Plan(manifest *models.Manifest, prune bool) (*models.Plan, error) {
+	objects := make([]map[string]any, len(manifest.Objects))
+	copy(objects, manifest.Objects)
+	sort.SliceStable(objects, func(i, j int) bool {
+		return manifestOrder(objects[i]) < manifestOrder(objects[j])
+	})
+
+	plan := &models.Plan{}
+	described := map[string]bool{}
+	for _, object := range objects {
+		category := object["category"].(string)
+		key := manifestKey(object)
+		if !isManifestSlugCategory(category) {
+			described[key] = true
+		}
+
+		existing, err := controller.Client().GetObject(category+"s", key)
+		if err != nil {
+			if sdk.IsNotFound(err) {
+				plan.Changes = append(plan.Changes, models.PlanChange{
+					Action:   models.PlanCreate,
+					Category: category,
+					Id:       key,
+					Data:     creationData(object),
+				})
+				continue
+			}
+			return nil, err
+		}
+
+		if change := updateChange(object, existing); change != nil {
+			plan.Changes = append(plan.Changes, *change)
+		} else {
+			plan.Unchanged++
+		}
+	}
+
+	if prune {
+		for _, object := range objects {
+			if isManifestSlugCategory(object["category"].(string)) {
+				continue
+			}
+			if parentId, _ := object["parentId"].(string); parentId != "" && described[parentId] {
+				continue
+			}
+			deletions, err := controller.pruneChanges(manifestKey(object), described)
+			if err != nil {
+				return nil, err
+			}
+			plan.Changes = append(plan.Changes, deletions...)
+		}
+	}
+
+	return plan, nil
+}
+
+// creationData: the body of the creation of the object by the API
+func creationData(object map[string]any) map[string]any {
+	data := map[string]any{}
+	for field, value := range object {
+		if field == "id" || (field == "category" && isManifestSlugCategory(object["category"].(string))) {
+			continue
+		}
+		data[field] = value
+	}
+	return data
+}
+
+// updateChange: the update of the fields given by the manifest that differ
+// from the existing object, nil if there is none. The attributes that the
+// manifest does not give are left untouched.
+func updateChange(object, existing map[string]any) *models.PlanChange {
+	data := map[string]any{}
+	changes := []models.PlanFieldChange{}
+	for field, value := range object {
+		switch field {
+		case "category", "id", "slug", "name", "parentId":
+			continue
+		case "attributes":
+			attributes, _ := value.(map[string]any)
+			existingAttributes, _ := existing["attributes"].(map[string]any)
+			changedAttributes := map[string]any{}
+			for attribute, attributeValue := range attributes {
+				if !jsonEqual(attributeValue, existingAttributes[attribute]) {
+					changedAttributes[attribute] = attributeValue
+					changes = append(changes, models.PlanFieldChange{
+						Field: "attributes." + attribute,
+						Old:   existingAttributes[attribute],
+						New:   attributeValue,
+					})
+				}
+			}
+			if len(changedAttributes) > 0 {
+				data["attributes"] = changedAttributes
+			}
+		default:
+			if !jsonEqual(value, existing[field]) {
+				data[field] = value
+				changes = append(changes, models.PlanFieldChange{Field: field, Old: existing[field], New: value})
+			}
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return &models.PlanChange{
+		Action:   models.PlanUpdate,
+		Category: object["category"].(string),
+		Id:       manifestKey(object),
+		Data:     data,
+		Changes:  changes,
+	}
+}
+
+// pruneChanges: the deletions of the objects of the subtree of the root that
+// the manifest does not describe. The children of a deleted object are
+// deleted with it by the API, so they are not deleted on their own.
+func (controller Controller) pruneChanges(root string, described map[string]bool) ([]models.PlanChange, error) {
+	objects, err := controller.Client().GetObjects(url.Values{"id": {root + ".**"}})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		idI, _ := objects[i]["id"].(string)
+		idJ, _ := objects[j]["id"].(string)
+		return strings.Count(idI, ".") < strings.Count(idJ, ".") ||
+			(strings.Count(idI, ".") == strings.Count(idJ, ".") && idI < idJ)
+	})
+
+	deletions := []models.PlanChange{}
+	deleted := []string{}
+	for _, object := range objects {
+		id, _ := object["id"].(string)
+		category, _ := object["category"].(string)
+		if id == "" || described[id] || pie.Any(deleted, func(deletedId string) bool {
+			return strings.HasPrefix(id, deletedId+".")
+		}) {
+			continue
+		}
+		deleted = append(deleted, id)
+		deletions = append(deletions, models.PlanChange{Action: models.PlanDelete, Category: category, Id: id})
+	}
+	return deletions, nil
+}
+
+func jsonEqual(a, b any) bool {
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(aJSON) == string(bJSON)
+}
+
+// Apply sends the changes of the plan to the API, in order, and stops at the
+// first error. The number of applied changes is returned.
+func (controller Controller) Apply(plan *models.Plan) (int, error) {
+	applied := 0
+	for _, change := range plan.Changes {
+		var err error
+		switch change.Action {
+		case models.PlanCreate:
+			_, err = controller.Client().CreateObject(change.Category, change.Data)
+		case models.PlanUpdate:
+			_, err = controller.Client().UpdateObject(change.Category+"s", change.Id, change.Data, false)
+		case models.PlanDelete:
+			err = controller.Client().DeleteObject(change.Category+"s", change.Id)
+		}
+		if err != nil {
+			return applied, fmt.Errorf("cannot %s %s %s: %s", change.Action, change.Category, change.Id, err.Error())
+		}
+		applied++
+
+		if change.Category == models.EntityToString(models.LAYER) && State.Hierarchy != nil {
+			State.Hierarchy.Children["Logical"].Children["Layers"].IsCached = false
+		}
+	}
+	return applied, nil
+}
+
+// Exit codes of plan and apply when run from the command line
+const (
+	ExitSuccess = 0
+	ExitFailure = 1
+	ExitChanges = 2 // plan only: the API differs from the manifest
+)
+
+// RunManifestCommand runs plan or apply on the manifest without the shell,
+// e.g. in a GitOps pipeline, and returns the exit code of the process:
+// plan exits with ExitChanges when there are changes to apply, and both
+// exit with ExitFailure on error
+func (controller Controller) RunManifestCommand(command, filePath string, prune bool) int {
+	manifest, err := ReadManifest(filePath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return ExitFailure
+	}
+
+	plan, err := controller.Plan(manifest, prune)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return ExitFailure
+	}
+	fmt.Print(views.Plan(plan))
+
+	if command == "plan" {
+		if len(plan.Changes) > 0 {
+			return ExitChanges
+		}
+		return ExitSuccess
+	}
+
+	applied, err := controller.Apply(plan)
+	fmt.Print(views.Applied(applied, len(plan.Changes)))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return ExitFailure
+	}
+	return ExitSuccess
+}
@@ -0,0 +1,165 @@
+package controllers_test
+
+import (
+	"cli/controllers"
+	"cli/models"
+	test_utils "cli/test"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testManifest = `
+objects:
+  - category: rack
+    id: PAR.BA.R1.A01
+    domain: demo
+    attributes:
+      height: 47
+      heightUnit: U
+  - category: tag
+    slug: gpu
+    color: 00ff00
+  - category: room
+    id: PAR.BA.R1
+    domain: demo
+`
+
+func writeManifest(t *testing.T, content string) string {
+	filePath := filepath.Join(t.TempDir(), "manifest.yaml")
+	assert.Nil(t, os.WriteFile(filePath, []byte(content), 0644))
+	return filePath
+}
+
+func TestReadManifestDeducesNameAndParent(t *testing.T) {
+	manifest, err := controllers.ReadManifest(writeManifest(t, testManifest))
+	assert.Nil(t, err)
+	assert.Len(t, manifest.Objects, 3)
+	assert.Equal(t, map[string]any{
+		"category":   "rack",
+		"id":         "PAR.BA.R1.A01",
+		"name":       "A01",
+		"parentId":   "PAR.BA.R1",
+		"domain":     "demo",
+		"attributes": map[string]any{"height": 47.0, "heightUnit": "U"},
+	}, manifest.Objects[0])
+}
+
+func TestReadManifestAcceptsJSON(t *testing.T) {
+	manifest, err := controllers.ReadManifest(writeManifest(t,
+		`{"objects": [{"category": "site", "id": "PAR", "domain": "demo"}]}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "PAR", manifest.Objects[0]["name"])
+}
+
+func TestReadManifestWithInvalidObjectsFails(t *testing.T) {
+	_, err := controllers.ReadManifest(writeManifest(t, "objects:\n  - category: user\n    id: admin\n"))
+	assert.ErrorContains(t, err, "invalid object 1 of manifest")
+
+	_, err = controllers.ReadManifest(writeManifest(t, "objects:\n  - category: layer\n    id: servers\n"))
+	assert.ErrorContains(t, err, "a layer must have a slug")
+
+	_, err = controllers.ReadManifest(writeManifest(t,
+		"objects:\n  - category: site\n    id: PAR\n  - category: site\n    id: PAR\n"))
+	assert.ErrorContains(t, err, "site PAR is described twice")
+}
+
+func TestPlanCreatesUpdatesAndKeeps(t *testing.T) {
+	controller, mockAPI, _, _ := test_utils.NewControllerWithMocks(t)
+	manifest, err := controllers.ReadManifest(writeManifest(t, testManifest))
+	assert.Nil(t, err)
+
+	test_utils.MockObjectNotFound(mockAPI, "/api/tags/gpu")
+	test_utils.MockGetObjectByEntity(mockAPI, "rooms", map[string]any{
+		"category": "room", "id": "PAR.BA.R1", "name": "R1", "parentId": "PAR.BA", "domain": "demo",
+	})
+	test_utils.MockGetObjectByEntity(mockAPI, "racks", map[string]any{
+		"category": "rack", "id": "PAR.BA.R1.A01", "name": "A01", "parentId": "PAR.BA.R1", "domain": "demo",
+		"attributes": map[string]any{"height": 42.0, "heightUnit": "U", "vendor": "APC"},
+	})
+
+	plan, err := controller.Plan(manifest, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, plan.Unchanged)
+	assert.Equal(t, []models.PlanChange{
+		{
+			Action:   models.PlanCreate,
+			Category: "tag",
+			Id:       "gpu",
+			Data:     map[string]any{"slug": "gpu", "color": "00ff00"},
+		},
+		{
+			Action:   models.PlanUpdate,
+			Category: "rack",
+			Id:       "PAR.BA.R1.A01",
+			Data:     map[string]any{"attributes": map[string]any{"height": 47.0}},
+			Changes:  []models.PlanFieldChange{{Field: "attributes.height", Old: 42.0, New: 47.0}},
+		},
+	}, plan.Changes)
+}
+
+func TestPlanWithPruneDeletesTopMostUnlistedObjects(t *testing.T) {
+	controller, mockAPI, _, _ := test_utils.NewControllerWithMocks(t)
+	manifest, err := controllers.ReadManifest(writeManifest(t,
+		"objects:\n  - category: room\n    id: PAR.BA.R1\n  - category: rack\n    id: PAR.BA.R1.A01\n"))
+	assert.Nil(t, err)
+
+	test_utils.MockGetObjectByEntity(mockAPI, "rooms", map[string]any{"category": "room", "id": "PAR.BA.R1"})
+	test_utils.MockGetObjectByEntity(mockAPI, "racks", map[string]any{"category": "rack", "id": "PAR.BA.R1.A01"})
+	test_utils.MockGetObjects(mockAPI, "id=PAR.BA.R1.**", []any{
+		map[string]any{"category": "device", "id": "PAR.BA.R1.A02.srv01"},
+		map[string]any{"category": "rack", "id": "PAR.BA.R1.A01"},
+		map[string]any{"category": "rack", "id": "PAR.BA.R1.A02"},
+		map[string]any{"category": "device", "id": "PAR.BA.R1.A01.srv01"},
+	})
+
+	plan, err := controller.Plan(manifest, true)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, plan.Unchanged)
+	assert.Equal(t, []models.PlanChange{
+		{Action: models.PlanDelete, Category: "rack", Id: "PAR.BA.R1.A02"},
+		{Action: models.PlanDelete, Category: "device", Id: "PAR.BA.R1.A01.srv01"},
+	}, plan.Changes)
+}
+
+func TestApplySendsChangesInOrder(t *testing.T) {
+	controller, mockAPI, _, _ := test_utils.NewControllerWithMocks(t)
+
+	rack := map[string]any{"category": "rack", "name": "A01", "parentId": "PAR.BA.R1"}
+	test_utils.MockCreateObject(mockAPI, "rack", rack)
+	test_utils.MockUpdateObject(mockAPI, map[string]any{"domain": "demo"}, map[string]any{"domain": "demo"})
+	mockAPI.On("Request", http.MethodDelete, "/api/devices/PAR.BA.R1.A02.srv01", mock.Anything, http.StatusNoContent).
+		Return(&controllers.Response{Status: http.StatusNoContent}, nil).Once()
+
+	applied, err := controller.Apply(&models.Plan{Changes: []models.PlanChange{
+		{Action: models.PlanCreate, Category: "rack", Id: "PAR.BA.R1.A01", Data: rack},
+		{Action: models.PlanUpdate, Category: "room", Id: "PAR.BA.R1", Data: map[string]any{"domain": "demo"}},
+		{Action: models.PlanDelete, Category: "device", Id: "PAR.BA.R1.A02.srv01"},
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, applied)
+}
+
+func TestRunManifestCommandPlanExitsWithChanges(t *testing.T) {
+	controller, mockAPI, _, _ := test_utils.NewControllerWithMocks(t)
+	filePath := writeManifest(t, "objects:\n  - category: room\n    id: PAR.BA.R1\n")
+
+	test_utils.MockGetObjectByEntity(mockAPI, "rooms", map[string]any{
+		"category": "room", "id": "PAR.BA.R1", "name": "R1", "parentId": "PAR.BA",
+	})
+	assert.Equal(t, controllers.ExitSuccess, controller.RunManifestCommand("plan", filePath, false))
+
+	test_utils.MockObjectNotFound(mockAPI, "/api/rooms/PAR.BA.R1")
+	assert.Equal(t, controllers.ExitChanges, controller.RunManifestCommand("plan", filePath, false))
+}
+
+func TestRunManifestCommandWithInvalidManifestFails(t *testing.T) {
+	controller, _, _, _ := test_utils.NewControllerWithMocks(t)
+	filePath := writeManifest(t, "objects:\n  - category: user\n    id: admin\n")
+
+	assert.Equal(t, controllers.ExitFailure, controller.RunManifestCommand("apply", filePath, false))
+}
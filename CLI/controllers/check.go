@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"cli/models"
+	sdk "ogree-sdk"
+)
+
+// CheckIntegrity returns the dangling references of the objects
+func (controller Controller) CheckIntegrity() ([]models.IntegrityIssue, error) {
+	issues, err := controller.Client().CheckIntegrity()
+	if err != nil {
+		return nil, err
+	}
+	return integrityIssues(issues), nil
+}
+
+// FixIntegrity removes the dangling references that can be safely removed and
+// returns the issues fixed and those that need a manual repair
+func (controller Controller) FixIntegrity() ([]models.IntegrityIssue, []models.IntegrityIssue, error) {
+	fix, err := controller.Client().FixIntegrity()
+	if err != nil {
+		return nil, nil, err
+	}
+	return integrityIssues(fix.Fixed), integrityIssues(fix.Remaining), nil
+}
+
+func integrityIssues(issues []sdk.IntegrityIssue) []models.IntegrityIssue {
+	result := []models.IntegrityIssue{}
+	for _, issue := range issues {
+		result = append(result, models.IntegrityIssue{
+			Path:      searchResultPath(issue.Category, map[string]any{"id": issue.Id}),
+			Type:      issue.Type,
+			Field:     issue.Field,
+			Reference: issue.Reference,
+			Fixable:   issue.Fixable,
+		})
+	}
+	return result
+}
@@ -0,0 +1,45 @@
+package controllers_test
+
+import (
+	"cli/controllers"
+	"cli/models"
+	test_utils "cli/test"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckIntegrityReturnsIssuesByPath(t *testing.T) {
+	controller, mockAPI, _, _ := test_utils.NewControllerWithMocks(t)
+
+	mockAPI.On(
+		"Request", http.MethodGet,
+		"/api/integrity",
+		map[string]any(nil), http.StatusOK,
+	).Return(
+		&controllers.Response{
+			Body: map[string]any{
+				"data": map[string]any{
+					"issues": []any{
+						map[string]any{
+							"type": "group-content", "id": "BASIC.A.R1.GR1", "category": "group",
+							"field": "attributes.content", "reference": "A02", "fixable": true,
+						},
+						map[string]any{
+							"type": "orphan", "id": "BASIC.A.R2.A01", "category": "rack",
+							"field": "parentId", "reference": "BASIC.A.R2", "fixable": false,
+						},
+					},
+				},
+			},
+		}, nil,
+	).Once()
+
+	issues, err := controller.CheckIntegrity()
+	assert.Nil(t, err)
+	assert.Equal(t, []models.IntegrityIssue{
+		{Path: models.GroupsPath + "BASIC/A/R1/GR1", Type: "group-content", Field: "attributes.content", Reference: "A02", Fixable: true},
+		{Path: "/Physical/BASIC/A/R2/A01", Type: "orphan", Field: "parentId", Reference: "BASIC.A.R2"},
+	}, issues)
+}
@@ -3,11 +3,21 @@ package controllers
 import (
 	"cli/models"
 	"errors"
+	"fmt"
+	sdk "ogree-sdk"
+	"strings"
 )
 
 var ErrObjectCantBeCopied = errors.New("source object can not be copied")
 
-func (controller Controller) Cp(source, dest string) error {
+// Cp copies the source to dest. Layers are copied to a path or slug. Physical
+// objects are copied with their children to a path or name, which can be a
+// pattern such as R{01..10}, and the attributes can give their offset and position.
+func (controller Controller) Cp(source, dest string, attrs []string, values []any) error {
+	if models.IsPhysical(source) && !models.IsStray(source) {
+		return controller.copyPhysicalObject(source, dest, attrs, values)
+	}
+
 	object, err := controller.GetObject(source)
 	if err != nil {
 		return err
@@ -16,6 +26,9 @@ func (controller Controller) Cp(source, dest string) error {
 	if !models.IsLayer(source) {
 		return ErrObjectCantBeCopied
 	}
+	if len(attrs) > 0 {
+		return fmt.Errorf("attributes can only be given to copy physical objects")
+	}
 
 	var destPath string
 
@@ -42,3 +55,51 @@ func (controller Controller) Cp(source, dest string) error {
 
 	return nil
 }
+
+// copyPhysicalObject: dest is the path of the copies or only their name
+// to copy the object next to itself
+func (controller Controller) copyPhysicalObject(source, dest string, attrs []string, values []any) error {
+	collection, id, err := controller.objectInCollection(source)
+	if err != nil {
+		return err
+	}
+
+	objectCopy := sdk.ObjectCopy{Name: dest, Position: map[string]any{}}
+	if strings.Contains(dest, "/") {
+		dest = TranslatePath(dest, false)
+		if !models.IsPhysical(dest) || models.IsStray(dest) {
+			return fmt.Errorf("physical objects can only be copied in %s", models.PhysicalPath)
+		}
+		lastSlash := strings.LastIndex(dest, "/")
+		parentPath, err := controller.SplitPath(dest[:lastSlash])
+		if err != nil {
+			return err
+		}
+		objectCopy.ParentId = parentPath.ObjectID
+		objectCopy.Name = dest[lastSlash+1:]
+	}
+
+	for i, attr := range attrs {
+		if attr != "offset" {
+			objectCopy.Position[attr] = values[i]
+			continue
+		}
+		offset, ok := values[i].([]any)
+		if !ok {
+			return fmt.Errorf("offset must be a vector of numbers")
+		}
+		for _, value := range offset {
+			switch number := value.(type) {
+			case int:
+				objectCopy.Offset = append(objectCopy.Offset, float64(number))
+			case float64:
+				objectCopy.Offset = append(objectCopy.Offset, number)
+			default:
+				return fmt.Errorf("offset must be a vector of numbers")
+			}
+		}
+	}
+
+	_, err = controller.Client().CopyObject(collection, id, objectCopy)
+	return err
+}
@@ -18,7 +18,7 @@ func TestCpObjectThatIsNotALayerCantBeCopied(t *testing.T) {
 		"slug": "asd",
 	})
 
-	err := controller.Cp("/Logical/Tags/asd", "asd2")
+	err := controller.Cp("/Logical/Tags/asd", "asd2", nil, nil)
 	assert.ErrorIs(t, err, controllers.ErrObjectCantBeCopied)
 }
 
@@ -48,7 +48,7 @@ func TestCpLayerWithDestPathOrSlugCopiesSource(t *testing.T) {
 				models.LayerFilters:       "category = device",
 			})
 
-			err := controller.Cp("/Logical/Layers/layer1", tt.destination)
+			err := controller.Cp("/Logical/Layers/layer1", tt.destination, nil, nil)
 			assert.Nil(t, err)
 		})
 	}
@@ -79,7 +79,7 @@ func TestCpLayerWhenSourceIsCachedCopiesSource(t *testing.T) {
 		models.LayerFilters:       "category = device",
 	})
 
-	err = controller.Cp("/Logical/Layers/layer1", "/Logical/Layers/layer2")
+	err = controller.Cp("/Logical/Layers/layer1", "/Logical/Layers/layer2", nil, nil)
 	assert.Nil(t, err)
 
 	mockClock.On("Now").Return(now.Add(5 * time.Second)).Once()
@@ -98,3 +98,40 @@ func TestCpLayerWhenSourceIsCachedCopiesSource(t *testing.T) {
 	utils.ContainsObjectNamed(t, objects, "#layer1")
 	utils.ContainsObjectNamed(t, objects, "#layer2")
 }
+
+func TestCpPhysicalObjectWithPatternAndOffset(t *testing.T) {
+	controller, mockAPI, _, _ := lsSetup(t)
+
+	body := map[string]any{
+		"parentId": "BASIC.A.R2",
+		"name":     "R{01..02}",
+		"offset":   []float64{0, 1, 0},
+	}
+	test_utils.MockCopyObject(mockAPI, "BASIC.A.R1.A01", body, []any{
+		map[string]any{"id": "BASIC.A.R2.R01"},
+		map[string]any{"id": "BASIC.A.R2.R02"},
+	})
+
+	err := controller.Cp("/Physical/BASIC/A/R1/A01", "/Physical/BASIC/A/R2/R{01..02}",
+		[]string{"offset"}, []any{[]any{0, 1, 0}})
+	assert.Nil(t, err)
+}
+
+func TestCpPhysicalObjectWithNameCopiesUnderSameParent(t *testing.T) {
+	controller, mockAPI, _, _ := lsSetup(t)
+
+	test_utils.MockCopyObject(mockAPI, "BASIC.A.R1.A01", map[string]any{"name": "A02"}, []any{
+		map[string]any{"id": "BASIC.A.R1.A02"},
+	})
+
+	err := controller.Cp("/Physical/BASIC/A/R1/A01", "A02", nil, nil)
+	assert.Nil(t, err)
+}
+
+func TestCpPhysicalObjectWithInvalidOffset(t *testing.T) {
+	controller, _, _, _ := lsSetup(t)
+
+	err := controller.Cp("/Physical/BASIC/A/R1/A01", "A02", []string{"offset"}, []any{"front"})
+	assert.NotNil(t, err)
+	assert.Equal(t, "offset must be a vector of numbers", err.Error())
+}
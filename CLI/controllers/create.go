@@ -4,12 +4,11 @@ import (
 	"cli/models"
 	"cli/utils"
 	"fmt"
-	"net/http"
 	pathutil "path"
 )
 
 func (controller Controller) PostObj(ent int, entity string, data map[string]any, path string) error {
-	resp, err := controller.API.Request(http.MethodPost, "/api/"+entity+"s", data, http.StatusCreated)
+	created, err := controller.Client().CreateObject(entity, data)
 	if err != nil {
 		return err
 	}
@@ -17,7 +16,7 @@ func (controller Controller) PostObj(ent int, entity string, data map[string]any
 	if entInt := models.EntityStrToInt(entity); models.EntityCreationMustBeInformed(ent) &&
 		IsInObjForUnity(entity) && entInt != models.LAYER {
 		createType := "create"
-		Ogree3D.InformOptional("PostObj", entInt, map[string]any{"type": createType, "data": resp.Body["data"]})
+		Ogree3D.InformOptional("PostObj", entInt, map[string]any{"type": createType, "data": created})
 	}
 
 	if models.IsLayer(path) {
@@ -32,15 +31,11 @@ func (controller Controller) PostObj(ent int, entity string, data map[string]any
 }
 
 func (controller Controller) ValidateObj(ent int, entity string, data map[string]any, path string) error {
-	resp, err := controller.API.Request(http.MethodPost, "/api/validate/"+entity+"s", data, http.StatusOK)
+	err := controller.Client().ValidateObject(entity, data)
 	if err != nil {
 		fmt.Println(err)
-		fmt.Println("RESP:")
-		fmt.Println(resp)
-		return err
 	}
-
-	return nil
+	return err
 }
 
 func (controller Controller) CreateObject(path string, ent int, data map[string]any, validate ...bool) error {
@@ -3,17 +3,16 @@ package controllers
 import (
 	"cli/models"
 	"fmt"
-	"net/http"
 	"strings"
 )
 
 func (controller Controller) DeleteObj(path string) ([]string, error) {
-	url, err := controller.ObjectUrlGeneric(path, 0, nil, nil)
+	query, _, err := controller.ObjectsQuery(path, 0, nil, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var resp *Response
+	var objs []map[string]any
 	if models.PathHasLayer(path) {
 		var pathSplit models.Path
 		filters := map[string]string{}
@@ -24,15 +23,14 @@ func (controller Controller) DeleteObj(path string) ([]string, error) {
 		if pathSplit.Layer != nil {
 			pathSplit.Layer.ApplyFilters(filters)
 		}
-		body := map[string]any{"filter": filters["filter"]}
-		resp, err = controller.API.Request(http.MethodDelete, url, body, http.StatusOK)
+		objs, err = controller.Client().DeleteObjectsWithFilter(query, filters["filter"])
 	} else {
-		resp, err = controller.API.Request(http.MethodDelete, url, nil, http.StatusOK)
+		objs, err = controller.Client().DeleteObjects(query)
 	}
 	if err != nil {
 		return nil, err
 	}
-	_, paths, err := controller.ParseWildcardResponse(resp, path, "DELETE "+url)
+	_, paths, err := controller.objectsWithPaths(objs, path)
 	if err != nil {
 		return nil, err
 	}
@@ -68,10 +66,10 @@ func (controller Controller) UnsetAttribute(path string, attr string) error {
 	} else {
 		delete(attributes, attr)
 	}
-	url, err := controller.ObjectUrl(path, 0)
+	collection, id, err := controller.objectInCollection(path)
 	if err != nil {
 		return err
 	}
-	_, err = controller.API.Request("PUT", url, obj, http.StatusOK)
+	_, err = controller.Client().ReplaceObject(collection, id, obj)
 	return err
 }
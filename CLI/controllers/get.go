@@ -6,9 +6,10 @@ import (
 	"cli/views"
 	"errors"
 	"fmt"
-	"net/http"
 	"strconv"
 	"strings"
+
+	sdk "ogree-sdk"
 )
 
 var ErrObjectNotFound = errors.New("object not found")
@@ -18,13 +19,11 @@ func (controller Controller) GetObject(path string) (map[string]any, error) {
 }
 
 func (controller Controller) GetObjectsWildcard(pathStr string, filters map[string]string, recursive *RecursiveParams) ([]map[string]any, []string, error) {
-	url, err := controller.ObjectUrlGeneric(pathStr, 0, filters, recursive)
+	query, _, err := controller.ObjectsQuery(pathStr, 0, filters, recursive)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	var resp *Response
-	var method string
 	if models.PathHasLayer(pathStr) {
 		if filters == nil {
 			filters = map[string]string{}
@@ -38,33 +37,26 @@ func (controller Controller) GetObjectsWildcard(pathStr string, filters map[stri
 		}
 	}
 
+	var objs []map[string]any
 	if complexFilter, ok := filters["filter"]; ok {
-		body := map[string]any{"filter": complexFilter}
-		method = "POST "
-		resp, err = controller.API.Request(http.MethodPost, url, body, http.StatusOK)
+		objs, err = controller.Client().SearchObjects(query, complexFilter)
 	} else {
-		method = "GET "
-		resp, err = controller.API.Request(http.MethodGet, url, nil, http.StatusOK)
+		objs, err = controller.Client().GetObjects(query)
 	}
 
 	if err != nil {
 		return nil, nil, err
 	}
-	return controller.ParseWildcardResponse(resp, pathStr, method+url)
+	return controller.objectsWithPaths(objs, pathStr)
 }
 
-func (controller Controller) ParseWildcardResponse(resp *Response, pathStr string, route string) ([]map[string]any, []string, error) {
-	objsAny, ok := resp.Body["data"].([]any)
-	if !ok {
-		return nil, nil, fmt.Errorf("invalid response from API on %s", route)
-	}
-
+// objectsWithPaths gives the path of each object found under the path
+func (controller Controller) objectsWithPaths(objs []map[string]any, pathStr string) ([]map[string]any, []string, error) {
 	path, err := controller.SplitPath(pathStr)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	objs := utils.AnyArrToMapArr(objsAny)
 	paths := []string{}
 	for _, obj := range objs {
 		var suffix string
@@ -98,7 +90,7 @@ func (controller Controller) PollObject(path string) (map[string]any, error) {
 }
 
 func (controller Controller) PollObjectWithChildren(path string, depth int) (map[string]any, error) {
-	url, err := controller.ObjectUrl(path, depth)
+	location, err := controller.ObjectLocation(path)
 	if err != nil {
 		if errors.Is(err, errLayerNotFound) {
 			return nil, err
@@ -106,23 +98,32 @@ func (controller Controller) PollObjectWithChildren(path string, depth int) (map
 
 		return nil, nil
 	}
-	resp, err := controller.API.Request(http.MethodGet, url, nil, http.StatusOK)
+
+	var obj map[string]any
+	if location.Query != nil {
+		if depth > 0 {
+			location.Query.Add("limit", strconv.Itoa(depth))
+		}
+		var objs []map[string]any
+		objs, err = controller.Client().GetObjects(location.Query)
+		if err == nil {
+			if len(objs) < 1 {
+				return nil, fmt.Errorf("invalid response from API on GET /api/objects?%s", location.Query.Encode())
+			}
+			obj = objs[0]
+		}
+	} else if depth > 0 {
+		obj, err = controller.Client().GetHierarchy(location.Collection, location.Id, depth)
+	} else {
+		obj, err = controller.Client().GetObject(location.Collection, location.Id)
+	}
 	if err != nil {
-		if resp != nil && resp.Status == http.StatusNotFound {
+		if sdk.IsNotFound(err) {
 			return nil, nil
 		}
 		return nil, err
 	}
 
-	obj, ok := resp.Body["data"].(map[string]any)
-	if !ok {
-		if listObj, ok := resp.Body["data"].([]any); !ok || len(listObj) < 1 {
-			return nil, fmt.Errorf("invalid response from API on GET %s", url)
-		} else {
-			obj = listObj[0].(map[string]any)
-		}
-	}
-
 	return obj, nil
 }
 
@@ -176,11 +177,11 @@ func (controller Controller) GetSlot(rack map[string]any, location string) (map[
 	if template == "" {
 		return nil, nil
 	}
-	resp, err := controller.API.Request("GET", "/api/obj_templates/"+template, nil, http.StatusOK)
+	objTemplate, err := controller.Client().GetObject("obj_templates", template)
 	if err != nil {
 		return nil, err
 	}
-	slots, ok := resp.Body["data"].(map[string]any)["slots"]
+	slots, ok := objTemplate["slots"]
 	if !ok {
 		return nil, nil
 	}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"cli/models"
+	"fmt"
+	"os"
+	"strings"
+
+	sdk "ogree-sdk"
+)
+
+// ImportCSV sends the CSV file to the API, that creates an object for each of its rows.
+// If the API rejects some rows, the report is returned with the error.
+func (controller Controller) ImportCSV(filePath string, options models.CSVImportOptions) (*models.CSVImportReport, error) {
+	file, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := controller.Client().ImportCSV(sdk.CSVImport{
+		File:           string(file),
+		Mapping:        options.Mapping,
+		ParentColumn:   options.ParentColumn,
+		TemplateColumn: options.TemplateColumn,
+		Category:       options.Category,
+		Separator:      options.Separator,
+	})
+	if report == nil {
+		return nil, err
+	}
+	return csvImportReport(report), err
+}
+
+// ImportDeviceTypes sends the device types of a YAML file of the community device-type library
+// to the API, that creates an object template for each of them.
+// The slugs of the created templates are returned.
+func (controller Controller) ImportDeviceTypes(filePath string, options models.DeviceTypeImportOptions) ([]string, error) {
+	file, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := controller.Client().ImportDeviceTypes(sdk.DeviceTypeImport{
+		File:      string(file),
+		WidthMm:   options.WidthMm,
+		DepthMm:   options.DepthMm,
+		ImagesUrl: options.ImagesUrl,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slugs := []string{}
+	for _, template := range templates {
+		slugs = append(slugs, template.Slug)
+	}
+	return slugs, nil
+}
+
+// ParseCSVImportMapping parses a mapping given as column:field pairs separated by commas
+func ParseCSVImportMapping(mapping string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, pair := range strings.Split(mapping, ",") {
+		separatorIndex := strings.LastIndex(pair, ":")
+		if separatorIndex <= 0 || separatorIndex == len(pair)-1 {
+			return nil, fmt.Errorf("invalid mapping %q, expected column:field", pair)
+		}
+		result[strings.TrimSpace(pair[:separatorIndex])] = strings.TrimSpace(pair[separatorIndex+1:])
+	}
+	return result, nil
+}
+
+func csvImportReport(report *sdk.CSVImportReport) *models.CSVImportReport {
+	result := &models.CSVImportReport{Created: report.Created, Errors: report.Errors}
+	for _, row := range report.Rows {
+		result.Rows = append(result.Rows, models.CSVImportRowReport{
+			Row:      row.Row,
+			Id:       row.Id,
+			Category: row.Category,
+			Status:   row.Status,
+			Error:    row.Error,
+			Details:  row.Details,
+		})
+	}
+	return result
+}
@@ -0,0 +1,102 @@
+package controllers_test
+
+import (
+	"cli/controllers"
+	"cli/models"
+	test_utils "cli/test"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCSVImportMapping(t *testing.T) {
+	mapping, err := controllers.ParseCSVImportMapping("Name:name, Rack U:posU,Vendor:attributes.vendor")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"Name": "name", "Rack U": "posU", "Vendor": "attributes.vendor"}, mapping)
+
+	_, err = controllers.ParseCSVImportMapping("Name:name,Vendor")
+	assert.ErrorContains(t, err, `invalid mapping "Vendor", expected column:field`)
+}
+
+func TestImportCSVReturnsReport(t *testing.T) {
+	controller, mockAPI, _, _ := test_utils.NewControllerWithMocks(t)
+
+	file := "Name,Rack,U\nsrv01,BASIC.A.R1.A01,10\nsrv02,BASIC.A.R1.A01,\n"
+	filePath := filepath.Join(t.TempDir(), "servers.csv")
+	assert.Nil(t, os.WriteFile(filePath, []byte(file), 0644))
+
+	mockAPI.On(
+		"Request", http.MethodPost, "/api/import/csv",
+		map[string]any{
+			"file":         file,
+			"mapping":      map[string]string{"Name": "name", "U": "posU"},
+			"parentColumn": "Rack",
+			"category":     "device",
+		}, http.StatusCreated,
+	).Return(
+		&controllers.Response{
+			Status: http.StatusBadRequest,
+			Body: map[string]any{
+				"data": map[string]any{
+					"created": 0,
+					"errors":  1,
+					"rows": []any{
+						map[string]any{"row": 2, "id": "BASIC.A.R1.A01.srv01", "category": "device", "status": "valid"},
+						map[string]any{"row": 3, "id": "BASIC.A.R1.A01.srv02", "category": "device", "status": "error",
+							"error": "JSON body doesn't validate with the expected JSON schema"},
+					},
+				},
+			},
+		}, errors.New("[Response From API] 1 invalid rows, no object was created"),
+	).Once()
+
+	report, err := controller.ImportCSV(filePath, models.CSVImportOptions{
+		Mapping:      map[string]string{"Name": "name", "U": "posU"},
+		ParentColumn: "Rack",
+		Category:     "device",
+	})
+	assert.ErrorContains(t, err, "1 invalid rows, no object was created")
+	assert.Equal(t, &models.CSVImportReport{
+		Errors: 1,
+		Rows: []models.CSVImportRowReport{
+			{Row: 2, Id: "BASIC.A.R1.A01.srv01", Category: "device", Status: "valid"},
+			{Row: 3, Id: "BASIC.A.R1.A01.srv02", Category: "device", Status: "error",
+				Error: "JSON body doesn't validate with the expected JSON schema"},
+		},
+	}, report)
+}
+
+func TestImportCSVWithUnknownFileFails(t *testing.T) {
+	controller, _, _, _ := test_utils.NewControllerWithMocks(t)
+
+	_, err := controller.ImportCSV(filepath.Join(t.TempDir(), "unknown.csv"), models.CSVImportOptions{})
+	assert.NotNil(t, err)
+}
+
+func TestImportDeviceTypesReturnsSlugs(t *testing.T) {
+	controller, mockAPI, _, _ := test_utils.NewControllerWithMocks(t)
+
+	file := "manufacturer: Dell\nmodel: PowerEdge R640\nslug: dell-poweredge-r640\nu_height: 1\n"
+	filePath := filepath.Join(t.TempDir(), "PowerEdge-R640.yaml")
+	assert.Nil(t, os.WriteFile(filePath, []byte(file), 0644))
+
+	mockAPI.On(
+		"Request", http.MethodPost, "/api/obj_templates/import",
+		map[string]any{"file": file, "depthMm": 750.0}, http.StatusCreated,
+	).Return(
+		&controllers.Response{
+			Status: http.StatusCreated,
+			Body: map[string]any{
+				"data": []any{map[string]any{"slug": "dell-poweredge-r640", "category": "device"}},
+			},
+		}, nil,
+	).Once()
+
+	slugs, err := controller.ImportDeviceTypes(filePath, models.DeviceTypeImportOptions{DepthMm: 750})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"dell-poweredge-r640"}, slugs)
+}
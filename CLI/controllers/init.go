@@ -10,12 +10,13 @@ import (
 	"cli/utils"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
+
+	sdk "ogree-sdk"
 )
 
 func InitConfigFilePath(configPath string) {
@@ -42,8 +43,7 @@ func InitDebugLevel(verbose string) {
 }
 
 func PingAPI() bool {
-	_, err := Send("", State.APIURL, "", nil)
-	return err == nil
+	return sdk.NewHTTPTransport(State.APIURL).Ping()
 }
 
 // Intialise the ShellState
@@ -68,19 +68,11 @@ func InitState(conf *config.Config) error {
 	//Set Draw Threshold
 	SetDrawThreshold(conf.DrawLimit)
 
-	resp, err := API.Request("GET", "/api/version", nil, http.StatusOK)
+	version, err := sdk.NewClient(API).Version()
 	if err != nil {
 		return err
 	}
-	info, ok := resp.Body["data"].(map[string]any)
-	if !ok {
-		return fmt.Errorf("invalid response from API on GET /api/version")
-	}
-	if cInf, ok := info["Customer"]; ok {
-		if customer, ok := cInf.(string); ok {
-			State.Customer = customer
-		}
-	}
+	State.Customer = version.Customer
 	if State.Customer == "" {
 		if State.DebugLvl > NONE {
 			println("Tenant Information not found!")
@@ -237,16 +229,10 @@ func Login(user string, password string) (*User, string, error) {
 		}
 		password = string(passwordBytes)
 	}
-	data := map[string]any{"email": user, "password": password}
-	resp, err := API.Request("POST", "/api/login", data, http.StatusOK)
+	// the token is refreshed with the credentials when it expires
+	account, err := sdk.NewClient(API).Login(user, password)
 	if err != nil {
 		return nil, "", err
 	}
-	account, accountOk := (resp.Body["account"].(map[string]interface{}))
-	token, tokenOk := account["token"].(string)
-	userID, userIDOk := account["_id"].(string)
-	if !accountOk || !tokenOk || !userIDOk {
-		return nil, "", fmt.Errorf("invalid response from API")
-	}
-	return &User{user, userID}, token, nil
+	return &User{user, account.Id}, account.Token, nil
 }
@@ -3,12 +3,10 @@ package controllers
 import (
 	"cli/models"
 	"fmt"
-	"net/http"
-	"strings"
 )
 
 func (controller Controller) LinkObject(source string, destination string, attrs []string, values []any, slots []string) error {
-	sourceUrl, err := controller.ObjectUrl(source, 0)
+	collection, strayId, err := controller.objectInCollection(source)
 	if err != nil {
 		return err
 	}
@@ -16,30 +14,23 @@ func (controller Controller) LinkObject(source string, destination string, attrs
 	if err != nil {
 		return err
 	}
-	if !strings.HasPrefix(sourceUrl, "/api/stray_objects/") {
+	if collection != "stray_objects" {
 		return fmt.Errorf("only stray objects can be linked")
 	}
-	payload := map[string]any{"parentId": destPath.ObjectID}
 
 	if slots != nil {
 		if slots, err = models.CheckExpandStrVector(slots); err != nil {
 			return err
 		}
-		payload["slot"] = slots
 	}
 
-	_, err = controller.API.Request("PATCH", sourceUrl+"/link", payload, http.StatusOK)
-	if err != nil {
-		return err
-	}
-	return nil
+	return controller.Client().LinkObject(strayId, destPath.ObjectID, slots)
 }
 
 func (controller Controller) UnlinkObject(path string) error {
-	sourceUrl, err := controller.ObjectUrl(path, 0)
+	collection, id, err := controller.objectInCollection(path)
 	if err != nil {
 		return err
 	}
-	_, err = controller.API.Request("PATCH", sourceUrl+"/unlink", nil, http.StatusOK)
-	return err
+	return controller.Client().UnlinkObject(collection, id)
 }
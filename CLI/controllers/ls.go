@@ -5,11 +5,11 @@ import (
 	"cli/views"
 	"errors"
 	"fmt"
-	"net/http"
 	"strings"
 
 	"github.com/elliotchance/pie/v2"
 	"golang.org/x/exp/maps"
+	sdk "ogree-sdk"
 )
 
 var errLayerNotFound = errors.New("the layer used does not exist")
@@ -59,7 +59,7 @@ func (controller Controller) lsObjectsWithoutFilters(path string) ([]map[string]
 }
 
 func (controller Controller) lsObjectsWithFilters(path string, filters map[string]string, recursive *RecursiveParams) ([]map[string]any, error) {
-	url, err := controller.ObjectUrlGeneric(path+"/*", 0, filters, recursive)
+	query, _, err := controller.ObjectsQuery(path+"/*", 0, filters, recursive)
 	if err != nil {
 		if errors.Is(err, errLayerNotFound) || errors.Is(err, models.ErrMaxLessMin) {
 			return nil, err
@@ -68,31 +68,21 @@ func (controller Controller) lsObjectsWithFilters(path string, filters map[strin
 		return nil, fmt.Errorf("cannot use filters at this location")
 	}
 
-	var resp *Response
+	var objects []map[string]any
 	if complexFilter, ok := filters["filter"]; ok {
-		body := map[string]any{"filter": complexFilter}
-		resp, err = controller.API.Request(http.MethodPost, url, body, http.StatusOK)
+		objects, err = controller.Client().SearchObjects(query, complexFilter)
 	} else {
-		resp, err = controller.API.Request(http.MethodGet, url, map[string]any{}, http.StatusOK)
+		objects, err = controller.Client().GetObjects(query)
 	}
 
 	if err != nil {
 		return nil, err
 	}
 
-	objectsAny := resp.Body["data"].([]any)
-	objects := []map[string]any{}
-
-	for _, objAny := range objectsAny {
-		obj, ok := objAny.(map[string]any)
-		if !ok {
-			return nil, fmt.Errorf("invalid response from API on POST %s", url)
-		}
-		if models.IsVirtual(path) {
+	if models.IsVirtual(path) {
+		for _, obj := range objects {
 			adaptObjectNameForVirtual(path, obj)
 		}
-
-		objects = append(objects, obj)
 	}
 
 	return objects, nil
@@ -183,10 +173,10 @@ func addAutomaticLayers(rootNode *HierarchyNode) {
 }
 
 func LSEnterprise() error {
-	resp, err := API.Request("GET", "/api/stats", nil, http.StatusOK)
+	stats, err := sdk.NewClient(API).Stats()
 	if err != nil {
 		return err
 	}
-	views.DisplayJson("", resp.Body)
+	views.DisplayJson("", stats)
 	return nil
 }
@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"cli/models"
+	"strings"
+)
+
+// MoveObject moves the object of the source path under the destination,
+// with the given position attributes. If the current path is in the moved
+// subtree, it follows the object to its new location.
+func (controller Controller) MoveObject(source string, destination string, attrs []string, values []any, slots []string) error {
+	collection, id, err := controller.objectInCollection(source)
+	if err != nil {
+		return err
+	}
+	destPath, err := controller.SplitPath(destination)
+	if err != nil {
+		return err
+	}
+
+	position := map[string]any{}
+	for i, attr := range attrs {
+		position[attr] = values[i]
+	}
+	if slots != nil {
+		if slots, err = models.CheckExpandStrVector(slots); err != nil {
+			return err
+		}
+		position["slot"] = slots
+	}
+
+	moved, err := controller.Client().MoveObject(collection, id, destPath.ObjectID, position)
+	if err != nil {
+		return err
+	}
+
+	if State.CurrPath == source || strings.HasPrefix(State.CurrPath, source+"/") {
+		name, _ := moved["name"].(string)
+		State.CurrPath = strings.TrimSuffix(destination, "/") + "/" + name +
+			strings.TrimPrefix(State.CurrPath, source)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package controllers_test
+
+import (
+	"cli/controllers"
+	"cli/models"
+	test_utils "cli/test"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests MoveObject
+func TestMoveObjectSendsParentAndPosition(t *testing.T) {
+	controller, mockAPI, _ := layersSetup(t)
+
+	moved := test_utils.CopyMap(rack2)
+	moved["id"] = "BASIC.A.R2.B01"
+	moved["parentId"] = "BASIC.A.R2"
+	body := map[string]any{
+		"parentId":   "BASIC.A.R2",
+		"attributes": map[string]any{"rotation": []any{0, 0, 90}},
+	}
+	test_utils.MockMoveObject(mockAPI, "BASIC.A.R1.B01", body, moved)
+
+	err := controller.MoveObject(models.PhysicalPath+"BASIC/A/R1/B01", models.PhysicalPath+"BASIC/A/R2",
+		[]string{"rotation"}, []any{[]any{0, 0, 90}}, nil)
+	assert.Nil(t, err)
+}
+
+func TestMoveObjectWithInvalidSlots(t *testing.T) {
+	controller, _, _ := layersSetup(t)
+
+	err := controller.MoveObject(models.PhysicalPath+"BASIC/A/R1/A01/chT", models.PhysicalPath+"BASIC/A/R1/B01",
+		[]string{}, []any{}, []string{"slot01..slot03", "slot4"})
+	assert.NotNil(t, err)
+	assert.Equal(t, "invalid device syntax: .. can only be used in a single element vector", err.Error())
+}
+
+func TestMoveObjectUpdatesCurrentPath(t *testing.T) {
+	controller, mockAPI, _ := layersSetup(t)
+	oldCurrPath := controllers.State.CurrPath
+	defer func() { controllers.State.CurrPath = oldCurrPath }()
+	controllers.State.CurrPath = models.PhysicalPath + "BASIC/A/R1/A01/chT"
+
+	moved := test_utils.CopyMap(rack1)
+	moved["id"] = "BASIC.A.R2.A01"
+	moved["parentId"] = "BASIC.A.R2"
+	test_utils.MockMoveObject(mockAPI, "BASIC.A.R1.A01", map[string]any{"parentId": "BASIC.A.R2"}, moved)
+
+	err := controller.MoveObject(models.PhysicalPath+"BASIC/A/R1/A01", models.PhysicalPath+"BASIC/A/R2",
+		[]string{}, []any{}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, models.PhysicalPath+"BASIC/A/R2/A01/chT", controllers.State.CurrPath)
+}
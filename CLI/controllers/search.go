@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"cli/models"
+	"fmt"
+	"strings"
+)
+
+// Search sends a full text search to the API and returns the path of
+// the matching objects, ranked by relevance, with the fields that matched
+func (controller Controller) Search(query string) ([]models.SearchResult, error) {
+	found, err := controller.Client().Search(query)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []models.SearchResult{}
+	for _, item := range found {
+		result := models.SearchResult{Path: searchResultPath(item.Entity, item.Object)}
+		for _, highlight := range item.Highlights {
+			result.Highlights = append(result.Highlights,
+				fmt.Sprintf("%v: %v", highlight.Field, highlight.Value))
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func searchResultPath(entity string, object map[string]any) string {
+	id, hasId := object["id"].(string)
+	if !hasId {
+		id, _ = object["slug"].(string)
+	}
+
+	switch entity {
+	case "stray_object", models.EntityToString(models.STRAY_DEV):
+		return models.StrayPath + strings.ReplaceAll(id, ".", "/")
+	case models.EntityToString(models.OBJTMPL):
+		return models.ObjectTemplatesPath + id
+	case models.EntityToString(models.ROOMTMPL):
+		return models.RoomTemplatesPath + id
+	case models.EntityToString(models.BLDGTMPL):
+		return models.BuildingTemplatesPath + id
+	case models.EntityToString(models.TAG):
+		return models.TagsPath + id
+	case models.EntityToString(models.LAYER):
+		return models.LayersPath + id
+	case models.EntityToString(models.GROUP):
+		return models.GroupsPath + strings.ReplaceAll(id, ".", "/")
+	case models.EntityToString(models.VIRTUALOBJ):
+		return models.VirtualObjsPath + strings.ReplaceAll(id, ".", "/")
+	case models.EntityToString(models.DOMAIN):
+		return models.DomainsPath + strings.ReplaceAll(id, ".", "/")
+	default:
+		return models.PhysicalIDToPath(id)
+	}
+}
@@ -0,0 +1,49 @@
+package controllers_test
+
+import (
+	"cli/controllers"
+	"cli/models"
+	test_utils "cli/test"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchReturnsPathsAndHighlights(t *testing.T) {
+	controller, mockAPI, _, _ := test_utils.NewControllerWithMocks(t)
+
+	mockAPI.On(
+		"Request", http.MethodGet,
+		"/api/objects/search?q=dell+r740",
+		map[string]any(nil), http.StatusOK,
+	).Return(
+		&controllers.Response{
+			Body: map[string]any{
+				"data": []any{
+					map[string]any{
+						"entity": "device",
+						"score":  2.5,
+						"object": map[string]any{"id": "BASIC.A.R1.A01.chT"},
+						"highlights": []any{
+							map[string]any{"field": "attributes.vendor", "value": "Dell", "matches": []any{"dell"}},
+						},
+					},
+					map[string]any{
+						"entity":     "obj_template",
+						"score":      1.0,
+						"object":     map[string]any{"slug": "dell-r740"},
+						"highlights": []any{},
+					},
+				},
+			},
+		}, nil,
+	).Once()
+
+	results, err := controller.Search("dell r740")
+	assert.Nil(t, err)
+	assert.Equal(t, []models.SearchResult{
+		{Path: "/Physical/BASIC/A/R1/A01/chT", Highlights: []string{"attributes.vendor: Dell"}},
+		{Path: models.ObjectTemplatesPath + "dell-r740"},
+	}, results)
+}
@@ -7,12 +7,13 @@ import (
 	"cli/readline"
 	"cli/utils"
 	"fmt"
-	"net/http"
 	"os"
 	"os/exec"
 	"runtime"
 	"strings"
 	"time"
+
+	sdk "ogree-sdk"
 )
 
 var BuildTime string
@@ -76,7 +77,7 @@ func Help(entry string) {
 		"lsog", "grep", "for", "while", "if", "env",
 		"cmds", "var", "unset", "selection", commands.Connect3D, commands.Disconnect3D, "camera", "ui", "drawable",
 		"link", "unlink", "draw", "undraw",
-		"lsenterprise", commands.Cp:
+		"lsenterprise", commands.Cp, commands.Mv, commands.Search, commands.Import, commands.Plan, commands.Apply, commands.Check:
 		path = "./other/man/" + entry + ".txt"
 
 	case ">":
@@ -236,18 +237,14 @@ func LSOG() error {
 	fmt.Println("********************************************")
 
 	//Get API Information here
-	resp, err := API.Request("GET", "/api/version", nil, http.StatusOK)
+	apiInfo, err := sdk.NewClient(API).Version()
 	if err != nil {
 		return err
 	}
-	apiInfo, ok := resp.Body["data"].(map[string]any)
-	if !ok {
-		return fmt.Errorf("invalid response from API on GET /api/version")
-	}
-	fmt.Println("BUILD DATE:", apiInfo["BuildDate"])
-	fmt.Println("BUILD TREE:", apiInfo["BuildTree"])
-	fmt.Println("BUILD HASH:", apiInfo["BuildHash"])
-	fmt.Println("COMMIT DATE: ", apiInfo["CommitDate"])
-	fmt.Println("CUSTOMER: ", apiInfo["Customer"])
+	fmt.Println("BUILD DATE:", apiInfo.BuildDate)
+	fmt.Println("BUILD TREE:", apiInfo.BuildTree)
+	fmt.Println("BUILD HASH:", apiInfo.BuildHash)
+	fmt.Println("COMMIT DATE: ", apiInfo.CommitDate)
+	fmt.Println("CUSTOMER: ", apiInfo.Customer)
 	return nil
 }
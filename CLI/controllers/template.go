@@ -4,7 +4,6 @@ import (
 	"cli/models"
 	"errors"
 	"fmt"
-	"net/http"
 )
 
 // GetTemplate gets a template for "entity" with "name".
@@ -86,21 +85,21 @@ func (controller Controller) ApplyTemplate(attr, data map[string]interface{}, en
 }
 
 func (controller Controller) LoadTemplate(data map[string]interface{}) error {
-	var URL string
+	var entity string
 	if cat := data["category"]; cat == "room" {
 		//Room template
-		URL = "/api/room_templates"
+		entity = "room_template"
 	} else if cat == "bldg" || cat == "building" {
 		//Bldg template
-		URL = "/api/bldg_templates"
+		entity = "bldg_template"
 	} else if cat == "rack" || cat == "device" || cat == "generic" {
 		// Obj template
-		URL = "/api/obj_templates"
+		entity = "obj_template"
 	} else {
 		return fmt.Errorf("this template does not have a valid category. Please add a category attribute with a value of building, room, rack, device or generic")
 	}
 
-	_, err := controller.API.Request(http.MethodPost, URL, data, http.StatusCreated)
+	_, err := controller.Client().CreateObject(entity, data)
 
 	return err
 }
@@ -5,13 +5,14 @@ import (
 	"cli/utils"
 	"errors"
 	"fmt"
-	"net/http"
+	"net/url"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/elliotchance/pie/v2"
 	"github.com/mitchellh/mapstructure"
+	sdk "ogree-sdk"
 )
 
 const updatedThreshold time.Duration = 10 * time.Minute
@@ -281,11 +282,11 @@ func BuildBaseTree(controller Controller) *HierarchyNode {
 	root.FillFn = FillChildren
 
 	physical := NewNode("Physical")
-	physical.FillFn = FillUrlTreeFnAndFillChildren[map[string]any]("/api/sites", controller.FillObjectTree, false, controller.API)
+	physical.FillFn = FillUrlTreeFnAndFillChildren[map[string]any]("sites", nil, controller.FillObjectTree, false, controller.API)
 	root.AddChild(physical)
 
 	stray := NewNode("Stray")
-	stray.FillFn = FillUrlTreeFn[map[string]any]("/api/stray_objects", controller.FillObjectTree, false, controller.API)
+	stray.FillFn = FillUrlTreeFn[map[string]any]("stray_objects", nil, controller.FillObjectTree, false, controller.API)
 	physical.AddChild(stray)
 
 	logical := NewNode("Logical")
@@ -293,31 +294,31 @@ func BuildBaseTree(controller Controller) *HierarchyNode {
 	root.AddChild(logical)
 
 	objectTemplates := NewNode("ObjectTemplates")
-	objectTemplates.FillFn = FillUrlTreeFn[map[string]any]("/api/obj_templates", nil, false, controller.API)
+	objectTemplates.FillFn = FillUrlTreeFn[map[string]any]("obj_templates", nil, nil, false, controller.API)
 	logical.AddChild(objectTemplates)
 
 	roomTemplates := NewNode("RoomTemplates")
-	roomTemplates.FillFn = FillUrlTreeFn[map[string]any]("/api/room_templates", nil, false, controller.API)
+	roomTemplates.FillFn = FillUrlTreeFn[map[string]any]("room_templates", nil, nil, false, controller.API)
 	logical.AddChild(roomTemplates)
 
 	bldgTemplates := NewNode("BldgTemplates")
-	bldgTemplates.FillFn = FillUrlTreeFn[map[string]any]("/api/bldg_templates", nil, false, controller.API)
+	bldgTemplates.FillFn = FillUrlTreeFn[map[string]any]("bldg_templates", nil, nil, false, controller.API)
 	logical.AddChild(bldgTemplates)
 
 	layers := NewCachedNode("Layers")
-	layers.FillFn = FillUrlTreeFn[models.UserDefinedLayer](LayersURL, nil, false, controller.API)
+	layers.FillFn = FillUrlTreeFn[models.UserDefinedLayer]("layers", nil, nil, false, controller.API)
 	logical.AddChild(layers)
 
 	tags := NewNode("Tags")
-	tags.FillFn = FillUrlTreeFn[map[string]any]("/api/tags", nil, false, controller.API)
+	tags.FillFn = FillUrlTreeFn[map[string]any]("tags", nil, nil, false, controller.API)
 	logical.AddChild(tags)
 
 	groups := NewNode("Groups")
-	groups.FillFn = FillUrlTreeFn[map[string]any]("/api/groups", nil, true, controller.API)
+	groups.FillFn = FillUrlTreeFn[map[string]any]("groups", nil, nil, true, controller.API)
 	logical.AddChild(groups)
 
 	virtual := NewNode(models.VirtualObjsNode)
-	virtual.FillFn = FillUrlTreeFn[map[string]any]("/api/virtual_objs?limit=1", controller.FillObjectTree, true, controller.API)
+	virtual.FillFn = FillUrlTreeFn[map[string]any]("virtual_objs", url.Values{"limit": {"1"}}, controller.FillObjectTree, true, controller.API)
 	logical.AddChild(virtual)
 
 	organisation := NewNode("Organisation")
@@ -325,7 +326,7 @@ func BuildBaseTree(controller Controller) *HierarchyNode {
 	root.AddChild(organisation)
 
 	domain := NewNode("Domain")
-	domain.FillFn = FillUrlTreeFn[map[string]any]("/api/domains", controller.FillObjectTree, false, controller.API)
+	domain.FillFn = FillUrlTreeFn[map[string]any]("domains", nil, controller.FillObjectTree, false, controller.API)
 	organisation.AddChild(domain)
 
 	organisation.AddChild(NewNode("Enterprise"))
@@ -361,30 +362,19 @@ func (controller Controller) FillObjectTree(n *HierarchyNode, path string, depth
 	return n.FillWithMap(obj)
 }
 
-func FillUrlTree[T any](n *HierarchyNode, api APIPort, path string, depth int, url string, followFillFn FillFunc, fullId bool) error {
-	resp, err := api.Request(http.MethodGet, url, nil, http.StatusOK)
+func FillUrlTree[T any](n *HierarchyNode, api APIPort, path string, depth int, collection string, query url.Values, followFillFn FillFunc, fullId bool) error {
+	objects, err := sdk.NewClient(api).ListObjects(collection, query)
 	if err != nil {
 		return err
 	}
 
-	invalidRespErr := fmt.Errorf("invalid response from API on GET %s", url)
-	objects, hasObjects := resp.Body["data"].([]any)
-	if !hasObjects {
-		return invalidRespErr
-	}
-
 	if _, ok := n.Children["Stray"]; ok && n.Name == "Physical" {
 		n.Children = map[string]*HierarchyNode{"Stray": n.Children["Stray"]}
 	} else {
 		n.Children = map[string]*HierarchyNode{}
 	}
 
-	for _, objAny := range objects {
-		obj, isMap := objAny.(map[string]any)
-		if !isMap {
-			return invalidRespErr
-		}
-
+	for _, obj := range objects {
 		var objName string
 		if fullId {
 			objName = obj["id"].(string)
@@ -415,9 +405,9 @@ func FillUrlTree[T any](n *HierarchyNode, api APIPort, path string, depth int, u
 	return nil
 }
 
-func FillUrlTreeFnAndFillChildren[T any](url string, followFn FillFunc, fullId bool, api APIPort) FillFunc {
+func FillUrlTreeFnAndFillChildren[T any](collection string, query url.Values, followFn FillFunc, fullId bool, api APIPort) FillFunc {
 	return func(n *HierarchyNode, path string, depth int) error {
-		err := FillUrlTree[T](n, api, path, depth, url, followFn, fullId)
+		err := FillUrlTree[T](n, api, path, depth, collection, query, followFn, fullId)
 		if err != nil {
 			return err
 		}
@@ -426,9 +416,9 @@ func FillUrlTreeFnAndFillChildren[T any](url string, followFn FillFunc, fullId b
 	}
 }
 
-func FillUrlTreeFn[T any](url string, followFn FillFunc, fullId bool, api APIPort) FillFunc {
+func FillUrlTreeFn[T any](collection string, query url.Values, followFn FillFunc, fullId bool, api APIPort) FillFunc {
 	return func(n *HierarchyNode, path string, depth int) error {
-		return FillUrlTree[T](n, api, path, depth, url, followFn, fullId)
+		return FillUrlTree[T](n, api, path, depth, collection, query, followFn, fullId)
 	}
 }
 
@@ -4,7 +4,6 @@ import (
 	"cli/models"
 	"cli/utils"
 	"fmt"
-	"net/http"
 	"regexp"
 	"strings"
 )
@@ -67,29 +66,25 @@ func (controller Controller) PatchObj(pathStr string, data map[string]any, withR
 		models.ComputeSizeUAndHeight(obj, data)
 	}
 
-	url, err := controller.ObjectUrl(pathStr, 0)
+	collection, id, err := controller.objectInCollection(pathStr)
 	if err != nil {
 		return nil, err
 	}
-	if withRecursive {
-		url = url + "?recursive=true"
-	}
 
-	resp, err := controller.API.Request(http.MethodPatch, url, data, http.StatusOK)
+	updated, err := controller.Client().UpdateObject(collection, id, data, withRecursive)
 	if err != nil {
 		return nil, err
 	}
 
 	if models.IsLayer(pathStr) {
 		// For layers, update the object to the hierarchy in order to be cached
-		data := resp.Body["data"].(map[string]any)
-		_, err = State.Hierarchy.AddObjectInPath(data, pathStr)
+		_, err = State.Hierarchy.AddObjectInPath(updated, pathStr)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	return resp.Body, nil
+	return map[string]any{"data": updated}, nil
 }
 
 // [obj]:[attributeName]=[values]
@@ -4,7 +4,8 @@ import (
 	"cli/readline"
 	"fmt"
 	"math/rand"
-	"net/http"
+
+	sdk "ogree-sdk"
 )
 
 type User struct {
@@ -14,63 +15,35 @@ type User struct {
 
 func (controller Controller) CreateUser(email string, role string, domain string) error {
 	password := randPassword(14)
-	response, err := controller.API.Request(
-		"POST",
-		"/api/users",
-		map[string]any{
-			"email":    email,
-			"password": password,
-			"roles": map[string]any{
-				domain: role,
-			},
-		},
-		http.StatusCreated,
-	)
+	message, err := controller.Client().CreateUser(email, password, map[string]sdk.Role{domain: sdk.Role(role)})
 	if err != nil {
 		return err
 	}
-	println(response.message)
+	println(message)
 	println("password:" + password)
 	return nil
 }
 
 func (controller Controller) AddRole(email string, role string, domain string) error {
-	response, err := controller.API.Request("GET", "/api/users", nil, http.StatusOK)
+	users, err := controller.Client().GetUsers()
 	if err != nil {
 		return err
 	}
-	userList, userListOk := response.Body["data"].([]any)
-	if !userListOk {
-		return fmt.Errorf("response contains no user list")
-	}
 	userID := ""
-	for _, user := range userList {
-		userMap, ok := user.(map[string]any)
-		if !ok {
-			continue
-		}
-		userEmail, emailOk := userMap["email"].(string)
-		id, idOk := userMap["_id"].(string)
-		if emailOk && idOk && userEmail == email {
-			userID = id
+	for _, user := range users {
+		if user.Id != "" && user.Email == email {
+			userID = user.Id
 			break
 		}
 	}
 	if userID == "" {
 		return fmt.Errorf("user not found")
 	}
-	response, err = controller.API.Request("PATCH", fmt.Sprintf("/api/users/%s", userID),
-		map[string]any{
-			"roles": map[string]any{
-				domain: role,
-			},
-		},
-		http.StatusOK,
-	)
+	message, err := controller.Client().UpdateUserRoles(userID, map[string]sdk.Role{domain: sdk.Role(role)})
 	if err != nil {
 		return err
 	}
-	println(response.message)
+	println(message)
 	return nil
 }
 
@@ -83,17 +56,12 @@ func ChangePassword() error {
 	if err != nil {
 		return err
 	}
-	response, err := API.Request("POST", "/api/users/password/change",
-		map[string]any{
-			"currentPassword": string(currentPassword),
-			"newPassword":     string(newPassword),
-		},
-		http.StatusOK,
-	)
+	// the next requests are sent with the new token
+	message, err := sdk.NewClient(API).ChangePassword(string(currentPassword), string(newPassword))
 	if err != nil {
 		return err
 	}
-	println(response.message)
+	println(message)
 	return nil
 }
 
@@ -15,11 +15,14 @@ require (
 	golang.org/x/exp v0.0.0-20221217163422-3c43f8badb15
 	golang.org/x/sys v0.20.0
 	golang.org/x/text v0.16.0
+	gopkg.in/yaml.v3 v3.0.1
+	ogree-sdk v0.0.0
 )
 
 require (
 	github.com/chzyer/logex v1.2.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/stretchr/objx v0.5.2 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace ogree-sdk => ../SDK
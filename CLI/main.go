@@ -23,7 +23,7 @@ func main() {
 
 	if !c.PingAPI() {
 		println("Cannot reach API at", c.State.APIURL)
-		return
+		os.Exit(c.ExitFailure)
 	}
 
 	var err error
@@ -31,7 +31,7 @@ func main() {
 	user, apiKey, err := c.Login(conf.User, conf.Password)
 	if err != nil {
 		println(err.Error())
-		return
+		os.Exit(c.ExitFailure)
 	} else {
 		fmt.Printf("Successfully connected to %s\n", c.State.APIURL)
 	}
@@ -41,13 +41,22 @@ func main() {
 	err = c.InitState(conf)
 	if err != nil {
 		println(err.Error())
-		return
+		os.Exit(c.ExitFailure)
 	}
 
 	err = parser.InitVars(conf.Variables)
 	if err != nil {
 		println("Error while initializing variables :", err.Error())
-		return
+		os.Exit(c.ExitFailure)
+	}
+
+	// Run plan or apply on a manifest and exit, for pipelines
+	if conf.Command != "" {
+		if conf.Manifest == "" {
+			println("Usage:", conf.Command, "[--prune] manifest")
+			os.Exit(c.ExitFailure)
+		}
+		os.Exit(c.C.RunManifestCommand(conf.Command, conf.Manifest, conf.Prune))
 	}
 
 	userShort := strings.Split(c.State.User.Email, "@")[0]
@@ -0,0 +1,33 @@
+package models
+
+// CSVImportOptions is the way to convert the rows of a CSV file to objects
+type CSVImportOptions struct {
+	Mapping        map[string]string // column: field or attribute of the object
+	ParentColumn   string
+	TemplateColumn string
+	Category       string // of all the objects, if no column is mapped to category
+	Separator      string
+}
+
+type CSVImportRowReport struct {
+	Row      int
+	Id       string
+	Category string
+	Status   string
+	Error    string
+	Details  []string
+}
+
+// CSVImportReport is the result of the import of each row of a CSV file
+type CSVImportReport struct {
+	Created int
+	Errors  int
+	Rows    []CSVImportRowReport
+}
+
+// DeviceTypeImportOptions are the sizes that the device-type library does not give
+type DeviceTypeImportOptions struct {
+	WidthMm   float64 // 0 for the default of the API (19")
+	DepthMm   float64 // of the full depth devices, 0 for the default of the API
+	ImagesUrl string  // base URL of the elevation images of the library
+}
@@ -0,0 +1,11 @@
+package models
+
+// IntegrityIssue: the object at path references, in field, something
+// that does not exist
+type IntegrityIssue struct {
+	Path      string
+	Type      string
+	Field     string
+	Reference string
+	Fixable   bool
+}
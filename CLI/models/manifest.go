@@ -0,0 +1,55 @@
+package models
+
+// Categories that a manifest can describe
+var ManifestCategories = []string{
+	EntityToString(TAG), EntityToString(LAYER),
+	EntityToString(SITE), EntityToString(BLDG), EntityToString(ROOM),
+	EntityToString(RACK), EntityToString(DEVICE), EntityToString(CORRIDOR),
+	EntityToString(GENERIC), EntityToString(GROUP),
+}
+
+// Manifest is the desired state of a subtree of the model: its objects,
+// with their fields as sent to the API. Physical objects are identified by
+// their id (name and parentId are deduced from it), tags and layers by their slug.
+type Manifest struct {
+	Objects []map[string]any `json:"objects" yaml:"objects"`
+}
+
+const (
+	PlanCreate = "create"
+	PlanUpdate = "update"
+	PlanDelete = "delete"
+)
+
+type PlanFieldChange struct {
+	Field string
+	Old   any
+	New   any
+}
+
+// PlanChange is a request to send to the API to reach the desired state
+type PlanChange struct {
+	Action   string
+	Category string
+	Id       string         // id or slug
+	Data     map[string]any // body of the creation or update
+	Changes  []PlanFieldChange
+}
+
+// Plan is the difference between a manifest and the objects of the API,
+// as the changes to apply, in order: parents are created before their
+// children and only the top-most objects to delete are deleted
+type Plan struct {
+	Changes   []PlanChange
+	Unchanged int
+}
+
+func (plan *Plan) Count(action string) int {
+	count := 0
+	for _, change := range plan.Changes {
+		if change.Action == action {
+			count++
+		}
+	}
+	return count
+}
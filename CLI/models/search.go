@@ -0,0 +1,7 @@
+package models
+
+// SearchResult is an object found by a full text search
+type SearchResult struct {
+	Path       string
+	Highlights []string
+}
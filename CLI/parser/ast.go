@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -1467,6 +1468,8 @@ func (a *assignNode) execute() (interface{}, error) {
 type cpNode struct {
 	source node
 	dest   node
+	attrs  []string
+	values []node
 }
 
 func (n *cpNode) execute() (interface{}, error) {
@@ -1480,8 +1483,251 @@ func (n *cpNode) execute() (interface{}, error) {
 		return nil, err
 	}
 
+	values := []any{}
+	for _, valueNode := range n.values {
+		var val any
+		if num, err := nodeToNum(valueNode, "copy attribute"); err == nil {
+			val = num
+		} else {
+			val, err = valueNode.execute()
+			if err != nil {
+				return nil, err
+			}
+		}
+		values = append(values, val)
+	}
+
 	if cmd.State.DryRun {
 		return nil, nil
 	}
-	return nil, cmd.C.Cp(source, dest)
+	return nil, cmd.C.Cp(source, dest, n.attrs, values)
+}
+
+type mvNode struct {
+	source      node
+	destination node
+	attrs       []string
+	values      []node
+	slots       []node
+}
+
+func (n *mvNode) execute() (interface{}, error) {
+	source, err := nodeToString(n.source, "source object path")
+	if err != nil {
+		return nil, err
+	}
+	dest, err := nodeToString(n.destination, "destination object path")
+	if err != nil {
+		return nil, err
+	}
+
+	values := []any{}
+	for _, valueNode := range n.values {
+		var val any
+		if num, err := nodeToNum(valueNode, "position attribute"); err == nil {
+			val = num
+		} else {
+			val, err = valueNode.execute()
+			if err != nil {
+				return nil, err
+			}
+		}
+		values = append(values, val)
+	}
+
+	var slots []string
+	if n.slots != nil {
+		slots = []string{}
+		for _, node := range n.slots {
+			str, err := nodeToString(node, "slots")
+			if err != nil {
+				return nil, err
+			}
+			slots = append(slots, str)
+		}
+	}
+
+	if cmd.State.DryRun {
+		return nil, nil
+	}
+	return nil, cmd.C.MoveObject(source, dest, n.attrs, values, slots)
+}
+
+type searchNode struct {
+	query node
+}
+
+func (n *searchNode) execute() (interface{}, error) {
+	query, err := nodeToString(n.query, "query")
+	if err != nil {
+		return nil, err
+	}
+
+	if cmd.State.DryRun {
+		return nil, nil
+	}
+
+	results, err := cmd.C.Search(query)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Print(views.SearchResults(results))
+
+	return nil, nil
+}
+
+type importCSVNode struct {
+	file node
+	args map[string]string
+}
+
+func (n *importCSVNode) execute() (interface{}, error) {
+	file, err := nodeToString(n.file, "file path")
+	if err != nil {
+		return nil, err
+	}
+
+	mapping, err := cmd.ParseCSVImportMapping(n.args["m"])
+	if err != nil {
+		return nil, err
+	}
+
+	if cmd.State.DryRun {
+		return nil, nil
+	}
+
+	report, err := cmd.C.ImportCSV(file, models.CSVImportOptions{
+		Mapping:        mapping,
+		ParentColumn:   n.args["p"],
+		TemplateColumn: n.args["t"],
+		Category:       n.args["c"],
+		Separator:      n.args["s"],
+	})
+	if report != nil {
+		fmt.Print(views.CSVImportReport(report))
+	}
+
+	return nil, err
+}
+
+type importDeviceTypesNode struct {
+	file node
+	args map[string]string
+}
+
+func (n *importDeviceTypesNode) execute() (interface{}, error) {
+	file, err := nodeToString(n.file, "file path")
+	if err != nil {
+		return nil, err
+	}
+
+	options := models.DeviceTypeImportOptions{ImagesUrl: n.args["u"]}
+	for arg, size := range map[string]*float64{"w": &options.WidthMm, "d": &options.DepthMm} {
+		if n.args[arg] == "" {
+			continue
+		}
+		*size, err = strconv.ParseFloat(n.args[arg], 64)
+		if err != nil || *size <= 0 {
+			return nil, fmt.Errorf("-%s expects a positive number of millimeters", arg)
+		}
+	}
+
+	if cmd.State.DryRun {
+		return nil, nil
+	}
+
+	slugs, err := cmd.C.ImportDeviceTypes(file, options)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Print(views.DeviceTypesImport(slugs))
+	return nil, nil
+}
+
+type planNode struct {
+	file  node
+	prune bool
+}
+
+func (n *planNode) execute() (interface{}, error) {
+	file, err := nodeToString(n.file, "manifest path")
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := cmd.ReadManifest(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if cmd.State.DryRun {
+		return nil, nil
+	}
+
+	plan, err := cmd.C.Plan(manifest, n.prune)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Print(views.Plan(plan))
+	return nil, nil
+}
+
+type applyNode struct {
+	file  node
+	prune bool
+}
+
+func (n *applyNode) execute() (interface{}, error) {
+	file, err := nodeToString(n.file, "manifest path")
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := cmd.ReadManifest(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if cmd.State.DryRun {
+		return nil, nil
+	}
+
+	plan, err := cmd.C.Plan(manifest, n.prune)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Print(views.Plan(plan))
+	applied, err := cmd.C.Apply(plan)
+	fmt.Print(views.Applied(applied, len(plan.Changes)))
+	return nil, err
+}
+
+type checkNode struct {
+	fix bool
+}
+
+func (n *checkNode) execute() (interface{}, error) {
+	if cmd.State.DryRun {
+		return nil, nil
+	}
+
+	if n.fix {
+		fixed, remaining, err := cmd.C.FixIntegrity()
+		if err != nil {
+			return nil, err
+		}
+		fmt.Print(views.IntegrityFix(fixed, remaining))
+		return nil, nil
+	}
+
+	issues, err := cmd.C.CheckIntegrity()
+	if err != nil {
+		return nil, err
+	}
+	fmt.Print(views.IntegrityIssues(issues))
+	return nil, nil
 }
@@ -360,3 +360,32 @@ func (p *parser) parsePathToken() token {
 	defer un(trace(p, ""))
 	return p.lexPath()
 }
+
+// lexPathPattern: like lexPath, but a name range such as R{01..10} is part of the path
+func (p *parser) lexPathPattern() token {
+	c := p.next()
+	if c == '$' {
+		return p.lexDeref()
+	}
+	inRange := false
+	for {
+		if c == eof || (!inRange && strings.Contains(" @;,}):$", string(c))) {
+			p.backward(1)
+			if p.cursor == p.startCursor {
+				return p.emit(tokEOF, nil)
+			}
+			return p.emit(tokText, nil)
+		}
+		if c == '{' {
+			inRange = true
+		} else if c == '}' {
+			inRange = false
+		}
+		c = p.next()
+	}
+}
+
+func (p *parser) parsePathPatternToken() token {
+	defer un(trace(p, ""))
+	return p.lexPathPattern()
+}
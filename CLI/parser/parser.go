@@ -30,7 +30,7 @@ var manCommands = []string{
 	"tree", "lsog", "env", "cd", "pwd", "clear", "ls", "exit", "len", "man",
 	"print", "printf", "unset", "selection",
 	"for", "while", "if",
-	commands.Cp,
+	commands.Cp, commands.Mv, commands.Search, commands.Import, commands.Plan, commands.Apply, commands.Check,
 }
 
 type traceItem struct {
@@ -1332,9 +1332,100 @@ func (p *parser) parseCp() node {
 
 	p.skipWhiteSpaces()
 
-	dest := p.parseString("dest")
+	var dest node
+	if p.parseExact("\"") {
+		p.backward(1)
+		dest = p.parseString("dest")
+	} else {
+		dest = p.parseText(p.parsePathPatternToken, true, false)
+	}
+
+	attrs := []string{}
+	values := []node{}
+	for p.parseExact("@") {
+		p.skipWhiteSpaces()
+		attr := p.parseComplexWord("attribute")
+		p.skipWhiteSpaces()
+		p.expect("=")
+		p.skipWhiteSpaces()
+		values = append(values, p.parseValue())
+		attrs = append(attrs, attr)
+	}
+
+	return &cpNode{source: source, dest: dest, attrs: attrs, values: values}
+}
+
+func (p *parser) parseMv() node {
+	defer un(trace(p, "mv"))
+	source := p.parsePath("source")
+	p.skipWhiteSpaces()
+	dest := p.parsePath("destination")
+	values := []node{}
+	attrs := []string{}
+	var slots []node
+	for p.parseExact("@") {
+		p.skipWhiteSpaces()
+		attr := p.parseComplexWord("attribute")
+		p.skipWhiteSpaces()
+		p.expect("=")
+		p.skipWhiteSpaces()
+		if attr == "slot" {
+			slots = p.parseStringOrVecStr("slot")
+		} else {
+			values = append(values, p.parseValue())
+			attrs = append(attrs, attr)
+		}
+	}
+	return &mvNode{source, dest, attrs, values, slots}
+}
+
+func (p *parser) parseSearch() node {
+	defer un(trace(p, "search"))
+	return &searchNode{query: p.parseString("query")}
+}
+
+func (p *parser) parseImport() node {
+	defer un(trace(p, "import"))
+	if p.parseExact("devicetype") {
+		args := p.parseArgs([]string{"w", "d", "u"}, []string{}, "import devicetype")
+		file := p.parseString("file path")
+		return &importDeviceTypesNode{file: file, args: args}
+	}
+	if !p.parseExact("csv") {
+		p.error("csv or devicetype expected")
+	}
+	args := p.parseArgs([]string{"p", "m", "t", "c", "s"}, []string{}, "import csv")
+	if args["p"] == "" || args["m"] == "" {
+		p.error("-p parentColumn and -m mapping expected")
+	}
+	file := p.parseString("file path")
+	return &importCSVNode{file: file, args: args}
+}
+
+func (p *parser) parsePlan() node {
+	defer un(trace(p, "plan"))
+	args := p.parseArgs([]string{}, []string{"prune"}, "plan")
+	file := p.parseString("manifest path")
+	return &planNode{file: file, prune: hasPruneFlag(args)}
+}
+
+func (p *parser) parseApply() node {
+	defer un(trace(p, "apply"))
+	args := p.parseArgs([]string{}, []string{"prune"}, "apply")
+	file := p.parseString("manifest path")
+	return &applyNode{file: file, prune: hasPruneFlag(args)}
+}
+
+func (p *parser) parseCheck() node {
+	defer un(trace(p, "check"))
+	args := p.parseArgs([]string{}, []string{"fix"}, "check")
+	_, fix := args["fix"]
+	return &checkNode{fix: fix}
+}
 
-	return &cpNode{source: source, dest: dest}
+func hasPruneFlag(args map[string]string) bool {
+	_, prune := args["prune"]
+	return prune
 }
 
 func (p *parser) parseCommandKeyWord() string {
@@ -1438,6 +1529,12 @@ func newParser(buffer string) *parser {
 		"if":               p.parseIf,
 		"alias":            p.parseAlias,
 		commands.Cp:        p.parseCp,
+		commands.Mv:        p.parseMv,
+		commands.Search:    p.parseSearch,
+		commands.Import:    p.parseImport,
+		commands.Plan:      p.parsePlan,
+		commands.Apply:     p.parseApply,
+		commands.Check:     p.parseCheck,
 	}
 	p.createObjDispatch = map[string]parseCommandFunc{
 		"domain":   p.parseCreateDomain,
@@ -612,6 +612,71 @@ func TestParseCp(t *testing.T) {
 	assert.Equal(t, destination, parsedNode.dest.(*valueNode).val)
 }
 
+func TestParseCpWithPatternAndAttributes(t *testing.T) {
+	source := models.PhysicalPath + "site/building/room1/rack"
+	destination := models.PhysicalPath + "site/building/room2/R{01..10}"
+	p := newParser(source + " " + destination + "@offset=[0,1,0]@posU=3")
+	parsedNode := p.parseCp().(*cpNode)
+	assert.Equal(t, source, parsedNode.source.(*pathNode).path.(*valueNode).val)
+	assert.Equal(t, destination, parsedNode.dest.(*valueNode).val)
+	assert.Equal(t, []string{"offset", "posU"}, parsedNode.attrs)
+	assert.Len(t, parsedNode.values, 2)
+}
+
+func TestParseMv(t *testing.T) {
+	sourcePath := models.PhysicalPath + "site/building/room1/rack"
+	destinationPath := models.PhysicalPath + "site/building/room2"
+	p := newParser(sourcePath + " " + destinationPath)
+	parsedNode := p.parseMv().(*mvNode)
+	assert.Equal(t, sourcePath, parsedNode.source.(*pathNode).path.(*valueNode).val)
+	assert.Equal(t, destinationPath, parsedNode.destination.(*pathNode).path.(*valueNode).val)
+	assert.Len(t, parsedNode.attrs, 0)
+
+	p = newParser(sourcePath + " " + destinationPath + "@slot=[slot1,slot2]@posU=3")
+	parsedNode = p.parseMv().(*mvNode)
+	assert.Equal(t, destinationPath, parsedNode.destination.(*pathNode).path.(*valueNode).val)
+	assert.Equal(t, []string{"posU"}, parsedNode.attrs)
+	assert.Equal(t, "3", parsedNode.values[0].(*valueNode).val)
+	assert.Len(t, parsedNode.slots, 2)
+	assert.Equal(t, "slot2", parsedNode.slots[1].(*valueNode).val)
+}
+
+func TestParseSearch(t *testing.T) {
+	p := newParser("dell")
+	parsedNode := p.parseSearch().(*searchNode)
+	assert.Equal(t, "dell", parsedNode.query.(*valueNode).val)
+
+	p = newParser(`"dell r740"`)
+	parsedNode = p.parseSearch().(*searchNode)
+	query, err := nodeToString(parsedNode.query, "query")
+	assert.Nil(t, err)
+	assert.Equal(t, "dell r740", query)
+}
+
+func TestParseImportCSV(t *testing.T) {
+	p := newParser(`csv -p Rack -m "Name:name,U:posU" -c device ./servers.csv`)
+	parsedNode := p.parseImport().(*importCSVNode)
+	assert.Equal(t, map[string]string{"p": "Rack", "m": "Name:name,U:posU", "c": "device"}, parsedNode.args)
+	assert.Equal(t, "./servers.csv", parsedNode.file.(*valueNode).val)
+}
+
+func TestParseImportCSVWithoutMappingFails(t *testing.T) {
+	_, err := Parse("import csv -p Rack ./servers.csv")
+	assert.NotNil(t, err)
+}
+
+func TestParseImportDeviceTypes(t *testing.T) {
+	p := newParser(`devicetype -d 750 ./PowerEdge-R640.yaml`)
+	parsedNode := p.parseImport().(*importDeviceTypesNode)
+	assert.Equal(t, map[string]string{"d": "750"}, parsedNode.args)
+	assert.Equal(t, "./PowerEdge-R640.yaml", parsedNode.file.(*valueNode).val)
+}
+
+func TestParseImportUnknownFormatFails(t *testing.T) {
+	_, err := Parse("import xml ./servers.xml")
+	assert.NotNil(t, err)
+}
+
 func TestParseExprList(t *testing.T) {
 	p := newParser("-1")
 	parsedNode := p.parseUnaryExpr().(*negateNode)
@@ -693,3 +758,30 @@ func TestParseConnect3D(t *testing.T) {
 	parsedNode := p.parseConnect3D().(*connect3DNode)
 	assert.Equal(t, url, parsedNode.url)
 }
+
+func TestParsePlan(t *testing.T) {
+	p := newParser(`./paris.yaml`)
+	parsedNode := p.parsePlan().(*planNode)
+	assert.False(t, parsedNode.prune)
+	assert.Equal(t, "./paris.yaml", parsedNode.file.(*valueNode).val)
+}
+
+func TestParseCheck(t *testing.T) {
+	p := newParser(``)
+	assert.False(t, p.parseCheck().(*checkNode).fix)
+
+	p = newParser(`-fix`)
+	assert.True(t, p.parseCheck().(*checkNode).fix)
+}
+
+func TestParseApplyWithPrune(t *testing.T) {
+	p := newParser(`-prune ./paris.yaml`)
+	parsedNode := p.parseApply().(*applyNode)
+	assert.True(t, parsedNode.prune)
+	assert.Equal(t, "./paris.yaml", parsedNode.file.(*valueNode).val)
+}
+
+func TestParseApplyUnknownFlagFails(t *testing.T) {
+	_, err := Parse("apply -force ./paris.yaml")
+	assert.NotNil(t, err)
+}
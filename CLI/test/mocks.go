@@ -93,6 +93,14 @@ func MockUpdateObject(mockAPI *mocks.APIPort, dataUpdate map[string]any, dataUpd
 	mockResponse(mockAPI, http.MethodPatch, mock.Anything, dataUpdate, http.StatusOK, dataUpdated)
 }
 
+func MockMoveObject(mockAPI *mocks.APIPort, id string, move map[string]any, moved map[string]any) {
+	mockResponse(mockAPI, http.MethodPost, "/api/hierarchy_objects/"+id+"/move", move, http.StatusOK, moved)
+}
+
+func MockCopyObject(mockAPI *mocks.APIPort, id string, objectCopy map[string]any, copies []any) {
+	mockResponse(mockAPI, http.MethodPost, "/api/hierarchy_objects/"+id+"/copy", objectCopy, http.StatusCreated, copies)
+}
+
 func MockPutObject(mockAPI *mocks.APIPort, dataUpdate map[string]any, dataUpdated map[string]any) {
 	mockResponse(mockAPI, http.MethodPut, mock.Anything, dataUpdate, http.StatusOK, dataUpdated)
 }
@@ -0,0 +1,40 @@
+package views
+
+import (
+	"cli/models"
+	"fmt"
+	"strings"
+)
+
+func IntegrityIssues(issues []models.IntegrityIssue) string {
+	if len(issues) == 0 {
+		return "No integrity issues found\n"
+	}
+
+	var builder strings.Builder
+	fixable := 0
+	for _, issue := range issues {
+		builder.WriteString(integrityIssue(issue))
+		if issue.Fixable {
+			fixable++
+		}
+	}
+	builder.WriteString(fmt.Sprintf("%d issues found, %d can be fixed with check -fix\n", len(issues), fixable))
+	return builder.String()
+}
+
+func IntegrityFix(fixed, remaining []models.IntegrityIssue) string {
+	var builder strings.Builder
+	for _, issue := range fixed {
+		builder.WriteString("fixed " + integrityIssue(issue))
+	}
+	for _, issue := range remaining {
+		builder.WriteString("remaining " + integrityIssue(issue))
+	}
+	builder.WriteString(fmt.Sprintf("%d issues fixed, %d need a manual repair\n", len(fixed), len(remaining)))
+	return builder.String()
+}
+
+func integrityIssue(issue models.IntegrityIssue) string {
+	return fmt.Sprintf("%s %s: %s %s does not exist\n", issue.Type, issue.Path, issue.Field, issue.Reference)
+}
@@ -0,0 +1,33 @@
+package views
+
+import (
+	"cli/models"
+	"fmt"
+	"strings"
+)
+
+func CSVImportReport(report *models.CSVImportReport) string {
+	var builder strings.Builder
+	for _, row := range report.Rows {
+		switch row.Status {
+		case "error":
+			builder.WriteString(fmt.Sprintf("row %d: %s\n", row.Row, row.Error))
+			for _, detail := range row.Details {
+				builder.WriteString("    " + detail + "\n")
+			}
+		default:
+			builder.WriteString(fmt.Sprintf("row %d: %s %s\n", row.Row, row.Status, row.Id))
+		}
+	}
+	builder.WriteString(fmt.Sprintf("%d objects created, %d invalid rows\n", report.Created, report.Errors))
+	return builder.String()
+}
+
+func DeviceTypesImport(slugs []string) string {
+	var builder strings.Builder
+	for _, slug := range slugs {
+		builder.WriteString("created template " + slug + "\n")
+	}
+	builder.WriteString(fmt.Sprintf("%d templates created\n", len(slugs)))
+	return builder.String()
+}
@@ -0,0 +1,32 @@
+package views
+
+import (
+	"cli/models"
+	"fmt"
+	"strings"
+)
+
+var planSymbols = map[string]string{
+	models.PlanCreate: "+",
+	models.PlanUpdate: "~",
+	models.PlanDelete: "-",
+}
+
+// Plan prints each change of the plan, with the fields that change
+// for an update, then the number of changes of each kind
+func Plan(plan *models.Plan) string {
+	var builder strings.Builder
+	for _, change := range plan.Changes {
+		builder.WriteString(fmt.Sprintf("%s %s %s\n", planSymbols[change.Action], change.Category, change.Id))
+		for _, fieldChange := range change.Changes {
+			builder.WriteString(fmt.Sprintf("    %s: %v -> %v\n", fieldChange.Field, fieldChange.Old, fieldChange.New))
+		}
+	}
+	builder.WriteString(fmt.Sprintf("Plan: %d to create, %d to update, %d to delete, %d unchanged\n",
+		plan.Count(models.PlanCreate), plan.Count(models.PlanUpdate), plan.Count(models.PlanDelete), plan.Unchanged))
+	return builder.String()
+}
+
+func Applied(applied, total int) string {
+	return fmt.Sprintf("%d of %d changes applied\n", applied, total)
+}
@@ -0,0 +1,21 @@
+package views
+
+import (
+	"cli/models"
+	"strings"
+)
+
+func SearchResults(results []models.SearchResult) string {
+	if len(results) == 0 {
+		return "No objects found\n"
+	}
+
+	var builder strings.Builder
+	for _, result := range results {
+		builder.WriteString(result.Path + "\n")
+		for _, highlight := range result.Highlights {
+			builder.WriteString("    " + highlight + "\n")
+		}
+	}
+	return builder.String()
+}
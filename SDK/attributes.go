@@ -0,0 +1,307 @@
+package sdk
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// The attributes of each entity give the attributes known by the API.
+// The other attributes of an object, set by its users, are in Custom.
+
+type SiteAttributes struct {
+	ReservedColor  string         `json:"reservedColor,omitempty"`
+	TechnicalColor string         `json:"technicalColor,omitempty"`
+	UsableColor    string         `json:"usableColor,omitempty"`
+	Custom         map[string]any `json:"-"`
+}
+
+type BuildingAttributes struct {
+	Height     float64        `json:"height"`
+	HeightUnit string         `json:"heightUnit"`
+	PosXY      []float64      `json:"posXY"`
+	PosXYUnit  string         `json:"posXYUnit"`
+	Size       []float64      `json:"size"`
+	SizeUnit   string         `json:"sizeUnit"`
+	Rotation   float64        `json:"rotation"`
+	Template   string         `json:"template,omitempty"`
+	Custom     map[string]any `json:"-"`
+}
+
+type RoomAttributes struct {
+	Height          float64        `json:"height"`
+	HeightUnit      string         `json:"heightUnit"`
+	PosXY           []float64      `json:"posXY"`
+	PosXYUnit       string         `json:"posXYUnit"`
+	Size            []float64      `json:"size"`
+	SizeUnit        string         `json:"sizeUnit"`
+	Rotation        float64        `json:"rotation"`
+	AxisOrientation string         `json:"axisOrientation"`
+	FloorUnit       string         `json:"floorUnit"`
+	Template        string         `json:"template,omitempty"`
+	Reserved        []int          `json:"reserved,omitempty"`
+	Technical       []int          `json:"technical,omitempty"`
+	Custom          map[string]any `json:"-"`
+}
+
+// Breaker of a rack, fed by a power panel
+type Breaker struct {
+	Powerpanel string  `json:"powerpanel"`
+	Circuit    string  `json:"circuit,omitempty"`
+	Type       string  `json:"type,omitempty"`
+	Tag        string  `json:"tag,omitempty"`
+	Intensity  float64 `json:"intensity,omitempty"`
+}
+
+type RackAttributes struct {
+	Height     float64            `json:"height"`
+	HeightUnit string             `json:"heightUnit"`
+	PosXYZ     []float64          `json:"posXYZ"`
+	PosXYUnit  string             `json:"posXYUnit"`
+	PosZUnit   string             `json:"posZUnit,omitempty"`
+	Size       []float64          `json:"size"`
+	SizeUnit   string             `json:"sizeUnit"`
+	Rotation   []float64          `json:"rotation"`
+	Template   string             `json:"template,omitempty"`
+	Color      string             `json:"color,omitempty"`
+	Clearance  []float64          `json:"clearance,omitempty"`
+	Breakers   map[string]Breaker `json:"breakers,omitempty"`
+	Custom     map[string]any     `json:"-"`
+}
+
+type DeviceAttributes struct {
+	Height       float64        `json:"height"`
+	HeightUnit   string         `json:"heightUnit"`
+	Size         []float64      `json:"size"`
+	SizeUnit     string         `json:"sizeUnit"`
+	Orientation  string         `json:"orientation"`
+	PosU         float64        `json:"posU,omitempty"`
+	SizeU        int            `json:"sizeU,omitempty"`
+	Slot         []string       `json:"slot,omitempty"`
+	Template     string         `json:"template,omitempty"`
+	Type         string         `json:"type,omitempty"`
+	FbxModel     string         `json:"fbxModel,omitempty"`
+	Color        string         `json:"color,omitempty"`
+	InvertOffset bool           `json:"invertOffset,omitempty"`
+	Custom       map[string]any `json:"-"`
+}
+
+type GenericAttributes struct {
+	Height     float64        `json:"height"`
+	HeightUnit string         `json:"heightUnit"`
+	PosXYZ     []float64      `json:"posXYZ"`
+	PosXYUnit  string         `json:"posXYUnit"`
+	PosZUnit   string         `json:"posZUnit,omitempty"`
+	Size       []float64      `json:"size"`
+	SizeUnit   string         `json:"sizeUnit"`
+	Rotation   []float64      `json:"rotation"`
+	Type       string         `json:"type"`
+	Shape      string         `json:"shape"`
+	Template   string         `json:"template,omitempty"`
+	FbxModel   string         `json:"fbxModel,omitempty"`
+	Color      string         `json:"color,omitempty"`
+	Clearance  []float64      `json:"clearance,omitempty"`
+	Custom     map[string]any `json:"-"`
+}
+
+type CorridorAttributes struct {
+	Height      float64        `json:"height"`
+	HeightUnit  string         `json:"heightUnit"`
+	PosXYZ      []float64      `json:"posXYZ"`
+	PosXYUnit   string         `json:"posXYUnit"`
+	PosZUnit    string         `json:"posZUnit,omitempty"`
+	Size        []float64      `json:"size"`
+	SizeUnit    string         `json:"sizeUnit"`
+	Rotation    []float64      `json:"rotation"`
+	Temperature string         `json:"temperature"` // cold or warm
+	Custom      map[string]any `json:"-"`
+}
+
+// BaseAttributes are the attributes of the ACs, cabinets and panels
+type BaseAttributes struct {
+	Clearance []float64      `json:"clearance,omitempty"`
+	Custom    map[string]any `json:"-"`
+}
+
+type GroupAttributes struct {
+	Content []string       `json:"content"` // names of the objects of the group
+	Color   string         `json:"color,omitempty"`
+	Custom  map[string]any `json:"-"`
+}
+
+type VirtualConfig struct {
+	Type      string `json:"type"`
+	Role      string `json:"role,omitempty"`
+	ClusterId string `json:"clusterId,omitempty"`
+}
+
+type VirtualObjectAttributes struct {
+	VirtualConfig VirtualConfig  `json:"virtual_config"`
+	Vlinks        []string       `json:"vlinks,omitempty"`
+	Custom        map[string]any `json:"-"`
+}
+
+type DomainAttributes struct {
+	Color  string         `json:"color,omitempty"`
+	Custom map[string]any `json:"-"`
+}
+
+func (attributes SiteAttributes) MarshalJSON() ([]byte, error) {
+	type known SiteAttributes
+	return marshalAttributes(known(attributes), attributes.Custom)
+}
+
+func (attributes *SiteAttributes) UnmarshalJSON(data []byte) error {
+	type known SiteAttributes
+	return unmarshalAttributes(data, (*known)(attributes), &attributes.Custom)
+}
+
+func (attributes BuildingAttributes) MarshalJSON() ([]byte, error) {
+	type known BuildingAttributes
+	return marshalAttributes(known(attributes), attributes.Custom)
+}
+
+func (attributes *BuildingAttributes) UnmarshalJSON(data []byte) error {
+	type known BuildingAttributes
+	return unmarshalAttributes(data, (*known)(attributes), &attributes.Custom)
+}
+
+func (attributes RoomAttributes) MarshalJSON() ([]byte, error) {
+	type known RoomAttributes
+	return marshalAttributes(known(attributes), attributes.Custom)
+}
+
+func (attributes *RoomAttributes) UnmarshalJSON(data []byte) error {
+	type known RoomAttributes
+	return unmarshalAttributes(data, (*known)(attributes), &attributes.Custom)
+}
+
+func (attributes RackAttributes) MarshalJSON() ([]byte, error) {
+	type known RackAttributes
+	return marshalAttributes(known(attributes), attributes.Custom)
+}
+
+func (attributes *RackAttributes) UnmarshalJSON(data []byte) error {
+	type known RackAttributes
+	return unmarshalAttributes(data, (*known)(attributes), &attributes.Custom)
+}
+
+func (attributes DeviceAttributes) MarshalJSON() ([]byte, error) {
+	type known DeviceAttributes
+	return marshalAttributes(known(attributes), attributes.Custom)
+}
+
+func (attributes *DeviceAttributes) UnmarshalJSON(data []byte) error {
+	type known DeviceAttributes
+	return unmarshalAttributes(data, (*known)(attributes), &attributes.Custom)
+}
+
+func (attributes GenericAttributes) MarshalJSON() ([]byte, error) {
+	type known GenericAttributes
+	return marshalAttributes(known(attributes), attributes.Custom)
+}
+
+func (attributes *GenericAttributes) UnmarshalJSON(data []byte) error {
+	type known GenericAttributes
+	return unmarshalAttributes(data, (*known)(attributes), &attributes.Custom)
+}
+
+func (attributes CorridorAttributes) MarshalJSON() ([]byte, error) {
+	type known CorridorAttributes
+	return marshalAttributes(known(attributes), attributes.Custom)
+}
+
+func (attributes *CorridorAttributes) UnmarshalJSON(data []byte) error {
+	type known CorridorAttributes
+	return unmarshalAttributes(data, (*known)(attributes), &attributes.Custom)
+}
+
+func (attributes BaseAttributes) MarshalJSON() ([]byte, error) {
+	type known BaseAttributes
+	return marshalAttributes(known(attributes), attributes.Custom)
+}
+
+func (attributes *BaseAttributes) UnmarshalJSON(data []byte) error {
+	type known BaseAttributes
+	return unmarshalAttributes(data, (*known)(attributes), &attributes.Custom)
+}
+
+func (attributes GroupAttributes) MarshalJSON() ([]byte, error) {
+	type known GroupAttributes
+	return marshalAttributes(known(attributes), attributes.Custom)
+}
+
+func (attributes *GroupAttributes) UnmarshalJSON(data []byte) error {
+	type known GroupAttributes
+	return unmarshalAttributes(data, (*known)(attributes), &attributes.Custom)
+}
+
+func (attributes VirtualObjectAttributes) MarshalJSON() ([]byte, error) {
+	type known VirtualObjectAttributes
+	return marshalAttributes(known(attributes), attributes.Custom)
+}
+
+func (attributes *VirtualObjectAttributes) UnmarshalJSON(data []byte) error {
+	type known VirtualObjectAttributes
+	return unmarshalAttributes(data, (*known)(attributes), &attributes.Custom)
+}
+
+func (attributes DomainAttributes) MarshalJSON() ([]byte, error) {
+	type known DomainAttributes
+	return marshalAttributes(known(attributes), attributes.Custom)
+}
+
+func (attributes *DomainAttributes) UnmarshalJSON(data []byte) error {
+	type known DomainAttributes
+	return unmarshalAttributes(data, (*known)(attributes), &attributes.Custom)
+}
+
+// marshalAttributes merges the custom attributes into the known ones
+func marshalAttributes(known any, custom map[string]any) ([]byte, error) {
+	knownJSON, err := json.Marshal(known)
+	if err != nil || len(custom) == 0 {
+		return knownJSON, err
+	}
+	attributes := map[string]any{}
+	if err := json.Unmarshal(knownJSON, &attributes); err != nil {
+		return nil, err
+	}
+	for attribute, value := range custom {
+		if _, isKnown := attributes[attribute]; !isKnown {
+			attributes[attribute] = value
+		}
+	}
+	return json.Marshal(attributes)
+}
+
+// unmarshalAttributes decodes the known attributes and keeps the others in custom
+func unmarshalAttributes(data []byte, known any, custom *map[string]any) error {
+	if err := json.Unmarshal(data, known); err != nil {
+		return err
+	}
+	attributes := map[string]any{}
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return err
+	}
+	for _, attribute := range jsonFields(known) {
+		delete(attributes, attribute)
+	}
+	*custom = nil
+	if len(attributes) > 0 {
+		*custom = attributes
+	}
+	return nil
+}
+
+// jsonFields gives the JSON names of the fields of the pointed struct
+func jsonFields(structPtr any) []string {
+	structType := reflect.TypeOf(structPtr).Elem()
+	fields := []string{}
+	for i := 0; i < structType.NumField(); i++ {
+		name, _, _ := strings.Cut(structType.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,165 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const loginEndpoint = "/api/login"
+
+// Client of the OGrEE API. Its methods send the requests through its
+// transport and decode the data of the responses.
+type Client struct {
+	Transport Transport
+}
+
+func NewClient(transport Transport) *Client {
+	return &Client{Transport: transport}
+}
+
+// New returns a client of the API at the given URL, over HTTP
+func New(url string) *Client {
+	return NewClient(NewHTTPTransport(url))
+}
+
+// credentialsHolder is a transport that can refresh the token of the user
+type credentialsHolder interface {
+	SetCredentials(email, password, token string)
+}
+
+// Account of the logged in user
+type Account struct {
+	Id    string          `json:"_id"`
+	Name  string          `json:"name"`
+	Email string          `json:"email"`
+	Roles map[string]Role `json:"roles"`
+	Token string          `json:"token"`
+}
+
+// Login authenticates the user. If the transport can (HTTPTransport), the
+// next requests are authenticated with the token of the user, which is
+// refreshed when it expires.
+func (client *Client) Login(email, password string) (*Account, error) {
+	account, err := login(client.Transport, email, password)
+	if err != nil {
+		return nil, err
+	}
+	if holder, ok := client.Transport.(credentialsHolder); ok {
+		holder.SetCredentials(email, password, account.Token)
+	}
+	return account, nil
+}
+
+func login(transport Transport, email, password string) (*Account, error) {
+	resp, err := transport.Request(http.MethodPost, loginEndpoint,
+		map[string]any{"email": email, "password": password}, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	account := &Account{}
+	if err := decode(resp.Body["account"], account); err != nil || account.Token == "" || account.Id == "" {
+		return nil, fmt.Errorf("invalid response from API on POST %s", loginEndpoint)
+	}
+	return account, nil
+}
+
+// Version of the API, and its tenant (customer)
+type Version struct {
+	BuildDate  string `json:"BuildDate"`
+	BuildHash  string `json:"BuildHash"`
+	BuildTree  string `json:"BuildTree"`
+	CommitDate string `json:"CommitDate"`
+	Customer   string `json:"Customer"`
+}
+
+func (client *Client) Version() (*Version, error) {
+	version := &Version{}
+	if err := client.getData("/api/version", version); err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// Stats gives the number of objects of each category and the date of the last update
+func (client *Client) Stats() (map[string]any, error) {
+	resp, err := client.request(http.MethodGet, "/api/stats", nil, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// request sends the request with the transport. When the status is not
+// the expected one, the error is an APIError.
+func (client *Client) request(method, endpoint string, body map[string]any, expectedStatus int) (*Response, error) {
+	resp, err := client.Transport.Request(method, endpoint, body, expectedStatus)
+	if err != nil {
+		if _, isAPIError := err.(*APIError); !isAPIError && resp != nil {
+			err = &APIError{Status: resp.Status, Message: resp.Message, Err: err}
+		}
+		return resp, err
+	}
+	return resp, nil
+}
+
+// getData decodes the data of the response to a GET of the endpoint
+func (client *Client) getData(endpoint string, result any) error {
+	resp, err := client.request(http.MethodGet, endpoint, nil, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	return decodeData(resp, http.MethodGet, endpoint, result)
+}
+
+func decodeData(resp *Response, method, endpoint string, result any) error {
+	data, ok := resp.Body["data"]
+	if !ok {
+		return fmt.Errorf("invalid response from API on %s %s", method, endpoint)
+	}
+	if err := decode(data, result); err != nil {
+		return fmt.Errorf("invalid response from API on %s %s: %s", method, endpoint, err.Error())
+	}
+	return nil
+}
+
+// decode converts the decoded JSON value to the result
+func decode(value any, result any) error {
+	if value == nil {
+		return fmt.Errorf("no data")
+	}
+	if resultMap, ok := result.(*map[string]any); ok {
+		if valueMap, ok := value.(map[string]any); ok {
+			*resultMap = valueMap
+			return nil
+		}
+	}
+	if resultList, ok := result.(*[]map[string]any); ok {
+		if valueList, ok := value.([]any); ok {
+			*resultList = []map[string]any{}
+			for _, item := range valueList {
+				itemMap, ok := item.(map[string]any)
+				if !ok {
+					return fmt.Errorf("list of objects expected")
+				}
+				*resultList = append(*resultList, itemMap)
+			}
+			return nil
+		}
+	}
+
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(valueJSON, result)
+}
+
+// withQuery adds the query, if any, to the endpoint
+func withQuery(endpoint string, query url.Values) string {
+	if len(query) == 0 {
+		return endpoint
+	}
+	return endpoint + "?" + query.Encode()
+}
@@ -0,0 +1,17 @@
+// Package sdk is a Go client of the OGrEE API.
+//
+// A client sends its requests through a transport. HTTPTransport
+// authenticates them with the token of the user and refreshes it:
+//
+//	client := sdk.New("http://localhost:3001")
+//	if _, err := client.Login("admin@example.com", "password"); err != nil {
+//		return err
+//	}
+//	rack, err := sdk.Get[sdk.Rack](client, "SITE.BLDG.ROOM.RACK")
+//
+// The objects are typed by entity (Site, Rack, Device, Tag, Layer...) for
+// the generic functions Get, List, Create, Update, Replace and Delete, and
+// untyped (map[string]any) for the methods of the client, which also give
+// the hierarchy, the objects matching filters, the objects of a layer, the
+// impact of an object and the events of the API.
+package sdk
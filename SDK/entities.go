@@ -0,0 +1,217 @@
+package sdk
+
+import "time"
+
+// Entity is a type of object of the API, stored in a collection
+// (e.g. the racks in /api/racks)
+type Entity interface {
+	Collection() string
+}
+
+// categorized entities have a single category, set on their creation
+type categorized interface {
+	category() string
+}
+
+// Object gives the fields shared by the objects of the hierarchy,
+// the virtual objects and the domains
+type Object struct {
+	Id          string     `json:"id,omitempty"` // parentId.name, computed by the API
+	Name        string     `json:"name"`
+	ParentId    string     `json:"parentId,omitempty"`
+	Category    string     `json:"category"`
+	Description string     `json:"description"`
+	Domain      string     `json:"domain,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	CreatedDate *time.Time `json:"createdDate,omitempty"`
+	LastUpdated *time.Time `json:"lastUpdated,omitempty"`
+}
+
+type Site struct {
+	Object
+	Attributes SiteAttributes `json:"attributes"`
+}
+
+func (Site) Collection() string { return "sites" }
+func (Site) category() string   { return "site" }
+
+type Building struct {
+	Object
+	Attributes BuildingAttributes `json:"attributes"`
+}
+
+func (Building) Collection() string { return "buildings" }
+func (Building) category() string   { return "building" }
+
+type Room struct {
+	Object
+	Attributes RoomAttributes `json:"attributes"`
+}
+
+func (Room) Collection() string { return "rooms" }
+func (Room) category() string   { return "room" }
+
+type Rack struct {
+	Object
+	Attributes RackAttributes `json:"attributes"`
+}
+
+func (Rack) Collection() string { return "racks" }
+func (Rack) category() string   { return "rack" }
+
+type Device struct {
+	Object
+	Attributes DeviceAttributes `json:"attributes"`
+}
+
+func (Device) Collection() string { return "devices" }
+func (Device) category() string   { return "device" }
+
+// Generic is an object of a room that is not a rack (e.g. a table, a power panel)
+type Generic struct {
+	Object
+	Attributes GenericAttributes `json:"attributes"`
+}
+
+func (Generic) Collection() string { return "generics" }
+func (Generic) category() string   { return "generic" }
+
+type Corridor struct {
+	Object
+	Attributes CorridorAttributes `json:"attributes"`
+}
+
+func (Corridor) Collection() string { return "corridors" }
+func (Corridor) category() string   { return "corridor" }
+
+// AC is an air conditioner of a room
+type AC struct {
+	Object
+	Attributes BaseAttributes `json:"attributes"`
+}
+
+func (AC) Collection() string { return "acs" }
+func (AC) category() string   { return "ac" }
+
+type Cabinet struct {
+	Object
+	Attributes BaseAttributes `json:"attributes"`
+}
+
+func (Cabinet) Collection() string { return "cabinets" }
+func (Cabinet) category() string   { return "cabinet" }
+
+// Panel is a power panel of a room, feeding the breakers of its racks
+type Panel struct {
+	Object
+	Attributes BaseAttributes `json:"attributes"`
+}
+
+func (Panel) Collection() string { return "panels" }
+func (Panel) category() string   { return "panel" }
+
+// Group of racks of a room or of devices of a rack
+type Group struct {
+	Object
+	Attributes GroupAttributes `json:"attributes"`
+}
+
+func (Group) Collection() string { return "groups" }
+func (Group) category() string   { return "group" }
+
+// VirtualObject is a software object (cluster, VM, application...), linked
+// to the devices that host it by its vlinks
+type VirtualObject struct {
+	Object
+	Attributes VirtualObjectAttributes `json:"attributes"`
+}
+
+func (VirtualObject) Collection() string { return "virtual_objs" }
+func (VirtualObject) category() string   { return "virtual_obj" }
+
+type Domain struct {
+	Object
+	Attributes DomainAttributes `json:"attributes"`
+}
+
+func (Domain) Collection() string { return "domains" }
+func (Domain) category() string   { return "domain" }
+
+// StrayObject is an object outside of the hierarchy, until it is linked to a parent
+type StrayObject struct {
+	Object
+	Attributes map[string]any `json:"attributes"`
+}
+
+func (StrayObject) Collection() string { return "stray_objects" }
+
+type Tag struct {
+	Slug        string     `json:"slug"`
+	Description string     `json:"description"`
+	Color       string     `json:"color"`
+	Image       string     `json:"image,omitempty"`
+	CreatedDate *time.Time `json:"createdDate,omitempty"`
+	LastUpdated *time.Time `json:"lastUpdated,omitempty"`
+}
+
+func (Tag) Collection() string { return "tags" }
+
+// Layer gives the objects matching its filter under the objects of its
+// applicability (an id pattern)
+type Layer struct {
+	Slug          string     `json:"slug"`
+	Applicability string     `json:"applicability"`
+	Filter        string     `json:"filter"`
+	CreatedDate   *time.Time `json:"createdDate,omitempty"`
+	LastUpdated   *time.Time `json:"lastUpdated,omitempty"`
+}
+
+func (Layer) Collection() string { return "layers" }
+
+// ObjTemplate is the template of racks, devices or generic objects
+type ObjTemplate struct {
+	Slug        string            `json:"slug"`
+	Category    string            `json:"category"` // rack, device or generic
+	Description string            `json:"description"`
+	FbxModel    string            `json:"fbxModel"`
+	SizeWDHmm   []float64         `json:"sizeWDHmm"`
+	Version     int               `json:"version,omitempty"`
+	Attributes  map[string]string `json:"attributes"`
+	Colors      []map[string]any  `json:"colors,omitempty"`
+	Components  []map[string]any  `json:"components,omitempty"`
+	Slots       []map[string]any  `json:"slots,omitempty"`
+}
+
+func (ObjTemplate) Collection() string { return "obj_templates" }
+
+type RoomTemplate struct {
+	Slug            string           `json:"slug"`
+	Category        string           `json:"category"`
+	AxisOrientation string           `json:"axisOrientation"`
+	FloorUnit       string           `json:"floorUnit"`
+	SizeWDHm        []float64        `json:"sizeWDHm"`
+	Center          []float64        `json:"center,omitempty"`
+	Vertices        [][]float64      `json:"vertices,omitempty"`
+	TileAngle       float64          `json:"tileAngle,omitempty"`
+	TileOffset      []float64        `json:"tileOffset,omitempty"`
+	ReservedArea    []int            `json:"reservedArea,omitempty"`
+	TechnicalArea   []int            `json:"technicalArea,omitempty"`
+	Separators      map[string]any   `json:"separators,omitempty"`
+	Pillars         map[string]any   `json:"pillars,omitempty"`
+	Colors          []map[string]any `json:"colors,omitempty"`
+	Tiles           []map[string]any `json:"tiles,omitempty"`
+}
+
+func (RoomTemplate) Collection() string { return "room_templates" }
+func (RoomTemplate) category() string   { return "room" }
+
+type BldgTemplate struct {
+	Slug     string      `json:"slug"`
+	Category string      `json:"category"`
+	SizeWDHm []float64   `json:"sizeWDHm"`
+	Center   []float64   `json:"center"`
+	Vertices [][]float64 `json:"vertices"`
+}
+
+func (BldgTemplate) Collection() string { return "bldg_templates" }
+func (BldgTemplate) category() string   { return "building" }
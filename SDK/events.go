@@ -0,0 +1,95 @@
+package sdk
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Event notified by the API when an object is created, modified or deleted.
+// Type is create, modify or delete, suffixed by -tag or -layer for tags and
+// layers, or raise-alert. Data is the object, or its id for a deletion.
+type Event struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// eventSource is a transport that can receive the events of the API
+type eventSource interface {
+	Events(ctx context.Context) (<-chan Event, error)
+}
+
+// Events opens the stream of the events of the API (Server-Sent Events).
+// The channel is closed when the API ends the stream or the context is done.
+func (client *Client) Events(ctx context.Context) (<-chan Event, error) {
+	source, ok := client.Transport.(eventSource)
+	if !ok {
+		return nil, errors.New("the transport does not receive the events of the API")
+	}
+	return source.Events(ctx)
+}
+
+func (transport *HTTPTransport) Events(ctx context.Context) (<-chan Event, error) {
+	if transport.tokenExpiresSoon() {
+		if err := transport.refreshToken(); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, transport.URL+"/api/events", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+transport.Token())
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := transport.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		response, err := parseResponse(resp)
+		if err != nil {
+			return nil, fmt.Errorf("on GET /api/events : %s", err.Error())
+		}
+		return nil, newAPIError(response)
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+		readEvents(ctx, bufio.NewScanner(resp.Body), events)
+	}()
+	return events, nil
+}
+
+// readEvents sends the events of the stream: the data lines of an event are
+// followed by an empty line, and the close event ends the stream
+func readEvents(ctx context.Context, scanner *bufio.Scanner, events chan<- Event) {
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	data := []string{}
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		case strings.HasPrefix(line, "event:") && strings.TrimSpace(strings.TrimPrefix(line, "event:")) == "close":
+			return
+		case line == "" && len(data) > 0:
+			event := Event{}
+			if err := json.Unmarshal([]byte(strings.Join(data, "\n")), &event); err == nil {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+			data = []string{}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestEventsReceivesTheEventsUntilClose(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/events" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"type\":\"create\",\"data\":{\"id\":\"SITE\"}}\n\n")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		fmt.Fprint(w, "data: {\"type\":\"delete\",\"data\":\"SITE\"}\n\n")
+		fmt.Fprint(w, "event: close\ndata: \n\n")
+		fmt.Fprint(w, "data: {\"type\":\"create\",\"data\":{\"id\":\"IGNORED\"}}\n\n")
+	}))
+
+	events, err := client.Events(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := []Event{}
+	for event := range events {
+		received = append(received, event)
+	}
+
+	if len(received) != 2 {
+		t.Fatalf("unexpected events %+v", received)
+	}
+	if received[0].Type != "create" || received[0].Data.(map[string]any)["id"] != "SITE" {
+		t.Errorf("unexpected event %+v", received[0])
+	}
+	if received[1].Type != "delete" || received[1].Data != "SITE" {
+		t.Errorf("unexpected event %+v", received[1])
+	}
+}
+
+func TestEventsNeedsAnEventSource(t *testing.T) {
+	client := NewClient(&recordingTransport{})
+
+	if _, err := client.Events(context.Background()); err == nil {
+		t.Error("events opened on a transport without stream")
+	}
+}
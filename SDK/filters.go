@@ -0,0 +1,64 @@
+package sdk
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// The objects of all the entities can be found with a query of field=value
+// pairs. Ids accept the wildcards * (any name), ** (any number of levels)
+// and **{m,M} (from m to M levels), e.g. id=SITE.**.R1 (a list of the
+// descendants of SITE named R1), and limit gives the depth of the children.
+// A complex filter is an expression of conditions on the fields and
+// attributes, e.g. "category=rack & height>40", applied to the objects of
+// the query.
+
+// GetObjects gets the objects matching the query
+func (client *Client) GetObjects(query url.Values) ([]map[string]any, error) {
+	return client.objects(http.MethodGet, withQuery("/api/objects", query), nil)
+}
+
+// SearchObjects gets the objects matching the query and the complex filter
+func (client *Client) SearchObjects(query url.Values, filter string) ([]map[string]any, error) {
+	return client.objects(http.MethodPost, withQuery("/api/objects/search", query), map[string]any{"filter": filter})
+}
+
+// DeleteObjects deletes the objects matching the query and returns them
+func (client *Client) DeleteObjects(query url.Values) ([]map[string]any, error) {
+	return client.objects(http.MethodDelete, withQuery("/api/objects", query), nil)
+}
+
+// DeleteObjectsWithFilter deletes the objects matching the query and the
+// complex filter and returns them
+func (client *Client) DeleteObjectsWithFilter(query url.Values, filter string) ([]map[string]any, error) {
+	return client.objects(http.MethodDelete, withQuery("/api/objects/search", query), map[string]any{"filter": filter})
+}
+
+func (client *Client) objects(method, endpoint string, body map[string]any) ([]map[string]any, error) {
+	resp, err := client.request(method, endpoint, body, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	var objects []map[string]any
+	err = decodeData(resp, method, endpoint, &objects)
+	return objects, err
+}
+
+// SearchResult is an object found by a full text search, with the
+// fields that matched
+type SearchResult struct {
+	Entity     string         `json:"entity"`
+	Object     map[string]any `json:"object"`
+	Highlights []struct {
+		Field string `json:"field"`
+		Value any    `json:"value"`
+	} `json:"highlights"`
+}
+
+// Search the objects whose names, descriptions or attributes match the
+// words of the query, ranked by relevance
+func (client *Client) Search(text string) ([]SearchResult, error) {
+	results := []SearchResult{}
+	err := client.getData("/api/objects/search?q="+url.QueryEscape(text), &results)
+	return results, err
+}
@@ -0,0 +1,3 @@
+module ogree-sdk
+
+go 1.21
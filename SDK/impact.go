@@ -0,0 +1,82 @@
+package sdk
+
+import "net/url"
+
+// ImpactFilters selects the objects indirectly impacted, by category,
+// physical type (attribute type) and virtual type (virtual_config.type).
+// The objects with a relation of one of the Relations types to an
+// impacted object are impacted too
+type ImpactFilters struct {
+	Categories []string
+	Ptypes     []string
+	Vtypes     []string
+	Relations  []string
+}
+
+// Impact of an object: its descendants (direct), the objects linked to
+// them (indirect), the objects of each cluster of the descendants and
+// the typed relations followed
+type Impact struct {
+	Direct         map[string]map[string]any `json:"direct"`
+	Indirect       map[string]map[string]any `json:"indirect"`
+	Relations      map[string][]string       `json:"relations"`
+	TypedRelations []Relation                `json:"typedRelations"`
+}
+
+// ImpactGraph is the impact as nodes, styled by category, and edges
+// (contains, cluster or the type of a typed relation)
+type ImpactGraph struct {
+	Nodes []ImpactNode `json:"nodes"`
+	Edges []ImpactEdge `json:"edges"`
+}
+
+type ImpactNode struct {
+	Id       string `json:"id"`
+	Label    string `json:"label"`
+	Category string `json:"category"`
+	Impact   string `json:"impact"`
+	Color    string `json:"color"`
+	Shape    string `json:"shape"`
+}
+
+type ImpactEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"`
+}
+
+// GetImpact gets the objects that a failure of the object would impact
+func (client *Client) GetImpact(id string, filters ImpactFilters) (*Impact, error) {
+	impact := &Impact{}
+	if err := client.getData(withQuery("/api/impact/"+id, filters.query()), impact); err != nil {
+		return nil, err
+	}
+	return impact, nil
+}
+
+// GetImpactGraph gets the impact of the object as a graph
+func (client *Client) GetImpactGraph(id string, filters ImpactFilters) (*ImpactGraph, error) {
+	query := filters.query()
+	query.Set("format", "json-graph")
+
+	graph := &ImpactGraph{}
+	if err := client.getData(withQuery("/api/impact/"+id, query), graph); err != nil {
+		return nil, err
+	}
+	return graph, nil
+}
+
+func (filters ImpactFilters) query() url.Values {
+	query := url.Values{}
+	for param, values := range map[string][]string{
+		"categories": filters.Categories,
+		"ptypes":     filters.Ptypes,
+		"vtypes":     filters.Vtypes,
+		"relations":  filters.Relations,
+	} {
+		for _, value := range values {
+			query.Add(param, value)
+		}
+	}
+	return query
+}
@@ -0,0 +1,32 @@
+package sdk
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestGetImpactGraphAsksForJsonGraph(t *testing.T) {
+	transport := &recordingTransport{response: &Response{Status: http.StatusOK, Body: map[string]any{
+		"data": map[string]any{
+			"nodes": []any{
+				map[string]any{"id": "SITE.BLDG.ROOM.R1", "label": "R1", "category": "rack", "impact": "target"},
+				map[string]any{"id": "SITE.BLDG.ROOM.R1.srv", "label": "srv", "category": "device", "impact": "direct"},
+			},
+			"edges": []any{
+				map[string]any{"from": "SITE.BLDG.ROOM.R1", "to": "SITE.BLDG.ROOM.R1.srv", "type": "contains"},
+			},
+		},
+	}}}
+	client := NewClient(transport)
+
+	graph, err := client.GetImpactGraph("SITE.BLDG.ROOM.R1", ImpactFilters{Relations: []string{"depends-on"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transport.endpoint != "/api/impact/SITE.BLDG.ROOM.R1?format=json-graph&relations=depends-on" {
+		t.Errorf("unexpected endpoint %s", transport.endpoint)
+	}
+	if len(graph.Nodes) != 2 || len(graph.Edges) != 1 || graph.Edges[0].Type != "contains" {
+		t.Errorf("unexpected graph %v", graph)
+	}
+}
@@ -0,0 +1,89 @@
+package sdk
+
+import "net/http"
+
+// CSVImport creates an object for each row of a CSV file
+type CSVImport struct {
+	File           string            // content of the file
+	Mapping        map[string]string // column: field or attribute of the objects
+	ParentColumn   string
+	TemplateColumn string
+	Category       string // of all the objects, if no column is mapped to category
+	Separator      string
+}
+
+type CSVImportRow struct {
+	Row      int      `json:"row"`
+	Id       string   `json:"id"`
+	Category string   `json:"category"`
+	Status   string   `json:"status"`
+	Error    string   `json:"error"`
+	Details  []string `json:"details"`
+}
+
+// CSVImportReport is the result of the import of each row
+type CSVImportReport struct {
+	Created int            `json:"created"`
+	Errors  int            `json:"errors"`
+	Rows    []CSVImportRow `json:"rows"`
+}
+
+// ImportCSV creates the objects of the rows of the file, or none if a row is
+// invalid. The report is returned with the error of the invalid rows.
+func (client *Client) ImportCSV(request CSVImport) (*CSVImportReport, error) {
+	body := map[string]any{
+		"file":         request.File,
+		"mapping":      request.Mapping,
+		"parentColumn": request.ParentColumn,
+	}
+	if request.TemplateColumn != "" {
+		body["templateColumn"] = request.TemplateColumn
+	}
+	if request.Category != "" {
+		body["category"] = request.Category
+	}
+	if request.Separator != "" {
+		body["separator"] = request.Separator
+	}
+
+	resp, err := client.request(http.MethodPost, "/api/import/csv", body, http.StatusCreated)
+	if resp == nil || resp.Body["data"] == nil {
+		return nil, err
+	}
+	report := &CSVImportReport{}
+	if decodeErr := decodeData(resp, http.MethodPost, "/api/import/csv", report); decodeErr != nil {
+		return nil, decodeErr
+	}
+	return report, err
+}
+
+// DeviceTypeImport creates an object template for each device type of a
+// YAML file of the community device-type library
+type DeviceTypeImport struct {
+	File      string  // content of the file
+	WidthMm   float64 // 0 for the default of the API (19")
+	DepthMm   float64 // of the full depth devices, 0 for the default of the API
+	ImagesUrl string  // base URL of the elevation images of the library
+}
+
+// ImportDeviceTypes creates the templates of the device types and returns them
+func (client *Client) ImportDeviceTypes(request DeviceTypeImport) ([]ObjTemplate, error) {
+	body := map[string]any{"file": request.File}
+	if request.WidthMm != 0 {
+		body["widthMm"] = request.WidthMm
+	}
+	if request.DepthMm != 0 {
+		body["depthMm"] = request.DepthMm
+	}
+	if request.ImagesUrl != "" {
+		body["imagesUrl"] = request.ImagesUrl
+	}
+
+	resp, err := client.request(http.MethodPost, "/api/obj_templates/import", body, http.StatusCreated)
+	if err != nil {
+		return nil, err
+	}
+	templates := []ObjTemplate{}
+	err = decodeData(resp, http.MethodPost, "/api/obj_templates/import", &templates)
+	return templates, err
+}
@@ -0,0 +1,46 @@
+package sdk
+
+import "net/http"
+
+// IntegrityIssue: the object references, in field, something that does not exist.
+// The type is one of group-content, vlink, tag, breaker-powerpanel and orphan
+type IntegrityIssue struct {
+	Type      string `json:"type"`
+	Id        string `json:"id"`
+	Category  string `json:"category"`
+	Domain    string `json:"domain"`
+	Field     string `json:"field"`
+	Reference string `json:"reference"`
+	Fixable   bool   `json:"fixable"`
+}
+
+// IntegrityFix lists the issues repaired and those that need a manual repair
+type IntegrityFix struct {
+	Fixed     []IntegrityIssue `json:"fixed"`
+	Remaining []IntegrityIssue `json:"remaining"`
+}
+
+// CheckIntegrity gets the dangling references of the objects the user can read
+func (client *Client) CheckIntegrity() ([]IntegrityIssue, error) {
+	var data struct {
+		Issues []IntegrityIssue `json:"issues"`
+	}
+	if err := client.getData("/api/integrity", &data); err != nil {
+		return nil, err
+	}
+	return data.Issues, nil
+}
+
+// FixIntegrity removes the dangling references that can be safely removed
+// from the objects the user can modify
+func (client *Client) FixIntegrity() (*IntegrityFix, error) {
+	resp, err := client.request(http.MethodPost, "/api/integrity/fix", nil, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	fix := &IntegrityFix{}
+	if err := decodeData(resp, http.MethodPost, "/api/integrity/fix", fix); err != nil {
+		return nil, err
+	}
+	return fix, nil
+}
@@ -0,0 +1,15 @@
+package sdk
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// GetLayerObjects gets the objects of the layer under the root object: its
+// children or, with recursive, all its descendants
+func (client *Client) GetLayerObjects(slug, root string, recursive bool) ([]map[string]any, error) {
+	var objects []map[string]any
+	err := client.getData(withQuery(objectEndpoint("layers", slug)+"/objects",
+		url.Values{"root": {root}, "recursive": {strconv.FormatBool(recursive)}}), &objects)
+	return objects, err
+}
@@ -0,0 +1,243 @@
+package sdk
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Typed CRUD, for each entity, e.g.:
+//
+//	rack, err := sdk.Get[sdk.Rack](client, "SITE.BLDG.ROOM.RACK")
+//	racks, err := sdk.List[sdk.Rack](client, url.Values{"domain": {"demo"}})
+
+// Get the object of the entity with this id (or slug)
+func Get[T Entity](client *Client, id string) (*T, error) {
+	var object T
+	if err := client.getData(objectEndpoint(object.Collection(), id), &object); err != nil {
+		return nil, err
+	}
+	return &object, nil
+}
+
+// List the objects of the entity matching the query (field=value, * wildcards)
+func List[T Entity](client *Client, query url.Values) ([]T, error) {
+	var entity T
+	objects := []T{}
+	if err := client.getData(withQuery("/api/"+entity.Collection(), query), &objects); err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// Create the object, whose category is set if empty, and returns it as created by the API
+func Create[T Entity](client *Client, object T) (*T, error) {
+	data, err := toData(object)
+	if err != nil {
+		return nil, err
+	}
+	if categorized, ok := any(object).(categorized); ok {
+		if category, _ := data["category"].(string); category == "" {
+			data["category"] = categorized.category()
+		}
+	}
+
+	resp, err := client.request(http.MethodPost, "/api/"+object.Collection(), data, http.StatusCreated)
+	if err != nil {
+		return nil, err
+	}
+	var created T
+	if err := decodeData(resp, http.MethodPost, "/api/"+object.Collection(), &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// Update the given fields of the object with this id (or slug). The given
+// attributes are added to the attributes of the object.
+func Update[T Entity](client *Client, id string, changes map[string]any) (*T, error) {
+	var entity T
+	updated, err := client.UpdateObject(entity.Collection(), id, changes, false)
+	if err != nil {
+		return nil, err
+	}
+	var object T
+	if err := decode(updated, &object); err != nil {
+		return nil, err
+	}
+	return &object, nil
+}
+
+// Replace the object with this id (or slug) by the given one
+func Replace[T Entity](client *Client, id string, object T) (*T, error) {
+	data, err := toData(object)
+	if err != nil {
+		return nil, err
+	}
+	replaced, err := client.ReplaceObject(object.Collection(), id, data)
+	if err != nil {
+		return nil, err
+	}
+	var result T
+	if err := decode(replaced, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Delete the object with this id (or slug), with its children
+func Delete[T Entity](client *Client, id string) error {
+	var entity T
+	return client.DeleteObject(entity.Collection(), id)
+}
+
+func toData(object any) (map[string]any, error) {
+	objectJSON, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	data := map[string]any{}
+	err = json.Unmarshal(objectJSON, &data)
+	return data, err
+}
+
+func objectEndpoint(collection, id string) string {
+	return "/api/" + collection + "/" + id
+}
+
+// Untyped objects, as decoded from JSON. The collection is the plural of
+// the entity (e.g. racks, obj_templates), or hierarchy_objects for any
+// object of the physical hierarchy.
+
+// GetObject gets the object with this id (or slug)
+func (client *Client) GetObject(collection, id string) (map[string]any, error) {
+	var object map[string]any
+	err := client.getData(objectEndpoint(collection, id), &object)
+	return object, err
+}
+
+// GetHierarchy gets the object with this id and its descendants, up to the
+// given depth, in its children
+func (client *Client) GetHierarchy(collection, id string, depth int) (map[string]any, error) {
+	var object map[string]any
+	err := client.getData(withQuery(objectEndpoint(collection, id)+"/all",
+		url.Values{"limit": {strconv.Itoa(depth)}}), &object)
+	return object, err
+}
+
+// ListObjects gets the objects of the collection matching the query
+func (client *Client) ListObjects(collection string, query url.Values) ([]map[string]any, error) {
+	return client.objects(http.MethodGet, withQuery("/api/"+collection, query), nil)
+}
+
+// CreateObject creates an object of the entity and returns it as created by the API
+func (client *Client) CreateObject(entity string, data map[string]any) (map[string]any, error) {
+	endpoint := "/api/" + entity + "s"
+	resp, err := client.request(http.MethodPost, endpoint, data, http.StatusCreated)
+	if err != nil {
+		return nil, err
+	}
+	var created map[string]any
+	err = decodeData(resp, http.MethodPost, endpoint, &created)
+	return created, err
+}
+
+// ValidateObject checks that the object of the entity could be created
+func (client *Client) ValidateObject(entity string, data map[string]any) error {
+	_, err := client.request(http.MethodPost, "/api/validate/"+entity+"s", data, http.StatusOK)
+	return err
+}
+
+// UpdateObject updates the given fields of the object. With recursive,
+// a renaming applies to the objects of a layer.
+func (client *Client) UpdateObject(collection, id string, changes map[string]any, recursive bool) (map[string]any, error) {
+	endpoint := objectEndpoint(collection, id)
+	if recursive {
+		endpoint += "?recursive=true"
+	}
+	return client.sendObject(http.MethodPatch, endpoint, changes)
+}
+
+// ReplaceObject replaces all the fields of the object
+func (client *Client) ReplaceObject(collection, id string, data map[string]any) (map[string]any, error) {
+	return client.sendObject(http.MethodPut, objectEndpoint(collection, id), data)
+}
+
+func (client *Client) sendObject(method, endpoint string, data map[string]any) (map[string]any, error) {
+	resp, err := client.request(method, endpoint, data, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	var object map[string]any
+	err = decodeData(resp, method, endpoint, &object)
+	return object, err
+}
+
+// DeleteObject deletes the object, with its children
+func (client *Client) DeleteObject(collection, id string) error {
+	_, err := client.request(http.MethodDelete, objectEndpoint(collection, id), nil, http.StatusNoContent)
+	return err
+}
+
+// LinkObject moves the stray object in the hierarchy, under the parent,
+// in the given slots of a rack for a device
+func (client *Client) LinkObject(strayId, parentId string, slots []string) error {
+	payload := map[string]any{"parentId": parentId}
+	if slots != nil {
+		payload["slot"] = slots
+	}
+	_, err := client.request(http.MethodPatch, objectEndpoint("stray_objects", strayId)+"/link", payload, http.StatusOK)
+	return err
+}
+
+// UnlinkObject moves the object of the hierarchy to the stray objects
+func (client *Client) UnlinkObject(collection, id string) error {
+	_, err := client.request(http.MethodPatch, objectEndpoint(collection, id)+"/unlink", nil, http.StatusOK)
+	return err
+}
+
+// MoveObject moves the object under the parent, with the new position
+// attributes if any, and returns it with its new id. The ids of its
+// descendants and the references to them are updated by the API.
+func (client *Client) MoveObject(collection, id, parentId string, position map[string]any) (map[string]any, error) {
+	payload := map[string]any{"parentId": parentId}
+	if len(position) > 0 {
+		payload["attributes"] = position
+	}
+	return client.sendObject(http.MethodPost, objectEndpoint(collection, id)+"/move", payload)
+}
+
+// ObjectCopy describes the copies of an object made by CopyObject
+type ObjectCopy struct {
+	ParentId string         // the parent of the object if empty
+	Name     string         // name or pattern such as R{01..10}, the name of the object if empty
+	Offset   []float64      // added n times to the position of the nth copy
+	Position map[string]any // position attributes of the copies
+}
+
+// CopyObject copies the object with its descendants and returns the copies
+func (client *Client) CopyObject(collection, id string, objectCopy ObjectCopy) ([]map[string]any, error) {
+	payload := map[string]any{}
+	if objectCopy.ParentId != "" {
+		payload["parentId"] = objectCopy.ParentId
+	}
+	if objectCopy.Name != "" {
+		payload["name"] = objectCopy.Name
+	}
+	if len(objectCopy.Offset) > 0 {
+		payload["offset"] = objectCopy.Offset
+	}
+	if len(objectCopy.Position) > 0 {
+		payload["attributes"] = objectCopy.Position
+	}
+
+	endpoint := objectEndpoint(collection, id) + "/copy"
+	resp, err := client.request(http.MethodPost, endpoint, payload, http.StatusCreated)
+	if err != nil {
+		return nil, err
+	}
+	var copies []map[string]any
+	err = decodeData(resp, http.MethodPost, endpoint, &copies)
+	return copies, err
+}
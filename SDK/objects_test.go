@@ -0,0 +1,191 @@
+package sdk
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// recordingTransport records the last request and answers with the given response
+type recordingTransport struct {
+	method, endpoint string
+	body             map[string]any
+	response         *Response
+}
+
+func (transport *recordingTransport) Request(method, endpoint string, body map[string]any, expectedStatus int) (*Response, error) {
+	transport.method, transport.endpoint, transport.body = method, endpoint, body
+	return transport.response, nil
+}
+
+func TestRackAttributesKeepCustomAttributes(t *testing.T) {
+	data := []byte(`{"height":47,"heightUnit":"U","posXYZ":[1,2,0],"posXYUnit":"tile",` +
+		`"size":[60,120],"sizeUnit":"cm","rotation":[0,0,180],"vendor":"ACME","row":{"name":"R1"}}`)
+
+	var attributes RackAttributes
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		t.Fatal(err)
+	}
+	if attributes.Height != 47 || attributes.Rotation[2] != 180 {
+		t.Errorf("known attributes not decoded: %+v", attributes)
+	}
+	if len(attributes.Custom) != 2 || attributes.Custom["vendor"] != "ACME" {
+		t.Errorf("unexpected custom attributes %v", attributes.Custom)
+	}
+
+	encoded, err := json.Marshal(attributes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var original, roundTrip map[string]any
+	json.Unmarshal(data, &original)
+	json.Unmarshal(encoded, &roundTrip)
+	if !jsonMapEqual(original, roundTrip) {
+		t.Errorf("attributes changed by round trip: %s", encoded)
+	}
+}
+
+func jsonMapEqual(a, b map[string]any) bool {
+	aJSON, _ := json.Marshal(a)
+	bJSON, _ := json.Marshal(b)
+	return string(aJSON) == string(bJSON)
+}
+
+func TestCreateSetsTheCategory(t *testing.T) {
+	transport := &recordingTransport{response: &Response{Status: http.StatusCreated, Body: map[string]any{
+		"data": map[string]any{"id": "SITE.BLDG.ROOM.A01", "name": "A01", "category": "rack",
+			"attributes": map[string]any{"height": 42.0, "vendor": "ACME"}},
+	}}}
+	client := NewClient(transport)
+
+	rack := Rack{Object: Object{Name: "A01", ParentId: "SITE.BLDG.ROOM"}}
+	rack.Attributes.Height = 42
+	rack.Attributes.Custom = map[string]any{"vendor": "ACME"}
+	created, err := Create(client, rack)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if transport.method != http.MethodPost || transport.endpoint != "/api/racks" {
+		t.Errorf("unexpected request %s %s", transport.method, transport.endpoint)
+	}
+	if transport.body["category"] != "rack" || transport.body["attributes"].(map[string]any)["vendor"] != "ACME" {
+		t.Errorf("unexpected body %v", transport.body)
+	}
+	if created.Id != "SITE.BLDG.ROOM.A01" || created.Attributes.Custom["vendor"] != "ACME" {
+		t.Errorf("unexpected created rack %+v", created)
+	}
+}
+
+func TestUpdatePanel(t *testing.T) {
+	transport := &recordingTransport{response: &Response{Status: http.StatusOK, Body: map[string]any{
+		"data": map[string]any{"id": "SITE.BLDG.ROOM.PP-1", "name": "PP-1", "category": "panel",
+			"attributes": map[string]any{"clearance": []any{0.0, 1.0, 0.0, 0.0, 0.0, 0.0}, "voltage": 230.0}},
+	}}}
+	client := NewClient(transport)
+
+	panel, err := Update[Panel](client, "SITE.BLDG.ROOM.PP-1", map[string]any{"attributes": map[string]any{"voltage": 230}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if transport.method != http.MethodPatch || transport.endpoint != "/api/panels/SITE.BLDG.ROOM.PP-1" {
+		t.Errorf("unexpected request %s %s", transport.method, transport.endpoint)
+	}
+	if panel.Category != "panel" || panel.Attributes.Clearance[1] != 1 || panel.Attributes.Custom["voltage"] != 230.0 {
+		t.Errorf("unexpected updated panel %+v", panel)
+	}
+}
+
+func TestListSendsTheQuery(t *testing.T) {
+	transport := &recordingTransport{response: &Response{Status: http.StatusOK, Body: map[string]any{
+		"data": []any{map[string]any{"id": "SITE.BLDG.ROOM.A01", "category": "rack"}},
+	}}}
+	client := NewClient(transport)
+
+	racks, err := List[Rack](client, url.Values{"domain": {"demo"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transport.endpoint != "/api/racks?domain=demo" {
+		t.Errorf("unexpected endpoint %s", transport.endpoint)
+	}
+	if len(racks) != 1 || racks[0].Id != "SITE.BLDG.ROOM.A01" {
+		t.Errorf("unexpected racks %+v", racks)
+	}
+}
+
+func TestUpdateObjectRecursive(t *testing.T) {
+	transport := &recordingTransport{response: &Response{Status: http.StatusOK, Body: map[string]any{
+		"data": map[string]any{"slug": "new"},
+	}}}
+	client := NewClient(transport)
+
+	if _, err := client.UpdateObject("layers", "old", map[string]any{"slug": "new"}, true); err != nil {
+		t.Fatal(err)
+	}
+	if transport.method != http.MethodPatch || transport.endpoint != "/api/layers/old?recursive=true" {
+		t.Errorf("unexpected request %s %s", transport.method, transport.endpoint)
+	}
+}
+
+func TestLinkObjectWithoutSlots(t *testing.T) {
+	transport := &recordingTransport{response: &Response{Status: http.StatusOK}}
+	client := NewClient(transport)
+
+	if err := client.LinkObject("A01", "SITE.BLDG.ROOM", nil); err != nil {
+		t.Fatal(err)
+	}
+	if transport.endpoint != "/api/stray_objects/A01/link" || len(transport.body) != 1 {
+		t.Errorf("unexpected request %s %v", transport.endpoint, transport.body)
+	}
+}
+
+func TestMoveObjectSendsParentAndPosition(t *testing.T) {
+	transport := &recordingTransport{response: &Response{Status: http.StatusOK, Body: map[string]any{
+		"data": map[string]any{"id": "SITE.BLDG.ROOM2.A01"},
+	}}}
+	client := NewClient(transport)
+
+	moved, err := client.MoveObject("racks", "SITE.BLDG.ROOM1.A01", "SITE.BLDG.ROOM2",
+		map[string]any{"posXYZ": []float64{1, 2, 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transport.method != http.MethodPost || transport.endpoint != "/api/racks/SITE.BLDG.ROOM1.A01/move" {
+		t.Errorf("unexpected request %s %s", transport.method, transport.endpoint)
+	}
+	if transport.body["parentId"] != "SITE.BLDG.ROOM2" || transport.body["attributes"] == nil {
+		t.Errorf("unexpected body %v", transport.body)
+	}
+	if moved["id"] != "SITE.BLDG.ROOM2.A01" {
+		t.Errorf("unexpected moved object %v", moved)
+	}
+}
+
+func TestCopyObjectWithPattern(t *testing.T) {
+	transport := &recordingTransport{response: &Response{Status: http.StatusCreated, Body: map[string]any{
+		"data": []any{
+			map[string]any{"id": "SITE.BLDG.ROOM2.R01"},
+			map[string]any{"id": "SITE.BLDG.ROOM2.R02"},
+		},
+	}}}
+	client := NewClient(transport)
+
+	copies, err := client.CopyObject("racks", "SITE.BLDG.ROOM1.A01", ObjectCopy{
+		ParentId: "SITE.BLDG.ROOM2", Name: "R{01..02}", Offset: []float64{0, 1, 0},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transport.endpoint != "/api/racks/SITE.BLDG.ROOM1.A01/copy" {
+		t.Errorf("unexpected endpoint %s", transport.endpoint)
+	}
+	if transport.body["name"] != "R{01..02}" || transport.body["attributes"] != nil {
+		t.Errorf("unexpected body %v", transport.body)
+	}
+	if len(copies) != 2 || copies[1]["id"] != "SITE.BLDG.ROOM2.R02" {
+		t.Errorf("unexpected copies %v", copies)
+	}
+}
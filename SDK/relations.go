@@ -0,0 +1,59 @@
+package sdk
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// Relation is a typed and directional relationship between two objects.
+// The type is one of depends-on, backs-up, connected-to and managed-by
+type Relation struct {
+	Id         string         `json:"id,omitempty"`
+	Type       string         `json:"type"`
+	From       string         `json:"from"`
+	To         string         `json:"to"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// CreateRelation creates a relation from an object the user can modify
+// to an object the user can read
+func (client *Client) CreateRelation(relation Relation) (*Relation, error) {
+	payload := map[string]any{"type": relation.Type, "from": relation.From, "to": relation.To}
+	if relation.Attributes != nil {
+		payload["attributes"] = relation.Attributes
+	}
+	resp, err := client.request(http.MethodPost, "/api/relations", payload, http.StatusCreated)
+	if err != nil {
+		return nil, err
+	}
+	created := &Relation{}
+	if err := decodeData(resp, http.MethodPost, "/api/relations", created); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetRelations gets the relations matching from, to and relationType,
+// empty values match any relation
+func (client *Client) GetRelations(from, to, relationType string) ([]Relation, error) {
+	query := url.Values{}
+	for param, value := range map[string]string{"from": from, "to": to, "type": relationType} {
+		if value != "" {
+			query.Set(param, value)
+		}
+	}
+
+	var data struct {
+		Relations []Relation `json:"relations"`
+	}
+	if err := client.getData(withQuery("/api/relations", query), &data); err != nil {
+		return nil, err
+	}
+	return data.Relations, nil
+}
+
+// DeleteRelation deletes the relation of given id
+func (client *Client) DeleteRelation(id string) error {
+	_, err := client.request(http.MethodDelete, "/api/relations/"+id, nil, http.StatusOK)
+	return err
+}
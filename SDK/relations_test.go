@@ -0,0 +1,32 @@
+package sdk
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestGetRelationsSendsTheQuery(t *testing.T) {
+	transport := &recordingTransport{response: &Response{Status: http.StatusOK, Body: map[string]any{
+		"data": map[string]any{
+			"relations": []any{map[string]any{
+				"id":         "6564b0a0a1b2c3d4e5f60718",
+				"type":       "depends-on",
+				"from":       "app",
+				"to":         "SITE.BLDG.ROOM.R1.srv",
+				"attributes": map[string]any{},
+			}},
+		},
+	}}}
+	client := NewClient(transport)
+
+	relations, err := client.GetRelations("", "SITE.BLDG.ROOM.R1.srv", "depends-on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transport.endpoint != "/api/relations?to=SITE.BLDG.ROOM.R1.srv&type=depends-on" {
+		t.Errorf("unexpected endpoint %s", transport.endpoint)
+	}
+	if len(relations) != 1 || relations[0].From != "app" {
+		t.Errorf("unexpected relations %v", relations)
+	}
+}
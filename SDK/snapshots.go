@@ -0,0 +1,102 @@
+package sdk
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Snapshot is a copy of the physical and virtual objects of the tenant
+// (empty root) or of the subtree of an object at a point in time
+type Snapshot struct {
+	Name        string    `json:"name"`
+	Root        string    `json:"root"`
+	Domain      string    `json:"domain"`
+	Schedule    string    `json:"schedule,omitempty"`
+	CreatedDate time.Time `json:"createdDate"`
+	ObjectCount int       `json:"objectCount"`
+}
+
+// ObjectsDiff lists the objects added, removed and changed between two points in time
+type ObjectsDiff struct {
+	From    string       `json:"from"`
+	To      string       `json:"to"`
+	Added   []ObjectDiff `json:"added"`
+	Removed []ObjectDiff `json:"removed"`
+	Changed []ObjectDiff `json:"changed"`
+}
+
+type ObjectDiff struct {
+	Id       string          `json:"id"`
+	Category string          `json:"category"`
+	Changes  []AttributeDiff `json:"changes,omitempty"`
+}
+
+// AttributeDiff: attribute is the dotted path of the field, e.g. attributes.height
+type AttributeDiff struct {
+	Attribute string `json:"attribute"`
+	From      any    `json:"from"`
+	To        any    `json:"to"`
+}
+
+// TakeSnapshot copies the objects of the root, the whole tenant if empty
+func (client *Client) TakeSnapshot(name, root string) (*Snapshot, error) {
+	payload := map[string]any{"name": name}
+	if root != "" {
+		payload["root"] = root
+	}
+	resp, err := client.request(http.MethodPost, "/api/snapshots", payload, http.StatusCreated)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{}
+	if err := decodeData(resp, http.MethodPost, "/api/snapshots", snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// GetSnapshots gets the snapshots the user can see, latest first
+func (client *Client) GetSnapshots() ([]Snapshot, error) {
+	var data struct {
+		Snapshots []Snapshot `json:"snapshots"`
+	}
+	if err := client.getData("/api/snapshots", &data); err != nil {
+		return nil, err
+	}
+	return data.Snapshots, nil
+}
+
+// DeleteSnapshot deletes the snapshot and its objects
+func (client *Client) DeleteSnapshot(name string) error {
+	_, err := client.request(http.MethodDelete, "/api/snapshots/"+name, nil, http.StatusOK)
+	return err
+}
+
+// GetObjectAsOf gets the object as it was in a snapshot. asOf is the name
+// of the snapshot or a date (yyyy-mm-dd or RFC3339), the latest snapshot
+// of the object taken at or before it is used
+func (client *Client) GetObjectAsOf(collection, id, asOf string) (map[string]any, error) {
+	var object map[string]any
+	err := client.getData(withQuery(objectEndpoint(collection, id), url.Values{"asOf": {asOf}}), &object)
+	return object, err
+}
+
+// GetDiff gets the objects of the root (whole tenant if empty) added, removed
+// or changed between from and to, asOf values as in GetObjectAsOf.
+// An empty to stands for the current state
+func (client *Client) GetDiff(from, to, root string) (*ObjectsDiff, error) {
+	query := url.Values{"from": {from}}
+	if to != "" {
+		query.Set("to", to)
+	}
+	if root != "" {
+		query.Set("root", root)
+	}
+
+	diff := &ObjectsDiff{}
+	if err := client.getData(withQuery("/api/diff", query), diff); err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
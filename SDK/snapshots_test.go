@@ -0,0 +1,36 @@
+package sdk
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestGetDiffSendsTheQuery(t *testing.T) {
+	transport := &recordingTransport{response: &Response{Status: http.StatusOK, Body: map[string]any{
+		"data": map[string]any{
+			"from":    "march",
+			"added":   []any{map[string]any{"id": "SITE.BLDG.ROOM.R2", "category": "rack"}},
+			"removed": []any{},
+			"changed": []any{map[string]any{
+				"id":       "SITE.BLDG.ROOM.R1",
+				"category": "rack",
+				"changes":  []any{map[string]any{"attribute": "attributes.height", "from": 42.0, "to": 47.0}},
+			}},
+		},
+	}}}
+	client := NewClient(transport)
+
+	diff, err := client.GetDiff("2024-03-01", "", "SITE.BLDG.ROOM")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transport.endpoint != "/api/diff?from=2024-03-01&root=SITE.BLDG.ROOM" {
+		t.Errorf("unexpected endpoint %s", transport.endpoint)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].Id != "SITE.BLDG.ROOM.R2" {
+		t.Errorf("unexpected added objects %v", diff.Added)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].Changes[0] != (AttributeDiff{"attributes.height", 42.0, 47.0}) {
+		t.Errorf("unexpected changed objects %v", diff.Changed)
+	}
+}
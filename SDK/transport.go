@@ -0,0 +1,246 @@
+package sdk
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Response of the API, with its decoded JSON body
+type Response struct {
+	Status  int
+	Message string
+	Body    map[string]any
+}
+
+// Transport sends a request to the API and returns its response, with an
+// error if its status is not the expected one. HTTPTransport is the
+// transport over HTTP, other ones can be given to the client for tests.
+type Transport interface {
+	Request(method string, endpoint string, body map[string]any, expectedStatus int) (*Response, error)
+}
+
+// APIError is an unexpected status of the API
+type APIError struct {
+	Status  int
+	Message string
+	Errors  []string // details, e.g. the errors of the validation of an object
+	Err     error    // error returned by the transport, if any
+}
+
+func (err *APIError) Error() string {
+	if err.Err != nil {
+		return err.Err.Error()
+	}
+	msg := fmt.Sprintf("%d %s", err.Status, err.Message)
+	for _, detail := range err.Errors {
+		msg += "\n    " + detail
+	}
+	return msg
+}
+
+func (err *APIError) Unwrap() error {
+	return err.Err
+}
+
+// IsNotFound reports whether the error is a 404 of the API
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound
+}
+
+// refreshMargin: the token is refreshed when it expires in less than this
+const refreshMargin = time.Minute
+
+// HTTPTransport sends the requests to the API with the token of the user.
+// Once logged in with Login, the token is refreshed by logging in again
+// when it is about to expire or when the API rejects it.
+type HTTPTransport struct {
+	URL        string // of the API, e.g. http://localhost:3001
+	HTTPClient *http.Client
+	// Called with the new token after each refresh
+	OnTokenRefresh func(token string)
+
+	mu       sync.Mutex
+	token    string
+	email    string
+	password string
+}
+
+func NewHTTPTransport(url string) *HTTPTransport {
+	return &HTTPTransport{
+		URL:        strings.TrimSuffix(url, "/"),
+		HTTPClient: &http.Client{},
+	}
+}
+
+// Token used to authenticate the requests
+func (transport *HTTPTransport) Token() string {
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	return transport.token
+}
+
+// SetToken authenticates the requests with a token obtained elsewhere,
+// which can not be refreshed
+func (transport *HTTPTransport) SetToken(token string) {
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	transport.token = token
+}
+
+// SetCredentials keeps the credentials of the user, to refresh the token
+func (transport *HTTPTransport) SetCredentials(email, password, token string) {
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	transport.email, transport.password, transport.token = email, password, token
+}
+
+// PasswordChanged authenticates the requests with the token given on the
+// change of the password, and keeps the new password to refresh it
+func (transport *HTTPTransport) PasswordChanged(password, token string) {
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if transport.email != "" {
+		transport.password = password
+	}
+	transport.token = token
+}
+
+// Ping reports whether the API can be reached
+func (transport *HTTPTransport) Ping() bool {
+	resp, err := transport.HTTPClient.Get(transport.URL)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return true
+}
+
+func (transport *HTTPTransport) Request(method string, endpoint string, body map[string]any, expectedStatus int) (*Response, error) {
+	if endpoint != loginEndpoint && transport.tokenExpiresSoon() {
+		if err := transport.refreshToken(); err != nil {
+			return nil, err
+		}
+	}
+
+	response, err := transport.send(method, endpoint, body)
+	if err == nil && response.Status == http.StatusForbidden && transport.canRefresh() && endpoint != loginEndpoint {
+		// the token may have been revoked or may have expired in between
+		if err := transport.refreshToken(); err != nil {
+			return nil, err
+		}
+		response, err = transport.send(method, endpoint, body)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Status != expectedStatus {
+		return response, newAPIError(response)
+	}
+	return response, nil
+}
+
+func newAPIError(response *Response) *APIError {
+	apiErr := &APIError{Status: response.Status, Message: response.Message}
+	if details, ok := response.Body["errors"].([]any); ok {
+		for _, detail := range details {
+			apiErr.Errors = append(apiErr.Errors, fmt.Sprint(detail))
+		}
+	}
+	return apiErr
+}
+
+func (transport *HTTPTransport) send(method string, endpoint string, body map[string]any) (*Response, error) {
+	bodyJSON, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, transport.URL+endpoint, bytes.NewBuffer(bodyJSON))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+transport.Token())
+	req.Header.Set("Content-Type", "application/json")
+
+	httpResponse, err := transport.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResponse.Body.Close()
+
+	response, err := parseResponse(httpResponse)
+	if err != nil {
+		return nil, fmt.Errorf("on %s %s : %s", method, endpoint, err.Error())
+	}
+	return response, nil
+}
+
+func parseResponse(httpResponse *http.Response) (*Response, error) {
+	bodyBytes, err := io.ReadAll(httpResponse.Body)
+	if err != nil {
+		return nil, err
+	}
+	response := &Response{Status: httpResponse.StatusCode, Body: map[string]any{}}
+	if len(bodyBytes) > 0 {
+		if err := json.Unmarshal(bodyBytes, &response.Body); err != nil {
+			return nil, fmt.Errorf("cannot unmarshal json : \n%s", string(bodyBytes))
+		}
+		response.Message, _ = response.Body["message"].(string)
+	}
+	return response, nil
+}
+
+func (transport *HTTPTransport) canRefresh() bool {
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	return transport.email != ""
+}
+
+// tokenExpiresSoon reads the expiration date of the JWT token, without
+// checking its signature, which only the API can do
+func (transport *HTTPTransport) tokenExpiresSoon() bool {
+	if !transport.canRefresh() {
+		return false
+	}
+	parts := strings.Split(transport.Token(), ".")
+	if len(parts) != 3 {
+		return false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+	claims := struct {
+		ExpiresAt int64 `json:"exp"`
+	}{}
+	if json.Unmarshal(payload, &claims) != nil || claims.ExpiresAt == 0 {
+		return false
+	}
+	return time.Until(time.Unix(claims.ExpiresAt, 0)) < refreshMargin
+}
+
+// refreshToken logs in again with the credentials of the user
+func (transport *HTTPTransport) refreshToken() error {
+	transport.mu.Lock()
+	email, password := transport.email, transport.password
+	transport.mu.Unlock()
+
+	account, err := login(transport, email, password)
+	if err != nil {
+		return fmt.Errorf("cannot refresh the token: %s", err.Error())
+	}
+	transport.SetToken(account.Token)
+	if transport.OnTokenRefresh != nil {
+		transport.OnTokenRefresh(account.Token)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package sdk
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeAuthAPI gives a token, valid for the given duration, to admin and
+// answers the requests authenticated with the last token
+type fakeAuthAPI struct {
+	validity time.Duration
+	logins   int
+	token    string
+}
+
+func testToken(expiresAt time.Time, n int) string {
+	payload, _ := json.Marshal(map[string]any{"exp": expiresAt.Unix(), "n": n})
+	return "header." + base64.RawURLEncoding.EncodeToString(payload) + ".signature"
+}
+
+func (api *fakeAuthAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/login" {
+		var credentials map[string]string
+		json.NewDecoder(r.Body).Decode(&credentials)
+		if credentials["email"] != "admin@example.com" || credentials["password"] != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]any{"message": "Invalid login credentials"})
+			return
+		}
+		api.logins++
+		api.token = testToken(time.Now().Add(api.validity), api.logins)
+		json.NewEncoder(w).Encode(map[string]any{"message": "Login succesful",
+			"account": map[string]any{"_id": "1", "email": credentials["email"], "token": api.token}})
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer "+api.token {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]any{"message": "Token is not valid."})
+		return
+	}
+	switch r.URL.Path {
+	case "/api/version":
+		json.NewEncoder(w).Encode(map[string]any{"status": true, "data": map[string]any{"Customer": "demo"}})
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]any{"message": "Invalid object",
+			"errors": []string{"attributes.height is required"}})
+	}
+}
+
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return New(server.URL)
+}
+
+func TestLoginAuthenticatesTheRequests(t *testing.T) {
+	api := &fakeAuthAPI{validity: time.Hour}
+	client := newTestClient(t, api)
+
+	if _, err := client.Version(); err == nil {
+		t.Fatal("request without token accepted")
+	}
+	account, err := client.Login("admin@example.com", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Token != api.token {
+		t.Errorf("unexpected account %+v", account)
+	}
+	version, err := client.Version()
+	if err != nil || version.Customer != "demo" {
+		t.Errorf("unexpected version %+v, %v", version, err)
+	}
+}
+
+func TestLoginWithInvalidCredentialsFails(t *testing.T) {
+	client := newTestClient(t, &fakeAuthAPI{validity: time.Hour})
+
+	_, err := client.Login("admin@example.com", "wrong")
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.Status != http.StatusBadRequest || apiErr.Message != "Invalid login credentials" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestTokenRefreshedBeforeItExpires(t *testing.T) {
+	api := &fakeAuthAPI{validity: 30 * time.Second}
+	client := newTestClient(t, api)
+	if _, err := client.Login("admin@example.com", "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	refreshed := ""
+	client.Transport.(*HTTPTransport).OnTokenRefresh = func(token string) { refreshed = token }
+	if _, err := client.Version(); err != nil {
+		t.Fatal(err)
+	}
+	if api.logins != 2 || refreshed != api.token {
+		t.Errorf("token expiring in 30s not refreshed: %d logins", api.logins)
+	}
+}
+
+func TestTokenRefreshedWhenRejected(t *testing.T) {
+	api := &fakeAuthAPI{validity: time.Hour}
+	client := newTestClient(t, api)
+	if _, err := client.Login("admin@example.com", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	// e.g. the API restarted with another secret
+	api.token = "revoked"
+
+	if _, err := client.Version(); err != nil {
+		t.Fatal(err)
+	}
+	if api.logins != 2 {
+		t.Errorf("rejected token not refreshed: %d logins", api.logins)
+	}
+}
+
+func TestUnexpectedStatusIsAnAPIError(t *testing.T) {
+	api := &fakeAuthAPI{validity: time.Hour}
+	client := newTestClient(t, api)
+	client.Login("admin@example.com", "secret")
+
+	_, err := client.CreateObject("rack", map[string]any{"name": "A01"})
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.Status != http.StatusBadRequest || len(apiErr.Errors) != 1 {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !strings.Contains(err.Error(), "attributes.height is required") {
+		t.Errorf("details missing in %q", err.Error())
+	}
+	if IsNotFound(err) {
+		t.Error("400 reported as not found")
+	}
+}
+
+// transportFunc is a transport answering with a function, as the mocks of the CLI
+type transportFunc func(method, endpoint string, body map[string]any, expectedStatus int) (*Response, error)
+
+func (f transportFunc) Request(method, endpoint string, body map[string]any, expectedStatus int) (*Response, error) {
+	return f(method, endpoint, body, expectedStatus)
+}
+
+func TestErrorOfTransportWithResponseIsAnAPIError(t *testing.T) {
+	client := NewClient(transportFunc(func(method, endpoint string, body map[string]any, expectedStatus int) (*Response, error) {
+		return &Response{Status: http.StatusNotFound}, fmt.Errorf("[Response From API] Nothing matches this request")
+	}))
+
+	_, err := client.GetObject("racks", "SITE.BLDG.ROOM.RACK")
+	if !IsNotFound(err) || err.Error() != "[Response From API] Nothing matches this request" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package sdk
+
+import "net/http"
+
+// Role of a user on a domain
+type Role string
+
+const (
+	RoleManager Role = "manager"
+	RoleUser    Role = "user"
+	RoleViewer  Role = "viewer"
+)
+
+type User struct {
+	Id    string          `json:"_id"`
+	Name  string          `json:"name"`
+	Email string          `json:"email"`
+	Roles map[string]Role `json:"roles"`
+}
+
+// CreateUser creates a user with its roles on domains and returns the message of the API
+func (client *Client) CreateUser(email, password string, roles map[string]Role) (string, error) {
+	resp, err := client.request(http.MethodPost, "/api/users", map[string]any{
+		"email":    email,
+		"password": password,
+		"roles":    rolesData(roles),
+	}, http.StatusCreated)
+	if err != nil {
+		return "", err
+	}
+	return resp.Message, nil
+}
+
+func (client *Client) GetUsers() ([]User, error) {
+	users := []User{}
+	err := client.getData("/api/users", &users)
+	return users, err
+}
+
+// UpdateUserRoles sets the roles of the user on the given domains and
+// returns the message of the API
+func (client *Client) UpdateUserRoles(userId string, roles map[string]Role) (string, error) {
+	resp, err := client.request(http.MethodPatch, "/api/users/"+userId,
+		map[string]any{"roles": rolesData(roles)}, http.StatusOK)
+	if err != nil {
+		return "", err
+	}
+	return resp.Message, nil
+}
+
+// passwordHolder is a transport that keeps the password to refresh the token
+type passwordHolder interface {
+	PasswordChanged(password, token string)
+}
+
+// ChangePassword changes the password of the logged in user and returns the
+// message of the API. The next requests are authenticated with the new token.
+func (client *Client) ChangePassword(currentPassword, newPassword string) (string, error) {
+	resp, err := client.request(http.MethodPost, "/api/users/password/change", map[string]any{
+		"currentPassword": currentPassword,
+		"newPassword":     newPassword,
+	}, http.StatusOK)
+	if err != nil {
+		return "", err
+	}
+	if holder, ok := client.Transport.(passwordHolder); ok {
+		if token, _ := resp.Body["token"].(string); token != "" {
+			holder.PasswordChanged(newPassword, token)
+		}
+	}
+	return resp.Message, nil
+}
+
+func rolesData(roles map[string]Role) map[string]any {
+	data := map[string]any{}
+	for domain, role := range roles {
+		data[domain] = string(role)
+	}
+	return data
+}